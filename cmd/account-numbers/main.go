@@ -0,0 +1,32 @@
+package main
+
+import (
+	"log"
+	"os"
+
+	"github.com/joho/godotenv"
+
+	"bank-api/config"
+	"bank-api/repositories"
+	"bank-api/services"
+)
+
+// account-numbers присваивает 20-значные номера счетам, открытым до их введения.
+func main() {
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using environment variables")
+	}
+
+	db, err := config.ConnectDB()
+	if err != nil {
+		log.Fatal("Failed to connect to DB:", err)
+	}
+	defer db.Close()
+
+	accountService := services.NewAccountService(repositories.NewAccountRepository(db), nil, nil, db, os.Getenv("BANK_BIK"))
+	n, err := accountService.AssignMissingNumbers()
+	if err != nil {
+		log.Fatalf("Assigned %d account numbers before failure: %v", n, err)
+	}
+	log.Printf("Assigned %d account numbers.", n)
+}
@@ -1,9 +1,13 @@
 package main
 
 import (
+	"encoding/hex"
+	"errors"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 
@@ -13,6 +17,7 @@ import (
 	"bank-api/repositories"
 	"bank-api/services"
 	"bank-api/scheduler"
+	"bank-api/utils"
 
 	"github.com/gorilla/mux"
 )
@@ -38,12 +43,99 @@ func main() {
 	creditRepo := repositories.NewCreditRepository(db)
 	paymentScheduleRepo := repositories.NewPaymentScheduleRepository(db)
 	cardRepo := repositories.NewCardRepository(db) // должен быть реализован
+	idempotencyRepo := repositories.NewIdempotencyRepository(db)
+	currencyRateRepo := repositories.NewCurrencyRateRepository(db)
+	standingOrderRepo := repositories.NewStandingOrderRepository(db)
+	transferRepo := repositories.NewTransferRepository(db)
+	batchTransferRepo := repositories.NewBatchTransferRepository(db)
+	feeRepo := repositories.NewFeeRepository(db)
+	cardAuthorizationRepo := repositories.NewCardAuthorizationRepository(db)
+	cardProductRepo := repositories.NewCardProductRepository(db)
 	// Создаем сервисы.
 	jwtSecret := os.Getenv("JWT_SECRET")
 	userService := services.NewUserService(userRepo, jwtSecret)
-    accountService := services.NewAccountService(accountRepo, db)
-	creditService := services.NewCreditService(creditRepo, paymentScheduleRepo)
-	cardService := services.NewCardService(cardRepo)
+	fxService, err := services.NewFXService(
+		currencyRateRepo,
+		floatFromEnv("FX_SPREAD_PERCENT", 1.5),
+		intFromEnv("FX_RATE_MAX_AGE_DAYS", 7),
+	)
+	if err != nil {
+		log.Fatalf("Failed to create FX service: %v", err)
+	}
+	bankBIK := os.Getenv("BANK_BIK")
+	if !utils.ValidBIK(bankBIK) {
+		log.Fatal("BANK_BIK must be a 9-digit BIK")
+	}
+	feeService := services.NewFeeService(feeRepo, accountRepo)
+	accountService := services.NewAccountService(accountRepo, fxService, feeService, db, bankBIK)
+	accountMemberService := services.NewAccountMemberService(accountRepo, userRepo)
+	creditService := services.NewCreditService(creditRepo, paymentScheduleRepo, accountRepo)
+	cardIndexKey := os.Getenv("CARD_INDEX_KEY")
+	if cardIndexKey == "" {
+		log.Fatal("CARD_INDEX_KEY is required")
+	}
+	// Ключи шифрования реквизитов карт; новые карты шифруются текущим ключом.
+	keyring, err := utils.LoadKeyring(os.Getenv("CARD_KEYS_DIR"), os.Getenv("CARD_KEY_CURRENT"))
+	if err != nil {
+		log.Fatalf("Failed to load card keys from CARD_KEYS_DIR: %v", err)
+	}
+	utils.SetKeyring(keyring)
+	cardKeyService := services.NewCardKeyService(cardRepo, keyring)
+	cardProductService := services.NewCardProductService(cardProductRepo)
+	// Лимит неверных CVV общий для проверок партнеров и авторизаций по картам.
+	cvvLimit := intFromEnv("CVV_VERIFY_LIMIT", 3)
+	cvvWindow := durationFromEnv("CVV_VERIFY_WINDOW", 24*time.Hour)
+	cvvService := services.NewCVVService(cardRepo, cvvLimit, cvvWindow)
+	cardReissueService := services.NewCardReissueService(
+		cardRepo,
+		cardProductRepo,
+		userRepo,
+		[]byte(cardIndexKey),
+		durationFromEnv("CARD_REISSUE_WINDOW", 30*24*time.Hour),
+		services.SendCardReissueEmail,
+	)
+	cardService := services.NewCardService(
+		cardRepo,
+		accountRepo,
+		cardProductRepo,
+		userRepo,
+		[]byte(cardIndexKey),
+		intFromEnv("CARD_REVEAL_LIMIT", 5),
+		durationFromEnv("CARD_REVEAL_WINDOW", 15*time.Minute),
+	)
+	transferService := services.NewTransferService(
+		cardRepo,
+		transferRepo,
+		accountService,
+		[]byte(cardIndexKey),
+		durationFromEnv("TRANSFER_REVERSAL_WINDOW", 24*time.Hour),
+	)
+	cardAuthorizationService := services.NewCardAuthorizationService(
+		cardAuthorizationRepo,
+		cardRepo,
+		accountRepo,
+		feeService,
+		[]byte(cardIndexKey),
+		cvvLimit,
+		cvvWindow,
+	)
+	transactionService := services.NewTransactionService(transactionRepo, accountRepo)
+	standingOrderService := services.NewStandingOrderService(
+		standingOrderRepo,
+		accountRepo,
+		accountService,
+		intFromEnv("STANDING_ORDER_MAX_FAILURES", 3),
+		durationFromEnv("STANDING_ORDER_RETRY_DELAY", time.Hour),
+	)
+	batchTransferService := services.NewBatchTransferService(
+		batchTransferRepo,
+		accountRepo,
+		accountService,
+		feeService,
+		bankBIK,
+		intFromEnv("BATCH_TRANSFER_MAX_LINES", 1000),
+	)
+	statementService := services.NewStatementService(transactionRepo, accountRepo, bankBIK)
     analyticsService := services.NewAnalyticsService(
         transactionRepo,
         accountRepo,
@@ -54,26 +146,112 @@ func main() {
 	// Создаем обработчики.
 	userHandler := handlers.NewUserHandler(userService)
 	accountHandler := handlers.NewAccountHandler(accountService)
+	accountMemberHandler := handlers.NewAccountMemberHandler(accountMemberService)
 	creditHandler := handlers.NewCreditHandler(creditService)
 	cardHandler := handlers.NewCardHandler(cardService)
+	cardProductHandler := handlers.NewCardProductHandler(cardProductService)
+	cvvHandler := handlers.NewCVVHandler(cvvService)
 	analyticsHandler := handlers.NewAnalyticsHandler(analyticsService)
+	transactionHandler := handlers.NewTransactionHandler(transactionService, statementService)
+	standingOrderHandler := handlers.NewStandingOrderHandler(standingOrderService)
+	transferHandler := handlers.NewTransferHandler(transferService)
+	batchTransferHandler := handlers.NewBatchTransferHandler(batchTransferService)
+	feeHandler := handlers.NewFeeHandler(feeService)
+	cardAuthorizationHandler := handlers.NewCardAuthorizationHandler(cardAuthorizationService)
 	// Настраиваем маршруты.
 	r := mux.NewRouter()
 	// Публичные маршруты.
 	r.HandleFunc("/register", userHandler.Register).Methods("POST")
 	r.HandleFunc("/login", userHandler.Login).Methods("POST")
+	// Авторизации по картам для торговых точек (симулятора эквайринга) — по ключу MERCHANT_API_KEY.
+	if merchantKey := os.Getenv("MERCHANT_API_KEY"); merchantKey != "" {
+		merchantRouter := r.PathPrefix("/merchant").Subrouter()
+		merchantRouter.Use(middleware.RecoveryMiddleware(nil))
+		merchantRouter.Use(middleware.LoggingMiddleware(nil))
+		merchantRouter.Use(middleware.MerchantKeyMiddleware(merchantKey))
+		merchantRouter.HandleFunc("/authorizations", cardAuthorizationHandler.Authorize).Methods("POST")
+		merchantRouter.HandleFunc("/authorizations/{id}", cardAuthorizationHandler.Get).Methods("GET")
+		merchantRouter.HandleFunc("/authorizations/{id}/capture", cardAuthorizationHandler.Capture).Methods("POST")
+		merchantRouter.HandleFunc("/authorizations/{id}/release", cardAuthorizationHandler.Release).Methods("POST")
+	} else {
+		log.Println("MERCHANT_API_KEY is not set, card authorization API is disabled")
+	}
+	// Проверка CVV для партнеров — по ключу PARTNER_API_KEY вместо JWT;
+	// регистрируется до защищенных маршрутов, чтобы не попасть под AuthMiddleware.
+	if partnerKey := os.Getenv("PARTNER_API_KEY"); partnerKey != "" {
+		partnerRouter := r.Path("/cards/{id}/verify-cvv").Subrouter()
+		partnerRouter.Use(middleware.RecoveryMiddleware(nil))
+		partnerRouter.Use(middleware.LoggingMiddleware(nil))
+		partnerRouter.Use(middleware.PartnerKeyMiddleware(partnerKey))
+		partnerRouter.HandleFunc("", cvvHandler.VerifyCVV).Methods("POST")
+	} else {
+		log.Println("PARTNER_API_KEY is not set, CVV verification API is disabled")
+	}
 	// Защищенные маршруты.
 	authRouter := r.PathPrefix("/").Subrouter()
 	authRouter.Use(middleware.RecoveryMiddleware(nil)) // можно передать логгер
 	authRouter.Use(middleware.LoggingMiddleware(nil))
 	authRouter.Use(middleware.AuthMiddleware(jwtSecret))
-	authRouter.HandleFunc("/credits", creditHandler.ApplyForCredit).Methods("POST")
+	// Повторы денежных POST-запросов с заголовком Idempotency-Key не выполняются дважды.
+	idempotent := middleware.IdempotencyMiddleware(idempotencyRepo, durationFromEnv("IDEMPOTENCY_TTL", 24*time.Hour))
+	authRouter.Handle("/credits", idempotent(http.HandlerFunc(creditHandler.ApplyForCredit))).Methods("POST")
 	authRouter.HandleFunc("/cards", cardHandler.CreateCard).Methods("POST")
+	authRouter.HandleFunc("/cards", cardHandler.ListCards).Methods("GET")
 	authRouter.HandleFunc("/cards/{id}", cardHandler.GetCard).Methods("GET")
+	authRouter.HandleFunc("/cards/{id}/reveal", cardHandler.RevealCard).Methods("POST")
+	authRouter.HandleFunc("/cards/{id}/block", cardHandler.BlockCard).Methods("POST")
+	authRouter.HandleFunc("/cards/{id}/unblock", cardHandler.UnblockCard).Methods("POST")
+	authRouter.HandleFunc("/cards/{id}/cancel", cardHandler.CancelCard).Methods("POST")
+	authRouter.HandleFunc("/cards/{id}/pin/unlock", cardHandler.UnlockCardPIN).Methods("POST")
+	authRouter.HandleFunc("/cards/{id}/history", cardHandler.StatusHistory).Methods("GET")
+	authRouter.HandleFunc("/accounts/{id}/cards", cardHandler.ListAccountCards).Methods("GET")
+	authRouter.HandleFunc("/card-products", cardProductHandler.List).Methods("GET")
+	authRouter.HandleFunc("/card-products", cardProductHandler.Create).Methods("POST")
+	authRouter.HandleFunc("/card-products/{code}", cardProductHandler.Deactivate).Methods("DELETE")
+	// PIN карты принимается только PIN-блоком под зональными ключами PIN_ZONE_KEY_TDES
+	// (формат 0) и PIN_ZONE_KEY_AES (формат 4).
+	if pinService, err := newPINService(cardRepo, accountRepo); err != nil {
+		log.Printf("Card PIN API is disabled: %v", err)
+	} else {
+		pinHandler := handlers.NewPINHandler(pinService)
+		authRouter.HandleFunc("/cards/{id}/pin", pinHandler.SetPIN).Methods("POST")
+		authRouter.HandleFunc("/cards/{id}/pin", pinHandler.ChangePIN).Methods("PUT")
+		authRouter.HandleFunc("/cards/{id}/pin/verify", pinHandler.VerifyPIN).Methods("POST")
+	}
 	
     // endpoint для переводов
-	authRouter.HandleFunc("/accounts", accountHandler.CreateAccount).Methods("POST")
-	authRouter.HandleFunc("/transfer", accountHandler.Transfer).Methods("POST")
+	authRouter.Handle("/accounts", idempotent(http.HandlerFunc(accountHandler.CreateAccount))).Methods("POST")
+	authRouter.Handle("/transfer", idempotent(http.HandlerFunc(accountHandler.Transfer))).Methods("POST")
+	authRouter.Handle("/transfers/card-to-card", idempotent(http.HandlerFunc(transferHandler.CardToCard))).Methods("POST")
+	authRouter.HandleFunc("/transfers/{id}", transferHandler.GetTransfer).Methods("GET")
+	authRouter.Handle("/transfers/{id}/reverse", idempotent(http.HandlerFunc(transferHandler.Reverse))).Methods("POST")
+	authRouter.Handle("/accounts/{id}/deposit", idempotent(http.HandlerFunc(accountHandler.Deposit))).Methods("POST")
+	authRouter.Handle("/accounts/{id}/withdraw", idempotent(http.HandlerFunc(accountHandler.Withdraw))).Methods("POST")
+	authRouter.HandleFunc("/accounts/{id}/freeze", accountHandler.Freeze).Methods("POST")
+	authRouter.HandleFunc("/accounts/{id}/unfreeze", accountHandler.Unfreeze).Methods("POST")
+	authRouter.HandleFunc("/accounts/{id}/close", accountHandler.Close).Methods("POST")
+	authRouter.HandleFunc("/accounts/{id}/reopen", accountHandler.Reopen).Methods("POST")
+	authRouter.HandleFunc("/accounts/{id}/members", accountMemberHandler.List).Methods("GET")
+	authRouter.HandleFunc("/accounts/{id}/members", accountMemberHandler.Invite).Methods("POST")
+	authRouter.HandleFunc("/accounts/{id}/members/{userId}", accountMemberHandler.Remove).Methods("DELETE")
+	// комиссии по тарифу.
+	authRouter.HandleFunc("/fees/quote", feeHandler.Quote).Methods("POST")
+	authRouter.HandleFunc("/fees/rules", feeHandler.ListRules).Methods("GET")
+	authRouter.HandleFunc("/fees/rules", feeHandler.CreateRule).Methods("POST")
+	authRouter.HandleFunc("/fees/rules/{id}", feeHandler.DeactivateRule).Methods("DELETE")
+	// пакетные переводы из файла.
+	authRouter.Handle("/accounts/{id}/batch-transfers", idempotent(http.HandlerFunc(batchTransferHandler.Create))).Methods("POST")
+	authRouter.HandleFunc("/batch-transfers/{id}", batchTransferHandler.Get).Methods("GET")
+	authRouter.HandleFunc("/batch-transfers/{id}/report", batchTransferHandler.Report).Methods("GET")
+	authRouter.HandleFunc("/accounts/{id}/transactions", transactionHandler.GetAccountTransactions).Methods("GET")
+	authRouter.HandleFunc("/accounts/{id}/statement", transactionHandler.GetStatement).Methods("GET")
+	// постоянные поручения.
+	authRouter.HandleFunc("/standing-orders", standingOrderHandler.Create).Methods("POST")
+	authRouter.HandleFunc("/standing-orders", standingOrderHandler.List).Methods("GET")
+	authRouter.HandleFunc("/standing-orders/{id}", standingOrderHandler.Get).Methods("GET")
+	authRouter.HandleFunc("/standing-orders/{id}", standingOrderHandler.Update).Methods("PUT")
+	authRouter.HandleFunc("/standing-orders/{id}", standingOrderHandler.Delete).Methods("DELETE")
+	authRouter.HandleFunc("/standing-orders/{id}/runs", standingOrderHandler.Runs).Methods("GET")
 	// маршруты аналитики.
 	authRouter.HandleFunc("/analytics", analyticsHandler.GetAnalytics).Methods("GET")
 	authRouter.HandleFunc("/accounts/{accountId}/predict", analyticsHandler.PredictBalance).Methods("GET")
@@ -81,8 +259,124 @@ func main() {
 	 authRouter.HandleFunc("/credits/{creditId}/schedule", creditHandler.GetSchedule).Methods("GET")
 	// Запуск шедулера (если используется).
 	paymentScheduler := scheduler.NewPaymentScheduler(creditService, accountService)
+	if err := paymentScheduler.AddJob("0 30 * * * *", "idempotency keys cleanup", func() error {
+		_, err := idempotencyRepo.DeleteExpired()
+		return err
+	}); err != nil {
+		log.Fatalf("Failed to schedule idempotency cleanup: %v", err)
+	}
+	if err := paymentScheduler.AddJob("0 * * * * *", "standing orders", func() error {
+		return standingOrderService.RunDue(time.Now())
+	}); err != nil {
+		log.Fatalf("Failed to schedule standing orders: %v", err)
+	}
+	if err := paymentScheduler.AddJob("*/10 * * * * *", "batch transfers", func() error {
+		return batchTransferService.ProcessPending(time.Now())
+	}); err != nil {
+		log.Fatalf("Failed to schedule batch transfers: %v", err)
+	}
+	// Карты, зашифрованные прежними ключами, переводятся на текущий.
+	reencryptBatch := intFromEnv("CARD_REENCRYPT_BATCH", 100)
+	if err := paymentScheduler.AddJob("0 */15 * * * *", "card re-encryption", func() error {
+		result, err := cardKeyService.ReencryptCards(reencryptBatch)
+		if result != nil && result.Reencrypted+result.Skipped+result.Failed > 0 {
+			log.Printf("Card re-encryption to key %s: %d moved, %d skipped, %d failed",
+				result.KeyID, result.Reencrypted, result.Skipped, result.Failed)
+		}
+		return err
+	}); err != nil {
+		log.Fatalf("Failed to schedule card re-encryption: %v", err)
+	}
+	// Карты с истекающим сроком перевыпускаются, с наступившим — переводятся в expired.
+	reissueBatch := intFromEnv("CARD_REISSUE_BATCH", 100)
+	if err := paymentScheduler.AddJob("0 10 * * * *", "card reissue", func() error {
+		result, err := cardReissueService.Run(time.Now(), reissueBatch)
+		if result != nil && result.Backfilled+result.Reissued+result.Expired+result.Failed > 0 {
+			log.Printf("Card reissue: %d backfilled, %d reissued, %d expired, %d failed",
+				result.Backfilled, result.Reissued, result.Expired, result.Failed)
+		}
+		return err
+	}); err != nil {
+		log.Fatalf("Failed to schedule card reissue: %v", err)
+	}
+	// Курсы ЦБ на текущую дату загружаются при старте и затем ежедневно.
+	refreshRates := func() error { return fxService.RefreshRates(time.Now()) }
+	if err := refreshRates(); err != nil {
+		log.Printf("Failed to load CBR currency rates: %v", err)
+	}
+	if err := paymentScheduler.AddJob("0 5 0 * * *", "CBR currency rates", refreshRates); err != nil {
+		log.Fatalf("Failed to schedule currency rates refresh: %v", err)
+	}
 	paymentScheduler.Start()
 
 	log.Println("Server running on :8080")
 	log.Fatal(http.ListenAndServe(":8080", r))
 }
+
+// durationFromEnv читает длительность из переменной окружения (например, "24h").
+func durationFromEnv(name string, def time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return def
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Fatalf("Invalid %s: %v", name, err)
+	}
+	return d
+}
+
+// floatFromEnv читает число из переменной окружения (например, "1.5").
+func floatFromEnv(name string, def float64) float64 {
+	value := os.Getenv(name)
+	if value == "" {
+		return def
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Fatalf("Invalid %s: %v", name, err)
+	}
+	return f
+}
+
+// intFromEnv читает целое число из переменной окружения.
+func intFromEnv(name string, def int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return def
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		log.Fatalf("Invalid %s: %v", name, err)
+	}
+	return n
+}
+
+// newPINService создает PINService с ключами из PIN_ZONE_KEY_TDES, PIN_ZONE_KEY_AES и PIN_PVK (hex).
+// Зональные ключи задаются отдельно для каждого алгоритма; нужен хотя бы один.
+func newPINService(cardRepo repositories.CardRepository, accountRepo repositories.AccountRepository) (services.PINService, error) {
+	tdesZoneKey, err := hex.DecodeString(os.Getenv("PIN_ZONE_KEY_TDES"))
+	if err != nil || (len(tdesZoneKey) != 0 && len(tdesZoneKey) != 16 && len(tdesZoneKey) != 24) {
+		return nil, errors.New("PIN_ZONE_KEY_TDES must be a hex-encoded 16 or 24 byte TDES key")
+	}
+	aesZoneKey, err := hex.DecodeString(os.Getenv("PIN_ZONE_KEY_AES"))
+	if err != nil || (len(aesZoneKey) != 0 && len(aesZoneKey) != 16 && len(aesZoneKey) != 24 && len(aesZoneKey) != 32) {
+		return nil, errors.New("PIN_ZONE_KEY_AES must be a hex-encoded 16, 24 or 32 byte AES key")
+	}
+	if len(tdesZoneKey) == 0 && len(aesZoneKey) == 0 {
+		return nil, errors.New("PIN_ZONE_KEY_TDES or PIN_ZONE_KEY_AES must be set")
+	}
+	pvk, err := hex.DecodeString(os.Getenv("PIN_PVK"))
+	if err != nil || (len(pvk) != 16 && len(pvk) != 24) {
+		return nil, errors.New("PIN_PVK must be a hex-encoded 16 or 24 byte TDES key")
+	}
+	return services.NewPINService(
+		cardRepo,
+		accountRepo,
+		tdesZoneKey,
+		aesZoneKey,
+		pvk,
+		intFromEnv("PIN_PVKI", 1),
+		intFromEnv("PIN_MAX_ATTEMPTS", 3),
+	), nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"github.com/joho/godotenv"
+
+	"bank-api/config"
+	"bank-api/repositories"
+	"bank-api/services"
+	"bank-api/utils"
+)
+
+// card-keys управляет ключами шифрования реквизитов карт в каталоге CARD_KEYS_DIR:
+//
+//	card-keys -generate <kid>   создает новый ключ; текущим он станет после перезапуска API
+//	card-keys -reencrypt        перешифровывает все карты текущим ключом
+func main() {
+	generate := flag.String("generate", "", "create a new key with this id")
+	reencrypt := flag.Bool("reencrypt", false, "re-encrypt all cards with the current key")
+	batch := flag.Int("batch", 100, "cards per re-encryption batch")
+	flag.Parse()
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using environment variables")
+	}
+	dir := os.Getenv("CARD_KEYS_DIR")
+	if dir == "" {
+		log.Fatal("CARD_KEYS_DIR is required")
+	}
+
+	switch {
+	case *generate != "":
+		key, err := utils.GenerateCardKey(*generate)
+		if err != nil {
+			log.Fatalf("Failed to generate key: %v", err)
+		}
+		if err := utils.WriteCardKey(dir, key); err != nil {
+			log.Fatalf("Failed to write key: %v", err)
+		}
+		log.Printf("Created card key %s in %s.", key.ID, dir)
+	case *reencrypt:
+		keyring, err := utils.LoadKeyring(dir, os.Getenv("CARD_KEY_CURRENT"))
+		if err != nil {
+			log.Fatalf("Failed to load card keys: %v", err)
+		}
+		db, err := config.ConnectDB()
+		if err != nil {
+			log.Fatal("Failed to connect to DB:", err)
+		}
+		defer db.Close()
+
+		svc := services.NewCardKeyService(repositories.NewCardRepository(db), keyring)
+		result, err := svc.ReencryptCards(*batch)
+		if err != nil {
+			log.Fatalf("Re-encrypted %d cards before failure: %v", result.Reencrypted, err)
+		}
+		log.Printf("Re-encrypted %d cards to key %s; %d skipped, %d failed.",
+			result.Reencrypted, result.KeyID, result.Skipped, result.Failed)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"github.com/joho/godotenv"
+
+	"bank-api/config"
+	"bank-api/repositories"
+)
+
+// ledger-rebuild пересчитывает кешированные балансы accounts.balance
+// по проводкам журнала двойной записи.
+func main() {
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using environment variables")
+	}
+
+	db, err := config.ConnectDB()
+	if err != nil {
+		log.Fatal("Failed to connect to DB:", err)
+	}
+	defer db.Close()
+
+	ledgerRepo := repositories.NewLedgerRepository(db)
+	if err := ledgerRepo.RebuildBalances(context.Background()); err != nil {
+		log.Fatal("Failed to rebuild balances:", err)
+	}
+	log.Println("Account balances rebuilt from ledger.")
+}
@@ -22,15 +22,26 @@ func NewAccountHandler(accountService services.AccountService) *AccountHandler {
 
 // CreateAccount обрабатывает POST-запрос на создание нового счета.
 // URL: /accounts
+// Создатель счета из токена становится его владельцем.
 func (h *AccountHandler) CreateAccount(w http.ResponseWriter, r *http.Request) {
-	var account models.Account
-	if err := json.NewDecoder(r.Body).Decode(&account); err != nil {
+	userID, err := userIDFromContext(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	// Остаток и блокировки клиент не задает: счет открывается пустым.
+	var req struct {
+		Type     string `json:"type"`
+		Currency string `json:"currency"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Неверный формат запроса", http.StatusBadRequest)
 		return
 	}
+	account := models.Account{UserID: userID, Type: req.Type, Currency: req.Currency}
 
 	if err := h.accountService.CreateAccount(&account); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeServiceError(w, "Error creating account: ", err)
 		return
 	}
 
@@ -41,47 +52,123 @@ func (h *AccountHandler) CreateAccount(w http.ResponseWriter, r *http.Request) {
 // Deposit обрабатывает POST-запрос для пополнения счета.
 // URL: /accounts/{id}/deposit
 func (h *AccountHandler) Deposit(w http.ResponseWriter, r *http.Request) {
+	h.cashOperation(w, r, "Deposit failed: ", h.accountService.Deposit)
+}
+
+// Withdraw обрабатывает POST-запрос для снятия средств со счета.
+// URL: /accounts/{id}/withdraw
+func (h *AccountHandler) Withdraw(w http.ResponseWriter, r *http.Request) {
+	h.cashOperation(w, r, "Withdrawal failed: ", h.accountService.Withdraw)
+}
+
+// cashOperation разбирает {"amount": ...}, выполняет операцию над счетом из URL
+// и возвращает новый остаток.
+func (h *AccountHandler) cashOperation(w http.ResponseWriter, r *http.Request, errPrefix string,
+	op func(userID, accountID int, amount models.Money) (*models.Account, error)) {
+	userID, err := userIDFromContext(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
 	// Получаем идентификатор счета из URL
-	vars := mux.Vars(r)
-	accountID, err := strconv.Atoi(vars["id"])
+	accountID, err := strconv.Atoi(mux.Vars(r)["id"])
 	if err != nil {
 		http.Error(w, "Неверный идентификатор счета", http.StatusBadRequest)
 		return
 	}
 
 	var payload struct {
-		Amount float64 `json:"amount"`
+		Amount models.Money `json:"amount"`
 	}
-
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
 		http.Error(w, "Неверный формат запроса", http.StatusBadRequest)
 		return
 	}
 
-	if err := h.accountService.Deposit(accountID, payload.Amount); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	account, err := op(userID, accountID, payload.Amount)
+	if err != nil {
+		writeServiceError(w, errPrefix, err)
 		return
 	}
-
-	// Если операция выполнена успешно, можно вернуть статус 204 (No Content)
-	w.WriteHeader(http.StatusNoContent)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"account_id": account.ID,
+		"balance":    account.Balance,
+		"currency":   account.Currency,
+	})
 }
 
 // POST /transfer
 func (h *AccountHandler) Transfer(w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromContext(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
 	var req struct {
-		FromAccountID int     `json:"from_account_id"`
-		ToAccountID   int     `json:"to_account_id"`
-		Amount        float64 `json:"amount"`
+		FromAccountID int `json:"from_account_id"`
+		ToAccountID   int `json:"to_account_id"`
+		// Номер счета получателя; используется вместо to_account_id
+		ToAccountNumber string       `json:"to_account_number"`
+		Amount          models.Money `json:"amount"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
-	if err := h.accountService.Transfer(req.FromAccountID, req.ToAccountID, req.Amount); err != nil {
-		http.Error(w, "Transfer failed: "+err.Error(), http.StatusInternalServerError)
+	var transfer *models.Transfer
+	if req.ToAccountNumber != "" {
+		transfer, err = h.accountService.TransferToNumber(userID, req.FromAccountID, req.ToAccountNumber, req.Amount)
+	} else {
+		transfer, err = h.accountService.Transfer(userID, req.FromAccountID, req.ToAccountID, req.Amount)
+	}
+	if err != nil {
+		writeServiceError(w, "Transfer failed: ", err)
 		return
 	}
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
-}
\ No newline at end of file
+	json.NewEncoder(w).Encode(map[string]interface{}{"status": "ok", "transfer": transfer})
+}
+
+// Freeze замораживает счет. URL: POST /accounts/{id}/freeze
+func (h *AccountHandler) Freeze(w http.ResponseWriter, r *http.Request) {
+	h.changeStatus(w, r, h.accountService.Freeze)
+}
+
+// Unfreeze размораживает счет. URL: POST /accounts/{id}/unfreeze
+func (h *AccountHandler) Unfreeze(w http.ResponseWriter, r *http.Request) {
+	h.changeStatus(w, r, h.accountService.Unfreeze)
+}
+
+// Close закрывает счет. URL: POST /accounts/{id}/close
+func (h *AccountHandler) Close(w http.ResponseWriter, r *http.Request) {
+	h.changeStatus(w, r, h.accountService.Close)
+}
+
+// Reopen повторно открывает закрытый счет. URL: POST /accounts/{id}/reopen
+func (h *AccountHandler) Reopen(w http.ResponseWriter, r *http.Request) {
+	h.changeStatus(w, r, h.accountService.Reopen)
+}
+
+// changeStatus выполняет смену статуса счета из URL и возвращает обновленный счет.
+func (h *AccountHandler) changeStatus(w http.ResponseWriter, r *http.Request, change func(userID, accountID int) (*models.Account, error)) {
+	userID, err := userIDFromContext(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	accountID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid account ID", http.StatusBadRequest)
+		return
+	}
+	account, err := change(userID, accountID)
+	if err != nil {
+		writeServiceError(w, "Status change failed: ", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(account)
+}
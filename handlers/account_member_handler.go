@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"bank-api/models"
+	"bank-api/services"
+
+	"github.com/gorilla/mux"
+)
+
+// AccountMemberHandler обрабатывает управление участниками совместных счетов.
+type AccountMemberHandler struct {
+	memberService services.AccountMemberService
+}
+
+// NewAccountMemberHandler создаёт новый экземпляр AccountMemberHandler.
+func NewAccountMemberHandler(memberService services.AccountMemberService) *AccountMemberHandler {
+	return &AccountMemberHandler{memberService: memberService}
+}
+
+// List возвращает участников счета. URL: GET /accounts/{id}/members
+func (h *AccountMemberHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromContext(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	accountID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid account ID", http.StatusBadRequest)
+		return
+	}
+	members, err := h.memberService.List(userID, accountID)
+	if err != nil {
+		writeServiceError(w, "Error fetching account members: ", err)
+		return
+	}
+	if members == nil {
+		members = []models.AccountMember{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(members)
+}
+
+// Invite добавляет участника счета.
+// URL: POST /accounts/{id}/members, тело: {"email", "role", "spend_limit"}
+func (h *AccountMemberHandler) Invite(w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromContext(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	accountID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid account ID", http.StatusBadRequest)
+		return
+	}
+	var req struct {
+		Email      string        `json:"email"`
+		Role       string        `json:"role"`
+		SpendLimit *models.Money `json:"spend_limit"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Email == "" {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	member, err := h.memberService.Invite(userID, accountID, req.Email, req.Role, req.SpendLimit)
+	if err != nil {
+		writeServiceError(w, "Error inviting account member: ", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(member)
+}
+
+// Remove удаляет участника счета. URL: DELETE /accounts/{id}/members/{userId}
+func (h *AccountMemberHandler) Remove(w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromContext(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	vars := mux.Vars(r)
+	accountID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid account ID", http.StatusBadRequest)
+		return
+	}
+	memberID, err := strconv.Atoi(vars["userId"])
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+	if err := h.memberService.Remove(userID, accountID, memberID); err != nil {
+		writeServiceError(w, "Error removing account member: ", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"strconv"
 
+	"bank-api/models"
 	"bank-api/services"
 
 	"github.com/gorilla/mux"
@@ -46,6 +47,11 @@ func (h *AnalyticsHandler) GetAnalytics(w http.ResponseWriter, r *http.Request)
 
 // PredictBalance обрабатывает GET /accounts/{accountId}/predict?days=N и возвращает прогноз баланса.
 func (h *AnalyticsHandler) PredictBalance(w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromContext(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
 	vars := mux.Vars(r)
 	accountIDStr := vars["accountId"]
 	accountID, err := strconv.Atoi(accountIDStr)
@@ -61,13 +67,13 @@ func (h *AnalyticsHandler) PredictBalance(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	prediction, err := h.analyticsService.PredictBalance(accountID, days)
+	prediction, err := h.analyticsService.PredictBalance(userID, accountID, days)
 	if err != nil {
-		http.Error(w, "Error predicting balance: "+err.Error(), http.StatusInternalServerError)
+		writeServiceError(w, "Error predicting balance: ", err)
 		return
 	}
 
-	response := map[string]float64{"predicted_balance": prediction}
+	response := map[string]models.Money{"predicted_balance": prediction}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
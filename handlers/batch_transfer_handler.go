@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"bank-api/models"
+	"bank-api/services"
+
+	"github.com/gorilla/mux"
+)
+
+// maxBatchFileSize — наибольший размер файла пакетного перевода.
+const maxBatchFileSize = 10 << 20
+
+// BatchTransferHandler обрабатывает загрузку пакетных переводов и отчеты по ним.
+type BatchTransferHandler struct {
+	batchTransferService services.BatchTransferService
+}
+
+// NewBatchTransferHandler создаёт новый экземпляр BatchTransferHandler.
+func NewBatchTransferHandler(batchTransferService services.BatchTransferService) *BatchTransferHandler {
+	return &BatchTransferHandler{batchTransferService: batchTransferService}
+}
+
+// Create принимает файл пакета, проверяет его целиком и ставит в очередь на исполнение.
+// URL: POST /accounts/{id}/batch-transfers
+// Формат берется из параметра format (csv|ndjson) или из Content-Type
+// (text/csv, application/x-ndjson). Пакет с ошибками в строках отклоняется
+// ответом 422 со списком строк и причин.
+func (h *BatchTransferHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromContext(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	accountID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid account ID", http.StatusBadRequest)
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = batchFormatFromContentType(r.Header.Get("Content-Type"))
+	}
+	body := http.MaxBytesReader(w, r.Body, maxBatchFileSize)
+	batch, err := h.batchTransferService.Create(userID, accountID, format, body)
+	if err != nil {
+		var validationErr *models.BatchValidationError
+		if errors.As(err, &validationErr) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"error": models.ErrInvalidBatch.Error(),
+				"lines": validationErr.Lines,
+			})
+			return
+		}
+		writeServiceError(w, "Error creating batch transfer: ", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(batch)
+}
+
+// Get возвращает пакет и статус каждой строки.
+// URL: GET /batch-transfers/{id}
+func (h *BatchTransferHandler) Get(w http.ResponseWriter, r *http.Request) {
+	userID, batchID, ok := batchTransferParams(w, r)
+	if !ok {
+		return
+	}
+	batch, err := h.batchTransferService.Get(userID, batchID)
+	if err != nil {
+		writeServiceError(w, "Error fetching batch transfer: ", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(batch)
+}
+
+// Report выгружает CSV-отчет о результатах строк пакета.
+// URL: GET /batch-transfers/{id}/report
+func (h *BatchTransferHandler) Report(w http.ResponseWriter, r *http.Request) {
+	userID, batchID, ok := batchTransferParams(w, r)
+	if !ok {
+		return
+	}
+	var buf bytes.Buffer
+	if err := h.batchTransferService.WriteReport(userID, batchID, &buf); err != nil {
+		writeServiceError(w, "Error building batch transfer report: ", err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="batch-transfer-%d.csv"`, batchID))
+	w.Write(buf.Bytes())
+}
+
+// batchFormatFromContentType сопоставляет Content-Type запроса формату пакета.
+func batchFormatFromContentType(contentType string) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "text/csv":
+		return models.BatchFormatCSV
+	case "application/x-ndjson", "application/jsonl", "application/x-jsonlines":
+		return models.BatchFormatNDJSON
+	}
+	return mediaType
+}
+
+func batchTransferParams(w http.ResponseWriter, r *http.Request) (userID, batchID int, ok bool) {
+	userID, err := userIDFromContext(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return 0, 0, false
+	}
+	batchID, err = strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid batch transfer ID", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	return userID, batchID, true
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"bank-api/models"
+	"bank-api/services"
+
+	"github.com/gorilla/mux"
+)
+
+// CardAuthorizationHandler обрабатывает запросы торговых точек на авторизацию оплат картами.
+type CardAuthorizationHandler struct {
+	authService services.CardAuthorizationService
+}
+
+// NewCardAuthorizationHandler создаёт новый экземпляр CardAuthorizationHandler.
+func NewCardAuthorizationHandler(authService services.CardAuthorizationService) *CardAuthorizationHandler {
+	return &CardAuthorizationHandler{authService: authService}
+}
+
+// Authorize авторизует оплату картой. Отказ возвращается со статусом 200
+// и причиной в decline_reason, одобрение — 201 с кодом одобрения.
+// URL: POST /merchant/authorizations
+func (h *CardAuthorizationHandler) Authorize(w http.ResponseWriter, r *http.Request) {
+	var req models.AuthorizationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	req.ClientAddr = r.RemoteAddr
+	auth, err := h.authService.Authorize(req)
+	if err != nil {
+		writeServiceError(w, "Authorization failed: ", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if auth.Status == models.AuthorizationStatusApproved {
+		w.WriteHeader(http.StatusCreated)
+	}
+	json.NewEncoder(w).Encode(auth)
+}
+
+// Get возвращает авторизацию. URL: GET /merchant/authorizations/{id}
+func (h *CardAuthorizationHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid authorization ID", http.StatusBadRequest)
+		return
+	}
+	auth, err := h.authService.GetAuthorization(id)
+	if err != nil {
+		writeServiceError(w, "Failed to get authorization: ", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(auth)
+}
+
+// Capture списывает заблокированную сумму, целиком или частично ({"amount"}).
+// URL: POST /merchant/authorizations/{id}/capture
+func (h *CardAuthorizationHandler) Capture(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid authorization ID", http.StatusBadRequest)
+		return
+	}
+	var req struct {
+		Amount *models.Money `json:"amount"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request payload", http.StatusBadRequest)
+			return
+		}
+	}
+	auth, err := h.authService.Capture(id, req.Amount)
+	if err != nil {
+		writeServiceError(w, "Capture failed: ", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(auth)
+}
+
+// Release отменяет блокировку суммы. URL: POST /merchant/authorizations/{id}/release
+func (h *CardAuthorizationHandler) Release(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid authorization ID", http.StatusBadRequest)
+		return
+	}
+	auth, err := h.authService.Release(id)
+	if err != nil {
+		writeServiceError(w, "Release failed: ", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(auth)
+}
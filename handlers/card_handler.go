@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"strconv"
 
+	"bank-api/models"
 	"bank-api/services"
 
 	"github.com/gorilla/mux"
@@ -37,9 +38,11 @@ func (h *CardHandler) CreateCard(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Ожидаемый JSON-запрос должен содержать account_id.
+	// Ожидаемый JSON-запрос должен содержать account_id; product_code необязателен.
+	// Для kind single_use или merchant_locked обязателен spend_cap, valid_until необязателен.
 	var reqBody struct {
 		AccountID int `json:"account_id"`
+		models.VirtualCardRequest
 	}
 	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
 		http.Error(w, "Invalid request payload", http.StatusBadRequest)
@@ -51,18 +54,26 @@ func (h *CardHandler) CreateCard(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Вызываем CardService для генерации карты.
-	card, err := h.cardService.CreateCard(userID, reqBody.AccountID)
+	var card *models.Card
+	if reqBody.Kind == "" || reqBody.Kind == models.CardKindStandard {
+		card, err = h.cardService.CreateCard(userID, reqBody.AccountID, reqBody.ProductCode)
+	} else {
+		card, err = h.cardService.CreateVirtualCard(userID, reqBody.AccountID, reqBody.VirtualCardRequest)
+	}
 	if err != nil {
-		http.Error(w, "Failed to create card: "+err.Error(), http.StatusInternalServerError)
+		writeServiceError(w, "Failed to create card: ", err)
 		return
 	}
 
-	// Возвращаем созданную карту в JSON-формате.
+	// Возвращаем созданную карту в JSON-формате. CVV в ответе показывается
+	// один раз и не должен оседать в кешах.
+	w.Header().Set("Cache-Control", "no-store")
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(card)
 }
 
 // GetCard обрабатывает GET-запрос на просмотр карты по ID.
+// Номер карты замаскирован; реквизиты раскрывает RevealCard.
 // URL: GET /cards/{id}
 func (h *CardHandler) GetCard(w http.ResponseWriter, r *http.Request) {
 	// Получаем userID из контекста.
@@ -85,21 +96,170 @@ func (h *CardHandler) GetCard(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Получаем карту через сервис.
-	card, err := h.cardService.GetCardByID(cardID)
+	// Получаем карту через сервис; доступ проверяется по членству в счете карты.
+	card, err := h.cardService.GetCard(userID, cardID)
+	if err != nil {
+		writeServiceError(w, "Failed to get card: ", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(card)
+}
+
+// ListCards возвращает карты пользователя с замаскированными номерами.
+// URL: GET /cards
+func (h *CardHandler) ListCards(w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromContext(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	cards, err := h.cardService.ListCards(userID)
 	if err != nil {
-		http.Error(w, "Card not found", http.StatusNotFound)
+		writeServiceError(w, "Failed to list cards: ", err)
 		return
 	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(cards)
+}
 
-	// Проверяем, что пользователь является владельцем карты.
-	if card.UserID != userID {
-		http.Error(w, "Forbidden", http.StatusForbidden)
+// ListAccountCards возвращает карты счета с замаскированными номерами.
+// URL: GET /accounts/{id}/cards
+func (h *CardHandler) ListAccountCards(w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromContext(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	accountID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid account ID", http.StatusBadRequest)
+		return
+	}
+	cards, err := h.cardService.ListAccountCards(userID, accountID)
+	if err != nil {
+		writeServiceError(w, "Failed to list cards: ", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(cards)
+}
+
+// RevealCard раскрывает номер и срок действия карты после повторного ввода пароля.
+// URL: POST /cards/{id}/reveal
+func (h *CardHandler) RevealCard(w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromContext(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	cardID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid card ID", http.StatusBadRequest)
+		return
+	}
+	var req struct {
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Password == "" {
+		http.Error(w, "password is required", http.StatusBadRequest)
+		return
+	}
+	details, err := h.cardService.RevealCard(userID, cardID, req.Password)
+	if err != nil {
+		writeServiceError(w, "Failed to reveal card: ", err)
 		return
 	}
+	// Реквизиты не должны оседать в кешах прокси и браузера.
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(details)
+}
+
+// cardStatusRequest — тело запросов смены статуса карты; reason необязателен.
+type cardStatusRequest struct {
+	Status string `json:"status"`
+	Reason string `json:"reason"`
+}
+
+// BlockCard временно блокирует карту. URL: POST /cards/{id}/block
+func (h *CardHandler) BlockCard(w http.ResponseWriter, r *http.Request) {
+	h.changeStatus(w, r, func(userID int, cardID int, req cardStatusRequest) (*models.CardSummary, error) {
+		return h.cardService.BlockCard(userID, cardID, req.Reason)
+	})
+}
+
+// UnblockCard снимает временную блокировку. URL: POST /cards/{id}/unblock
+func (h *CardHandler) UnblockCard(w http.ResponseWriter, r *http.Request) {
+	h.changeStatus(w, r, func(userID int, cardID int, req cardStatusRequest) (*models.CardSummary, error) {
+		return h.cardService.UnblockCard(userID, cardID, req.Reason)
+	})
+}
+
+// CancelCard окончательно блокирует карту (только операционист).
+// URL: POST /cards/{id}/cancel, тело {"status": "lost"|"stolen"|"expired", "reason"}
+func (h *CardHandler) CancelCard(w http.ResponseWriter, r *http.Request) {
+	role := roleFromContext(r)
+	h.changeStatus(w, r, func(userID int, cardID int, req cardStatusRequest) (*models.CardSummary, error) {
+		return h.cardService.CancelCard(userID, role, cardID, req.Status, req.Reason)
+	})
+}
+
+// UnlockCardPIN снимает блокировку карты по неверным PIN (только операционист).
+// URL: POST /cards/{id}/pin/unlock
+func (h *CardHandler) UnlockCardPIN(w http.ResponseWriter, r *http.Request) {
+	role := roleFromContext(r)
+	h.changeStatus(w, r, func(userID int, cardID int, req cardStatusRequest) (*models.CardSummary, error) {
+		return h.cardService.UnlockCardPIN(userID, role, cardID, req.Reason)
+	})
+}
 
-	// Если требуется, здесь можно реализовать дешифрование зашифрованных полей,
-	// но если возвращать в неизменном виде – делаем JSON-ответ.
+// changeStatus разбирает ID карты и тело запроса, выполняет смену статуса и возвращает карту.
+func (h *CardHandler) changeStatus(w http.ResponseWriter, r *http.Request, change func(userID, cardID int, req cardStatusRequest) (*models.CardSummary, error)) {
+	userID, err := userIDFromContext(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	cardID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid card ID", http.StatusBadRequest)
+		return
+	}
+	var req cardStatusRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request payload", http.StatusBadRequest)
+			return
+		}
+	}
+	card, err := change(userID, cardID, req)
+	if err != nil {
+		writeServiceError(w, "Card status change failed: ", err)
+		return
+	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(card)
 }
+
+// StatusHistory возвращает историю статусов карты. URL: GET /cards/{id}/history
+func (h *CardHandler) StatusHistory(w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromContext(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	cardID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid card ID", http.StatusBadRequest)
+		return
+	}
+	history, err := h.cardService.CardStatusHistory(userID, roleFromContext(r), cardID)
+	if err != nil {
+		writeServiceError(w, "Failed to get card history: ", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(history)
+}
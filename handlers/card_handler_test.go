@@ -17,7 +17,7 @@ import (
 // fakeCardService реализует интерфейс CardService для тестирования обработчиков.
 type fakeCardService struct{}
 
-func (f *fakeCardService) CreateCard(userID, accountID int) (*models.Card, error) {
+func (f *fakeCardService) CreateCard(userID, accountID int, productCode string) (*models.Card, error) {
 	// Возвращаем фиксированный объект карты.
 	return &models.Card{
 		ID:             1,
@@ -30,8 +30,18 @@ func (f *fakeCardService) CreateCard(userID, accountID int) (*models.Card, error
 	}, nil
 }
 
-func (f *fakeCardService) GetCardByID(id int) (*models.Card, error) {
-	// Для теста возвращаем карту с userID 42.
+func (f *fakeCardService) CreateVirtualCard(userID, accountID int, req models.VirtualCardRequest) (*models.Card, error) {
+	if !req.SpendCap.IsPositive() {
+		return nil, models.ErrInvalidVirtualCard
+	}
+	return &models.Card{ID: 2, UserID: userID, AccountID: accountID, Kind: req.Kind, SpendCap: &req.SpendCap}, nil
+}
+
+func (f *fakeCardService) GetCardByID(userID, id int) (*models.Card, error) {
+	// Для теста возвращаем карту с userID 42; остальным пользователям она недоступна.
+	if userID != 42 {
+		return nil, models.ErrNotCardOwner
+	}
 	return &models.Card{
 		ID:             id,
 		UserID:         42,
@@ -43,6 +53,71 @@ func (f *fakeCardService) GetCardByID(id int) (*models.Card, error) {
 	}, nil
 }
 
+func (f *fakeCardService) GetCard(userID, id int) (*models.CardSummary, error) {
+	card, err := f.GetCardByID(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	return &models.CardSummary{
+		ID:          card.ID,
+		UserID:      card.UserID,
+		AccountID:   card.AccountID,
+		MaskedPAN:   "220012******1234",
+		Status:      models.CardStatusActive,
+		ExpiryMonth: "05/30",
+	}, nil
+}
+
+func (f *fakeCardService) ListCards(userID int) ([]models.CardSummary, error) {
+	card, err := f.GetCard(userID, 1)
+	if err != nil {
+		return []models.CardSummary{}, nil
+	}
+	return []models.CardSummary{*card}, nil
+}
+
+func (f *fakeCardService) ListAccountCards(userID, accountID int) ([]models.CardSummary, error) {
+	return f.ListCards(userID)
+}
+
+func (f *fakeCardService) RevealCard(userID, id int, password string) (*models.CardDetails, error) {
+	if password != "secret" {
+		return nil, models.ErrInvalidPassword
+	}
+	return &models.CardDetails{ID: id, CardNumber: "2200120000001234", ExpirationDate: "05/30"}, nil
+}
+
+func (f *fakeCardService) BlockCard(userID, id int, reason string) (*models.CardSummary, error) {
+	card, err := f.GetCard(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	card.Status = models.CardStatusBlocked
+	return card, nil
+}
+
+func (f *fakeCardService) UnblockCard(userID, id int, reason string) (*models.CardSummary, error) {
+	return f.GetCard(userID, id)
+}
+
+func (f *fakeCardService) CancelCard(operatorID int, role string, id int, status, reason string) (*models.CardSummary, error) {
+	if role != models.RoleOperator {
+		return nil, models.ErrOperatorRequired
+	}
+	return &models.CardSummary{ID: id, Status: status}, nil
+}
+
+func (f *fakeCardService) UnlockCardPIN(operatorID int, role string, id int, reason string) (*models.CardSummary, error) {
+	if role != models.RoleOperator {
+		return nil, models.ErrOperatorRequired
+	}
+	return &models.CardSummary{ID: id, Status: models.CardStatusActive}, nil
+}
+
+func (f *fakeCardService) CardStatusHistory(userID int, role string, id int) ([]models.CardStatusChange, error) {
+	return []models.CardStatusChange{}, nil
+}
+
 func TestCreateCardHandler(t *testing.T) {
 	fakeSvc := &fakeCardService{}
 	handler := handlers.NewCardHandler(fakeSvc)
@@ -74,6 +149,38 @@ func TestCreateCardHandler(t *testing.T) {
 	}
 }
 
+func TestCreateVirtualCardHandler(t *testing.T) {
+	handler := handlers.NewCardHandler(&fakeCardService{})
+	tests := []struct {
+		name string
+		body string
+		code int
+	}{
+		{"single-use", `{"account_id": 101, "kind": "single_use", "spend_cap": "30.00"}`, http.StatusOK},
+		{"no spend cap", `{"account_id": 101, "kind": "merchant_locked"}`, http.StatusBadRequest},
+	}
+	for _, tc := range tests {
+		req := httptest.NewRequest("POST", "/cards", strings.NewReader(tc.body))
+		req = req.WithContext(context.WithValue(req.Context(), "userID", "42"))
+		rr := httptest.NewRecorder()
+		handler.CreateCard(rr, req)
+		if rr.Code != tc.code {
+			t.Errorf("%s: expected status %d, got %d", tc.name, tc.code, rr.Code)
+			continue
+		}
+		if tc.code != http.StatusOK {
+			continue
+		}
+		var card models.Card
+		if err := json.NewDecoder(rr.Body).Decode(&card); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if card.Kind != models.CardKindSingleUse || card.SpendCap == nil || card.SpendCap.String() != "30.00" {
+			t.Errorf("expected single-use card with cap 30.00, got %+v", card)
+		}
+	}
+}
+
 func TestGetCardHandler(t *testing.T) {
 	fakeSvc := &fakeCardService{}
 	handler := handlers.NewCardHandler(fakeSvc)
@@ -91,7 +198,7 @@ func TestGetCardHandler(t *testing.T) {
 		t.Errorf("expected status 200, got %d", rr.Code)
 	}
 
-	var card models.Card
+	var card models.CardSummary
 	if err := json.NewDecoder(rr.Body).Decode(&card); err != nil {
 		t.Fatalf("failed to decode response: %v", err)
 	}
@@ -101,4 +208,87 @@ func TestGetCardHandler(t *testing.T) {
 	if card.UserID != 42 {
 		t.Errorf("expected userID 42, got %d", card.UserID)
 	}
+	if card.MaskedPAN != "220012******1234" {
+		t.Errorf("expected masked PAN, got %q", card.MaskedPAN)
+	}
+}
+
+func TestGetCardHandlerForbidden(t *testing.T) {
+	handler := handlers.NewCardHandler(&fakeCardService{})
+
+	req := httptest.NewRequest("GET", "/cards/1", nil)
+	req = req.WithContext(context.WithValue(req.Context(), "userID", "7"))
+	req = mux.SetURLVars(req, map[string]string{"id": "1"})
+
+	rr := httptest.NewRecorder()
+	handler.GetCard(rr, req)
+
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("expected status 403, got %d", rr.Code)
+	}
+}
+
+func TestRevealCardHandler(t *testing.T) {
+	handler := handlers.NewCardHandler(&fakeCardService{})
+
+	reveal := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/cards/1/reveal", strings.NewReader(body))
+		req = req.WithContext(context.WithValue(req.Context(), "userID", "42"))
+		req = mux.SetURLVars(req, map[string]string{"id": "1"})
+		rr := httptest.NewRecorder()
+		handler.RevealCard(rr, req)
+		return rr
+	}
+
+	if rr := reveal(`{"password": "wrong"}`); rr.Code != http.StatusForbidden {
+		t.Errorf("expected status 403 for wrong password, got %d", rr.Code)
+	}
+	if rr := reveal(`{}`); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 without password, got %d", rr.Code)
+	}
+
+	rr := reveal(`{"password": "secret"}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rr.Code)
+	}
+	if rr.Header().Get("Cache-Control") != "no-store" {
+		t.Errorf("expected Cache-Control: no-store, got %q", rr.Header().Get("Cache-Control"))
+	}
+	var details models.CardDetails
+	if err := json.NewDecoder(rr.Body).Decode(&details); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if details.CardNumber != "2200120000001234" {
+		t.Errorf("unexpected card number %q", details.CardNumber)
+	}
+}
+
+func TestCardStatusHandlers(t *testing.T) {
+	handler := handlers.NewCardHandler(&fakeCardService{})
+
+	req := httptest.NewRequest("POST", "/cards/1/block", strings.NewReader(`{"reason": "misplaced"}`))
+	req = req.WithContext(context.WithValue(req.Context(), "userID", "42"))
+	req = mux.SetURLVars(req, map[string]string{"id": "1"})
+	rr := httptest.NewRecorder()
+	handler.BlockCard(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rr.Code)
+	}
+	var card models.CardSummary
+	if err := json.NewDecoder(rr.Body).Decode(&card); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if card.Status != models.CardStatusBlocked {
+		t.Errorf("expected blocked card, got %q", card.Status)
+	}
+
+	// Клиент не может окончательно заблокировать карту.
+	req = httptest.NewRequest("POST", "/cards/1/cancel", strings.NewReader(`{"status": "stolen"}`))
+	req = req.WithContext(context.WithValue(req.Context(), "userID", "42"))
+	req = mux.SetURLVars(req, map[string]string{"id": "1"})
+	rr = httptest.NewRecorder()
+	handler.CancelCard(rr, req)
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("expected status 403, got %d", rr.Code)
+	}
 }
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"bank-api/models"
+	"bank-api/services"
+
+	"github.com/gorilla/mux"
+)
+
+// CardProductHandler обрабатывает запросы к карточным продуктам.
+type CardProductHandler struct {
+	productService services.CardProductService
+}
+
+// NewCardProductHandler создаёт новый экземпляр CardProductHandler.
+func NewCardProductHandler(productService services.CardProductService) *CardProductHandler {
+	return &CardProductHandler{productService: productService}
+}
+
+// List возвращает продукты, доступные для выпуска карт.
+// URL: GET /card-products
+func (h *CardProductHandler) List(w http.ResponseWriter, r *http.Request) {
+	products, err := h.productService.ListProducts()
+	if err != nil {
+		writeServiceError(w, "Error fetching card products: ", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(products)
+}
+
+// Create добавляет карточный продукт (только операционист).
+// URL: POST /card-products
+func (h *CardProductHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var product models.CardProduct
+	if err := json.NewDecoder(r.Body).Decode(&product); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	if err := h.productService.CreateProduct(roleFromContext(r), &product); err != nil {
+		writeServiceError(w, "Error creating card product: ", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(product)
+}
+
+// Deactivate прекращает выпуск карт по продукту (только операционист).
+// URL: DELETE /card-products/{code}
+func (h *CardProductHandler) Deactivate(w http.ResponseWriter, r *http.Request) {
+	if err := h.productService.DeactivateProduct(roleFromContext(r), mux.Vars(r)["code"]); err != nil {
+		writeServiceError(w, "Error deactivating card product: ", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -29,14 +29,22 @@ func NewCreditHandler(creditService services.CreditService) *CreditHandler {
 // ApplyForCredit обрабатывает POST-запрос на оформление кредита.
 // URL: /credits
 func (h *CreditHandler) ApplyForCredit(w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromContext(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
 	var credit models.Credit
 	if err := json.NewDecoder(r.Body).Decode(&credit); err != nil {
 		http.Error(w, "Неверный формат запроса", http.StatusBadRequest)
 		return
 	}
+	// Заемщиком всегда является текущий пользователь.
+	credit.UserID = userID
 
 	if err := h.creditService.ApplyForCredit(&credit); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeServiceError(w, "", err)
 		return
 	}
 
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"bank-api/services"
+
+	"github.com/gorilla/mux"
+)
+
+// CVVHandler обрабатывает запросы партнеров на проверку CVV.
+type CVVHandler struct {
+	cvvService services.CVVService
+}
+
+// NewCVVHandler создаёт новый экземпляр CVVHandler.
+func NewCVVHandler(cvvService services.CVVService) *CVVHandler {
+	return &CVVHandler{cvvService: cvvService}
+}
+
+// VerifyCVV сверяет CVV карты {"cvv"} и возвращает {"valid", "attempts_left"}.
+// URL: POST /cards/{id}/verify-cvv
+func (h *CVVHandler) VerifyCVV(w http.ResponseWriter, r *http.Request) {
+	cardID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid card ID", http.StatusBadRequest)
+		return
+	}
+	var req struct {
+		CVV string `json:"cvv"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.CVV == "" {
+		http.Error(w, "cvv is required", http.StatusBadRequest)
+		return
+	}
+	result, err := h.cvvService.VerifyCVV(cardID, req.CVV, r.RemoteAddr)
+	if err != nil {
+		writeServiceError(w, "CVV verification failed: ", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"bank-api/models"
+	"bank-api/services"
+
+	"github.com/gorilla/mux"
+)
+
+// FeeHandler обрабатывает расчет комиссий и правила тарифа.
+type FeeHandler struct {
+	feeService services.FeeService
+}
+
+// NewFeeHandler создаёт новый экземпляр FeeHandler.
+func NewFeeHandler(feeService services.FeeService) *FeeHandler {
+	return &FeeHandler{feeService: feeService}
+}
+
+// Quote рассчитывает комиссию за операцию без ее исполнения.
+// URL: POST /fees/quote
+func (h *FeeHandler) Quote(w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromContext(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var req struct {
+		AccountID int          `json:"account_id"`
+		Operation string       `json:"operation"`
+		Amount    models.Money `json:"amount"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	quote, err := h.feeService.QuoteForUser(userID, req.AccountID, req.Operation, req.Amount)
+	if err != nil {
+		writeServiceError(w, "Error quoting fee: ", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(quote)
+}
+
+// ListRules возвращает правила тарифа, включая выключенные.
+// URL: GET /fees/rules
+func (h *FeeHandler) ListRules(w http.ResponseWriter, r *http.Request) {
+	rules, err := h.feeService.ListRules()
+	if err != nil {
+		writeServiceError(w, "Error fetching fee rules: ", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rules)
+}
+
+// CreateRule добавляет правило тарифа (только операционист).
+// URL: POST /fees/rules
+func (h *FeeHandler) CreateRule(w http.ResponseWriter, r *http.Request) {
+	var rule models.FeeRule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	if err := h.feeService.CreateRule(roleFromContext(r), &rule); err != nil {
+		writeServiceError(w, "Error creating fee rule: ", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(rule)
+}
+
+// DeactivateRule выключает правило тарифа (только операционист).
+// URL: DELETE /fees/rules/{id}
+func (h *FeeHandler) DeactivateRule(w http.ResponseWriter, r *http.Request) {
+	ruleID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid fee rule ID", http.StatusBadRequest)
+		return
+	}
+	if err := h.feeService.DeactivateRule(roleFromContext(r), ruleID); err != nil {
+		writeServiceError(w, "Error deactivating fee rule: ", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
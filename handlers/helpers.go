@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"bank-api/middleware"
+	"bank-api/models"
+)
+
+// userIDFromContext извлекает идентификатор пользователя, установленный AuthMiddleware.
+func userIDFromContext(r *http.Request) (int, error) {
+	userIDStr, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok || userIDStr == "" {
+		return 0, errors.New("user id not found in context")
+	}
+	return strconv.Atoi(userIDStr)
+}
+
+// roleFromContext возвращает роль пользователя из токена; по умолчанию — клиент.
+func roleFromContext(r *http.Request) string {
+	if role, ok := r.Context().Value(middleware.RoleKey).(string); ok && role != "" {
+		return role
+	}
+	return models.RoleCustomer
+}
+
+// writeServiceError отображает доменные ошибки в HTTP-статусы;
+// неизвестные ошибки считаются внутренними (500).
+func writeServiceError(w http.ResponseWriter, prefix string, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, models.ErrAccountNotFound),
+		errors.Is(err, models.ErrStandingOrderNotFound),
+		errors.Is(err, models.ErrCardNotFound),
+		errors.Is(err, models.ErrTransferNotFound),
+		errors.Is(err, models.ErrBatchTransferNotFound),
+		errors.Is(err, models.ErrFeeRuleNotFound),
+		errors.Is(err, models.ErrCardProductNotFound),
+		errors.Is(err, models.ErrAuthorizationNotFound),
+		errors.Is(err, models.ErrMemberNotFound),
+		errors.Is(err, models.ErrUserNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, models.ErrNotAccountOwner),
+		errors.Is(err, models.ErrNotCardOwner),
+		errors.Is(err, models.ErrReversalNotAllowed),
+		errors.Is(err, models.ErrSpendLimitExceeded),
+		errors.Is(err, models.ErrOperatorRequired),
+		errors.Is(err, models.ErrInvalidPIN),
+		errors.Is(err, models.ErrInvalidPassword):
+		status = http.StatusForbidden
+	case errors.Is(err, models.ErrAccountInactive),
+		errors.Is(err, models.ErrCardInactive),
+		errors.Is(err, models.ErrVirtualCardSource),
+		errors.Is(err, models.ErrCardNumberTaken),
+		errors.Is(err, models.ErrInvalidStatusTransition),
+		errors.Is(err, models.ErrAccountNotEmpty),
+		errors.Is(err, models.ErrAccountHasCredits),
+		errors.Is(err, models.ErrAccountHasCards),
+		errors.Is(err, models.ErrAccountHasHolds),
+		errors.Is(err, models.ErrTransferFullyReversed),
+		errors.Is(err, models.ErrMemberAlreadyExists),
+		errors.Is(err, models.ErrPINNotSet),
+		errors.Is(err, models.ErrPINAlreadySet),
+		errors.Is(err, models.ErrLastAccountOwner):
+		status = http.StatusConflict
+	case errors.Is(err, models.ErrInsufficientFunds),
+		errors.Is(err, models.ErrReversalExceedsBalance),
+		errors.Is(err, models.ErrCaptureExceedsHold),
+		errors.Is(err, models.ErrCurrencyMismatch):
+		status = http.StatusUnprocessableEntity
+	case errors.Is(err, models.ErrSameAccount),
+		errors.Is(err, models.ErrInvalidAmount),
+		errors.Is(err, models.ErrInvalidInterestRate),
+		errors.Is(err, models.ErrInvalidPeriod),
+		errors.Is(err, models.ErrInvalidSchedule),
+		errors.Is(err, models.ErrInvalidPAN),
+		errors.Is(err, models.ErrInvalidCardStatus),
+		errors.Is(err, models.ErrInvalidPINBlock),
+		errors.Is(err, models.ErrInvalidAccountNumber),
+		errors.Is(err, models.ErrInvalidAccountType),
+		errors.Is(err, models.ErrInvalidMemberRole),
+		errors.Is(err, models.ErrUnsupportedCurrency),
+		errors.Is(err, models.ErrInvalidBatch),
+		errors.Is(err, models.ErrUnsupportedBatchFormat),
+		errors.Is(err, models.ErrInvalidFeeRule),
+		errors.Is(err, models.ErrInvalidCardProduct),
+		errors.Is(err, models.ErrInvalidVirtualCard),
+		errors.Is(err, models.ErrInvalidOperation),
+		errors.Is(err, models.ErrUnsupportedStatementFmt):
+		status = http.StatusBadRequest
+	case errors.Is(err, models.ErrTooManyAttempts):
+		status = http.StatusTooManyRequests
+	case errors.Is(err, models.ErrRateUnavailable):
+		status = http.StatusServiceUnavailable
+	}
+	http.Error(w, prefix+err.Error(), status)
+}
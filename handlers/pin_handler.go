@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"bank-api/models"
+	"bank-api/services"
+
+	"github.com/gorilla/mux"
+)
+
+// PINHandler обрабатывает запросы на установку, смену и проверку PIN карты.
+type PINHandler struct {
+	pinService services.PINService
+}
+
+// NewPINHandler создаёт новый экземпляр PINHandler.
+func NewPINHandler(pinService services.PINService) *PINHandler {
+	return &PINHandler{pinService: pinService}
+}
+
+// SetPIN устанавливает первый PIN карты из PIN-блока {"format", "pin_block"}.
+// URL: POST /cards/{id}/pin
+func (h *PINHandler) SetPIN(w http.ResponseWriter, r *http.Request) {
+	userID, cardID, ok := pinRequestIDs(w, r)
+	if !ok {
+		return
+	}
+	var block models.PINBlock
+	if err := json.NewDecoder(r.Body).Decode(&block); err != nil || block.Block == "" {
+		http.Error(w, "pin_block is required", http.StatusBadRequest)
+		return
+	}
+	if err := h.pinService.SetPIN(userID, cardID, block); err != nil {
+		writeServiceError(w, "Failed to set PIN: ", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ChangePIN меняет PIN: {"current": PIN-блок, "new": PIN-блок}.
+// URL: PUT /cards/{id}/pin
+func (h *PINHandler) ChangePIN(w http.ResponseWriter, r *http.Request) {
+	userID, cardID, ok := pinRequestIDs(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		Current models.PINBlock `json:"current"`
+		New     models.PINBlock `json:"new"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Current.Block == "" || req.New.Block == "" {
+		http.Error(w, "current and new PIN blocks are required", http.StatusBadRequest)
+		return
+	}
+	if err := h.pinService.ChangePIN(userID, cardID, req.Current, req.New); err != nil {
+		writeServiceError(w, "Failed to change PIN: ", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// VerifyPIN проверяет PIN карты. URL: POST /cards/{id}/pin/verify
+func (h *PINHandler) VerifyPIN(w http.ResponseWriter, r *http.Request) {
+	userID, cardID, ok := pinRequestIDs(w, r)
+	if !ok {
+		return
+	}
+	var block models.PINBlock
+	if err := json.NewDecoder(r.Body).Decode(&block); err != nil || block.Block == "" {
+		http.Error(w, "pin_block is required", http.StatusBadRequest)
+		return
+	}
+	if err := h.pinService.VerifyPIN(userID, cardID, block); err != nil {
+		writeServiceError(w, "PIN verification failed: ", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// pinRequestIDs извлекает пользователя и карту; при ошибке ответ уже записан.
+func pinRequestIDs(w http.ResponseWriter, r *http.Request) (userID, cardID int, ok bool) {
+	userID, err := userIDFromContext(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return 0, 0, false
+	}
+	cardID, err = strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid card ID", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	return userID, cardID, true
+}
@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"bank-api/models"
+	"bank-api/services"
+
+	"github.com/gorilla/mux"
+)
+
+// StandingOrderHandler обрабатывает CRUD постоянных поручений.
+type StandingOrderHandler struct {
+	standingOrderService services.StandingOrderService
+}
+
+// NewStandingOrderHandler создаёт новый экземпляр StandingOrderHandler.
+func NewStandingOrderHandler(standingOrderService services.StandingOrderService) *StandingOrderHandler {
+	return &StandingOrderHandler{standingOrderService: standingOrderService}
+}
+
+// standingOrderRequest — тело POST и PUT запросов.
+type standingOrderRequest struct {
+	FromAccountID int          `json:"from_account_id"`
+	ToAccountID   int          `json:"to_account_id"`
+	Amount        models.Money `json:"amount"`
+	Frequency     string       `json:"frequency"`
+	CronExpr      string       `json:"cron_expr"`
+	StartAt       time.Time    `json:"start_at"`
+	Status        string       `json:"status"`
+}
+
+func (req standingOrderRequest) toModel(userID int) *models.StandingOrder {
+	return &models.StandingOrder{
+		UserID:        userID,
+		FromAccountID: req.FromAccountID,
+		ToAccountID:   req.ToAccountID,
+		Amount:        req.Amount,
+		Frequency:     req.Frequency,
+		CronExpr:      req.CronExpr,
+		StartAt:       req.StartAt,
+		Status:        req.Status,
+	}
+}
+
+// Create создаёт постоянное поручение.
+// URL: POST /standing-orders
+func (h *StandingOrderHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromContext(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var req standingOrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	order := req.toModel(userID)
+	if err := h.standingOrderService.Create(order); err != nil {
+		writeServiceError(w, "Error creating standing order: ", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(order)
+}
+
+// List возвращает поручения пользователя.
+// URL: GET /standing-orders
+func (h *StandingOrderHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromContext(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	orders, err := h.standingOrderService.List(userID)
+	if err != nil {
+		writeServiceError(w, "Error fetching standing orders: ", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(orders)
+}
+
+// Get возвращает поручение по ID.
+// URL: GET /standing-orders/{id}
+func (h *StandingOrderHandler) Get(w http.ResponseWriter, r *http.Request) {
+	userID, orderID, ok := standingOrderParams(w, r)
+	if !ok {
+		return
+	}
+	order, err := h.standingOrderService.Get(userID, orderID)
+	if err != nil {
+		writeServiceError(w, "Error fetching standing order: ", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(order)
+}
+
+// Update заменяет параметры поручения; status позволяет приостановить или возобновить его.
+// URL: PUT /standing-orders/{id}
+func (h *StandingOrderHandler) Update(w http.ResponseWriter, r *http.Request) {
+	userID, orderID, ok := standingOrderParams(w, r)
+	if !ok {
+		return
+	}
+	var req standingOrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	order, err := h.standingOrderService.Update(userID, orderID, req.toModel(userID))
+	if err != nil {
+		writeServiceError(w, "Error updating standing order: ", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(order)
+}
+
+// Delete отменяет поручение.
+// URL: DELETE /standing-orders/{id}
+func (h *StandingOrderHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	userID, orderID, ok := standingOrderParams(w, r)
+	if !ok {
+		return
+	}
+	if err := h.standingOrderService.Cancel(userID, orderID); err != nil {
+		writeServiceError(w, "Error cancelling standing order: ", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Runs возвращает историю запусков поручения.
+// URL: GET /standing-orders/{id}/runs
+func (h *StandingOrderHandler) Runs(w http.ResponseWriter, r *http.Request) {
+	userID, orderID, ok := standingOrderParams(w, r)
+	if !ok {
+		return
+	}
+	runs, err := h.standingOrderService.Runs(userID, orderID)
+	if err != nil {
+		writeServiceError(w, "Error fetching standing order runs: ", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(runs)
+}
+
+// standingOrderParams извлекает пользователя и ID поручения; при ошибке ответ уже записан.
+func standingOrderParams(w http.ResponseWriter, r *http.Request) (userID, orderID int, ok bool) {
+	userID, err := userIDFromContext(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return 0, 0, false
+	}
+	orderID, err = strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid standing order ID", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	return userID, orderID, true
+}
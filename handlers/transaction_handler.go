@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"bank-api/models"
+	"bank-api/services"
+
+	"github.com/gorilla/mux"
+)
+
+// TransactionHandler отвечает за историю операций по счетам.
+type TransactionHandler struct {
+	transactionService services.TransactionService
+	statementService   services.StatementService
+}
+
+// NewTransactionHandler создаёт новый экземпляр TransactionHandler.
+func NewTransactionHandler(transactionService services.TransactionService, statementService services.StatementService) *TransactionHandler {
+	return &TransactionHandler{transactionService: transactionService, statementService: statementService}
+}
+
+// GetAccountTransactions возвращает историю операций счета от новых к старым.
+// URL: GET /accounts/{id}/transactions?cursor=&limit=&from=&to=&type=&min_amount=&max_amount=
+// Даты принимаются в формате RFC 3339 или YYYY-MM-DD; to не включается в период.
+func (h *TransactionHandler) GetAccountTransactions(w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromContext(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	accountID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid account ID", http.StatusBadRequest)
+		return
+	}
+	filter, err := parseTransactionFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, err := h.transactionService.GetAccountTransactions(userID, accountID, filter)
+	if err != nil {
+		writeServiceError(w, "Error fetching transactions: ", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
+
+// GetStatement выгружает выписку по счету с остатками на начало и конец периода.
+// URL: GET /accounts/{id}/statement?from=&to=&format=csv|ofx|camt053
+// Выписка пишется в ответ потоком; to не включается в период.
+func (h *TransactionHandler) GetStatement(w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromContext(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	accountID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid account ID", http.StatusBadRequest)
+		return
+	}
+	q := r.URL.Query()
+	format := q.Get("format")
+	if format == "" {
+		format = models.StatementFormatCSV
+	}
+	contentType, ext, err := services.StatementContentType(format)
+	if err != nil {
+		writeServiceError(w, "", err)
+		return
+	}
+	from, err := parseDateParam(q.Get("from"))
+	if err != nil {
+		http.Error(w, "invalid from parameter", http.StatusBadRequest)
+		return
+	}
+	to, err := parseDateParam(q.Get("to"))
+	if err != nil {
+		http.Error(w, "invalid to parameter", http.StatusBadRequest)
+		return
+	}
+
+	sw := &statementResponseWriter{
+		w:           w,
+		contentType: contentType,
+		filename:    fmt.Sprintf("statement-%d.%s", accountID, ext),
+	}
+	if err := h.statementService.WriteStatement(r.Context(), userID, accountID, from, to, format, sw); err != nil {
+		if !sw.started {
+			writeServiceError(w, "Error building statement: ", err)
+			return
+		}
+		// Заголовки уже отправлены: статус изменить нельзя, ответ обрывается.
+		log.Printf("statement for account %d interrupted: %v", accountID, err)
+	}
+}
+
+// statementResponseWriter выставляет заголовки выписки при первой записи,
+// чтобы ошибки до начала выгрузки можно было вернуть обычным статусом.
+type statementResponseWriter struct {
+	w           http.ResponseWriter
+	contentType string
+	filename    string
+	started     bool
+}
+
+func (s *statementResponseWriter) Write(p []byte) (int, error) {
+	if !s.started {
+		s.started = true
+		s.w.Header().Set("Content-Type", s.contentType)
+		s.w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, s.filename))
+		s.w.WriteHeader(http.StatusOK)
+	}
+	return s.w.Write(p)
+}
+
+// parseTransactionFilter читает параметры фильтра из строки запроса.
+func parseTransactionFilter(r *http.Request) (models.TransactionFilter, error) {
+	q := r.URL.Query()
+	var filter models.TransactionFilter
+	var err error
+
+	if v := q.Get("limit"); v != "" {
+		if filter.Limit, err = strconv.Atoi(v); err != nil || filter.Limit <= 0 {
+			return filter, fmt.Errorf("invalid limit parameter")
+		}
+	}
+	if v := q.Get("cursor"); v != "" {
+		if filter.After, err = models.DecodeTransactionCursor(v); err != nil {
+			return filter, err
+		}
+	}
+	if filter.From, err = parseDateParam(q.Get("from")); err != nil {
+		return filter, fmt.Errorf("invalid from parameter")
+	}
+	if filter.To, err = parseDateParam(q.Get("to")); err != nil {
+		return filter, fmt.Errorf("invalid to parameter")
+	}
+	filter.Type = q.Get("type")
+	for name, dst := range map[string]**models.Money{"min_amount": &filter.MinAmount, "max_amount": &filter.MaxAmount} {
+		if v := q.Get(name); v != "" {
+			amount, err := models.ParseMoney(v, "")
+			if err != nil {
+				return filter, fmt.Errorf("invalid %s parameter", name)
+			}
+			*dst = &amount
+		}
+	}
+	return filter, nil
+}
+
+// parseDateParam разбирает дату в формате RFC 3339 или YYYY-MM-DD; пустая строка — нулевое время.
+func parseDateParam(v string) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", v)
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"bank-api/models"
+	"bank-api/services"
+
+	"github.com/gorilla/mux"
+)
+
+// TransferHandler обрабатывает переводы по реквизитам карт и возвраты переводов.
+type TransferHandler struct {
+	transferService services.TransferService
+}
+
+// NewTransferHandler создаёт новый экземпляр TransferHandler.
+func NewTransferHandler(transferService services.TransferService) *TransferHandler {
+	return &TransferHandler{transferService: transferService}
+}
+
+// CardToCard переводит деньги с карты пользователя на карту по номеру.
+// URL: POST /transfers/card-to-card
+func (h *TransferHandler) CardToCard(w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromContext(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var req struct {
+		FromCardID int          `json:"from_card_id"`
+		ToPAN      string       `json:"to_pan"`
+		Amount     models.Money `json:"amount"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	result, err := h.transferService.CardToCard(userID, req.FromCardID, req.ToPAN, req.Amount)
+	if err != nil {
+		writeServiceError(w, "Transfer failed: ", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"status": "ok", "card_transfer": result})
+}
+
+// GetTransfer возвращает перевод и проведенные по нему возвраты.
+// URL: GET /transfers/{id}
+func (h *TransferHandler) GetTransfer(w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromContext(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	transferID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid transfer ID", http.StatusBadRequest)
+		return
+	}
+	transfer, reversals, err := h.transferService.GetTransfer(userID, roleFromContext(r), transferID)
+	if err != nil {
+		writeServiceError(w, "Error fetching transfer: ", err)
+		return
+	}
+	if reversals == nil {
+		reversals = []models.TransferReversal{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"transfer": transfer, "reversals": reversals})
+}
+
+// Reverse возвращает перевод полностью или частично.
+// URL: POST /transfers/{id}/reverse
+// Тело запроса необязательно: {"amount": 100.50, "reason": "..."}; без amount возвращается весь остаток.
+func (h *TransferHandler) Reverse(w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromContext(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	transferID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid transfer ID", http.StatusBadRequest)
+		return
+	}
+	var req struct {
+		Amount *models.Money `json:"amount"`
+		Reason string        `json:"reason"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+	}
+	reversal, transfer, err := h.transferService.Reverse(userID, roleFromContext(r), transferID, req.Amount, req.Reason)
+	if err != nil {
+		writeServiceError(w, "Reversal failed: ", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"status": "ok", "reversal": reversal, "transfer": transfer})
+}
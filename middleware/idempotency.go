@@ -0,0 +1,135 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"bank-api/models"
+)
+
+// IdempotencyKeyHeader — заголовок, по которому клиент помечает повторы запроса.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// IdempotencyStore хранит ключи идемпотентности.
+type IdempotencyStore interface {
+	// Reserve атомарно занимает ключ для rec. Если действующий ключ уже есть,
+	// возвращает сохраненную запись; если ключ занят успешно — nil.
+	Reserve(rec *models.IdempotencyRecord) (*models.IdempotencyRecord, error)
+	// Complete сохраняет ответ на запрос.
+	Complete(rec *models.IdempotencyRecord) error
+	// Release удаляет ключ, чтобы запрос можно было повторить.
+	Release(userID int, key string) error
+}
+
+// IdempotencyMiddleware обрабатывает заголовок Idempotency-Key для изменяющих запросов.
+// Повтор с тем же запросом получает сохраненный ответ, с другим телом — 422,
+// повтор во время выполнения первого запроса — 409. Ответы 5xx не сохраняются.
+// Должен подключаться после AuthMiddleware: ключи хранятся в разрезе пользователя.
+func IdempotencyMiddleware(store IdempotencyStore, ttl time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			userIDStr, _ := r.Context().Value(UserIDKey).(string)
+			userID, err := strconv.Atoi(userIDStr)
+			if err != nil {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				http.Error(w, "Invalid request body", http.StatusBadRequest)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			now := time.Now()
+			rec := &models.IdempotencyRecord{
+				UserID:      userID,
+				Key:         key,
+				RequestHash: requestHash(r, body),
+				CreatedAt:   now,
+				ExpiresAt:   now.Add(ttl),
+			}
+			existing, err := store.Reserve(rec)
+			if err != nil {
+				http.Error(w, "Idempotency store error", http.StatusInternalServerError)
+				return
+			}
+			if existing != nil {
+				switch {
+				case existing.RequestHash != rec.RequestHash:
+					http.Error(w, "Idempotency-Key was used with a different request", http.StatusUnprocessableEntity)
+				case !existing.Completed:
+					http.Error(w, "Request with this Idempotency-Key is still in progress", http.StatusConflict)
+				default:
+					if existing.ContentType != "" {
+						w.Header().Set("Content-Type", existing.ContentType)
+					}
+					w.Header().Set("Idempotent-Replayed", "true")
+					w.WriteHeader(existing.StatusCode)
+					w.Write(existing.ResponseBody)
+				}
+				return
+			}
+
+			rw := &capturingResponseWriter{ResponseWriter: w, status: http.StatusOK}
+			defer func() {
+				// При панике или ошибке сервера ключ освобождается для повтора.
+				if p := recover(); p != nil {
+					store.Release(userID, key)
+					panic(p)
+				}
+				if rw.status >= http.StatusInternalServerError {
+					store.Release(userID, key)
+					return
+				}
+				rec.Completed = true
+				rec.StatusCode = rw.status
+				rec.ContentType = rw.Header().Get("Content-Type")
+				rec.ResponseBody = rw.body.Bytes()
+				store.Complete(rec)
+			}()
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
+
+// requestHash связывает ключ с конкретным запросом: методом, путем и телом.
+func requestHash(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.Path+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// capturingResponseWriter передает ответ клиенту и запоминает его копию.
+type capturingResponseWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (w *capturingResponseWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *capturingResponseWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
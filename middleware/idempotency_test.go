@@ -0,0 +1,127 @@
+package middleware_test
+
+import (
+	"bank-api/middleware"
+	"bank-api/models"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryIdempotencyStore — хранилище ключей в памяти для тестов.
+type memoryIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]*models.IdempotencyRecord
+}
+
+func newMemoryIdempotencyStore() *memoryIdempotencyStore {
+	return &memoryIdempotencyStore{records: make(map[string]*models.IdempotencyRecord)}
+}
+
+func (s *memoryIdempotencyStore) Reserve(rec *models.IdempotencyRecord) (*models.IdempotencyRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existing, ok := s.records[rec.Key]; ok && existing.ExpiresAt.After(time.Now()) {
+		copied := *existing
+		return &copied, nil
+	}
+	copied := *rec
+	s.records[rec.Key] = &copied
+	return nil, nil
+}
+
+func (s *memoryIdempotencyStore) Complete(rec *models.IdempotencyRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	copied := *rec
+	s.records[rec.Key] = &copied
+	return nil
+}
+
+func (s *memoryIdempotencyStore) Release(userID int, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, key)
+	return nil
+}
+
+func idempotentRequest(key, body string) *http.Request {
+	req := httptest.NewRequest("POST", "/transfer", strings.NewReader(body))
+	req.Header.Set(middleware.IdempotencyKeyHeader, key)
+	return req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, "42"))
+}
+
+func TestIdempotencyMiddleware_ReplaysStoredResponse(t *testing.T) {
+	calls := 0
+	finalHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"status":"ok"}`))
+	})
+	handler := middleware.IdempotencyMiddleware(newMemoryIdempotencyStore(), time.Hour)(finalHandler)
+
+	for i := 0; i < 2; i++ {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, idempotentRequest("k1", `{"amount": 10}`))
+		if rr.Code != http.StatusCreated {
+			t.Errorf("attempt %d: expected status 201, got %d", i+1, rr.Code)
+		}
+		if rr.Body.String() != `{"status":"ok"}` {
+			t.Errorf("attempt %d: unexpected body %q", i+1, rr.Body.String())
+		}
+	}
+	if calls != 1 {
+		t.Errorf("expected handler to run once, ran %d times", calls)
+	}
+
+	// Тот же ключ с другим телом запроса.
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, idempotentRequest("k1", `{"amount": 20}`))
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected status 422 for different body, got %d", rr.Code)
+	}
+}
+
+func TestIdempotencyMiddleware_InProgressAndServerErrors(t *testing.T) {
+	store := newMemoryIdempotencyStore()
+	release := make(chan struct{})
+	started := make(chan struct{})
+	slowHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := middleware.IdempotencyMiddleware(store, time.Hour)(slowHandler)
+
+	done := make(chan struct{})
+	go func() {
+		handler.ServeHTTP(httptest.NewRecorder(), idempotentRequest("k2", `{}`))
+		close(done)
+	}()
+	<-started
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, idempotentRequest("k2", `{}`))
+	if rr.Code != http.StatusConflict {
+		t.Errorf("expected status 409 while first request runs, got %d", rr.Code)
+	}
+	close(release)
+	<-done
+
+	// Ответ 5xx не сохраняется: повтор снова доходит до обработчика.
+	calls := 0
+	failing := middleware.IdempotencyMiddleware(store, time.Hour)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	failing.ServeHTTP(httptest.NewRecorder(), idempotentRequest("k3", `{}`))
+	failing.ServeHTTP(httptest.NewRecorder(), idempotentRequest("k3", `{}`))
+	if calls != 2 {
+		t.Errorf("expected failed request to be retried, handler ran %d times", calls)
+	}
+}
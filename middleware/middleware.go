@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"context"
+	"crypto/subtle"
 	"net/http"
 	"strings"
 	"time"
@@ -13,6 +14,9 @@ import (
 // UserIDKey используется для передачи идентификатора пользователя в контексте.
 const UserIDKey = "userID"
 
+// RoleKey используется для передачи роли пользователя (claim "role") в контексте.
+const RoleKey = "role"
+
 // AuthMiddleware проверяет наличие и валидность JWT-токена.
 // В случае успешной проверки извлекает идентификатор пользователя из токена 
 // и добавляет его в контекст запроса.
@@ -48,11 +52,44 @@ func AuthMiddleware(jwtSecret string) func(http.Handler) http.Handler {
 				return
 			}
 			ctx := context.WithValue(r.Context(), UserIDKey, userID)
+			if role, ok := claims["role"].(string); ok {
+				ctx = context.WithValue(ctx, RoleKey, role)
+			}
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
 
+// MerchantKeyHeader — заголовок с ключом торговой точки для API авторизаций по картам.
+const MerchantKeyHeader = "X-Merchant-Key"
+
+// PartnerKeyHeader — заголовок с ключом партнера для проверки CVV.
+const PartnerKeyHeader = "X-Partner-Key"
+
+// MerchantKeyMiddleware пропускает только запросы с ключом торговой точки merchantKey.
+func MerchantKeyMiddleware(merchantKey string) func(http.Handler) http.Handler {
+	return apiKeyMiddleware(MerchantKeyHeader, merchantKey, "Invalid merchant key")
+}
+
+// PartnerKeyMiddleware пропускает только запросы с ключом партнера partnerKey.
+func PartnerKeyMiddleware(partnerKey string) func(http.Handler) http.Handler {
+	return apiKeyMiddleware(PartnerKeyHeader, partnerKey, "Invalid partner key")
+}
+
+// apiKeyMiddleware сравнивает ключ из заголовка header с key за постоянное время.
+func apiKeyMiddleware(header, key, message string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got := r.Header.Get(header)
+			if got == "" || subtle.ConstantTimeCompare([]byte(got), []byte(key)) != 1 {
+				http.Error(w, message, http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 // LoggingMiddleware ведет логирование всех входящих HTTP-запросов,
 // фиксируя метод, URI, время выполнения запроса и IP клиента.
 func LoggingMiddleware(logger *logrus.Logger) func(http.Handler) http.Handler {
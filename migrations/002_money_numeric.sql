@@ -0,0 +1,6 @@
+-- Денежные суммы хранятся как NUMERIC с двумя знаками после запятой,
+-- чтобы исключить накопление ошибок округления float.
+ALTER TABLE accounts ALTER COLUMN balance TYPE NUMERIC(18,2) USING round(balance::numeric, 2);
+ALTER TABLE credits ALTER COLUMN amount TYPE NUMERIC(18,2) USING round(amount::numeric, 2);
+ALTER TABLE transactions ALTER COLUMN amount TYPE NUMERIC(18,2) USING round(amount::numeric, 2);
+ALTER TABLE payment_schedules ALTER COLUMN amount TYPE NUMERIC(18,2) USING round(amount::numeric, 2);
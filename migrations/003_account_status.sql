@@ -0,0 +1 @@
+ALTER TABLE accounts ADD COLUMN status TEXT NOT NULL DEFAULT 'active';
@@ -0,0 +1,43 @@
+-- Журнал двойной записи: каждая операция — запись с проводками,
+-- сумма проводок которой в каждой валюте равна нулю.
+CREATE TABLE journal_entries (
+    id SERIAL PRIMARY KEY,
+    type TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE postings (
+    id SERIAL PRIMARY KEY,
+    entry_id INTEGER NOT NULL REFERENCES journal_entries(id),
+    account_id INTEGER REFERENCES accounts(id),
+    system_account TEXT,
+    amount NUMERIC(18,2) NOT NULL,
+    currency TEXT NOT NULL,
+    CHECK ((account_id IS NULL) <> (system_account IS NULL))
+);
+
+CREATE INDEX postings_entry_id_idx ON postings (entry_id);
+CREATE INDEX postings_account_id_idx ON postings (account_id);
+
+ALTER TABLE transactions ADD COLUMN entry_id INTEGER REFERENCES journal_entries(id);
+ALTER TABLE credits ADD COLUMN currency TEXT NOT NULL DEFAULT 'RUB';
+
+-- Текущие остатки переносятся в журнал входящими записями,
+-- чтобы accounts.balance можно было пересчитать из проводок.
+DO $$
+DECLARE
+    acc RECORD;
+    new_entry_id INTEGER;
+BEGIN
+    FOR acc IN SELECT id, balance, currency FROM accounts WHERE balance <> 0 LOOP
+        INSERT INTO journal_entries (type, description)
+        VALUES ('opening_balance', 'opening balance, account ' || acc.id)
+        RETURNING id INTO new_entry_id;
+
+        INSERT INTO postings (entry_id, account_id, amount, currency)
+        VALUES (new_entry_id, acc.id, acc.balance, acc.currency);
+        INSERT INTO postings (entry_id, system_account, amount, currency)
+        VALUES (new_entry_id, 'opening_balance', -acc.balance, acc.currency);
+    END LOOP;
+END $$;
@@ -0,0 +1,18 @@
+ALTER TABLE transactions ADD COLUMN balance_after NUMERIC(18,2);
+
+-- Остаток после операции для существующих строк: текущий баланс счета
+-- минус все более поздние операции.
+UPDATE transactions t SET balance_after = s.balance_after
+FROM (
+    SELECT tr.id,
+           a.balance - COALESCE(SUM(tr.amount) OVER (
+               PARTITION BY tr.account_id
+               ORDER BY tr.created_at DESC, tr.id DESC
+               ROWS BETWEEN UNBOUNDED PRECEDING AND 1 PRECEDING
+           ), 0) AS balance_after
+    FROM transactions tr
+    JOIN accounts a ON a.id = tr.account_id
+) s
+WHERE s.id = t.id;
+
+CREATE INDEX transactions_account_created_idx ON transactions (account_id, created_at DESC, id DESC);
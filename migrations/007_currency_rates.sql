@@ -0,0 +1,11 @@
+CREATE TABLE currency_rates (
+    rate_date DATE NOT NULL,
+    currency TEXT NOT NULL,
+    nominal INTEGER NOT NULL,
+    value NUMERIC(18,4) NOT NULL,
+    PRIMARY KEY (rate_date, currency)
+);
+
+-- Курс конвертации для переводов между счетами в разных валютах.
+ALTER TABLE journal_entries ADD COLUMN fx_rate NUMERIC(18,6);
+ALTER TABLE transactions ADD COLUMN fx_rate NUMERIC(18,6);
@@ -0,0 +1,3 @@
+-- Допустимые статусы счета: active -> frozen -> active, active -> closed -> active.
+ALTER TABLE accounts ADD CONSTRAINT accounts_status_check
+    CHECK (status IN ('active', 'frozen', 'closed'));
@@ -0,0 +1,36 @@
+CREATE TABLE standing_orders (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id),
+    from_account_id INTEGER NOT NULL REFERENCES accounts(id),
+    to_account_id INTEGER NOT NULL REFERENCES accounts(id),
+    amount NUMERIC(18,2) NOT NULL CHECK (amount > 0),
+    currency TEXT NOT NULL,
+    frequency TEXT NOT NULL CHECK (frequency IN ('daily', 'weekly', 'monthly', 'cron')),
+    cron_expr TEXT NOT NULL DEFAULT '',
+    start_at TIMESTAMP NOT NULL,
+    next_run_at TIMESTAMP NOT NULL,
+    retry_at TIMESTAMP,
+    failure_count INTEGER NOT NULL DEFAULT 0,
+    status TEXT NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'suspended', 'cancelled')),
+    -- Аренда запуска: пока claimed_until в будущем, поручение исполняет другой экземпляр
+    claimed_until TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX standing_orders_due_idx ON standing_orders (COALESCE(retry_at, next_run_at))
+    WHERE status = 'active';
+CREATE INDEX standing_orders_user_idx ON standing_orders (user_id);
+
+CREATE TABLE standing_order_runs (
+    id SERIAL PRIMARY KEY,
+    order_id INTEGER NOT NULL REFERENCES standing_orders(id),
+    scheduled_at TIMESTAMP NOT NULL,
+    attempt INTEGER NOT NULL,
+    status TEXT NOT NULL CHECK (status IN ('succeeded', 'failed')),
+    error TEXT NOT NULL DEFAULT '',
+    entry_id INTEGER REFERENCES journal_entries(id),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX standing_order_runs_order_idx ON standing_order_runs (order_id, created_at DESC);
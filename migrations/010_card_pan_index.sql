@@ -0,0 +1,8 @@
+-- HMAC зашифрованных полей сохраняется вместе с ними, иначе карту нельзя расшифровать.
+ALTER TABLE cards ADD COLUMN card_number_mac TEXT NOT NULL DEFAULT '';
+ALTER TABLE cards ADD COLUMN expiration_mac TEXT NOT NULL DEFAULT '';
+
+-- Слепой индекс номера карты: HMAC-SHA256 от PAN на ключе CARD_INDEX_KEY.
+-- У карт, выпущенных до миграции, индекса нет: переводы на них по номеру недоступны.
+ALTER TABLE cards ADD COLUMN pan_index TEXT;
+CREATE UNIQUE INDEX cards_pan_index_idx ON cards (pan_index) WHERE pan_index IS NOT NULL;
@@ -0,0 +1,51 @@
+-- Роль пользователя: операционист может возвращать переводы вне окна отмены.
+ALTER TABLE users ADD COLUMN role TEXT NOT NULL DEFAULT 'customer'
+    CHECK (role IN ('customer', 'operator'));
+
+-- Компенсирующая запись ссылается на исходную; сами записи журнала не удаляются.
+ALTER TABLE journal_entries ADD COLUMN reversal_of INTEGER REFERENCES journal_entries(id);
+
+-- Переводы между счетами получают собственную запись.
+CREATE TABLE transfers (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id),
+    from_account_id INTEGER NOT NULL REFERENCES accounts(id),
+    to_account_id INTEGER NOT NULL REFERENCES accounts(id),
+    amount NUMERIC(18,2) NOT NULL CHECK (amount > 0),
+    currency TEXT NOT NULL,
+    credited_amount NUMERIC(18,2) NOT NULL CHECK (credited_amount > 0),
+    credited_currency TEXT NOT NULL,
+    fx_rate NUMERIC(18,6),
+    entry_id INTEGER NOT NULL UNIQUE REFERENCES journal_entries(id),
+    -- Уже возвращено отправителю и списано с получателя
+    reversed_amount NUMERIC(18,2) NOT NULL DEFAULT 0 CHECK (reversed_amount BETWEEN 0 AND amount),
+    reversed_credited_amount NUMERIC(18,2) NOT NULL DEFAULT 0
+        CHECK (reversed_credited_amount BETWEEN 0 AND credited_amount),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX transfers_user_idx ON transfers (user_id, created_at DESC);
+
+-- Переводы, проведенные до миграции, восстанавливаются по проводкам журнала.
+INSERT INTO transfers (user_id, from_account_id, to_account_id, amount, currency,
+    credited_amount, credited_currency, fx_rate, entry_id, created_at)
+SELECT fa.user_id, d.account_id, c.account_id, -d.amount, d.currency,
+    c.amount, c.currency, e.fx_rate, e.id, e.created_at
+FROM journal_entries e
+JOIN postings d ON d.entry_id = e.id AND d.account_id IS NOT NULL AND d.amount < 0
+JOIN postings c ON c.entry_id = e.id AND c.account_id IS NOT NULL AND c.amount > 0
+JOIN accounts fa ON fa.id = d.account_id
+WHERE e.type = 'transfer';
+
+CREATE TABLE transfer_reversals (
+    id SERIAL PRIMARY KEY,
+    transfer_id INTEGER NOT NULL REFERENCES transfers(id),
+    user_id INTEGER NOT NULL REFERENCES users(id),
+    amount NUMERIC(18,2) NOT NULL CHECK (amount > 0),
+    debited_amount NUMERIC(18,2) NOT NULL CHECK (debited_amount > 0),
+    reason TEXT NOT NULL DEFAULT '',
+    entry_id INTEGER NOT NULL REFERENCES journal_entries(id),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX transfer_reversals_transfer_idx ON transfer_reversals (transfer_id);
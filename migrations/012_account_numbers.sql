@@ -0,0 +1,9 @@
+-- 20-значные номера счетов: балансовый счет, код валюты, контрольный ключ по БИК,
+-- подразделение и порядковый номер из account_number_seq.
+ALTER TABLE accounts ADD COLUMN type TEXT NOT NULL DEFAULT 'current'
+    CHECK (type IN ('current', 'deposit', 'business'));
+ALTER TABLE accounts ADD COLUMN number VARCHAR(20) CHECK (number ~ '^[0-9]{20}$');
+CREATE UNIQUE INDEX accounts_number_idx ON accounts (number);
+
+-- Порядковый номер занимает 7 разрядов номера счета.
+CREATE SEQUENCE account_number_seq MINVALUE 1 MAXVALUE 9999999;
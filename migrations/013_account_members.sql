@@ -0,0 +1,19 @@
+-- Участники совместных счетов. accounts.user_id остается создателем счета,
+-- а права на операции определяются только членством.
+CREATE TABLE account_members (
+    account_id INTEGER NOT NULL REFERENCES accounts(id),
+    user_id INTEGER NOT NULL REFERENCES users(id),
+    role TEXT NOT NULL CHECK (role IN ('owner', 'full_access', 'view_only', 'spend_with_limit')),
+    -- Лимит одного списания для роли spend_with_limit
+    spend_limit NUMERIC(18,2) CHECK (spend_limit > 0),
+    invited_by INTEGER REFERENCES users(id),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (account_id, user_id),
+    CHECK ((role = 'spend_with_limit') = (spend_limit IS NOT NULL))
+);
+
+CREATE INDEX account_members_user_idx ON account_members (user_id);
+
+-- Создатели существующих счетов становятся их владельцами.
+INSERT INTO account_members (account_id, user_id, role, created_at)
+SELECT id, user_id, 'owner', created_at FROM accounts;
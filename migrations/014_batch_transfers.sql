@@ -0,0 +1,31 @@
+CREATE TABLE batch_transfers (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id),
+    from_account_id INTEGER NOT NULL REFERENCES accounts(id),
+    total NUMERIC(18,2) NOT NULL CHECK (total > 0),
+    currency TEXT NOT NULL,
+    line_count INTEGER NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'processing', 'completed')),
+    -- Аренда обработки: пока claimed_until в будущем, пакет обрабатывает другой экземпляр
+    claimed_until TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    completed_at TIMESTAMP
+);
+
+CREATE INDEX batch_transfers_open_idx ON batch_transfers (id) WHERE status <> 'completed';
+
+CREATE TABLE batch_transfer_lines (
+    id SERIAL PRIMARY KEY,
+    batch_id INTEGER NOT NULL REFERENCES batch_transfers(id),
+    line_no INTEGER NOT NULL,
+    -- Получатель, как он указан в файле: номер счета или ID счета
+    destination TEXT NOT NULL,
+    to_account_id INTEGER NOT NULL REFERENCES accounts(id),
+    amount NUMERIC(18,2) NOT NULL CHECK (amount > 0),
+    status TEXT NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'processing', 'succeeded', 'failed', 'interrupted')),
+    error TEXT NOT NULL DEFAULT '',
+    transfer_id INTEGER REFERENCES transfers(id),
+    processed_at TIMESTAMP,
+    UNIQUE (batch_id, line_no)
+);
@@ -0,0 +1,36 @@
+-- Тариф: правила комиссий по операции, валюте, типу счета и месячному обороту.
+CREATE TABLE fee_rules (
+    id SERIAL PRIMARY KEY,
+    operation TEXT NOT NULL CHECK (operation IN ('transfer', 'card_transfer', 'withdrawal', 'deposit')),
+    -- Пустые валюта и тип счета подходят к любым
+    currency TEXT NOT NULL DEFAULT '',
+    account_type TEXT NOT NULL DEFAULT '',
+    volume_from NUMERIC(18,2) NOT NULL DEFAULT 0,
+    volume_to NUMERIC(18,2) NOT NULL DEFAULT 0,
+    percent NUMERIC(7,4) NOT NULL DEFAULT 0 CHECK (percent >= 0),
+    fixed NUMERIC(18,2) NOT NULL DEFAULT 0 CHECK (fixed >= 0),
+    min_fee NUMERIC(18,2) NOT NULL DEFAULT 0 CHECK (min_fee >= 0),
+    max_fee NUMERIC(18,2) NOT NULL DEFAULT 0 CHECK (max_fee >= 0),
+    -- Ступени по сумме операции: [{"up_to", "percent", "fixed"}]
+    tiers JSONB NOT NULL DEFAULT '[]',
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX fee_rules_operation_idx ON fee_rules (operation) WHERE active;
+
+-- Операции по счетам с начисленной комиссией (в том числе нулевой):
+-- по ним считается месячный оборот для тарифа.
+CREATE TABLE fee_charges (
+    id SERIAL PRIMARY KEY,
+    account_id INTEGER NOT NULL REFERENCES accounts(id),
+    operation TEXT NOT NULL,
+    amount NUMERIC(18,2) NOT NULL,
+    currency TEXT NOT NULL,
+    fee NUMERIC(18,2) NOT NULL DEFAULT 0,
+    rule_id INTEGER REFERENCES fee_rules(id),
+    entry_id INTEGER REFERENCES journal_entries(id),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX fee_charges_volume_idx ON fee_charges (account_id, operation, created_at);
@@ -0,0 +1,15 @@
+ALTER TABLE cards ADD COLUMN status TEXT NOT NULL DEFAULT 'active';
+
+CREATE INDEX cards_user_idx ON cards (user_id);
+CREATE INDEX cards_account_idx ON cards (account_id);
+
+-- Попытки раскрыть реквизиты карты: журнал доступа и основа ограничения частоты.
+CREATE TABLE card_reveals (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id),
+    card_id INTEGER NOT NULL REFERENCES cards(id),
+    success BOOLEAN NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX card_reveals_user_idx ON card_reveals (user_id, created_at);
@@ -0,0 +1,17 @@
+-- Допустимые статусы карты: active <-> blocked; lost, stolen, expired — окончательные.
+ALTER TABLE cards ADD CONSTRAINT cards_status_check
+    CHECK (status IN ('active', 'blocked', 'lost', 'stolen', 'expired'));
+
+-- История смены статусов карты с причиной и автором.
+CREATE TABLE card_status_history (
+    id SERIAL PRIMARY KEY,
+    card_id INTEGER NOT NULL REFERENCES cards(id),
+    from_status TEXT NOT NULL,
+    to_status TEXT NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    actor_id INTEGER NOT NULL REFERENCES users(id),
+    actor_role TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX card_status_history_card_idx ON card_status_history (card_id, created_at);
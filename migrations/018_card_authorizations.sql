@@ -0,0 +1,23 @@
+-- Сумма, заблокированная авторизациями по картам; доступный остаток — balance - held.
+ALTER TABLE accounts ADD COLUMN held NUMERIC(18,2) NOT NULL DEFAULT 0 CHECK (held >= 0);
+
+-- Авторизации торговых точек: одобренные блокируют сумму до списания или отмены,
+-- отклоненные хранятся с причиной отказа.
+CREATE TABLE card_authorizations (
+    id SERIAL PRIMARY KEY,
+    card_id INTEGER REFERENCES cards(id),
+    account_id INTEGER REFERENCES accounts(id),
+    merchant TEXT NOT NULL DEFAULT '',
+    amount NUMERIC(18,2) NOT NULL,
+    currency TEXT NOT NULL,
+    status TEXT NOT NULL CHECK (status IN ('approved', 'declined', 'captured', 'released')),
+    approval_code TEXT NOT NULL DEFAULT '',
+    decline_reason TEXT NOT NULL DEFAULT '',
+    captured_amount NUMERIC(18,2) NOT NULL DEFAULT 0,
+    entry_id INTEGER REFERENCES journal_entries(id),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX card_authorizations_card_idx ON card_authorizations (card_id, created_at);
+CREATE INDEX card_authorizations_open_idx ON card_authorizations (account_id) WHERE status = 'approved';
@@ -0,0 +1,5 @@
+-- Ключ шифрования реквизитов карты. Шифротексты, записанные до введения связки
+-- ключей, не содержат идентификатора ключа и остаются с пустым key_id.
+ALTER TABLE cards ADD COLUMN key_id TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX cards_key_idx ON cards (key_id, id);
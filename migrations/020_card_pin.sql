@@ -0,0 +1,4 @@
+-- PIN карты: хранится только Visa PVV с индексом ключа PVK и счетчик неверных попыток.
+ALTER TABLE cards ADD COLUMN pvv TEXT NOT NULL DEFAULT '';
+ALTER TABLE cards ADD COLUMN pvki SMALLINT NOT NULL DEFAULT 0;
+ALTER TABLE cards ADD COLUMN pin_attempts INTEGER NOT NULL DEFAULT 0 CHECK (pin_attempts >= 0);
@@ -0,0 +1,24 @@
+-- Карточные продукты: диапазон BIN, платежная система, тип карты, длина номера и срок действия.
+CREATE TABLE card_products (
+    code TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    payment_system TEXT NOT NULL CHECK (payment_system IN ('mir', 'visa', 'mastercard')),
+    type TEXT NOT NULL CHECK (type IN ('debit', 'credit', 'prepaid')),
+    bin_from TEXT NOT NULL,
+    bin_to TEXT NOT NULL,
+    pan_length SMALLINT NOT NULL CHECK (pan_length IN (16, 19)),
+    validity_years SMALLINT NOT NULL CHECK (validity_years BETWEEN 1 AND 10),
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CHECK (length(bin_from) = length(bin_to) AND bin_from <= bin_to)
+);
+
+INSERT INTO card_products (code, name, payment_system, type, bin_from, bin_to, pan_length, validity_years) VALUES
+    ('mir_debit', 'Мир дебетовая', 'mir', 'debit', '2200700', '2200799', 16, 5),
+    ('mir_credit', 'Мир кредитная', 'mir', 'credit', '2200800', '2200849', 16, 3),
+    ('mir_prepaid', 'Мир предоплаченная', 'mir', 'prepaid', '2200850', '2200859', 19, 3);
+
+-- Карты, выпущенные до появления продуктов, относятся к дебетовой карте «Мир».
+ALTER TABLE cards ADD COLUMN product_code TEXT REFERENCES card_products(code);
+UPDATE cards SET product_code = 'mir_debit';
+ALTER TABLE cards ALTER COLUMN product_code SET NOT NULL;
@@ -0,0 +1,16 @@
+-- Срок действия карты в открытом виде для выборки истекающих карт: момент, с которого
+-- карта недействительна. У выпущенных ранее карт заполняется планировщиком перевыпуска.
+ALTER TABLE cards ADD COLUMN expires_at TIMESTAMP;
+CREATE INDEX cards_expires_at_idx ON cards (expires_at) WHERE status IN ('active', 'blocked');
+
+-- Перевыпущенная карта ссылается на прежнюю; карта перевыпускается не больше одного раза.
+ALTER TABLE cards ADD COLUMN reissued_from INTEGER REFERENCES cards(id);
+CREATE UNIQUE INDEX cards_reissued_from_idx ON cards (reissued_from) WHERE reissued_from IS NOT NULL;
+
+-- При перевыпуске с сохранением номера у старой и новой карты один PAN с разными сроками.
+DROP INDEX cards_pan_index_idx;
+CREATE UNIQUE INDEX cards_pan_index_idx ON cards (pan_index, expires_at) WHERE pan_index IS NOT NULL;
+
+-- Политика перевыпуска продукта: сохранить номер карты или выдать новый.
+ALTER TABLE card_products ADD COLUMN reissue_keep_pan BOOLEAN NOT NULL DEFAULT FALSE;
+UPDATE card_products SET reissue_keep_pan = TRUE WHERE code = 'mir_debit';
@@ -0,0 +1,16 @@
+-- Журнал проверок CVV партнерами: каждая попытка с исходом и адресом клиента.
+-- По неверным CVV за окно считается лимит попыток на карту.
+CREATE TABLE card_cvv_checks (
+    id SERIAL PRIMARY KEY,
+    card_id INTEGER NOT NULL REFERENCES cards(id),
+    result TEXT NOT NULL CHECK (result IN ('match', 'mismatch', 'rate_limited', 'card_inactive')),
+    client_addr TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX card_cvv_checks_card_idx ON card_cvv_checks (card_id, created_at);
+
+-- CVV перевыпущенной карты, зашифрованный для однократной выдачи держателю
+-- при раскрытии реквизитов; после выдачи очищается.
+ALTER TABLE cards ADD COLUMN pending_cvv TEXT NOT NULL DEFAULT '';
+ALTER TABLE cards ADD COLUMN pending_cvv_mac TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,10 @@
+-- Виртуальные карты: одноразовые (выводятся из обращения после первого списания)
+-- и с привязкой к торговой точке первой авторизации. Лимит суммы оплат и срок
+-- использования задаются при выпуске; у обычных карт они пустые.
+ALTER TABLE cards ADD COLUMN kind TEXT NOT NULL DEFAULT 'standard'
+    CHECK (kind IN ('standard', 'single_use', 'merchant_locked'));
+ALTER TABLE cards ADD COLUMN spend_cap NUMERIC(18, 2);
+ALTER TABLE cards ADD COLUMN valid_until TIMESTAMP;
+ALTER TABLE cards ADD COLUMN locked_merchant TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX cards_valid_until_idx ON cards (valid_until) WHERE valid_until IS NOT NULL;
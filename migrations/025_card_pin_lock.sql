@@ -0,0 +1,10 @@
+-- Блокировка по неверным PIN — отдельный статус pin_locked: держатель не снимает ее
+-- сам, как временную блокировку blocked, чтобы не перебирать PIN тройками попыток.
+ALTER TABLE cards DROP CONSTRAINT cards_status_check;
+ALTER TABLE cards ADD CONSTRAINT cards_status_check
+    CHECK (status IN ('active', 'blocked', 'pin_locked', 'lost', 'stolen', 'expired'));
+
+UPDATE cards SET status = 'pin_locked' WHERE status = 'blocked' AND pin_attempts > 0;
+
+DROP INDEX cards_expires_at_idx;
+CREATE INDEX cards_expires_at_idx ON cards (expires_at) WHERE status IN ('active', 'blocked', 'pin_locked');
@@ -0,0 +1,8 @@
+-- Запуск поручения записывается со статусом started до перевода: если исполнение
+-- прервется после перевода, повторный захват поручения увидит незавершенный запуск
+-- и не спишет деньги второй раз. Незавершенный запуск у поручения не больше одного.
+ALTER TABLE standing_order_runs DROP CONSTRAINT standing_order_runs_status_check;
+ALTER TABLE standing_order_runs ADD CONSTRAINT standing_order_runs_status_check
+    CHECK (status IN ('started', 'succeeded', 'failed'));
+
+CREATE UNIQUE INDEX standing_order_runs_started_idx ON standing_order_runs (order_id) WHERE status = 'started';
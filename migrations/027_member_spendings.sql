@@ -0,0 +1,13 @@
+-- Списания участников с ролью spend_with_limit: spend_limit ограничивает их сумму
+-- за календарный месяц, а не одну операцию.
+CREATE TABLE member_spendings (
+    id SERIAL PRIMARY KEY,
+    account_id INTEGER NOT NULL REFERENCES accounts(id),
+    user_id INTEGER NOT NULL REFERENCES users(id),
+    amount NUMERIC(18,2) NOT NULL CHECK (amount > 0),
+    currency TEXT NOT NULL,
+    entry_id INTEGER NOT NULL REFERENCES journal_entries(id),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX member_spendings_member_idx ON member_spendings (account_id, user_id, created_at);
@@ -0,0 +1,4 @@
+-- Комиссия за оплату картой списывается при списании авторизации.
+ALTER TABLE fee_rules DROP CONSTRAINT fee_rules_operation_check;
+ALTER TABLE fee_rules ADD CONSTRAINT fee_rules_operation_check
+    CHECK (operation IN ('transfer', 'card_transfer', 'withdrawal', 'deposit', 'card_purchase'));
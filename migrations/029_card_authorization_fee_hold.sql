@@ -0,0 +1,3 @@
+-- Комиссия за оплату картой, заблокированная вместе с суммой авторизации:
+-- иначе списание всего доступного остатка не покрыло бы комиссию.
+ALTER TABLE card_authorizations ADD COLUMN held_fee NUMERIC(18,2) NOT NULL DEFAULT 0;
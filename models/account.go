@@ -4,11 +4,83 @@ import (
 	"time"
 )
 
+// Статусы счета. Операции с деньгами разрешены только по активным счетам.
+const (
+	AccountStatusActive = "active"
+	AccountStatusFrozen = "frozen"
+	AccountStatusClosed = "closed"
+)
+
+// Типы счетов. Тип определяет балансовый счет второго порядка в номере счета.
+const (
+	AccountTypeCurrent  = "current"  // текущий счет физического лица
+	AccountTypeDeposit  = "deposit"  // депозит до востребования физического лица
+	AccountTypeBusiness = "business" // расчетный счет коммерческой организации
+)
+
+// balanceAccountPrefixes — балансовые счета второго порядка по типам счетов.
+var balanceAccountPrefixes = map[string]string{
+	AccountTypeCurrent:  "40817",
+	AccountTypeDeposit:  "42301",
+	AccountTypeBusiness: "40702",
+}
+
+// accountCurrencyCodes — цифровые коды валют для номера счета; рубль обозначается кодом 810.
+var accountCurrencyCodes = map[string]string{
+	"RUB": "810",
+	"USD": "840",
+	"EUR": "978",
+	"CNY": "156",
+	"GBP": "826",
+	"CHF": "756",
+	"JPY": "392",
+}
+
+// BalanceAccountPrefix возвращает балансовый счет второго порядка для типа счета.
+func BalanceAccountPrefix(accountType string) (string, bool) {
+	prefix, ok := balanceAccountPrefixes[accountType]
+	return prefix, ok
+}
+
+// AccountCurrencyCode возвращает цифровой код валюты для номера счета.
+func AccountCurrencyCode(currency string) (string, bool) {
+	code, ok := accountCurrencyCodes[currency]
+	return code, ok
+}
+
+// accountTransitions — допустимые переходы между статусами счета.
+var accountTransitions = map[string][]string{
+	AccountStatusActive: {AccountStatusFrozen, AccountStatusClosed},
+	AccountStatusFrozen: {AccountStatusActive},
+	AccountStatusClosed: {AccountStatusActive},
+}
+
+// CanTransitionAccount сообщает, можно ли перевести счет из статуса from в статус to.
+func CanTransitionAccount(from, to string) bool {
+	for _, s := range accountTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
 // Account представляет банковский счёт пользователя.
 type Account struct {
-	ID        int       `json:"id"`
-	UserID    int       `json:"user_id" validate:"required"`
-	Balance   float64   `json:"balance"`
+	ID     int `json:"id"`
+	UserID int `json:"user_id" validate:"required"`
+	// 20-значный номер счета с контрольным ключом по БИК банка
+	Number  string `json:"number"`
+	Type    string `json:"type"`
+	Balance Money  `json:"balance"`
+	// Сумма, заблокированная авторизациями по картам до их списания или отмены
+	Held      Money     `json:"held"`
 	Currency  string    `json:"currency" validate:"required"`
+	Status    string    `json:"status"`
 	CreatedAt time.Time `json:"created_at"`
 }
+
+// Available возвращает остаток, доступный для списаний: баланс за вычетом блокировок.
+func (a *Account) Available() Money {
+	return NewMoney(a.Balance.Minor-a.Held.Minor, a.Currency)
+}
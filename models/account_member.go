@@ -0,0 +1,63 @@
+package models
+
+import "time"
+
+// Роли участников счета.
+const (
+	// MemberRoleOwner — владелец: все операции и управление участниками.
+	MemberRoleOwner = "owner"
+	// MemberRoleFullAccess — полный доступ к операциям без управления участниками.
+	MemberRoleFullAccess = "full_access"
+	// MemberRoleViewOnly — только просмотр остатка, операций и выписок.
+	MemberRoleViewOnly = "view_only"
+	// MemberRoleSpendWithLimit — списания не больше SpendLimit за календарный месяц.
+	MemberRoleSpendWithLimit = "spend_with_limit"
+)
+
+// ValidMemberRole сообщает, известна ли роль участника.
+func ValidMemberRole(role string) bool {
+	switch role {
+	case MemberRoleOwner, MemberRoleFullAccess, MemberRoleViewOnly, MemberRoleSpendWithLimit:
+		return true
+	}
+	return false
+}
+
+// AccountMember — участник совместного счета и его права.
+type AccountMember struct {
+	AccountID int    `json:"account_id"`
+	UserID    int    `json:"user_id"`
+	Email     string `json:"email,omitempty"`
+	Role      string `json:"role"`
+	// Лимит списаний за календарный месяц для роли spend_with_limit
+	SpendLimit *Money    `json:"spend_limit,omitempty"`
+	InvitedBy  int       `json:"invited_by,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// CanTransact разрешает пополнения и списания в пределах лимита роли.
+func (m *AccountMember) CanTransact() bool {
+	return m.Role != MemberRoleViewOnly
+}
+
+// CanSpend разрешает списание amount со счета без учета прежних списаний;
+// месячный лимит роли spend_with_limit проверяется репозиторием под блокировкой счета.
+func (m *AccountMember) CanSpend(amount Money) bool {
+	switch m.Role {
+	case MemberRoleOwner, MemberRoleFullAccess:
+		return true
+	case MemberRoleSpendWithLimit:
+		return m.SpendLimit != nil && amount.Minor <= m.SpendLimit.Minor
+	}
+	return false
+}
+
+// CanOperate разрешает управление счетом: смену статуса, выпуск карт, кредиты.
+func (m *AccountMember) CanOperate() bool {
+	return m.Role == MemberRoleOwner || m.Role == MemberRoleFullAccess
+}
+
+// CanManageMembers разрешает приглашать и удалять участников.
+func (m *AccountMember) CanManageMembers() bool {
+	return m.Role == MemberRoleOwner
+}
@@ -0,0 +1,99 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Форматы файла пакетного перевода.
+const (
+	BatchFormatCSV = "csv"
+	// BatchFormatNDJSON — JSON lines: по одному объекту {"destination", "amount"} на строку.
+	BatchFormatNDJSON = "ndjson"
+)
+
+// Статусы пакетного перевода.
+const (
+	// BatchStatusPending — пакет проверен и ждет обработки шедулером.
+	BatchStatusPending    = "pending"
+	BatchStatusProcessing = "processing"
+	BatchStatusCompleted  = "completed"
+)
+
+// Статусы строки пакетного перевода.
+const (
+	BatchLinePending = "pending"
+	// BatchLineProcessing — перевод по строке начат, но результат еще не сохранен.
+	BatchLineProcessing = "processing"
+	BatchLineSucceeded  = "succeeded"
+	BatchLineFailed     = "failed"
+	// BatchLineInterrupted — обработка строки прервалась сбоем. Такая строка не повторяется,
+	// чтобы не заплатить дважды: исполнен ли перевод, видно по истории счета.
+	BatchLineInterrupted = "interrupted"
+)
+
+// BatchTransfer — пакет переводов с одного счета, загруженный файлом (например, выплата зарплаты).
+type BatchTransfer struct {
+	ID            int    `json:"id"`
+	UserID        int    `json:"user_id"`
+	FromAccountID int    `json:"from_account_id"`
+	Total         Money  `json:"total"`
+	Currency      string `json:"currency"`
+	LineCount     int    `json:"line_count"`
+	// Итоги по строкам; прерванные строки считаются неудачными
+	SucceededCount int                 `json:"succeeded_count"`
+	FailedCount    int                 `json:"failed_count"`
+	PendingCount   int                 `json:"pending_count"`
+	Status         string              `json:"status"`
+	CreatedAt      time.Time           `json:"created_at"`
+	CompletedAt    *time.Time          `json:"completed_at,omitempty"`
+	Lines          []BatchTransferLine `json:"lines,omitempty"`
+}
+
+// Summarize пересчитывает итоги пакета по строкам.
+func (b *BatchTransfer) Summarize() {
+	b.SucceededCount, b.FailedCount, b.PendingCount = 0, 0, 0
+	for _, line := range b.Lines {
+		switch line.Status {
+		case BatchLineSucceeded:
+			b.SucceededCount++
+		case BatchLineFailed, BatchLineInterrupted:
+			b.FailedCount++
+		default:
+			b.PendingCount++
+		}
+	}
+}
+
+// BatchTransferLine — строка пакета: один перевод получателю.
+type BatchTransferLine struct {
+	ID      int `json:"-"`
+	BatchID int `json:"-"`
+	// Номер строки с данными в файле, начиная с 1 (без заголовка)
+	LineNo int `json:"line_no"`
+	// Получатель, как он указан в файле: 20-значный номер счета или ID счета
+	Destination string     `json:"destination"`
+	ToAccountID int        `json:"to_account_id"`
+	Amount      Money      `json:"amount"`
+	Status      string     `json:"status"`
+	Error       string     `json:"error,omitempty"`
+	TransferID  int        `json:"transfer_id,omitempty"`
+	ProcessedAt *time.Time `json:"processed_at,omitempty"`
+}
+
+// BatchLineError — ошибка проверки одной строки файла.
+type BatchLineError struct {
+	LineNo int    `json:"line_no"`
+	Error  string `json:"error"`
+}
+
+// BatchValidationError — пакет отклонен целиком из-за ошибок в строках.
+type BatchValidationError struct {
+	Lines []BatchLineError `json:"lines"`
+}
+
+func (e *BatchValidationError) Error() string {
+	return fmt.Sprintf("%v: %d invalid lines", ErrInvalidBatch, len(e.Lines))
+}
+
+func (e *BatchValidationError) Unwrap() error { return ErrInvalidBatch }
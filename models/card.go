@@ -1,9 +1,44 @@
 package models
 
 import (
+	"strings"
 	"time"
 )
 
+// Статусы карты. blocked — временная блокировка, которую снимает держатель;
+// pin_locked — блокировка после неверных PIN подряд, ее снимает только операционист;
+// lost, stolen и expired — окончательные блокировки операциониста.
+const (
+	CardStatusActive    = "active"
+	CardStatusBlocked   = "blocked"
+	CardStatusPINLocked = "pin_locked"
+	CardStatusLost      = "lost"
+	CardStatusStolen    = "stolen"
+	CardStatusExpired   = "expired"
+)
+
+// Виды карт. Одноразовая карта выводится из обращения после первого списания;
+// карта с привязкой к торговой точке принимает оплаты только той точки,
+// которая первой ее авторизовала.
+const (
+	CardKindStandard       = "standard"
+	CardKindSingleUse      = "single_use"
+	CardKindMerchantLocked = "merchant_locked"
+)
+
+// CardActorSystem — роль автора смены статуса, выполненной планировщиком
+// (например, истечение срока); actor_id в этом случае — держатель карты.
+const CardActorSystem = "system"
+
+// CardStatusPermanent сообщает, что карта в статусе status заблокирована окончательно.
+func CardStatusPermanent(status string) bool {
+	switch status {
+	case CardStatusLost, CardStatusStolen, CardStatusExpired:
+		return true
+	}
+	return false
+}
+
 // Card представляет виртуальную банковскую карту.
 type Card struct {
 	ID              int       `json:"id"`
@@ -19,5 +54,161 @@ type Card struct {
 	ExpirationMAC   string    `json:"expiration_mac"`
 	// Хеш CVV (bcrypt). Не выводится в JSON.
 	CVVHash         string    `json:"-"`
+	// CVV в открытом виде: заполняется только при выпуске, чтобы один раз
+	// вернуть его держателю, и никогда не сохраняется
+	CVV             string    `json:"cvv,omitempty"`
+	// CVV перевыпущенной карты (PGP) и его HMAC до однократной выдачи держателю
+	PendingCVV      string    `json:"-"`
+	PendingCVVMAC   string    `json:"-"`
+	// Слепой индекс номера карты (HMAC на ключе CARD_INDEX_KEY) для поиска по номеру
+	PANIndex        string    `json:"-"`
+	// Ключ, которым зашифрованы номер и срок действия (utils.CipherKeyID)
+	KeyID           string    `json:"-"`
+	// Visa PVV и индекс ключа PVK; сам PIN не хранится. Пустой PVV — PIN не установлен
+	PVV             string    `json:"-"`
+	PVKI            int       `json:"-"`
+	// Неверных PIN подряд
+	PINAttempts     int       `json:"-"`
+	// Карточный продукт, по которому выпущена карта
+	ProductCode     string    `json:"product_code"`
+	// Момент окончания срока действия (начало месяца после указанного на карте);
+	// нулевой — у карт, выпущенных до его появления, пока он не заполнен
+	ExpiresAt       time.Time `json:"-"`
+	// Карта, вместо которой перевыпущена эта; 0 — карта выпущена впервые
+	ReissuedFrom    int       `json:"reissued_from,omitempty"`
+	// Вид карты: standard, single_use или merchant_locked
+	Kind            string    `json:"kind"`
+	// Лимит суммы одобренных и списанных авторизаций за все время в валюте счета
+	SpendCap        *Money    `json:"spend_cap,omitempty"`
+	// Срок использования виртуальной карты, не позже срока действия
+	ValidUntil      *time.Time `json:"valid_until,omitempty"`
+	// Торговая точка, к которой привязана карта merchant_locked после первой авторизации
+	LockedMerchant  string    `json:"locked_merchant,omitempty"`
+	Status          string    `json:"status"`
 	CreatedAt       time.Time `json:"created_at"`
 }
+
+// CardSummary — карта без реквизитов: номер замаскирован, CVV не раскрывается.
+// Полные реквизиты отдаются только через CardDetails.
+type CardSummary struct {
+	ID        int `json:"id"`
+	UserID    int `json:"user_id"`
+	AccountID int `json:"account_id"`
+	// Первые 6 и последние 4 цифры номера: "220012******1234"
+	MaskedPAN   string `json:"masked_pan"`
+	ProductCode string `json:"product_code"`
+	Status      string `json:"status"`
+	// Месяц окончания срока действия, "MM/YY"
+	ExpiryMonth    string     `json:"expiry_month"`
+	PINSet         bool       `json:"pin_set"`
+	ReissuedFrom   int        `json:"reissued_from,omitempty"`
+	Kind           string     `json:"kind"`
+	SpendCap       *Money     `json:"spend_cap,omitempty"`
+	ValidUntil     *time.Time `json:"valid_until,omitempty"`
+	LockedMerchant string     `json:"locked_merchant,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// CheckUsage проверяет ограничения карты для авторизации на amount у merchant,
+// если по карте уже одобрено или списано used в uses авторизациях.
+func (c *Card) CheckUsage(merchant string, amount, used Money, uses int) error {
+	if c.Kind == CardKindSingleUse && uses > 0 {
+		return ErrCardAlreadyUsed
+	}
+	if c.Kind == CardKindMerchantLocked && c.LockedMerchant != "" &&
+		!strings.EqualFold(c.LockedMerchant, strings.TrimSpace(merchant)) {
+		return ErrCardMerchantLocked
+	}
+	if c.SpendCap != nil && used.Minor+amount.Minor > c.SpendCap.Minor {
+		return ErrSpendCapExceeded
+	}
+	return nil
+}
+
+// VirtualCardRequest — параметры выпуска одноразовой карты или карты с привязкой к торговой точке.
+type VirtualCardRequest struct {
+	ProductCode string `json:"product_code"`
+	Kind        string `json:"kind"`
+	// Лимит суммы оплат в валюте счета, обязателен
+	SpendCap Money `json:"spend_cap"`
+	// Срок использования; без него — до окончания срока действия карты
+	ValidUntil *time.Time `json:"valid_until"`
+}
+
+// CardDetails — полные реквизиты карты, раскрываемые после повторного ввода пароля.
+type CardDetails struct {
+	ID             int    `json:"id"`
+	CardNumber     string `json:"card_number"`
+	ExpirationDate string `json:"expiration_date"`
+	// CVV перевыпущенной карты: отдается один раз при первом раскрытии
+	CVV string `json:"cvv,omitempty"`
+}
+
+// CardStatusChange — запись истории статусов карты: кто, когда и почему сменил статус.
+type CardStatusChange struct {
+	ID         int    `json:"id"`
+	CardID     int    `json:"card_id"`
+	FromStatus string `json:"from_status"`
+	ToStatus   string `json:"to_status"`
+	Reason     string `json:"reason"`
+	// Пользователь, сменивший статус, и его роль
+	ActorID   int       `json:"actor_id"`
+	ActorRole string    `json:"actor_role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ReencryptionResult — итог перешифрования карт текущим ключом.
+type ReencryptionResult struct {
+	KeyID string `json:"key_id"`
+	// Перешифровано карт
+	Reencrypted int `json:"reencrypted"`
+	// Пропущено: карта изменилась во время перешифрования, попадет в следующий запуск
+	Skipped int `json:"skipped"`
+	// Не удалось расшифровать: ключа нет в связке или не сошелся HMAC
+	Failed int `json:"failed"`
+}
+
+// Исходы проверки CVV партнером.
+const (
+	CVVCheckMatch    = "match"
+	CVVCheckMismatch = "mismatch"
+	// Попытка отклонена без проверки: исчерпан лимит неверных CVV
+	CVVCheckLimited = "rate_limited"
+	// Попытка отклонена без проверки: карта не активна
+	CVVCheckCardInactive = "card_inactive"
+)
+
+// CVVCheck — запись журнала проверок CVV.
+type CVVCheck struct {
+	ID     int    `json:"id"`
+	CardID int    `json:"card_id"`
+	Result string `json:"result"`
+	// Адрес клиента, от которого пришел запрос
+	ClientAddr string    `json:"client_addr"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// CVVVerification — ответ на проверку CVV.
+type CVVVerification struct {
+	Valid bool `json:"valid"`
+	// Сколько неверных CVV еще допускается в текущем окне
+	AttemptsLeft int `json:"attempts_left"`
+}
+
+// CardReissueResult — итог запуска перевыпуска карт.
+type CardReissueResult struct {
+	// Картам, выпущенным до появления expires_at, заполнен срок действия
+	Backfilled int `json:"backfilled"`
+	// Перевыпущено карт с истекающим сроком
+	Reissued int `json:"reissued"`
+	// Карт переведено в статус expired
+	Expired int `json:"expired"`
+	// Не удалось перевыпустить или заполнить срок
+	Failed int `json:"failed"`
+}
+
+// PINBlock — PIN, зашифрованный в блок ISO 9564 формата 0 или 4 под зональным ключом (hex).
+type PINBlock struct {
+	Format int    `json:"format"`
+	Block  string `json:"pin_block"`
+}
@@ -0,0 +1,69 @@
+package models
+
+import "time"
+
+// Статусы авторизации по карте. approved — сумма заблокирована на счете
+// до списания (captured) или отмены (released).
+const (
+	AuthorizationStatusApproved = "approved"
+	AuthorizationStatusDeclined = "declined"
+	AuthorizationStatusCaptured = "captured"
+	AuthorizationStatusReleased = "released"
+)
+
+// Причины отказа в авторизации.
+const (
+	DeclineInvalidRequest    = "invalid_request"
+	DeclineCardNotFound      = "card_not_found"
+	DeclineIntegrityFailure  = "integrity_check_failed"
+	DeclineInvalidCVV        = "invalid_cvv"
+	DeclineCVVAttempts       = "cvv_attempts_exceeded"
+	DeclineInvalidExpiry     = "invalid_expiry"
+	DeclineExpiredCard       = "expired_card"
+	DeclineCardInactive      = "card_inactive"
+	DeclineAccountInactive   = "account_inactive"
+	DeclineCurrencyMismatch  = "currency_mismatch"
+	DeclineInsufficientFunds = "insufficient_funds"
+	DeclineCardUsed          = "card_already_used"
+	DeclineMerchantLocked    = "merchant_locked"
+	DeclineSpendCapExceeded  = "spend_cap_exceeded"
+)
+
+// AuthorizationRequest — запрос торговой точки на авторизацию оплаты картой.
+type AuthorizationRequest struct {
+	PAN string `json:"pan"`
+	// Срок действия, напечатанный на карте, "MM/YY"
+	Expiry   string `json:"expiry"`
+	CVV      string `json:"cvv"`
+	Amount   Money  `json:"amount"`
+	Currency string `json:"currency"`
+	Merchant string `json:"merchant"`
+	// Адрес клиента для журнала проверок CVV; заполняется обработчиком
+	ClientAddr string `json:"-"`
+}
+
+// CardAuthorization — результат авторизации и, если она одобрена, блокировка суммы на счете карты.
+type CardAuthorization struct {
+	ID int `json:"id"`
+	// Карта и ее счет; 0, если карта не найдена
+	CardID    int    `json:"card_id,omitempty"`
+	AccountID int    `json:"-"`
+	Merchant  string `json:"merchant"`
+	Amount    Money  `json:"amount"`
+	Status    string `json:"status"`
+	// Комиссия за оплату картой по тарифу на момент авторизации; блокируется вместе с Amount
+	HeldFee Money `json:"held_fee"`
+	// Код одобрения (6 цифр) для одобренных авторизаций
+	ApprovalCode  string `json:"approval_code,omitempty"`
+	DeclineReason string `json:"decline_reason,omitempty"`
+	// Списанная сумма; остаток блокировки при частичном списании снимается
+	CapturedAmount Money     `json:"captured_amount"`
+	EntryID        int       `json:"entry_id,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// Held возвращает сумму, которую авторизация блокирует на счете: Amount вместе с HeldFee.
+func (a *CardAuthorization) Held() Money {
+	return NewMoney(a.Amount.Minor+a.HeldFee.Minor, a.Amount.Currency)
+}
@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// Платежные системы карточных продуктов.
+const (
+	PaymentSystemMir        = "mir"
+	PaymentSystemVisa       = "visa"
+	PaymentSystemMastercard = "mastercard"
+)
+
+// Типы карт по источнику средств.
+const (
+	CardTypeDebit   = "debit"
+	CardTypeCredit  = "credit"
+	CardTypePrepaid = "prepaid"
+)
+
+// DefaultCardProduct — продукт, по которому выпускается карта, если код не указан.
+const DefaultCardProduct = "mir_debit"
+
+// CardProduct — карточный продукт: диапазон BIN, платежная система, тип карты,
+// длина номера и срок действия выпускаемых карт.
+type CardProduct struct {
+	Code          string `json:"code"`
+	Name          string `json:"name"`
+	PaymentSystem string `json:"payment_system"`
+	Type          string `json:"type"`
+	// Номера карт начинаются с префикса из [BINFrom, BINTo]; границы одной длины, 6–8 цифр
+	BINFrom string `json:"bin_from"`
+	BINTo   string `json:"bin_to"`
+	// Длина номера карты: 16 или 19
+	PANLength int `json:"pan_length"`
+	// Срок действия выпускаемых карт, лет
+	ValidityYears int `json:"validity_years"`
+	// При перевыпуске карта сохраняет номер (и PIN); иначе получает новый номер
+	ReissueKeepPAN bool      `json:"reissue_keep_pan"`
+	Active         bool      `json:"active"`
+	CreatedAt      time.Time `json:"created_at"`
+}
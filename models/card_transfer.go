@@ -0,0 +1,9 @@
+package models
+
+// CardTransfer — результат перевода с карты на карту. Номера карт только маскированные.
+type CardTransfer struct {
+	FromCardID int       `json:"from_card_id"`
+	FromPAN    string    `json:"from_pan"`
+	ToPAN      string    `json:"to_pan"`
+	Transfer   *Transfer `json:"transfer"`
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// RateDecimals — количество знаков после запятой при выводе курсов.
+const RateDecimals = 6
+
+// CurrencyRate — официальный курс ЦБ РФ на дату: Value рублей за Nominal единиц валюты.
+type CurrencyRate struct {
+	Date     time.Time `json:"date"`
+	Currency string    `json:"currency"` // буквенный код ISO 4217
+	Nominal  int       `json:"nominal"`
+	Value    string    `json:"value"` // как публикует ЦБ, например "92.5133"
+}
+
+// PerUnit возвращает стоимость одной единицы валюты в рублях.
+func (r CurrencyRate) PerUnit() (*big.Rat, error) {
+	value, ok := new(big.Rat).SetString(r.Value)
+	if !ok || value.Sign() <= 0 || r.Nominal <= 0 {
+		return nil, fmt.Errorf("invalid rate %s/%d for %s", r.Value, r.Nominal, r.Currency)
+	}
+	return value.Quo(value, big.NewRat(int64(r.Nominal), 1)), nil
+}
+
+// FormatRate форматирует курс с RateDecimals знаками после запятой.
+func FormatRate(rate *big.Rat) string {
+	return rate.FloatString(RateDecimals)
+}
+
+// FXQuote — котировка конвертации суммы по курсу ЦБ с учетом спреда банка.
+type FXQuote struct {
+	FromCurrency string `json:"from_currency"`
+	ToCurrency   string `json:"to_currency"`
+	// Кросс-курс ЦБ: единиц ToCurrency за единицу FromCurrency
+	MidRate string `json:"mid_rate"`
+	// Применённый курс с учетом спреда
+	Rate      string    `json:"rate"`
+	Amount    Money     `json:"amount"`
+	Converted Money     `json:"converted"`
+	RateDate  time.Time `json:"rate_date"`
+}
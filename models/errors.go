@@ -0,0 +1,78 @@
+package models
+
+import "errors"
+
+// Ошибки предметной области. Обработчики отображают их в HTTP-статусы 4xx.
+var (
+	ErrAccountNotFound   = errors.New("account not found")
+	ErrAccountInactive   = errors.New("account is not active")
+	ErrInsufficientFunds = errors.New("insufficient funds")
+	ErrNotAccountOwner   = errors.New("account does not belong to user")
+	ErrSameAccount       = errors.New("source and destination accounts must differ")
+	ErrInvalidAmount     = errors.New("amount must be positive")
+
+	ErrInvalidStatusTransition = errors.New("status transition is not allowed")
+	ErrAccountNotEmpty         = errors.New("account balance is not zero")
+	ErrAccountHasCredits       = errors.New("account has active credits")
+	ErrAccountHasCards         = errors.New("account has cards")
+	ErrAccountHasHolds         = errors.New("account has pending card authorizations")
+
+	ErrCardNotFound       = errors.New("card not found")
+	ErrNotCardOwner       = errors.New("card does not belong to user")
+	ErrInvalidPAN         = errors.New("invalid card number")
+	ErrCardInactive       = errors.New("card is not active")
+	ErrInvalidCardStatus  = errors.New("invalid card status")
+	ErrInvalidPINBlock    = errors.New("invalid PIN block")
+	ErrInvalidPIN         = errors.New("incorrect PIN")
+	ErrPINNotSet          = errors.New("card PIN is not set")
+	ErrPINAlreadySet      = errors.New("card PIN is already set")
+	ErrInvalidVirtualCard = errors.New("invalid virtual card parameters")
+	ErrCardAlreadyUsed    = errors.New("single-use card has already been used")
+	ErrCardMerchantLocked = errors.New("card is locked to another merchant")
+	ErrSpendCapExceeded   = errors.New("amount exceeds the card spend cap")
+	ErrVirtualCardSource  = errors.New("virtual cards can only pay merchants")
+	ErrCardNumberTaken    = errors.New("card number is already issued")
+
+	ErrAuthorizationNotFound = errors.New("card authorization not found")
+	ErrCaptureExceedsHold    = errors.New("capture amount exceeds the authorized amount")
+
+	ErrInvalidPassword = errors.New("invalid password")
+	ErrTooManyAttempts = errors.New("too many attempts, try again later")
+
+	ErrUserNotFound        = errors.New("user not found")
+	ErrInvalidMemberRole   = errors.New("invalid account member role")
+	ErrMemberAlreadyExists = errors.New("user is already an account member")
+	ErrMemberNotFound      = errors.New("account member not found")
+	ErrLastAccountOwner    = errors.New("account must keep at least one owner")
+	ErrSpendLimitExceeded  = errors.New("amount exceeds the member spend limit")
+
+	ErrInvalidAccountNumber = errors.New("invalid account number")
+	ErrInvalidAccountType   = errors.New("invalid account type")
+	ErrUnsupportedCurrency  = errors.New("unsupported currency")
+
+	ErrTransferNotFound       = errors.New("transfer not found")
+	ErrTransferFullyReversed  = errors.New("transfer is already fully reversed")
+	ErrReversalExceedsBalance = errors.New("reversal exceeds the remaining transfer amount")
+	ErrReversalNotAllowed     = errors.New("reversal window has expired")
+
+	ErrCardProductNotFound = errors.New("card product not found")
+	ErrInvalidCardProduct  = errors.New("invalid card product")
+
+	ErrFeeRuleNotFound  = errors.New("fee rule not found")
+	ErrInvalidFeeRule   = errors.New("invalid fee rule")
+	ErrInvalidOperation = errors.New("unknown operation")
+	ErrOperatorRequired = errors.New("operation requires operator role")
+
+	ErrBatchTransferNotFound  = errors.New("batch transfer not found")
+	ErrInvalidBatch           = errors.New("invalid batch transfer")
+	ErrUnsupportedBatchFormat = errors.New("unsupported batch transfer format")
+
+	ErrStandingOrderNotFound = errors.New("standing order not found")
+	ErrInvalidSchedule       = errors.New("invalid schedule")
+
+	ErrInvalidInterestRate = errors.New("invalid interest rate")
+	ErrRateUnavailable     = errors.New("currency rate is not available")
+
+	ErrInvalidPeriod           = errors.New("period start must be before its end")
+	ErrUnsupportedStatementFmt = errors.New("unsupported statement format")
+)
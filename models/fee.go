@@ -0,0 +1,105 @@
+package models
+
+import "time"
+
+// Операции, за которые тариф может взимать комиссию.
+const (
+	FeeOperationTransfer = "transfer"
+	// FeeOperationCardTransfer — перевод по реквизитам карт.
+	FeeOperationCardTransfer = "card_transfer"
+	FeeOperationWithdrawal   = "withdrawal"
+	FeeOperationDeposit      = "deposit"
+	// FeeOperationCardPurchase — оплата картой; комиссия списывается вместе с авторизацией.
+	FeeOperationCardPurchase = "card_purchase"
+)
+
+// ValidFeeOperation сообщает, тарифицируется ли операция.
+func ValidFeeOperation(operation string) bool {
+	switch operation {
+	case FeeOperationTransfer, FeeOperationCardTransfer, FeeOperationWithdrawal, FeeOperationDeposit,
+		FeeOperationCardPurchase:
+		return true
+	}
+	return false
+}
+
+// FeeTier — ступень тарифа по сумме операции: применяется к операциям на сумму до UpTo включительно.
+type FeeTier struct {
+	// Верхняя граница ступени; 0 — без границы
+	UpTo    Money   `json:"up_to"`
+	Percent float64 `json:"percent"`
+	Fixed   Money   `json:"fixed"`
+}
+
+// FeeRule — правило тарифа. Пустые Currency и AccountType подходят к любой валюте и типу счета;
+// суммы правила (Fixed, Min, Max, границы) берутся в валюте операции.
+// Комиссия — Percent процентов от суммы плюс Fixed, но не меньше Min и не больше Max.
+// Если заданы Tiers, процент и фиксированная часть берутся из первой ступени,
+// в которую попадает сумма; сверх последней ступени действуют Percent и Fixed правила.
+type FeeRule struct {
+	ID          int    `json:"id"`
+	Operation   string `json:"operation"`
+	Currency    string `json:"currency,omitempty"`
+	AccountType string `json:"account_type,omitempty"`
+	// Правило действует, пока оборот счета по операции с начала месяца в [VolumeFrom, VolumeTo);
+	// VolumeTo = 0 — без верхней границы
+	VolumeFrom Money   `json:"volume_from"`
+	VolumeTo   Money   `json:"volume_to"`
+	Percent    float64 `json:"percent"`
+	Fixed      Money   `json:"fixed"`
+	Min        Money   `json:"min"`
+	// 0 — без ограничения сверху
+	Max       Money     `json:"max"`
+	Tiers     []FeeTier `json:"tiers,omitempty"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Matches сообщает, применимо ли правило к операции по счету типа accountType
+// с оборотом volume с начала месяца.
+func (r *FeeRule) Matches(operation, currency, accountType string, volume Money) bool {
+	if !r.Active || r.Operation != operation {
+		return false
+	}
+	if r.Currency != "" && r.Currency != currency {
+		return false
+	}
+	if r.AccountType != "" && r.AccountType != accountType {
+		return false
+	}
+	if volume.Minor < r.VolumeFrom.Minor {
+		return false
+	}
+	return r.VolumeTo.IsZero() || volume.Minor < r.VolumeTo.Minor
+}
+
+// Specificity — насколько узко правило: при нескольких подходящих правилах
+// применяется правило с валютой и типом счета, затем с большей нижней границей оборота.
+func (r *FeeRule) Specificity() int {
+	score := 0
+	if r.Currency != "" {
+		score += 2
+	}
+	if r.AccountType != "" {
+		score++
+	}
+	return score
+}
+
+// FeeCharge — комиссия за одну операцию по счету. Каждая операция записывается,
+// даже бесплатная: из этих записей считается месячный оборот для тарифа.
+type FeeCharge struct {
+	ID        int    `json:"-"`
+	AccountID int    `json:"account_id"`
+	Operation string `json:"operation"`
+	// Сумма операции в валюте счета
+	Amount Money `json:"amount"`
+	Fee    Money `json:"fee"`
+	// Примененное правило; 0, если операция бесплатна по тарифу
+	RuleID int `json:"rule_id,omitempty"`
+	// Оборот счета по операции с начала месяца без учета этой операции
+	MonthlyVolume Money `json:"monthly_volume"`
+	// Запись журнала списания комиссии
+	EntryID   int       `json:"entry_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
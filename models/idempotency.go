@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+)
+
+// IdempotencyRecord хранит результат запроса с заголовком Idempotency-Key.
+type IdempotencyRecord struct {
+	UserID int
+	Key    string
+	// SHA-256 от метода, пути и тела запроса
+	RequestHash string
+	// Completed=false, пока первый запрос еще выполняется
+	Completed    bool
+	StatusCode   int
+	ContentType  string
+	ResponseBody []byte
+	CreatedAt    time.Time
+	ExpiresAt    time.Time
+}
@@ -0,0 +1,96 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Типы записей журнала. Тип записи копируется в transactions.type.
+const (
+	EntryTypeOpeningBalance     = "opening_balance"
+	EntryTypeDeposit            = "deposit"
+	EntryTypeWithdrawal         = "withdrawal"
+	EntryTypeTransfer           = "transfer"
+	EntryTypeCreditDisbursement = "credit_disbursement"
+	EntryTypePenalty            = "penalty"
+	EntryTypeReversal           = "reversal"
+	EntryTypeFee                = "fee"
+	EntryTypeCardPurchase       = "card_purchase"
+)
+
+// Системные (внутрибанковские) счета учета, не принадлежащие клиентам.
+const (
+	// SystemAccountCash — касса: источник пополнений и получатель снятий.
+	SystemAccountCash = "cash"
+	// SystemAccountLoans — ссудная задолженность клиентов.
+	SystemAccountLoans = "loans"
+	// SystemAccountPenaltyIncome — доходы от штрафов по кредитам.
+	SystemAccountPenaltyIncome = "penalty_income"
+	// SystemAccountOpeningBalance — источник входящих остатков при переходе на журнал.
+	SystemAccountOpeningBalance = "opening_balance"
+	// SystemAccountFXPosition — валютная позиция банка при конвертации между валютами.
+	SystemAccountFXPosition = "fx_position"
+	// SystemAccountFeeIncome — комиссионные доходы банка.
+	SystemAccountFeeIncome = "fee_income"
+	// SystemAccountCardSettlement — расчеты с торговыми точками по оплатам картами.
+	SystemAccountCardSettlement = "card_settlement"
+)
+
+// ErrUnbalancedEntry возвращается, если проводки записи не сходятся в ноль.
+var ErrUnbalancedEntry = errors.New("journal entry is not balanced")
+
+// JournalEntry — запись журнала двойной записи, объединяющая проводки одной операции.
+type JournalEntry struct {
+	ID          int       `json:"id"`
+	Type        string    `json:"type"`
+	Description string    `json:"description"`
+	Postings    []Posting `json:"postings"`
+	// Курс конвертации для записей с проводками в двух валютах
+	FXRate string `json:"fx_rate,omitempty"`
+	// Запись, которую компенсирует данная (для возвратов)
+	ReversalOf int       `json:"reversal_of,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Posting — проводка по одному счету. Положительная сумма увеличивает остаток
+// счета, отрицательная — уменьшает. Сумма проводок записи в каждой валюте равна нулю,
+// поэтому accounts.balance всегда равен сумме проводок по счету.
+type Posting struct {
+	ID      int `json:"id"`
+	EntryID int `json:"entry_id"`
+	// Клиентский счет; 0, если проводка идет по системному счету
+	AccountID int `json:"account_id,omitempty"`
+	// Системный счет учета (SystemAccount*); пустой для клиентских счетов
+	SystemAccount string `json:"system_account,omitempty"`
+	Amount        Money  `json:"amount"`
+}
+
+// Validate проверяет, что запись сбалансирована по каждой валюте.
+func (e *JournalEntry) Validate() error {
+	if e.Type == "" {
+		return errors.New("journal entry type is required")
+	}
+	if len(e.Postings) < 2 {
+		return fmt.Errorf("%w: at least two postings required", ErrUnbalancedEntry)
+	}
+	totals := make(map[string]int64)
+	for _, p := range e.Postings {
+		if (p.AccountID == 0) == (p.SystemAccount == "") {
+			return errors.New("posting must reference exactly one of account or system account")
+		}
+		if p.Amount.Currency == "" {
+			return errors.New("posting currency is required")
+		}
+		if p.Amount.IsZero() {
+			return errors.New("posting amount must be non-zero")
+		}
+		totals[p.Amount.Currency] += p.Amount.Minor
+	}
+	for currency, total := range totals {
+		if total != 0 {
+			return fmt.Errorf("%w: %s off by %s", ErrUnbalancedEntry, currency, NewMoney(total, currency))
+		}
+	}
+	return nil
+}
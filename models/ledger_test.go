@@ -0,0 +1,29 @@
+package models_test
+
+import (
+	"bank-api/models"
+	"errors"
+	"testing"
+)
+
+func TestJournalEntryValidate(t *testing.T) {
+	balanced := models.JournalEntry{
+		Type: models.EntryTypeDeposit,
+		Postings: []models.Posting{
+			{AccountID: 1, Amount: models.NewMoney(1000, "RUB")},
+			{SystemAccount: models.SystemAccountCash, Amount: models.NewMoney(-1000, "RUB")},
+		},
+	}
+	if err := balanced.Validate(); err != nil {
+		t.Errorf("expected balanced entry, got %v", err)
+	}
+
+	unbalanced := balanced
+	unbalanced.Postings = []models.Posting{
+		{AccountID: 1, Amount: models.NewMoney(1000, "RUB")},
+		{AccountID: 2, Amount: models.NewMoney(-1000, "USD")},
+	}
+	if err := unbalanced.Validate(); !errors.Is(err, models.ErrUnbalancedEntry) {
+		t.Errorf("expected ErrUnbalancedEntry, got %v", err)
+	}
+}
@@ -29,3 +29,15 @@ func TestUserJSON(t *testing.T) {
 		t.Errorf("expected %v, got %v", user, user2)
 	}
 }
+
+func TestTransactionCursorRoundTripInUTC(t *testing.T) {
+	moscow := time.FixedZone("MSK", 3*60*60)
+	created := time.Date(2024, 3, 1, 12, 30, 0, 123456000, moscow)
+	cursor, err := models.DecodeTransactionCursor(models.TransactionCursor{CreatedAt: created, ID: 7}.Encode())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cursor.CreatedAt.Location() != time.UTC || !cursor.CreatedAt.Equal(created) || cursor.ID != 7 {
+		t.Errorf("expected %s and ID 7 in UTC, got %s and %d", created.UTC(), cursor.CreatedAt, cursor.ID)
+	}
+}
@@ -0,0 +1,263 @@
+package models
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// DefaultCurrency — валюта по умолчанию для сумм, у которых валюта не указана явно.
+const DefaultCurrency = "RUB"
+
+// MinorUnits — количество знаков после запятой (копейки, центы).
+// Все поддерживаемые валюты имеют две минорные единицы.
+const MinorUnits = 2
+
+// minorScale — число минорных единиц в одной основной (100 копеек в рубле).
+const minorScale = 100
+
+// ErrCurrencyMismatch возвращается при попытке сложить или сравнить суммы в разных валютах.
+var ErrCurrencyMismatch = errors.New("currency mismatch")
+
+// RoundingMode задает правило округления до минорных единиц.
+type RoundingMode int
+
+const (
+	// RoundHalfEven — банковское округление: половина округляется к четному.
+	// Используется для процентов.
+	RoundHalfEven RoundingMode = iota
+	// RoundHalfUp — половина округляется от нуля.
+	RoundHalfUp
+	// RoundDown — отбрасывание дробной части (к нулю).
+	RoundDown
+)
+
+// Money — денежная сумма в минорных единицах вместе с кодом валюты.
+// Пустая валюта означает, что валюта еще не известна (например, сумма
+// только что пришла из JSON); такая сумма совместима с любой валютой.
+type Money struct {
+	// Сумма в минорных единицах (копейках)
+	Minor int64
+	// Код валюты ISO 4217
+	Currency string
+}
+
+// NewMoney создает сумму из минорных единиц.
+func NewMoney(minor int64, currency string) Money {
+	return Money{Minor: minor, Currency: currency}
+}
+
+// ParseMoney разбирает десятичную строку вида "1234.56".
+// Строки с ненулевыми знаками после второго десятичного разряда отклоняются.
+func ParseMoney(s, currency string) (Money, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || strings.ContainsAny(s, "/") {
+		return Money{}, fmt.Errorf("invalid amount %q", s)
+	}
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return Money{}, fmt.Errorf("invalid amount %q", s)
+	}
+	r.Mul(r, big.NewRat(minorScale, 1))
+	if !r.IsInt() {
+		return Money{}, fmt.Errorf("amount %q has more than %d decimal places", s, MinorUnits)
+	}
+	if !r.Num().IsInt64() {
+		return Money{}, fmt.Errorf("amount %q is out of range", s)
+	}
+	return Money{Minor: r.Num().Int64(), Currency: currency}, nil
+}
+
+// MoneyFromRat округляет рациональное число основных единиц до минорных по правилу mode.
+func MoneyFromRat(r *big.Rat, currency string, mode RoundingMode) Money {
+	scaled := new(big.Rat).Mul(r, big.NewRat(minorScale, 1))
+	return Money{Minor: roundRat(scaled, mode).Int64(), Currency: currency}
+}
+
+// roundRat округляет рациональное число до целого по правилу mode.
+func roundRat(r *big.Rat, mode RoundingMode) *big.Int {
+	num := new(big.Int).Set(r.Num())
+	den := r.Denom()
+	q, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+	if rem.Sign() == 0 || mode == RoundDown {
+		return q
+	}
+	// Сравниваем удвоенный модуль остатка со знаменателем.
+	twice := new(big.Int).Abs(rem)
+	twice.Lsh(twice, 1)
+	cmp := twice.Cmp(den)
+	away := cmp > 0
+	if cmp == 0 {
+		switch mode {
+		case RoundHalfUp:
+			away = true
+		case RoundHalfEven:
+			away = q.Bit(0) == 1
+		}
+	}
+	if away {
+		if num.Sign() < 0 {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	return q
+}
+
+// Rat возвращает сумму в основных единицах в виде точного рационального числа.
+func (m Money) Rat() *big.Rat {
+	return big.NewRat(m.Minor, minorScale)
+}
+
+// WithCurrency возвращает ту же сумму с указанной валютой.
+func (m Money) WithCurrency(currency string) Money {
+	m.Currency = currency
+	return m
+}
+
+// sameCurrency проверяет совместимость валют и возвращает валюту результата.
+func (m Money) sameCurrency(o Money) (string, error) {
+	switch {
+	case m.Currency == "":
+		return o.Currency, nil
+	case o.Currency == "" || o.Currency == m.Currency:
+		return m.Currency, nil
+	}
+	return "", fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, o.Currency)
+}
+
+// Add возвращает сумму m + o.
+func (m Money) Add(o Money) (Money, error) {
+	cur, err := m.sameCurrency(o)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Minor: m.Minor + o.Minor, Currency: cur}, nil
+}
+
+// Sub возвращает разность m - o.
+func (m Money) Sub(o Money) (Money, error) {
+	cur, err := m.sameCurrency(o)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Minor: m.Minor - o.Minor, Currency: cur}, nil
+}
+
+// Cmp сравнивает суммы: -1, если m < o; 0, если равны; +1, если m > o.
+func (m Money) Cmp(o Money) (int, error) {
+	if _, err := m.sameCurrency(o); err != nil {
+		return 0, err
+	}
+	switch {
+	case m.Minor < o.Minor:
+		return -1, nil
+	case m.Minor > o.Minor:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// MulRat умножает сумму на рациональный коэффициент с округлением по правилу mode.
+func (m Money) MulRat(factor *big.Rat, mode RoundingMode) Money {
+	r := new(big.Rat).Mul(big.NewRat(m.Minor, 1), factor)
+	return Money{Minor: roundRat(r, mode).Int64(), Currency: m.Currency}
+}
+
+// Neg возвращает сумму с противоположным знаком.
+func (m Money) Neg() Money {
+	return Money{Minor: -m.Minor, Currency: m.Currency}
+}
+
+// Abs возвращает модуль суммы.
+func (m Money) Abs() Money {
+	if m.Minor < 0 {
+		return m.Neg()
+	}
+	return m
+}
+
+// IsZero сообщает, равна ли сумма нулю.
+func (m Money) IsZero() bool { return m.Minor == 0 }
+
+// IsPositive сообщает, больше ли сумма нуля.
+func (m Money) IsPositive() bool { return m.Minor > 0 }
+
+// IsNegative сообщает, меньше ли сумма нуля.
+func (m Money) IsNegative() bool { return m.Minor < 0 }
+
+// String форматирует сумму как десятичное число с двумя знаками: "-1234.05".
+func (m Money) String() string {
+	sign := ""
+	minor := m.Minor
+	if minor < 0 {
+		sign = "-"
+		minor = -minor
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, minor/minorScale, minor%minorScale)
+}
+
+// MarshalJSON кодирует сумму JSON-числом с двумя знаками после запятой,
+// чтобы формат ответа не менялся для существующих клиентов.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON принимает сумму как JSON-число или строку.
+// Валюта не передается в JSON и проставляется сервисом по счету.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	if s == "null" {
+		*m = Money{}
+		return nil
+	}
+	parsed, err := ParseMoney(s, "")
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Value реализует driver.Valuer: сумма передается в БД как десятичная строка (NUMERIC).
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+// Scan реализует sql.Scanner для столбцов NUMERIC.
+// Валюта при сканировании не известна и заполняется репозиторием.
+func (m *Money) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*m = Money{}
+		return nil
+	case []byte:
+		return m.scanString(string(v))
+	case string:
+		return m.scanString(v)
+	case int64:
+		*m = Money{Minor: v * minorScale}
+		return nil
+	case float64:
+		*m = MoneyFromRat(new(big.Rat).SetFloat64(v), "", RoundHalfEven)
+		return nil
+	}
+	return fmt.Errorf("cannot scan %T into Money", src)
+}
+
+func (m *Money) scanString(s string) error {
+	parsed, err := ParseMoney(s, "")
+	if err != nil {
+		// NUMERIC без ограничения точности может содержать больше двух знаков.
+		r, ok := new(big.Rat).SetString(strings.TrimSpace(s))
+		if !ok {
+			return err
+		}
+		parsed = MoneyFromRat(r, "", RoundHalfEven)
+	}
+	*m = parsed
+	return nil
+}
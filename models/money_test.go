@@ -0,0 +1,90 @@
+package models_test
+
+import (
+	"bank-api/models"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	cases := map[string]int64{
+		"0":        0,
+		"12":       1200,
+		"12.3":     1230,
+		"12.30":    1230,
+		"-0.05":    -5,
+		"1000.010": 100001,
+	}
+	for in, want := range cases {
+		m, err := models.ParseMoney(in, "RUB")
+		if err != nil {
+			t.Fatalf("ParseMoney(%q) error: %v", in, err)
+		}
+		if m.Minor != want {
+			t.Errorf("ParseMoney(%q) = %d, want %d", in, m.Minor, want)
+		}
+	}
+	for _, in := range []string{"", "abc", "1.001", "1/3"} {
+		if _, err := models.ParseMoney(in, "RUB"); err == nil {
+			t.Errorf("ParseMoney(%q) expected error", in)
+		}
+	}
+}
+
+func TestMoneyRounding(t *testing.T) {
+	m := models.NewMoney(1, "RUB") // 0.01
+	half := big.NewRat(1, 2)
+	if got := m.MulRat(half, models.RoundHalfEven).Minor; got != 0 {
+		t.Errorf("half-even 0.005 = %d, want 0", got)
+	}
+	if got := models.NewMoney(3, "RUB").MulRat(half, models.RoundHalfEven).Minor; got != 2 {
+		t.Errorf("half-even 0.015 = %d, want 2", got)
+	}
+	if got := m.MulRat(half, models.RoundHalfUp).Minor; got != 1 {
+		t.Errorf("half-up 0.005 = %d, want 1", got)
+	}
+	if got := m.Neg().MulRat(half, models.RoundHalfUp).Minor; got != -1 {
+		t.Errorf("half-up -0.005 = %d, want -1", got)
+	}
+	if got := models.NewMoney(19, "RUB").MulRat(half, models.RoundDown).Minor; got != 9 {
+		t.Errorf("down 0.095 = %d, want 9", got)
+	}
+}
+
+func TestMoneyCurrencyMismatch(t *testing.T) {
+	rub := models.NewMoney(100, "RUB")
+	usd := models.NewMoney(100, "USD")
+	if _, err := rub.Add(usd); !errors.Is(err, models.ErrCurrencyMismatch) {
+		t.Errorf("expected ErrCurrencyMismatch, got %v", err)
+	}
+	sum, err := rub.Add(models.NewMoney(50, ""))
+	if err != nil || sum.Minor != 150 || sum.Currency != "RUB" {
+		t.Errorf("unexpected sum %+v, err %v", sum, err)
+	}
+}
+
+func TestMoneyJSONAndScan(t *testing.T) {
+	var payload struct {
+		Amount models.Money `json:"amount"`
+	}
+	if err := json.Unmarshal([]byte(`{"amount": 1234.5}`), &payload); err != nil {
+		t.Fatalf("unmarshal error: %v", err)
+	}
+	if payload.Amount.Minor != 123450 {
+		t.Errorf("expected 123450 minor units, got %d", payload.Amount.Minor)
+	}
+	data, _ := json.Marshal(payload)
+	if string(data) != `{"amount":1234.50}` {
+		t.Errorf("unexpected JSON %s", data)
+	}
+
+	var m models.Money
+	if err := m.Scan([]byte("-42.10")); err != nil || m.Minor != -4210 {
+		t.Errorf("Scan NUMERIC: got %d, err %v", m.Minor, err)
+	}
+	if err := m.Scan(0.1 + 0.2); err != nil || m.Minor != 30 {
+		t.Errorf("Scan float: got %d, err %v", m.Minor, err)
+	}
+}
@@ -0,0 +1,8 @@
+package models
+
+// Роли пользователей. Роль передается в JWT (claim "role").
+const (
+	RoleCustomer = "customer"
+	// RoleOperator — сотрудник банка: может возвращать переводы вне окна отмены и управлять тарифом.
+	RoleOperator = "operator"
+)
@@ -0,0 +1,73 @@
+package models
+
+import "time"
+
+// Периодичность постоянного поручения.
+const (
+	FrequencyDaily   = "daily"
+	FrequencyWeekly  = "weekly"
+	FrequencyMonthly = "monthly"
+	// FrequencyCron — расписание задается выражением CronExpr (5 полей, без секунд).
+	FrequencyCron = "cron"
+)
+
+// Статусы постоянного поручения.
+const (
+	StandingOrderStatusActive = "active"
+	// StandingOrderStatusSuspended — приостановлено клиентом или после серии неудачных запусков.
+	StandingOrderStatusSuspended = "suspended"
+	StandingOrderStatusCancelled = "cancelled"
+)
+
+// Результаты запуска постоянного поручения. started — запуск начат, перевод
+// мог быть выполнен, но результат еще не записан.
+const (
+	StandingOrderRunStarted   = "started"
+	StandingOrderRunSucceeded = "succeeded"
+	StandingOrderRunFailed    = "failed"
+)
+
+// StandingOrder — постоянное поручение: регулярный перевод со счета пользователя.
+type StandingOrder struct {
+	ID            int    `json:"id"`
+	UserID        int    `json:"user_id"`
+	FromAccountID int    `json:"from_account_id"`
+	ToAccountID   int    `json:"to_account_id"`
+	Amount        Money  `json:"amount"`
+	Frequency     string `json:"frequency"`
+	CronExpr      string `json:"cron_expr,omitempty"`
+	// Первый запуск; от него отсчитываются ежедневные, еженедельные и ежемесячные запуски
+	StartAt time.Time `json:"start_at"`
+	// Следующий плановый запуск
+	NextRunAt time.Time `json:"next_run_at"`
+	// Повтор запуска, не прошедшего из-за нехватки средств
+	RetryAt *time.Time `json:"retry_at,omitempty"`
+	// Неудачных запусков подряд
+	FailureCount int       `json:"failure_count"`
+	Status       string    `json:"status"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// DueAt возвращает момент, когда поручение должно быть исполнено: повтор или плановый запуск.
+func (o *StandingOrder) DueAt() time.Time {
+	if o.RetryAt != nil {
+		return *o.RetryAt
+	}
+	return o.NextRunAt
+}
+
+// StandingOrderRun — результат одного запуска постоянного поручения.
+type StandingOrderRun struct {
+	ID      int `json:"id"`
+	OrderID int `json:"order_id"`
+	// Плановое время запуска (или время повтора)
+	ScheduledAt time.Time `json:"scheduled_at"`
+	// Номер попытки подряд, начиная с 1
+	Attempt int    `json:"attempt"`
+	Status  string `json:"status"`
+	Error   string `json:"error,omitempty"`
+	// Запись журнала перевода при успехе
+	EntryID   int       `json:"entry_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// Форматы выписки по счету.
+const (
+	StatementFormatCSV     = "csv"
+	StatementFormatOFX     = "ofx"
+	StatementFormatCamt053 = "camt053"
+)
+
+// Statement — заголовок выписки по счету за период [From, To).
+// Операции выписки передаются потоком и в структуру не входят.
+type Statement struct {
+	Account        *Account
+	From           time.Time
+	To             time.Time
+	OpeningBalance Money // остаток на начало периода
+	ClosingBalance Money // остаток на конец периода
+	GeneratedAt    time.Time
+}
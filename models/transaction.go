@@ -1,15 +1,80 @@
 package models
 
 import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 )
 
 // Transaction представляет операцию по счету.
+// Type совпадает с типом записи журнала: deposit, withdrawal, transfer и т.д.
+// Amount положительна для зачислений и отрицательна для списаний.
 type Transaction struct {
-	ID        int       `json:"id"`
-	AccountID int       `json:"account_id" validate:"required"`
-	Amount    float64   `json:"amount" validate:"required"`
-	// Type может принимать значения: deposit, withdrawal, transfer и т.д.
-	Type      string    `json:"type" validate:"required"`
-	CreatedAt time.Time `json:"created_at"`
+	ID           int       `json:"id"`
+	AccountID    int       `json:"account_id" validate:"required"`
+	EntryID      int       `json:"entry_id,omitempty"` // запись журнала, породившая операцию
+	Amount       Money     `json:"amount" validate:"required"`
+	BalanceAfter Money     `json:"balance_after"` // остаток счета после операции
+	Type         string    `json:"type" validate:"required"`
+	FXRate       string    `json:"fx_rate,omitempty"` // курс, если операция шла с конвертацией
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// TransactionCursor — позиция в истории операций, отсортированной от новых к старым.
+// Время курсора хранится в UTC, как и created_at в БД.
+type TransactionCursor struct {
+	CreatedAt time.Time
+	ID        int
+}
+
+// Encode кодирует курсор в непрозрачную для клиента строку.
+func (c TransactionCursor) Encode() string {
+	raw := fmt.Sprintf("%d:%d", c.CreatedAt.UTC().UnixNano(), c.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeTransactionCursor разбирает строку, полученную из Encode.
+func DecodeTransactionCursor(s string) (*TransactionCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	parts := strings.SplitN(string(raw), ":", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	nanos, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	id, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	return &TransactionCursor{CreatedAt: time.Unix(0, nanos).UTC(), ID: id}, nil
+}
+
+// TransactionFilter задает условия выборки истории операций.
+// Нулевые значения полей означают отсутствие ограничения.
+type TransactionFilter struct {
+	// Начало периода (включительно)
+	From time.Time
+	// Конец периода (не включительно)
+	To   time.Time
+	Type string
+	// Границы модуля суммы операции (включительно)
+	MinAmount *Money
+	MaxAmount *Money
+	// Вернуть операции строго старше курсора
+	After *TransactionCursor
+	Limit int
+}
+
+// TransactionPage — страница истории операций.
+type TransactionPage struct {
+	Transactions []Transaction `json:"transactions"`
+	// Курсор следующей страницы; пустой, если страница последняя
+	NextCursor string `json:"next_cursor,omitempty"`
 }
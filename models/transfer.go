@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// Transfer — перевод между счетами клиентов.
+// Для счетов в разных валютах Amount списывается в валюте счета-источника,
+// а CreditedAmount зачисляется в валюте счета-получателя по курсу FXRate.
+type Transfer struct {
+	ID             int    `json:"id"`
+	UserID         int    `json:"-"`
+	FromAccountID  int    `json:"from_account_id"`
+	ToAccountID    int    `json:"to_account_id"`
+	Amount         Money  `json:"amount"`
+	Currency       string `json:"currency"`
+	CreditedAmount Money  `json:"credited_amount"`
+	// Валюта зачисления
+	CreditedCurrency string `json:"credited_currency"`
+	// Курс конвертации с учетом спреда; пустой для переводов в одной валюте
+	FXRate  string `json:"fx_rate,omitempty"`
+	EntryID int    `json:"entry_id"`
+	// Уже возвращено отправителю (в валюте Amount) и списано с получателя (в валюте CreditedAmount)
+	ReversedAmount         Money `json:"reversed_amount"`
+	ReversedCreditedAmount Money `json:"reversed_credited_amount"`
+	// Комиссия по тарифу, списанная со счета-источника вместе с переводом
+	Fee       *FeeCharge `json:"fee,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// Remaining возвращает сумму перевода, которую еще можно вернуть отправителю.
+func (t *Transfer) Remaining() Money {
+	return NewMoney(t.Amount.Minor-t.ReversedAmount.Minor, t.Amount.Currency)
+}
+
+// TransferReversal — полный или частичный возврат перевода компенсирующей записью журнала.
+type TransferReversal struct {
+	ID         int `json:"id"`
+	TransferID int `json:"transfer_id"`
+	// Инициатор возврата: отправитель или операционист
+	UserID int `json:"user_id"`
+	// Возвращается отправителю, в валюте счета-источника
+	Amount Money `json:"amount"`
+	// Списывается с получателя, в валюте счета-получателя
+	DebitedAmount Money     `json:"debited_amount"`
+	Reason        string    `json:"reason,omitempty"`
+	EntryID       int       `json:"entry_id"`
+	CreatedAt     time.Time `json:"created_at"`
+}
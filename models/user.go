@@ -10,5 +10,6 @@ type User struct {
 	Email        string    `json:"email" validate:"required,email"`
 	Username     string    `json:"username" validate:"required,min=3,max=30"`
 	PasswordHash string    `json:"-"` // Пароль хранится в виде хеша; не выводится в JSON
+	Role         string    `json:"role"` // RoleCustomer или RoleOperator
 	CreatedAt    time.Time `json:"created_at"`
 }
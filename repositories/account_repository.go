@@ -3,17 +3,46 @@ package repositories
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 
 	"bank-api/models"
 )
 
-// AccountRepository описывает методы работы с аккаунтами.
+// AccountRepository описывает методы работы с аккаунтами и их участниками.
 type AccountRepository interface {
+	// Create открывает счет и делает его создателя владельцем.
 	Create(a *models.Account) error
 	GetByID(id int) (*models.Account, error)
-	UpdateBalance(accountID int, delta float64) error
-	TransferTx(ctx context.Context, fromID, toID int, amount float64) error
+	// GetByNumber ищет счет по 20-значному номеру; ErrAccountNotFound, если его нет.
+	GetByNumber(number string) (*models.Account, error)
+	// NextNumberSerial выделяет порядковый номер для нового номера счета.
+	NextNumberSerial() (int64, error)
+	// ListWithoutNumber возвращает счета, открытые до введения номеров.
+	ListWithoutNumber() ([]*models.Account, error)
+	// SetNumber присваивает номер счету, у которого его еще нет.
+	SetNumber(accountID int, number string) error
+	// UpdateBalance проводит пополнение (delta > 0) или снятие (delta < 0) через кассу,
+	// в той же транзакции списывает комиссию fee (если задана) и возвращает счет с новым остатком.
+	// Снятие проводится от имени userID и засчитывается в месячный лимит участника.
+	UpdateBalance(userID, accountID int, delta models.Money, fee *models.FeeCharge) (*models.Account, error)
+	// TransferTx проводит перевод t от имени t.UserID вместе с комиссией t.Fee (если задана),
+	// сохраняет его запись и заполняет ID, EntryID и CreatedAt.
+	TransferTx(ctx context.Context, t *models.Transfer) error
+	// ChangeStatus переводит счет из статуса from в статус to под блокировкой строки;
+	// userID должен быть владельцем или участником с полным доступом.
+	// Закрытие требует нулевого остатка, отсутствия заблокированных авторизациями сумм,
+	// непогашенных кредитов и карт, кроме окончательно заблокированных.
+	ChangeStatus(ctx context.Context, userID, accountID int, from, to string) (*models.Account, error)
+
+	// GetMember возвращает членство пользователя в счете; ErrMemberNotFound, если его нет.
+	GetMember(accountID, userID int) (*models.AccountMember, error)
+	// ListMembers возвращает участников счета с их email.
+	ListMembers(accountID int) ([]models.AccountMember, error)
+	// AddMember добавляет участника; ErrMemberAlreadyExists, если он уже есть.
+	AddMember(m *models.AccountMember) error
+	// RemoveMember удаляет участника; последнего владельца удалить нельзя.
+	RemoveMember(ctx context.Context, accountID, userID int) error
 }
 
 type accountRepository struct {
@@ -25,64 +54,521 @@ func NewAccountRepository(db *sql.DB) AccountRepository {
 	return &accountRepository{db: db}
 }
 
+// accountColumns — столбцы, которые читает scanAccount.
+const accountColumns = `id, user_id, balance, currency, status, created_at, COALESCE(number, ''), type, held`
+
 func (r *accountRepository) Create(a *models.Account) error {
-	_, err := r.db.Exec(
-		`INSERT INTO accounts (user_id, balance, currency, created_at)
-		 VALUES ($1, $2, $3, NOW())`,
-		a.UserID, a.Balance, a.Currency,
-	)
-	return err
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := tx.QueryRow(
+		`INSERT INTO accounts (user_id, number, type, balance, currency, status, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, NOW()) RETURNING id, created_at`,
+		a.UserID, a.Number, a.Type, a.Balance, a.Currency, a.Status,
+	).Scan(&a.ID, &a.CreatedAt); err != nil {
+		return fmt.Errorf("insert account: %w", err)
+	}
+	if _, err := tx.Exec(
+		`INSERT INTO account_members (account_id, user_id, role, created_at) VALUES ($1, $2, $3, $4)`,
+		a.ID, a.UserID, models.MemberRoleOwner, a.CreatedAt,
+	); err != nil {
+		return fmt.Errorf("insert account owner: %w", err)
+	}
+	return tx.Commit()
 }
 
 func (r *accountRepository) GetByID(id int) (*models.Account, error) {
-	row := r.db.QueryRow(
-		`SELECT id, user_id, balance, currency, created_at
-		 FROM accounts WHERE id = $1`, id,
-	)
+	row := r.db.QueryRow(`SELECT `+accountColumns+` FROM accounts WHERE id = $1`, id)
+	acc, err := scanAccount(row)
+	if err == sql.ErrNoRows {
+		return nil, models.ErrAccountNotFound
+	}
+	return acc, err
+}
+
+func (r *accountRepository) GetByNumber(number string) (*models.Account, error) {
+	row := r.db.QueryRow(`SELECT `+accountColumns+` FROM accounts WHERE number = $1`, number)
+	acc, err := scanAccount(row)
+	if err == sql.ErrNoRows {
+		return nil, models.ErrAccountNotFound
+	}
+	return acc, err
+}
+
+func (r *accountRepository) NextNumberSerial() (int64, error) {
+	var serial int64
+	if err := r.db.QueryRow(`SELECT nextval('account_number_seq')`).Scan(&serial); err != nil {
+		return 0, fmt.Errorf("allocate account number: %w", err)
+	}
+	return serial, nil
+}
+
+func (r *accountRepository) ListWithoutNumber() ([]*models.Account, error) {
+	rows, err := r.db.Query(`SELECT ` + accountColumns + ` FROM accounts WHERE number IS NULL ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching accounts: %w", err)
+	}
+	defer rows.Close()
+
+	var accounts []*models.Account
+	for rows.Next() {
+		acc, err := scanAccount(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning account: %w", err)
+		}
+		accounts = append(accounts, acc)
+	}
+	return accounts, rows.Err()
+}
+
+func (r *accountRepository) SetNumber(accountID int, number string) error {
+	res, err := r.db.Exec(`UPDATE accounts SET number = $1 WHERE id = $2 AND number IS NULL`, number, accountID)
+	if err != nil {
+		return fmt.Errorf("set account number: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("%w: %d without number", models.ErrAccountNotFound, accountID)
+	}
+	return nil
+}
+
+func (r *accountRepository) UpdateBalance(userID, accountID int, delta models.Money, fee *models.FeeCharge) (*models.Account, error) {
+	if delta.IsZero() {
+		return nil, models.ErrInvalidAmount
+	}
+	ctx := context.Background()
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	acc, err := lockAccount(ctx, tx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if acc.Status != models.AccountStatusActive {
+		return nil, models.ErrAccountInactive
+	}
+	if delta.Currency != "" && delta.Currency != acc.Currency {
+		return nil, fmt.Errorf("%w: %s and %s", models.ErrCurrencyMismatch, delta.Currency, acc.Currency)
+	}
+	delta = delta.WithCurrency(acc.Currency)
+	var member *models.AccountMember
+	if delta.IsNegative() {
+		member, err = getMember(ctx, tx, accountID, userID)
+		if errors.Is(err, models.ErrMemberNotFound) {
+			return nil, models.ErrNotAccountOwner
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := checkMemberSpend(ctx, tx, member, delta.Neg()); err != nil {
+			return nil, err
+		}
+		if acc.Available().Minor < -delta.Minor {
+			return nil, models.ErrInsufficientFunds
+		}
+	}
+
+	entryType := models.EntryTypeDeposit
+	if delta.IsNegative() {
+		entryType = models.EntryTypeWithdrawal
+	}
+	entry := &models.JournalEntry{
+		Type:        entryType,
+		Description: fmt.Sprintf("%s, account %d", entryType, accountID),
+		Postings: []models.Posting{
+			{AccountID: accountID, Amount: delta},
+			{SystemAccount: models.SystemAccountCash, Amount: delta.Neg()},
+		},
+	}
+	locked := map[int]*models.Account{accountID: acc}
+	if err := postEntry(ctx, tx, entry, locked); err != nil {
+		return nil, err
+	}
+	if member != nil {
+		if err := recordMemberSpend(ctx, tx, member, delta.Neg(), entry.ID); err != nil {
+			return nil, err
+		}
+	}
+	if fee != nil {
+		if err := chargeFee(ctx, tx, fee, locked); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit tx: %w", err)
+	}
+	return acc, nil
+}
+
+// TransferTx блокирует оба счета (SELECT ... FOR UPDATE) в порядке возрастания ID,
+// чтобы встречные переводы не приводили к взаимной блокировке, проверяет
+// владельца, статусы, валюту и остаток, и только затем проводит запись журнала.
+// Перевод между валютами проходит через валютную позицию банка и требует t.FXRate.
+func (r *accountRepository) TransferTx(ctx context.Context, t *models.Transfer) error {
+	fromID, toID := t.FromAccountID, t.ToAccountID
+	if fromID == toID {
+		return models.ErrSameAccount
+	}
+	if !t.Amount.IsPositive() {
+		return models.ErrInvalidAmount
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	first, second := fromID, toID
+	if first > second {
+		first, second = second, first
+	}
+	locked := make(map[int]*models.Account, 2)
+	for _, id := range []int{first, second} {
+		acc, err := lockAccount(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		locked[id] = acc
+	}
+	from, to := locked[fromID], locked[toID]
+
+	member, err := getMember(ctx, tx, fromID, t.UserID)
+	if errors.Is(err, models.ErrMemberNotFound) {
+		return models.ErrNotAccountOwner
+	}
+	if err != nil {
+		return err
+	}
+	if from.Status != models.AccountStatusActive || to.Status != models.AccountStatusActive {
+		return models.ErrAccountInactive
+	}
+	if t.Amount.Currency != "" && t.Amount.Currency != from.Currency {
+		return fmt.Errorf("%w: %s and %s", models.ErrCurrencyMismatch, t.Amount.Currency, from.Currency)
+	}
+	amount := t.Amount.WithCurrency(from.Currency)
+	if err := checkMemberSpend(ctx, tx, member, amount); err != nil {
+		return err
+	}
+
+	credited := amount
+	if from.Currency != to.Currency {
+		if t.FXRate == "" || t.CreditedAmount.Currency != to.Currency {
+			return fmt.Errorf("%w: %s and %s", models.ErrCurrencyMismatch, from.Currency, to.Currency)
+		}
+		credited = t.CreditedAmount
+		if !credited.IsPositive() {
+			return models.ErrInvalidAmount
+		}
+	}
+	if cmp, err := from.Available().Cmp(amount); err != nil {
+		return err
+	} else if cmp < 0 {
+		return models.ErrInsufficientFunds
+	}
+
+	entry := &models.JournalEntry{
+		Type:        models.EntryTypeTransfer,
+		Description: fmt.Sprintf("transfer from account %d to account %d", fromID, toID),
+	}
+	if from.Currency == to.Currency {
+		entry.Postings = []models.Posting{
+			{AccountID: fromID, Amount: amount.Neg()},
+			{AccountID: toID, Amount: amount},
+		}
+	} else {
+		entry.FXRate = t.FXRate
+		entry.Postings = []models.Posting{
+			{AccountID: fromID, Amount: amount.Neg()},
+			{SystemAccount: models.SystemAccountFXPosition, Amount: amount},
+			{SystemAccount: models.SystemAccountFXPosition, Amount: credited.Neg()},
+			{AccountID: toID, Amount: credited},
+		}
+	}
+	if err := postEntry(ctx, tx, entry, locked); err != nil {
+		return err
+	}
+	if err := recordMemberSpend(ctx, tx, member, amount, entry.ID); err != nil {
+		return err
+	}
+	if t.Fee != nil {
+		if err := chargeFee(ctx, tx, t.Fee, locked); err != nil {
+			return err
+		}
+	}
+	if err := tx.QueryRowContext(ctx,
+		`INSERT INTO transfers (user_id, from_account_id, to_account_id, amount, currency,
+			credited_amount, credited_currency, fx_rate, entry_id, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id`,
+		t.UserID, fromID, toID, amount, amount.Currency, credited, credited.Currency,
+		sql.NullString{String: entry.FXRate, Valid: entry.FXRate != ""}, entry.ID, entry.CreatedAt,
+	).Scan(&t.ID); err != nil {
+		return fmt.Errorf("insert transfer: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	t.Amount, t.Currency = amount, amount.Currency
+	t.CreditedAmount, t.CreditedCurrency = credited, credited.Currency
+	t.FXRate = entry.FXRate
+	t.EntryID, t.CreatedAt = entry.ID, entry.CreatedAt
+	t.ReversedAmount = models.NewMoney(0, amount.Currency)
+	t.ReversedCreditedAmount = models.NewMoney(0, credited.Currency)
+	return nil
+}
+
+func (r *accountRepository) ChangeStatus(ctx context.Context, userID, accountID int, from, to string) (*models.Account, error) {
+	if !models.CanTransitionAccount(from, to) {
+		return nil, fmt.Errorf("%w: %s -> %s", models.ErrInvalidStatusTransition, from, to)
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	acc, err := lockAccount(ctx, tx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	member, err := getMember(ctx, tx, accountID, userID)
+	if err != nil && !errors.Is(err, models.ErrMemberNotFound) {
+		return nil, err
+	}
+	if member == nil || !member.CanOperate() {
+		return nil, models.ErrNotAccountOwner
+	}
+	if acc.Status != from {
+		return nil, fmt.Errorf("%w: account is %s, not %s", models.ErrInvalidStatusTransition, acc.Status, from)
+	}
+	if to == models.AccountStatusClosed {
+		if err := checkAccountClosable(ctx, tx, acc); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE accounts SET status = $1 WHERE id = $2`, to, accountID,
+	); err != nil {
+		return nil, fmt.Errorf("update account status: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit tx: %w", err)
+	}
+	acc.Status = to
+	return acc, nil
+}
+
+// checkAccountClosable проверяет условия закрытия заблокированного счета.
+// Незавершенные авторизации по картам проверяются раньше остатка: заблокированная
+// ими сумма входит в остаток, но снять ее владелец не может.
+func checkAccountClosable(ctx context.Context, tx *sql.Tx, acc *models.Account) error {
+	if !acc.Held.IsZero() {
+		return models.ErrAccountHasHolds
+	}
+	if !acc.Balance.IsZero() {
+		return models.ErrAccountNotEmpty
+	}
+	var hasCredits, hasCards bool
+	if err := tx.QueryRowContext(ctx,
+		`SELECT
+			EXISTS (SELECT 1 FROM credits c JOIN payment_schedules ps ON ps.credit_id = c.id
+			        WHERE c.account_id = $1 AND ps.is_paid = false),
+			EXISTS (SELECT 1 FROM cards WHERE account_id = $1 AND status IN ('active', 'blocked', 'pin_locked'))`,
+		acc.ID,
+	).Scan(&hasCredits, &hasCards); err != nil {
+		return fmt.Errorf("check account dependencies: %w", err)
+	}
+	if hasCredits {
+		return models.ErrAccountHasCredits
+	}
+	if hasCards {
+		return models.ErrAccountHasCards
+	}
+	return nil
+}
+
+// lockAccount читает счет с блокировкой строки до конца транзакции.
+func lockAccount(ctx context.Context, tx *sql.Tx, id int) (*models.Account, error) {
+	row := tx.QueryRowContext(ctx, `SELECT `+accountColumns+` FROM accounts WHERE id = $1 FOR UPDATE`, id)
+	acc, err := scanAccount(row)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %d", models.ErrAccountNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("lock account %d: %w", id, err)
+	}
+	return acc, nil
+}
+
+// scanAccount читает строку счета; валюта баланса берется из столбца currency.
+func scanAccount(row rowScanner) (*models.Account, error) {
 	acc := &models.Account{}
 	if err := row.Scan(
 		&acc.ID,
 		&acc.UserID,
 		&acc.Balance,
 		&acc.Currency,
+		&acc.Status,
 		&acc.CreatedAt,
+		&acc.Number,
+		&acc.Type,
+		&acc.Held,
 	); err != nil {
 		return nil, err
 	}
+	acc.Balance.Currency = acc.Currency
+	acc.Held.Currency = acc.Currency
 	return acc, nil
 }
 
-func (r *accountRepository) UpdateBalance(accountID int, delta float64) error {
-	_, err := r.db.Exec(
-		`UPDATE accounts SET balance = balance + $1 WHERE id = $2`,
-		delta, accountID,
+// memberColumns — столбцы, которые читает scanMember.
+const memberColumns = `m.account_id, m.user_id, m.role, m.spend_limit, COALESCE(m.invited_by, 0), m.created_at`
+
+func (r *accountRepository) GetMember(accountID, userID int) (*models.AccountMember, error) {
+	return getMember(context.Background(), r.db, accountID, userID)
+}
+
+func (r *accountRepository) ListMembers(accountID int) ([]models.AccountMember, error) {
+	rows, err := r.db.Query(
+		`SELECT `+memberColumns+`, u.email
+		 FROM account_members m JOIN users u ON u.id = m.user_id
+		 WHERE m.account_id = $1 ORDER BY m.created_at, m.user_id`, accountID,
 	)
-	return err
+	if err != nil {
+		return nil, fmt.Errorf("error fetching account members: %w", err)
+	}
+	defer rows.Close()
+
+	var members []models.AccountMember
+	for rows.Next() {
+		var m models.AccountMember
+		if err := rows.Scan(&m.AccountID, &m.UserID, &m.Role, &m.SpendLimit, &m.InvitedBy, &m.CreatedAt, &m.Email); err != nil {
+			return nil, fmt.Errorf("error scanning account member: %w", err)
+		}
+		members = append(members, m)
+	}
+	return members, rows.Err()
 }
 
-func (r *accountRepository) TransferTx(ctx context.Context, fromID, toID int, amount float64) error {
+func (r *accountRepository) AddMember(m *models.AccountMember) error {
+	err := r.db.QueryRow(
+		`INSERT INTO account_members (account_id, user_id, role, spend_limit, invited_by, created_at)
+		 VALUES ($1, $2, $3, $4, $5, NOW())
+		 ON CONFLICT (account_id, user_id) DO NOTHING
+		 RETURNING created_at`,
+		m.AccountID, m.UserID, m.Role, m.SpendLimit, sql.NullInt64{Int64: int64(m.InvitedBy), Valid: m.InvitedBy != 0},
+	).Scan(&m.CreatedAt)
+	if err == sql.ErrNoRows {
+		return models.ErrMemberAlreadyExists
+	}
+	if err != nil {
+		return fmt.Errorf("insert account member: %w", err)
+	}
+	return nil
+}
+
+// RemoveMember блокирует счет, чтобы параллельные удаления не оставили его без владельцев.
+func (r *accountRepository) RemoveMember(ctx context.Context, accountID, userID int) error {
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("begin tx: %w", err)
 	}
 	defer tx.Rollback()
 
+	if _, err := lockAccount(ctx, tx, accountID); err != nil {
+		return err
+	}
+	member, err := getMember(ctx, tx, accountID, userID)
+	if err != nil {
+		return err
+	}
+	if member.Role == models.MemberRoleOwner {
+		var owners int
+		if err := tx.QueryRowContext(ctx,
+			`SELECT COUNT(*) FROM account_members WHERE account_id = $1 AND role = $2`,
+			accountID, models.MemberRoleOwner,
+		).Scan(&owners); err != nil {
+			return fmt.Errorf("count account owners: %w", err)
+		}
+		if owners <= 1 {
+			return models.ErrLastAccountOwner
+		}
+	}
 	if _, err := tx.ExecContext(ctx,
-		`UPDATE accounts SET balance = balance - $1 WHERE id = $2`,
-		amount, fromID,
+		`DELETE FROM account_members WHERE account_id = $1 AND user_id = $2`, accountID, userID,
 	); err != nil {
-		return fmt.Errorf("debit from %d: %w", fromID, err)
+		return fmt.Errorf("delete account member: %w", err)
 	}
+	return tx.Commit()
+}
 
-	if _, err := tx.ExecContext(ctx,
-		`UPDATE accounts SET balance = balance + $1 WHERE id = $2`,
-		amount, toID,
-	); err != nil {
-		return fmt.Errorf("credit to %d: %w", toID, err)
+// queryRower — общий интерфейс *sql.DB и *sql.Tx для одиночных запросов.
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// getMember читает членство пользователя в счете через db или внутри транзакции.
+func getMember(ctx context.Context, q queryRower, accountID, userID int) (*models.AccountMember, error) {
+	m := &models.AccountMember{}
+	err := q.QueryRowContext(ctx,
+		`SELECT `+memberColumns+` FROM account_members m WHERE m.account_id = $1 AND m.user_id = $2`,
+		accountID, userID,
+	).Scan(&m.AccountID, &m.UserID, &m.Role, &m.SpendLimit, &m.InvitedBy, &m.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, models.ErrMemberNotFound
 	}
+	if err != nil {
+		return nil, fmt.Errorf("error fetching account member: %w", err)
+	}
+	return m, nil
+}
 
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("commit tx: %w", err)
+// checkMemberSpend проверяет право участника списать amount со счета. Участнику с ролью
+// spend_with_limit в лимит засчитываются его списания с начала календарного месяца;
+// вызывается под блокировкой счета, поэтому параллельные списания не превысят лимит.
+func checkMemberSpend(ctx context.Context, tx *sql.Tx, member *models.AccountMember, amount models.Money) error {
+	if !member.CanSpend(amount) {
+		if member.Role == models.MemberRoleSpendWithLimit {
+			return models.ErrSpendLimitExceeded
+		}
+		return models.ErrNotAccountOwner
+	}
+	if member.Role != models.MemberRoleSpendWithLimit {
+		return nil
+	}
+	var spent models.Money
+	if err := tx.QueryRowContext(ctx,
+		`SELECT COALESCE(SUM(amount), 0) FROM member_spendings
+		 WHERE account_id = $1 AND user_id = $2 AND created_at >= date_trunc('month', NOW())`,
+		member.AccountID, member.UserID,
+	).Scan(&spent); err != nil {
+		return fmt.Errorf("sum member spendings: %w", err)
+	}
+	if spent.Minor+amount.Minor > member.SpendLimit.Minor {
+		return models.ErrSpendLimitExceeded
+	}
+	return nil
+}
+
+// recordMemberSpend засчитывает списание записи entryID в месячный лимит участника.
+func recordMemberSpend(ctx context.Context, tx *sql.Tx, member *models.AccountMember, amount models.Money, entryID int) error {
+	if member.Role != models.MemberRoleSpendWithLimit {
+		return nil
+	}
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO member_spendings (account_id, user_id, amount, currency, entry_id, created_at)
+		 VALUES ($1, $2, $3, $4, $5, NOW())`,
+		member.AccountID, member.UserID, amount, amount.Currency, entryID,
+	); err != nil {
+		return fmt.Errorf("insert member spending: %w", err)
 	}
 	return nil
 }
@@ -0,0 +1,514 @@
+package repositories_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"bank-api/models"
+	"bank-api/repositories"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+var accountColumns = []string{"id", "user_id", "balance", "currency", "status", "created_at", "number", "type", "held"}
+
+var memberColumns = []string{"account_id", "user_id", "role", "spend_limit", "invited_by", "created_at"}
+
+const memberQuery = `FROM account_members m WHERE m.account_id = $1 AND m.user_id = $2`
+
+// expectMember ожидает чтение членства; пустая роль — пользователь не участник счета.
+func expectMember(mock sqlmock.Sqlmock, accountID, userID int, role string, spendLimit driver.Value) {
+	rows := sqlmock.NewRows(memberColumns)
+	if role != "" {
+		rows.AddRow(accountID, userID, role, spendLimit, 0, time.Now())
+	}
+	mock.ExpectQuery(regexp.QuoteMeta(memberQuery)).WithArgs(accountID, userID).WillReturnRows(rows)
+}
+
+const lockAccountQuery = `SELECT id, user_id, balance, currency, status, created_at, COALESCE(number, ''), type, held FROM accounts WHERE id = $1 FOR UPDATE`
+
+func TestTransferTx_LocksInIDOrderAndMovesFunds(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %v", err)
+	}
+	defer db.Close()
+	repo := repositories.NewAccountRepository(db)
+	now := time.Now()
+
+	// Перевод со счета 7 на счет 3: блокировка должна идти в порядке 3, 7.
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(lockAccountQuery)).WithArgs(3).
+		WillReturnRows(sqlmock.NewRows(accountColumns).AddRow(3, 2, "10.00", "RUB", "active", now, "", "current", "0.00"))
+	mock.ExpectQuery(regexp.QuoteMeta(lockAccountQuery)).WithArgs(7).
+		WillReturnRows(sqlmock.NewRows(accountColumns).AddRow(7, 1, "100.00", "RUB", "active", now, "", "current", "0.00"))
+	expectMember(mock, 7, 1, models.MemberRoleOwner, nil)
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO journal_entries`)).
+		WithArgs("transfer", sqlmock.AnyArg(), sqlmock.AnyArg(), nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(11, now))
+	expectPosting(mock, 11, 7, "-25.50", "74.50")
+	expectPosting(mock, 11, 3, "25.50", "35.50")
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO transfers`)).
+		WithArgs(1, 7, 3, "25.50", "RUB", "25.50", "RUB", nil, 11, now).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5))
+	mock.ExpectCommit()
+
+	transfer := &models.Transfer{UserID: 1, FromAccountID: 7, ToAccountID: 3, Amount: models.NewMoney(2550, "")}
+	if err := repo.TransferTx(context.Background(), transfer); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if transfer.ID != 5 || transfer.EntryID != 11 {
+		t.Errorf("expected transfer 5 with entry 11, got %d and %d", transfer.ID, transfer.EntryID)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+// expectPosting ожидает вставку проводки по клиентскому счету, обновление
+// его баланса и строку transactions.
+func expectPosting(mock sqlmock.Sqlmock, entryID, accountID int, amount, balanceAfter string) {
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO postings`)).
+		WithArgs(entryID, sqlmock.AnyArg(), sqlmock.AnyArg(), amount, "RUB").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery(regexp.QuoteMeta(`UPDATE accounts SET balance = balance + $1 WHERE id = $2 RETURNING balance`)).
+		WithArgs(amount, accountID).
+		WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow(balanceAfter))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO transactions`)).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+}
+
+func TestTransferTx_Rejections(t *testing.T) {
+	now := time.Now()
+	cases := []struct {
+		name    string
+		userID  int
+		role    string
+		limit   driver.Value
+		from    []driver.Value
+		to      []driver.Value
+		wantErr error
+	}{
+		{"not owner", 99, "", nil, []driver.Value{1, 1, "100.00", "RUB", "active", now, "", "current", "0.00"}, []driver.Value{2, 2, "0.00", "RUB", "active", now, "", "current", "0.00"}, models.ErrNotAccountOwner},
+		{"view only", 3, models.MemberRoleViewOnly, nil, []driver.Value{1, 1, "100.00", "RUB", "active", now, "", "current", "0.00"}, []driver.Value{2, 2, "0.00", "RUB", "active", now, "", "current", "0.00"}, models.ErrNotAccountOwner},
+		{"over spend limit", 3, models.MemberRoleSpendWithLimit, "20.00", []driver.Value{1, 1, "100.00", "RUB", "active", now, "", "current", "0.00"}, []driver.Value{2, 2, "0.00", "RUB", "active", now, "", "current", "0.00"}, models.ErrSpendLimitExceeded},
+		{"insufficient", 1, models.MemberRoleOwner, nil, []driver.Value{1, 1, "10.00", "RUB", "active", now, "", "current", "0.00"}, []driver.Value{2, 2, "0.00", "RUB", "active", now, "", "current", "0.00"}, models.ErrInsufficientFunds},
+		{"held by card authorizations", 1, models.MemberRoleOwner, nil, []driver.Value{1, 1, "100.00", "RUB", "active", now, "", "current", "60.00"}, []driver.Value{2, 2, "0.00", "RUB", "active", now, "", "current", "0.00"}, models.ErrInsufficientFunds},
+		{"currency", 1, models.MemberRoleOwner, nil, []driver.Value{1, 1, "100.00", "RUB", "active", now, "", "current", "0.00"}, []driver.Value{2, 2, "0.00", "USD", "active", now, "", "current", "0.00"}, models.ErrCurrencyMismatch},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("failed to open sqlmock database: %v", err)
+			}
+			defer db.Close()
+			repo := repositories.NewAccountRepository(db)
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(lockAccountQuery)).WithArgs(1).
+				WillReturnRows(sqlmock.NewRows(accountColumns).AddRow(tc.from...))
+			mock.ExpectQuery(regexp.QuoteMeta(lockAccountQuery)).WithArgs(2).
+				WillReturnRows(sqlmock.NewRows(accountColumns).AddRow(tc.to...))
+			expectMember(mock, 1, tc.userID, tc.role, tc.limit)
+			mock.ExpectRollback()
+
+			transfer := &models.Transfer{UserID: tc.userID, FromAccountID: 1, ToAccountID: 2, Amount: models.NewMoney(5000, "")}
+			err = repo.TransferTx(context.Background(), transfer)
+			if !errors.Is(err, tc.wantErr) {
+				t.Errorf("expected %v, got %v", tc.wantErr, err)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+const memberSpendingsQuery = `SELECT COALESCE(SUM(amount), 0) FROM member_spendings`
+
+func TestTransferTx_SpendLimitCoversMonth(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %v", err)
+	}
+	defer db.Close()
+	repo := repositories.NewAccountRepository(db)
+	now := time.Now()
+
+	// Лимит 60.00, с начала месяца уже списано 15.00: перевод 50.00 отклоняется.
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(lockAccountQuery)).WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(accountColumns).AddRow(1, 1, "100.00", "RUB", "active", now, "", "current", "0.00"))
+	mock.ExpectQuery(regexp.QuoteMeta(lockAccountQuery)).WithArgs(2).
+		WillReturnRows(sqlmock.NewRows(accountColumns).AddRow(2, 2, "0.00", "RUB", "active", now, "", "current", "0.00"))
+	expectMember(mock, 1, 3, models.MemberRoleSpendWithLimit, "60.00")
+	mock.ExpectQuery(regexp.QuoteMeta(memberSpendingsQuery)).WithArgs(1, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow("15.00"))
+	mock.ExpectRollback()
+
+	transfer := &models.Transfer{UserID: 3, FromAccountID: 1, ToAccountID: 2, Amount: models.NewMoney(5000, "")}
+	if err := repo.TransferTx(context.Background(), transfer); !errors.Is(err, models.ErrSpendLimitExceeded) {
+		t.Errorf("expected ErrSpendLimitExceeded, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestUpdateBalance_RecordsMemberSpending(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %v", err)
+	}
+	defer db.Close()
+	repo := repositories.NewAccountRepository(db)
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(lockAccountQuery)).WithArgs(3).
+		WillReturnRows(sqlmock.NewRows(accountColumns).AddRow(3, 1, "100.00", "RUB", "active", now, "", "current", "0.00"))
+	expectMember(mock, 3, 4, models.MemberRoleSpendWithLimit, "60.00")
+	mock.ExpectQuery(regexp.QuoteMeta(memberSpendingsQuery)).WithArgs(3, 4).
+		WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow("20.00"))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO journal_entries`)).
+		WithArgs("withdrawal", sqlmock.AnyArg(), sqlmock.AnyArg(), nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(21, now))
+	expectPosting(mock, 21, 3, "-40.00", "60.00")
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO postings`)).
+		WithArgs(21, sqlmock.AnyArg(), "cash", "40.00", "RUB").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO member_spendings`)).
+		WithArgs(3, 4, "40.00", "RUB", 21).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	if _, err := repo.UpdateBalance(4, 3, models.NewMoney(-4000, ""), nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestTransferTx_ConvertsThroughFXPosition(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %v", err)
+	}
+	defer db.Close()
+	repo := repositories.NewAccountRepository(db)
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(lockAccountQuery)).WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(accountColumns).AddRow(1, 1, "100.00", "USD", "active", now, "", "current", "0.00"))
+	mock.ExpectQuery(regexp.QuoteMeta(lockAccountQuery)).WithArgs(2).
+		WillReturnRows(sqlmock.NewRows(accountColumns).AddRow(2, 2, "0.00", "RUB", "active", now, "", "current", "0.00"))
+	expectMember(mock, 1, 1, models.MemberRoleOwner, nil)
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO journal_entries`)).
+		WithArgs("transfer", sqlmock.AnyArg(), "91.125601", nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(12, now))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO postings`)).
+		WithArgs(12, sqlmock.AnyArg(), sqlmock.AnyArg(), "-10.00", "USD").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery(regexp.QuoteMeta(`UPDATE accounts SET balance`)).
+		WithArgs("-10.00", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow("90.00"))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO transactions`)).
+		WithArgs(1, 12, "-10.00", "90.00", "transfer", "91.125601", now).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO postings`)).
+		WithArgs(12, sqlmock.AnyArg(), "fx_position", "10.00", "USD").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO postings`)).
+		WithArgs(12, sqlmock.AnyArg(), "fx_position", "-911.25", "RUB").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO postings`)).
+		WithArgs(12, sqlmock.AnyArg(), sqlmock.AnyArg(), "911.25", "RUB").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+	mock.ExpectQuery(regexp.QuoteMeta(`UPDATE accounts SET balance`)).
+		WithArgs("911.25", 2).
+		WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow("911.25"))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO transactions`)).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO transfers`)).
+		WithArgs(1, 1, 2, "10.00", "USD", "911.25", "RUB", "91.125601", 12, now).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(6))
+	mock.ExpectCommit()
+
+	transfer := &models.Transfer{
+		UserID: 1, FromAccountID: 1, ToAccountID: 2,
+		Amount:         models.NewMoney(1000, ""),
+		CreditedAmount: models.NewMoney(91125, "RUB"),
+		FXRate:         "91.125601",
+	}
+	if err := repo.TransferTx(context.Background(), transfer); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if transfer.EntryID != 12 || transfer.Currency != "USD" || transfer.CreditedCurrency != "RUB" {
+		t.Errorf("unexpected transfer result: %+v", transfer)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestChangeStatus_CloseChecks(t *testing.T) {
+	now := time.Now()
+	cases := []struct {
+		name       string
+		balance    string
+		held       string
+		hasCredits bool
+		hasCards   bool
+		wantErr    error
+	}{
+		{"non-zero balance", "0.01", "0.00", false, false, models.ErrAccountNotEmpty},
+		{"pending authorization", "10.00", "10.00", false, false, models.ErrAccountHasHolds},
+		{"unpaid credit", "0.00", "0.00", true, false, models.ErrAccountHasCredits},
+		{"card issued", "0.00", "0.00", false, true, models.ErrAccountHasCards},
+		{"closable", "0.00", "0.00", false, false, nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("failed to open sqlmock database: %v", err)
+			}
+			defer db.Close()
+			repo := repositories.NewAccountRepository(db)
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(lockAccountQuery)).WithArgs(5).
+				WillReturnRows(sqlmock.NewRows(accountColumns).AddRow(5, 1, tc.balance, "RUB", "active", now, "", "current", tc.held))
+			expectMember(mock, 5, 1, models.MemberRoleOwner, nil)
+			if tc.wantErr != models.ErrAccountNotEmpty && tc.wantErr != models.ErrAccountHasHolds {
+				mock.ExpectQuery(`SELECT\s+EXISTS`).WithArgs(5).
+					WillReturnRows(sqlmock.NewRows([]string{"credits", "cards"}).AddRow(tc.hasCredits, tc.hasCards))
+			}
+			if tc.wantErr == nil {
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE accounts SET status = $1 WHERE id = $2`)).
+					WithArgs("closed", 5).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			} else {
+				mock.ExpectRollback()
+			}
+
+			acc, err := repo.ChangeStatus(context.Background(), 1, 5, models.AccountStatusActive, models.AccountStatusClosed)
+			if !errors.Is(err, tc.wantErr) {
+				t.Errorf("expected %v, got %v", tc.wantErr, err)
+			}
+			if tc.wantErr == nil && acc.Status != models.AccountStatusClosed {
+				t.Errorf("expected closed account, got %s", acc.Status)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestChangeStatus_RejectsWrongCurrentStatus(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %v", err)
+	}
+	defer db.Close()
+	repo := repositories.NewAccountRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(lockAccountQuery)).WithArgs(5).
+		WillReturnRows(sqlmock.NewRows(accountColumns).AddRow(5, 1, "0.00", "RUB", "closed", time.Now(), "", "current", "0.00"))
+	expectMember(mock, 5, 1, models.MemberRoleOwner, nil)
+	mock.ExpectRollback()
+
+	// Закрытый счет нельзя «разморозить» — только открыть заново.
+	_, err = repo.ChangeStatus(context.Background(), 1, 5, models.AccountStatusFrozen, models.AccountStatusActive)
+	if !errors.Is(err, models.ErrInvalidStatusTransition) {
+		t.Errorf("expected ErrInvalidStatusTransition, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestUpdateBalance_WithdrawalRecordsTransaction(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %v", err)
+	}
+	defer db.Close()
+	repo := repositories.NewAccountRepository(db)
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(lockAccountQuery)).WithArgs(3).
+		WillReturnRows(sqlmock.NewRows(accountColumns).AddRow(3, 1, "100.00", "RUB", "active", now, "", "current", "0.00"))
+	expectMember(mock, 3, 1, models.MemberRoleOwner, nil)
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO journal_entries`)).
+		WithArgs("withdrawal", sqlmock.AnyArg(), sqlmock.AnyArg(), nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(21, now))
+	expectPosting(mock, 21, 3, "-40.00", "60.00")
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO postings`)).
+		WithArgs(21, sqlmock.AnyArg(), "cash", "40.00", "RUB").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	mock.ExpectCommit()
+
+	acc, err := repo.UpdateBalance(1, 3, models.NewMoney(-4000, ""), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if acc.Balance.String() != "60.00" {
+		t.Errorf("expected new balance 60.00, got %s", acc.Balance)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestUpdateBalance_ChargesFeeInSameTransaction(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %v", err)
+	}
+	defer db.Close()
+	repo := repositories.NewAccountRepository(db)
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(lockAccountQuery)).WithArgs(3).
+		WillReturnRows(sqlmock.NewRows(accountColumns).AddRow(3, 1, "100.00", "RUB", "active", now, "", "current", "0.00"))
+	expectMember(mock, 3, 1, models.MemberRoleOwner, nil)
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO journal_entries`)).
+		WithArgs("withdrawal", sqlmock.AnyArg(), sqlmock.AnyArg(), nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(21, now))
+	expectPosting(mock, 21, 3, "-40.00", "60.00")
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO postings`)).
+		WithArgs(21, sqlmock.AnyArg(), "cash", "40.00", "RUB").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	// Комиссия проводится отдельной записью в доход банка в той же транзакции.
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO journal_entries`)).
+		WithArgs("fee", sqlmock.AnyArg(), sqlmock.AnyArg(), nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(22, now))
+	expectPosting(mock, 22, 3, "-1.20", "58.80")
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO postings`)).
+		WithArgs(22, sqlmock.AnyArg(), "fee_income", "1.20", "RUB").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO fee_charges`)).
+		WithArgs(3, "withdrawal", "40.00", "RUB", "1.20", 5, 22).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(9, now))
+	mock.ExpectCommit()
+
+	fee := &models.FeeCharge{
+		AccountID: 3,
+		Operation: models.FeeOperationWithdrawal,
+		Amount:    models.NewMoney(4000, "RUB"),
+		Fee:       models.NewMoney(120, "RUB"),
+		RuleID:    5,
+	}
+	acc, err := repo.UpdateBalance(1, 3, models.NewMoney(-4000, ""), fee)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if acc.Balance.String() != "58.80" || fee.EntryID != 22 {
+		t.Errorf("unexpected result: balance %s, fee entry %d", acc.Balance, fee.EntryID)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestUpdateBalance_RejectsOverdraft(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %v", err)
+	}
+	defer db.Close()
+	repo := repositories.NewAccountRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(lockAccountQuery)).WithArgs(3).
+		WillReturnRows(sqlmock.NewRows(accountColumns).AddRow(3, 1, "10.00", "RUB", "active", time.Now(), "", "current", "0.00"))
+	expectMember(mock, 3, 1, models.MemberRoleOwner, nil)
+	mock.ExpectRollback()
+
+	if _, err := repo.UpdateBalance(1, 3, models.NewMoney(-4000, ""), nil); !errors.Is(err, models.ErrInsufficientFunds) {
+		t.Errorf("expected ErrInsufficientFunds, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestAccountCreateAndGetByNumber(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %v", err)
+	}
+	defer db.Close()
+	repo := repositories.NewAccountRepository(db)
+	now := time.Now()
+	number := "40817810700000000001"
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO accounts (user_id, number, type, balance, currency, status, created_at)`)).
+		WithArgs(1, number, "current", "0.00", "RUB", "active").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(9, now))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO account_members`)).
+		WithArgs(9, 1, "owner", now).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM accounts WHERE number = $1`)).WithArgs(number).
+		WillReturnRows(sqlmock.NewRows(accountColumns).AddRow(9, 1, "0.00", "RUB", "active", now, number, "current", "0.00"))
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM accounts WHERE number = $1`)).WithArgs("40817810800000000001").
+		WillReturnRows(sqlmock.NewRows(accountColumns))
+
+	acc := &models.Account{UserID: 1, Number: number, Type: models.AccountTypeCurrent,
+		Balance: models.NewMoney(0, "RUB"), Currency: "RUB", Status: models.AccountStatusActive}
+	if err := repo.Create(acc); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if acc.ID != 9 {
+		t.Errorf("expected account ID 9, got %d", acc.ID)
+	}
+	found, err := repo.GetByNumber(number)
+	if err != nil || found.ID != 9 || found.Number != number {
+		t.Errorf("expected account 9 by number, got %+v, %v", found, err)
+	}
+	if _, err := repo.GetByNumber("40817810800000000001"); !errors.Is(err, models.ErrAccountNotFound) {
+		t.Errorf("expected ErrAccountNotFound, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestRemoveMember_KeepsLastOwner(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %v", err)
+	}
+	defer db.Close()
+	repo := repositories.NewAccountRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(lockAccountQuery)).WithArgs(5).
+		WillReturnRows(sqlmock.NewRows(accountColumns).AddRow(5, 1, "0.00", "RUB", "active", time.Now(), "", "current", "0.00"))
+	expectMember(mock, 5, 1, models.MemberRoleOwner, nil)
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*) FROM account_members WHERE account_id = $1 AND role = $2`)).
+		WithArgs(5, "owner").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectRollback()
+
+	if err := repo.RemoveMember(context.Background(), 5, 1); !errors.Is(err, models.ErrLastAccountOwner) {
+		t.Errorf("expected ErrLastAccountOwner, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,195 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"bank-api/models"
+)
+
+// BatchTransferRepository хранит пакетные переводы и результаты их строк.
+type BatchTransferRepository interface {
+	// Create сохраняет пакет вместе со строками в одной транзакции.
+	Create(b *models.BatchTransfer) error
+	// GetByID возвращает пакет без строк; ErrBatchTransferNotFound, если его нет.
+	GetByID(id int) (*models.BatchTransfer, error)
+	// ListLines возвращает строки пакета в порядке файла.
+	ListLines(batchID int) ([]models.BatchTransferLine, error)
+	// ClaimOpen выбирает до limit незавершенных пакетов, переводит их в processing
+	// и арендует на lease, чтобы параллельные экземпляры шедулера их пропустили.
+	ClaimOpen(now time.Time, lease time.Duration, limit int) ([]*models.BatchTransfer, error)
+	// StartLine помечает строку начатой; false, если строка уже не ожидает обработки.
+	StartLine(lineID int) (bool, error)
+	// FinishLine сохраняет результат строки.
+	FinishLine(line *models.BatchTransferLine) error
+	// Complete завершает пакет и снимает аренду.
+	Complete(batchID int) error
+}
+
+type batchTransferRepository struct {
+	db *sql.DB
+}
+
+// NewBatchTransferRepository возвращает реализацию BatchTransferRepository.
+func NewBatchTransferRepository(db *sql.DB) BatchTransferRepository {
+	return &batchTransferRepository{db: db}
+}
+
+// batchTransferColumns — столбцы, которые читает scanBatchTransfer.
+const batchTransferColumns = `id, user_id, from_account_id, total, currency, line_count, status, created_at, completed_at`
+
+func (r *batchTransferRepository) Create(b *models.BatchTransfer) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := tx.QueryRow(
+		`INSERT INTO batch_transfers (user_id, from_account_id, total, currency, line_count, status, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, NOW()) RETURNING id, created_at`,
+		b.UserID, b.FromAccountID, b.Total, b.Currency, b.LineCount, b.Status,
+	).Scan(&b.ID, &b.CreatedAt); err != nil {
+		return fmt.Errorf("insert batch transfer: %w", err)
+	}
+	for i := range b.Lines {
+		line := &b.Lines[i]
+		line.BatchID = b.ID
+		if err := tx.QueryRow(
+			`INSERT INTO batch_transfer_lines (batch_id, line_no, destination, to_account_id, amount, status)
+			 VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`,
+			b.ID, line.LineNo, line.Destination, line.ToAccountID, line.Amount, line.Status,
+		).Scan(&line.ID); err != nil {
+			return fmt.Errorf("insert batch transfer line %d: %w", line.LineNo, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
+
+func (r *batchTransferRepository) GetByID(id int) (*models.BatchTransfer, error) {
+	row := r.db.QueryRow(`SELECT `+batchTransferColumns+` FROM batch_transfers WHERE id = $1`, id)
+	b, err := scanBatchTransfer(row)
+	if err == sql.ErrNoRows {
+		return nil, models.ErrBatchTransferNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error fetching batch transfer: %w", err)
+	}
+	return b, nil
+}
+
+func (r *batchTransferRepository) ListLines(batchID int) ([]models.BatchTransferLine, error) {
+	rows, err := r.db.Query(
+		`SELECT l.id, l.batch_id, l.line_no, l.destination, l.to_account_id, l.amount, b.currency,
+			l.status, l.error, COALESCE(l.transfer_id, 0), l.processed_at
+		 FROM batch_transfer_lines l JOIN batch_transfers b ON b.id = l.batch_id
+		 WHERE l.batch_id = $1 ORDER BY l.line_no`,
+		batchID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching batch transfer lines: %w", err)
+	}
+	defer rows.Close()
+
+	lines := []models.BatchTransferLine{}
+	for rows.Next() {
+		var line models.BatchTransferLine
+		var currency string
+		var processedAt sql.NullTime
+		if err := rows.Scan(&line.ID, &line.BatchID, &line.LineNo, &line.Destination, &line.ToAccountID,
+			&line.Amount, &currency, &line.Status, &line.Error, &line.TransferID, &processedAt); err != nil {
+			return nil, fmt.Errorf("error scanning batch transfer line: %w", err)
+		}
+		line.Amount.Currency = currency
+		if processedAt.Valid {
+			line.ProcessedAt = &processedAt.Time
+		}
+		lines = append(lines, line)
+	}
+	return lines, rows.Err()
+}
+
+func (r *batchTransferRepository) ClaimOpen(now time.Time, lease time.Duration, limit int) ([]*models.BatchTransfer, error) {
+	rows, err := r.db.Query(
+		`UPDATE batch_transfers SET status = 'processing', claimed_until = $2
+		 WHERE id IN (
+			SELECT id FROM batch_transfers
+			WHERE status <> 'completed' AND (claimed_until IS NULL OR claimed_until < $1)
+			ORDER BY id
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED)
+		 RETURNING `+batchTransferColumns,
+		now, now.Add(lease), limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error claiming batch transfers: %w", err)
+	}
+	defer rows.Close()
+
+	batches := []*models.BatchTransfer{}
+	for rows.Next() {
+		b, err := scanBatchTransfer(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning batch transfer: %w", err)
+		}
+		batches = append(batches, b)
+	}
+	return batches, rows.Err()
+}
+
+func (r *batchTransferRepository) StartLine(lineID int) (bool, error) {
+	res, err := r.db.Exec(
+		`UPDATE batch_transfer_lines SET status = 'processing' WHERE id = $1 AND status = 'pending'`, lineID,
+	)
+	if err != nil {
+		return false, fmt.Errorf("error starting batch transfer line: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+func (r *batchTransferRepository) FinishLine(line *models.BatchTransferLine) error {
+	transferID := sql.NullInt64{Int64: int64(line.TransferID), Valid: line.TransferID != 0}
+	var processedAt time.Time
+	if err := r.db.QueryRow(
+		`UPDATE batch_transfer_lines SET status = $1, error = $2, transfer_id = $3, processed_at = NOW()
+		 WHERE id = $4 RETURNING processed_at`,
+		line.Status, line.Error, transferID, line.ID,
+	).Scan(&processedAt); err != nil {
+		return fmt.Errorf("error updating batch transfer line: %w", err)
+	}
+	line.ProcessedAt = &processedAt
+	return nil
+}
+
+func (r *batchTransferRepository) Complete(batchID int) error {
+	if _, err := r.db.Exec(
+		`UPDATE batch_transfers SET status = 'completed', completed_at = NOW(), claimed_until = NULL
+		 WHERE id = $1`, batchID,
+	); err != nil {
+		return fmt.Errorf("error completing batch transfer: %w", err)
+	}
+	return nil
+}
+
+// scanBatchTransfer читает строку batchTransferColumns.
+func scanBatchTransfer(row rowScanner) (*models.BatchTransfer, error) {
+	b := &models.BatchTransfer{}
+	var completedAt sql.NullTime
+	if err := row.Scan(&b.ID, &b.UserID, &b.FromAccountID, &b.Total, &b.Currency, &b.LineCount,
+		&b.Status, &b.CreatedAt, &completedAt); err != nil {
+		return nil, err
+	}
+	b.Total.Currency = b.Currency
+	if completedAt.Valid {
+		b.CompletedAt = &completedAt.Time
+	}
+	return b, nil
+}
@@ -0,0 +1,271 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"bank-api/models"
+)
+
+// CardAuthorizationRepository хранит авторизации по картам и блокировки сумм на счетах.
+type CardAuthorizationRepository interface {
+	// Hold под блокировкой карты и счета проверяет статус счета, ограничения виртуальной
+	// карты (Card.CheckUsage) и доступный остаток, блокирует сумму вместе с комиссией
+	// auth.HeldFee и сохраняет одобренную авторизацию auth; карта merchant_locked привязывается
+	// к торговой точке первой авторизации. ErrCardInactive, ErrAccountInactive,
+	// ErrCardAlreadyUsed, ErrCardMerchantLocked, ErrSpendCapExceeded или
+	// ErrInsufficientFunds, если блокировка невозможна.
+	Hold(ctx context.Context, auth *models.CardAuthorization) error
+	// RecordDecline сохраняет отклоненную авторизацию.
+	RecordDecline(auth *models.CardAuthorization) error
+	GetByID(id int) (*models.CardAuthorization, error)
+	// Capture списывает amount со счета в расчеты с торговыми точками и снимает
+	// блокировку авторизации (сумму и комиссию) целиком; amount не больше заблокированной суммы.
+	// В той же транзакции списывается комиссия fee (если задана).
+	// Одноразовая карта после списания переводится в статус expired.
+	Capture(ctx context.Context, id int, amount models.Money, fee *models.FeeCharge) (*models.CardAuthorization, error)
+	// Release снимает блокировку без списания.
+	Release(ctx context.Context, id int) (*models.CardAuthorization, error)
+}
+
+type cardAuthorizationRepository struct {
+	db *sql.DB
+}
+
+// NewCardAuthorizationRepository возвращает реализацию CardAuthorizationRepository.
+func NewCardAuthorizationRepository(db *sql.DB) CardAuthorizationRepository {
+	return &cardAuthorizationRepository{db: db}
+}
+
+// authorizationColumns — столбцы, которые читает scanAuthorization.
+const authorizationColumns = `id, COALESCE(card_id, 0), COALESCE(account_id, 0), merchant, amount, held_fee, currency, status,
+	approval_code, decline_reason, captured_amount, COALESCE(entry_id, 0), created_at, updated_at`
+
+func (r *cardAuthorizationRepository) Hold(ctx context.Context, auth *models.CardAuthorization) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Карта блокируется раньше счета, как и в settle, чтобы параллельные
+	// авторизации одной карты не обошли ее ограничения.
+	card, err := lockCard(ctx, tx, auth.CardID)
+	if err != nil {
+		return err
+	}
+	if card.Status != models.CardStatusActive {
+		return models.ErrCardInactive
+	}
+	acc, err := lockAccount(ctx, tx, auth.AccountID)
+	if err != nil {
+		return err
+	}
+	if acc.Status != models.AccountStatusActive {
+		return models.ErrAccountInactive
+	}
+	if auth.Amount.Currency != acc.Currency {
+		return fmt.Errorf("%w: %s and %s", models.ErrCurrencyMismatch, auth.Amount.Currency, acc.Currency)
+	}
+	if err := checkCardUsage(ctx, tx, card, auth); err != nil {
+		return err
+	}
+	held := auth.Held()
+	if acc.Available().Minor < held.Minor {
+		return models.ErrInsufficientFunds
+	}
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE accounts SET held = held + $1 WHERE id = $2`, held, acc.ID,
+	); err != nil {
+		return fmt.Errorf("hold funds: %w", err)
+	}
+	if card.Kind == models.CardKindMerchantLocked && card.LockedMerchant == "" {
+		if _, err := tx.ExecContext(ctx,
+			`UPDATE cards SET locked_merchant = $1 WHERE id = $2`, auth.Merchant, card.ID,
+		); err != nil {
+			return fmt.Errorf("lock card merchant: %w", err)
+		}
+	}
+	auth.Status = models.AuthorizationStatusApproved
+	if err := insertAuthorization(ctx, tx, auth); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
+
+// checkCardUsage проверяет ограничения карты по ее одобренным и списанным авторизациям;
+// у обычной карты без лимита их нет, и авторизации не считаются.
+func checkCardUsage(ctx context.Context, tx *sql.Tx, card *models.Card, auth *models.CardAuthorization) error {
+	if card.Kind == models.CardKindStandard && card.SpendCap == nil {
+		return nil
+	}
+	var (
+		uses int
+		used models.Money
+	)
+	if err := tx.QueryRowContext(ctx,
+		`SELECT COUNT(*), COALESCE(SUM(CASE status WHEN 'captured' THEN captured_amount ELSE amount END), 0)
+		 FROM card_authorizations WHERE card_id = $1 AND status IN ('approved', 'captured')`, card.ID,
+	).Scan(&uses, &used); err != nil {
+		return fmt.Errorf("sum card authorizations: %w", err)
+	}
+	return card.CheckUsage(auth.Merchant, auth.Amount, used, uses)
+}
+
+func (r *cardAuthorizationRepository) RecordDecline(auth *models.CardAuthorization) error {
+	auth.Status = models.AuthorizationStatusDeclined
+	return insertAuthorization(context.Background(), r.db, auth)
+}
+
+func (r *cardAuthorizationRepository) GetByID(id int) (*models.CardAuthorization, error) {
+	auth, err := scanAuthorization(r.db.QueryRow(
+		`SELECT `+authorizationColumns+` FROM card_authorizations WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, models.ErrAuthorizationNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error fetching card authorization: %w", err)
+	}
+	return auth, nil
+}
+
+func (r *cardAuthorizationRepository) Capture(ctx context.Context, id int, amount models.Money, fee *models.FeeCharge) (*models.CardAuthorization, error) {
+	return r.settle(ctx, id, func(tx *sql.Tx, auth *models.CardAuthorization, card *models.Card, acc *models.Account) error {
+		if amount.Currency != "" && amount.Currency != auth.Amount.Currency {
+			return fmt.Errorf("%w: %s and %s", models.ErrCurrencyMismatch, amount.Currency, auth.Amount.Currency)
+		}
+		amount = amount.WithCurrency(auth.Amount.Currency)
+		if amount.Minor > auth.Amount.Minor {
+			return models.ErrCaptureExceedsHold
+		}
+		entry := &models.JournalEntry{
+			Type:        models.EntryTypeCardPurchase,
+			Description: fmt.Sprintf("card %d purchase at %s, authorization %d", auth.CardID, auth.Merchant, auth.ID),
+			Postings: []models.Posting{
+				{AccountID: acc.ID, Amount: amount.Neg()},
+				{SystemAccount: models.SystemAccountCardSettlement, Amount: amount},
+			},
+		}
+		locked := map[int]*models.Account{acc.ID: acc}
+		if err := postEntry(ctx, tx, entry, locked); err != nil {
+			return err
+		}
+		if fee != nil {
+			if err := chargeFee(ctx, tx, fee, locked); err != nil {
+				return err
+			}
+		}
+		auth.Status = models.AuthorizationStatusCaptured
+		auth.CapturedAmount = amount
+		auth.EntryID = entry.ID
+		if card != nil && card.Kind == models.CardKindSingleUse && card.Status == models.CardStatusActive {
+			return changeCardStatus(ctx, tx, card, &models.CardStatusChange{
+				ToStatus:  models.CardStatusExpired,
+				Reason:    fmt.Sprintf("single-use card used by authorization %d", auth.ID),
+				ActorID:   card.UserID,
+				ActorRole: models.CardActorSystem,
+			})
+		}
+		return nil
+	})
+}
+
+func (r *cardAuthorizationRepository) Release(ctx context.Context, id int) (*models.CardAuthorization, error) {
+	return r.settle(ctx, id, func(tx *sql.Tx, auth *models.CardAuthorization, card *models.Card, acc *models.Account) error {
+		auth.Status = models.AuthorizationStatusReleased
+		return nil
+	})
+}
+
+// settle блокирует одобренную авторизацию, ее карту и счет, снимает блокировку суммы
+// и вызывает apply для списания; итоговый статус авторизации сохраняется.
+// Повторное завершение авторизации возвращает ErrInvalidStatusTransition.
+func (r *cardAuthorizationRepository) settle(ctx context.Context, id int, apply func(tx *sql.Tx, auth *models.CardAuthorization, card *models.Card, acc *models.Account) error) (*models.CardAuthorization, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	auth, err := scanAuthorization(tx.QueryRowContext(ctx,
+		`SELECT `+authorizationColumns+` FROM card_authorizations WHERE id = $1 FOR UPDATE`, id))
+	if err == sql.ErrNoRows {
+		return nil, models.ErrAuthorizationNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("lock card authorization %d: %w", id, err)
+	}
+	if auth.Status != models.AuthorizationStatusApproved {
+		return nil, fmt.Errorf("%w: authorization is %s", models.ErrInvalidStatusTransition, auth.Status)
+	}
+	// Карта удалена или авторизация без карты — завершение не зависит от нее.
+	var card *models.Card
+	if auth.CardID != 0 {
+		if card, err = lockCard(ctx, tx, auth.CardID); err != nil && !errors.Is(err, models.ErrCardNotFound) {
+			return nil, err
+		}
+	}
+	acc, err := lockAccount(ctx, tx, auth.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	held := auth.Held()
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE accounts SET held = held - $1 WHERE id = $2`, held, acc.ID,
+	); err != nil {
+		return nil, fmt.Errorf("release hold: %w", err)
+	}
+	acc.Held = models.NewMoney(acc.Held.Minor-held.Minor, acc.Currency)
+
+	if err := apply(tx, auth, card, acc); err != nil {
+		return nil, err
+	}
+	entryID := sql.NullInt64{Int64: int64(auth.EntryID), Valid: auth.EntryID != 0}
+	if err := tx.QueryRowContext(ctx,
+		`UPDATE card_authorizations SET status = $1, captured_amount = $2, entry_id = $3, updated_at = NOW()
+		 WHERE id = $4 RETURNING updated_at`,
+		auth.Status, auth.CapturedAmount, entryID, auth.ID,
+	).Scan(&auth.UpdatedAt); err != nil {
+		return nil, fmt.Errorf("update card authorization: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit tx: %w", err)
+	}
+	return auth, nil
+}
+
+// insertAuthorization сохраняет авторизацию и заполняет ID и даты.
+func insertAuthorization(ctx context.Context, q queryRower, auth *models.CardAuthorization) error {
+	cardID := sql.NullInt64{Int64: int64(auth.CardID), Valid: auth.CardID != 0}
+	accountID := sql.NullInt64{Int64: int64(auth.AccountID), Valid: auth.AccountID != 0}
+	if err := q.QueryRowContext(ctx,
+		`INSERT INTO card_authorizations (card_id, account_id, merchant, amount, held_fee, currency, status,
+			approval_code, decline_reason, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NOW(), NOW()) RETURNING id, created_at, updated_at`,
+		cardID, accountID, auth.Merchant, auth.Amount, auth.HeldFee, auth.Amount.Currency, auth.Status,
+		auth.ApprovalCode, auth.DeclineReason,
+	).Scan(&auth.ID, &auth.CreatedAt, &auth.UpdatedAt); err != nil {
+		return fmt.Errorf("insert card authorization: %w", err)
+	}
+	return nil
+}
+
+// scanAuthorization читает строку authorizationColumns.
+func scanAuthorization(row rowScanner) (*models.CardAuthorization, error) {
+	auth := &models.CardAuthorization{}
+	var currency string
+	if err := row.Scan(&auth.ID, &auth.CardID, &auth.AccountID, &auth.Merchant, &auth.Amount, &auth.HeldFee, &currency,
+		&auth.Status, &auth.ApprovalCode, &auth.DeclineReason, &auth.CapturedAmount, &auth.EntryID,
+		&auth.CreatedAt, &auth.UpdatedAt); err != nil {
+		return nil, err
+	}
+	auth.Amount.Currency = currency
+	auth.HeldFee.Currency = currency
+	auth.CapturedAmount.Currency = currency
+	return auth, nil
+}
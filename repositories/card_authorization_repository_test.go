@@ -0,0 +1,185 @@
+package repositories_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"bank-api/models"
+	"bank-api/repositories"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+var authorizationColumns = []string{"id", "card_id", "account_id", "merchant", "amount", "held_fee", "currency", "status",
+	"approval_code", "decline_reason", "captured_amount", "entry_id", "created_at", "updated_at"}
+
+var cardColumns = []string{"id", "user_id", "account_id", "card_number", "card_number_mac", "expiration_date",
+	"expiration_mac", "cvv_hash", "pan_index", "status", "key_id", "pvv", "pvki", "pin_attempts", "product_code",
+	"expires_at", "reissued_from", "pending_cvv", "pending_cvv_mac", "kind", "spend_cap", "valid_until",
+	"locked_merchant", "created_at"}
+
+// expectLockCard ожидает блокировку активной карты id счета 3 вида kind с лимитом spendCap.
+func expectLockCard(mock sqlmock.Sqlmock, id int, kind string, spendCap driver.Value, lockedMerchant string) {
+	now := time.Now()
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM cards WHERE id = $1 FOR UPDATE`)).WithArgs(id).
+		WillReturnRows(sqlmock.NewRows(cardColumns).AddRow(id, 1, 3, "number", "number-mac", "expiry", "expiry-mac",
+			"cvv-hash", "index", "active", "k1", "", 1, 0, "mir_debit", now.AddDate(3, 0, 0), 0, "", "",
+			kind, spendCap, nil, lockedMerchant, now))
+}
+
+func TestHold_RejectsAmountAboveAvailable(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %v", err)
+	}
+	defer db.Close()
+	repo := repositories.NewCardAuthorizationRepository(db)
+
+	// Остаток 100.00, из них 70.00 уже заблокировано: 29.60 с комиссией 0.50 не помещаются.
+	mock.ExpectBegin()
+	expectLockCard(mock, 1, models.CardKindStandard, nil, "")
+	mock.ExpectQuery(regexp.QuoteMeta(lockAccountQuery)).WithArgs(3).
+		WillReturnRows(sqlmock.NewRows(accountColumns).AddRow(3, 1, "100.00", "RUB", "active", time.Now(), "", "current", "70.00"))
+	mock.ExpectRollback()
+
+	auth := &models.CardAuthorization{CardID: 1, AccountID: 3, Amount: models.NewMoney(2960, "RUB"), HeldFee: models.NewMoney(50, "RUB")}
+	if err := repo.Hold(context.Background(), auth); !errors.Is(err, models.ErrInsufficientFunds) {
+		t.Errorf("expected ErrInsufficientFunds, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestHold_LocksMerchantOfFirstAuthorization(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %v", err)
+	}
+	defer db.Close()
+	repo := repositories.NewCardAuthorizationRepository(db)
+	now := time.Now()
+
+	// Лимит 50.00, из них 20.00 уже одобрено: 30.00 помещается и блокируется вместе
+	// с комиссией 0.50, а карта привязывается к точке.
+	mock.ExpectBegin()
+	expectLockCard(mock, 1, models.CardKindMerchantLocked, "50.00", "")
+	mock.ExpectQuery(regexp.QuoteMeta(lockAccountQuery)).WithArgs(3).
+		WillReturnRows(sqlmock.NewRows(accountColumns).AddRow(3, 1, "100.00", "RUB", "active", now, "", "current", "0.00"))
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM card_authorizations WHERE card_id = $1 AND status IN ('approved', 'captured')`)).
+		WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"count", "sum"}).AddRow(1, "20.00"))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE accounts SET held = held + $1 WHERE id = $2`)).
+		WithArgs("30.50", 3).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE cards SET locked_merchant = $1 WHERE id = $2`)).
+		WithArgs("coffee", 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO card_authorizations`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow(5, now, now))
+	mock.ExpectCommit()
+
+	auth := &models.CardAuthorization{CardID: 1, AccountID: 3, Merchant: "coffee", Amount: models.NewMoney(3000, "RUB"),
+		HeldFee: models.NewMoney(50, "RUB")}
+	if err := repo.Hold(context.Background(), auth); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if auth.ID != 5 || auth.Status != models.AuthorizationStatusApproved {
+		t.Errorf("expected approved authorization 5, got %+v", auth)
+	}
+
+	// Следующие 40.00 превышают лимит.
+	mock.ExpectBegin()
+	expectLockCard(mock, 1, models.CardKindMerchantLocked, "50.00", "coffee")
+	mock.ExpectQuery(regexp.QuoteMeta(lockAccountQuery)).WithArgs(3).
+		WillReturnRows(sqlmock.NewRows(accountColumns).AddRow(3, 1, "100.00", "RUB", "active", now, "", "current", "50.00"))
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM card_authorizations WHERE card_id = $1`)).
+		WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"count", "sum"}).AddRow(2, "50.00"))
+	mock.ExpectRollback()
+
+	auth = &models.CardAuthorization{CardID: 1, AccountID: 3, Merchant: "coffee", Amount: models.NewMoney(4000, "RUB")}
+	if err := repo.Hold(context.Background(), auth); !errors.Is(err, models.ErrSpendCapExceeded) {
+		t.Errorf("expected ErrSpendCapExceeded, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestCapture_PartialReleasesWholeHold(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %v", err)
+	}
+	defer db.Close()
+	repo := repositories.NewCardAuthorizationRepository(db)
+	now := time.Now()
+
+	// Заблокировано 50.00 и комиссия 1.00, списывается 30.00 и комиссия 0.50; блокировка снимается целиком.
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM card_authorizations WHERE id = $1 FOR UPDATE`)).WithArgs(9).
+		WillReturnRows(sqlmock.NewRows(authorizationColumns).
+			AddRow(9, 1, 3, "coffee", "50.00", "1.00", "RUB", "approved", "123456", "", "0.00", 0, now, now))
+	expectLockCard(mock, 1, models.CardKindStandard, nil, "")
+	mock.ExpectQuery(regexp.QuoteMeta(lockAccountQuery)).WithArgs(3).
+		WillReturnRows(sqlmock.NewRows(accountColumns).AddRow(3, 1, "100.00", "RUB", "active", now, "", "current", "51.00"))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE accounts SET held = held - $1 WHERE id = $2`)).
+		WithArgs("51.00", 3).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO journal_entries`)).
+		WithArgs("card_purchase", sqlmock.AnyArg(), nil, nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(30, now))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO postings`)).
+		WithArgs(30, 3, nil, "-30.00", "RUB").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery(regexp.QuoteMeta(`UPDATE accounts SET balance`)).
+		WithArgs("-30.00", 3).
+		WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow("70.00"))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO transactions`)).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO postings`)).
+		WithArgs(30, nil, "card_settlement", "30.00", "RUB").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO journal_entries`)).
+		WithArgs("fee", sqlmock.AnyArg(), nil, nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(31, now))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO postings`)).
+		WithArgs(31, 3, nil, "-0.50", "RUB").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+	mock.ExpectQuery(regexp.QuoteMeta(`UPDATE accounts SET balance`)).
+		WithArgs("-0.50", 3).
+		WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow("69.50"))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO transactions`)).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO postings`)).
+		WithArgs(31, nil, "fee_income", "0.50", "RUB").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO fee_charges`)).
+		WithArgs(3, "card_purchase", "30.00", "RUB", "0.50", 7, 31).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(9, now))
+	mock.ExpectQuery(regexp.QuoteMeta(`UPDATE card_authorizations SET status = $1`)).
+		WithArgs("captured", "30.00", 30, 9).
+		WillReturnRows(sqlmock.NewRows([]string{"updated_at"}).AddRow(now))
+	mock.ExpectCommit()
+
+	fee := &models.FeeCharge{
+		AccountID: 3,
+		Operation: models.FeeOperationCardPurchase,
+		Amount:    models.NewMoney(3000, "RUB"),
+		Fee:       models.NewMoney(50, "RUB"),
+		RuleID:    7,
+	}
+	auth, err := repo.Capture(context.Background(), 9, models.NewMoney(3000, ""), fee)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if auth.Status != models.AuthorizationStatusCaptured || auth.CapturedAmount.String() != "30.00" || auth.EntryID != 30 {
+		t.Errorf("expected 30.00 captured by entry 30, got %+v", auth)
+	}
+	if fee.EntryID != 31 {
+		t.Errorf("expected the fee charged by entry 31, got %d", fee.EntryID)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,101 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+
+	"bank-api/models"
+)
+
+// CardProductRepository хранит карточные продукты.
+type CardProductRepository interface {
+	// List возвращает продукты; activeOnly — только доступные для выпуска.
+	List(activeOnly bool) ([]*models.CardProduct, error)
+	// GetByCode возвращает продукт или ErrCardProductNotFound.
+	GetByCode(code string) (*models.CardProduct, error)
+	Create(product *models.CardProduct) error
+	// Deactivate прекращает выпуск карт по продукту; выпущенные карты продолжают действовать.
+	Deactivate(code string) error
+}
+
+type cardProductRepository struct {
+	db *sql.DB
+}
+
+// NewCardProductRepository возвращает реализацию CardProductRepository.
+func NewCardProductRepository(db *sql.DB) CardProductRepository {
+	return &cardProductRepository{db: db}
+}
+
+// cardProductColumns — столбцы, которые читает scanCardProduct.
+const cardProductColumns = `code, name, payment_system, type, bin_from, bin_to, pan_length, validity_years,
+	reissue_keep_pan, active, created_at`
+
+func (r *cardProductRepository) List(activeOnly bool) ([]*models.CardProduct, error) {
+	query := `SELECT ` + cardProductColumns + ` FROM card_products`
+	if activeOnly {
+		query += ` WHERE active`
+	}
+	rows, err := r.db.Query(query + ` ORDER BY code`)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching card products: %w", err)
+	}
+	defer rows.Close()
+
+	products := []*models.CardProduct{}
+	for rows.Next() {
+		product, err := scanCardProduct(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning card product: %w", err)
+		}
+		products = append(products, product)
+	}
+	return products, rows.Err()
+}
+
+func (r *cardProductRepository) GetByCode(code string) (*models.CardProduct, error) {
+	product, err := scanCardProduct(r.db.QueryRow(`SELECT `+cardProductColumns+` FROM card_products WHERE code = $1`, code))
+	if err == sql.ErrNoRows {
+		return nil, models.ErrCardProductNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error fetching card product: %w", err)
+	}
+	return product, nil
+}
+
+func (r *cardProductRepository) Create(product *models.CardProduct) error {
+	err := r.db.QueryRow(
+		`INSERT INTO card_products (code, name, payment_system, type, bin_from, bin_to, pan_length,
+			validity_years, reissue_keep_pan, active, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NOW()) RETURNING created_at`,
+		product.Code, product.Name, product.PaymentSystem, product.Type, product.BINFrom, product.BINTo,
+		product.PANLength, product.ValidityYears, product.ReissueKeepPAN, product.Active,
+	).Scan(&product.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("error inserting card product: %w", err)
+	}
+	return nil
+}
+
+func (r *cardProductRepository) Deactivate(code string) error {
+	res, err := r.db.Exec(`UPDATE card_products SET active = FALSE WHERE code = $1`, code)
+	if err != nil {
+		return fmt.Errorf("error deactivating card product: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return models.ErrCardProductNotFound
+	}
+	return nil
+}
+
+// scanCardProduct читает строку cardProductColumns.
+func scanCardProduct(row rowScanner) (*models.CardProduct, error) {
+	p := &models.CardProduct{}
+	err := row.Scan(&p.Code, &p.Name, &p.PaymentSystem, &p.Type, &p.BINFrom, &p.BINTo, &p.PANLength,
+		&p.ValidityYears, &p.ReissueKeepPAN, &p.Active, &p.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
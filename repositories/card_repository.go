@@ -1,15 +1,68 @@
 package repositories
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"time"
+
 	"bank-api/models"
 )
 
 // CardRepository определяет методы для работы с картами.
 type CardRepository interface {
+	// Create сохраняет карту; ErrCardNumberTaken, если ее номер уже выдан карте
+	// вне цепочки перевыпуска.
 	Create(card *models.Card) error
 	GetByID(id int) (*models.Card, error)
+	// GetByPANIndex ищет карту по слепому индексу номера.
+	GetByPANIndex(index string) (*models.Card, error)
+	// ListByUser возвращает карты держателя userID.
+	ListByUser(userID int) ([]*models.Card, error)
+	// ListByAccount возвращает карты счета.
+	ListByAccount(accountID int) ([]*models.Card, error)
+	// RecordReveal сохраняет попытку раскрыть реквизиты карты.
+	RecordReveal(userID, cardID int, success bool) error
+	// CountReveals возвращает число попыток раскрытия реквизитов пользователем с since.
+	CountReveals(userID int, since time.Time) (int, error)
+	// CheckCVV под блокировкой карты check.CardID проверяет CVV: если неверных CVV по карте
+	// с since меньше limit и карта активна, вызывает match. Итог записывается в check
+	// и в журнал проверок в той же транзакции, поэтому параллельные проверки не обходят
+	// лимит. Возвращает число неверных CVV с since с учетом этой проверки.
+	CheckCVV(ctx context.Context, check *models.CVVCheck, since time.Time, limit int, match func(card *models.Card) bool) (int, error)
+	// TakePendingCVV возвращает зашифрованный CVV перевыпущенной карты и его HMAC
+	// и очищает их; пустые строки — CVV уже выдан.
+	TakePendingCVV(cardID int) (cvv, mac string, err error)
+	// ChangeStatus под блокировкой строки переводит карту change.CardID в статус change.ToStatus,
+	// если ее текущий статус входит в from, и записывает смену в историю.
+	// Иначе возвращает ErrInvalidStatusTransition.
+	ChangeStatus(ctx context.Context, change *models.CardStatusChange, from ...string) (*models.Card, error)
+	// ListStatusHistory возвращает историю статусов карты в хронологическом порядке.
+	ListStatusHistory(cardID int) ([]models.CardStatusChange, error)
+	// ListForReencryption возвращает до limit карт с ID больше afterID, реквизиты
+	// или невыданный CVV которых зашифрованы не ключом keyID, в порядке ID.
+	ListForReencryption(keyID string, afterID, limit int) ([]*models.Card, error)
+	// UpdateEncryption сохраняет перешифрованные реквизиты и невыданный CVV card, только если
+	// номер, срок и невыданный CVV карты в БД все еще равны прежним из prev.
+	// false — карта изменилась параллельно и не обновлена.
+	UpdateEncryption(card, prev *models.Card) (bool, error)
+	// ListByPANIndex возвращает все карты с номером по слепому индексу, новые первыми:
+	// при перевыпуске с сохранением номера их несколько.
+	ListByPANIndex(index string) ([]*models.Card, error)
+	// ListWithoutExpiry возвращает карты без expires_at (выпущенные до его появления).
+	ListWithoutExpiry(limit int) ([]*models.Card, error)
+	SetExpiresAt(cardID int, expiresAt time.Time) error
+	// ListExpiring возвращает активные карты со сроком действия до before, еще не перевыпущенные.
+	ListExpiring(before time.Time, limit int) ([]*models.Card, error)
+	// ExpireDue переводит в статус expired не больше limit действующих и заблокированных карт,
+	// срок которых наступил к now, с записью в историю статусов.
+	ExpireDue(ctx context.Context, now time.Time, limit int) ([]*models.Card, error)
+	// SetPVV сохраняет PVV нового PIN и сбрасывает счетчик неверных попыток.
+	SetPVV(cardID int, pvv string, pvki int) error
+	// RecordPINAttempt под блокировкой карты учитывает проверку PIN: удачная сбрасывает
+	// счетчик, неудачная увеличивает его, и на maxAttempts-й подряд карта переходит
+	// в pin_locked с записью в историю статусов от имени actorID. Для неактивной карты — ErrCardInactive.
+	RecordPINAttempt(ctx context.Context, cardID int, success bool, maxAttempts, actorID int) (*models.Card, error)
 }
 
 type cardRepository struct {
@@ -21,33 +74,472 @@ func NewCardRepository(db *sql.DB) CardRepository {
 	return &cardRepository{db: db}
 }
 
-// Create вставляет новую карту в базу данных.
+// cardColumns — столбцы, которые читает scanCard.
+const cardColumns = `id, user_id, account_id, card_number, card_number_mac, expiration_date, expiration_mac,
+	cvv_hash, COALESCE(pan_index, ''), status, key_id, pvv, pvki, pin_attempts, product_code, expires_at,
+	COALESCE(reissued_from, 0), pending_cvv, pending_cvv_mac, kind, spend_cap, valid_until, locked_merchant, created_at`
+
+// Create вставляет новую карту в базу данных. Номер карты уникален: карта с тем же
+// слепым индексом допускается, только если это предшественница новой карты в цепочке
+// перевыпуска (reissued_from), иначе ErrCardNumberTaken. Уникальный индекс
+// (pan_index, expires_at) этого не гарантирует, поэтому проверка и вставка идут
+// в одной транзакции под advisory-блокировкой номера.
 func (r *cardRepository) Create(card *models.Card) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	reissuedFrom := sql.NullInt64{Int64: int64(card.ReissuedFrom), Valid: card.ReissuedFrom != 0}
+	if card.PANIndex != "" {
+		if _, err := tx.Exec(`SELECT pg_advisory_xact_lock(hashtext($1))`, card.PANIndex); err != nil {
+			return fmt.Errorf("lock card number: %w", err)
+		}
+		var taken bool
+		if err := tx.QueryRow(
+			`WITH RECURSIVE chain (id) AS (
+				SELECT id FROM cards WHERE id = $2
+				UNION
+				SELECT c.reissued_from FROM cards c JOIN chain ON c.id = chain.id WHERE c.reissued_from IS NOT NULL
+			)
+			SELECT EXISTS (SELECT 1 FROM cards WHERE pan_index = $1 AND id NOT IN (SELECT id FROM chain))`,
+			card.PANIndex, reissuedFrom,
+		).Scan(&taken); err != nil {
+			return fmt.Errorf("check card number: %w", err)
+		}
+		if taken {
+			return models.ErrCardNumberTaken
+		}
+	}
+
 	query := `
-		INSERT INTO cards (user_id, account_id, card_number, expiration_date, cvv_hash, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id
+		INSERT INTO cards (user_id, account_id, card_number, card_number_mac, expiration_date, expiration_mac,
+			cvv_hash, pan_index, status, key_id, product_code, pvv, pvki, expires_at, reissued_from,
+			pending_cvv, pending_cvv_mac, kind, spend_cap, valid_until, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21)
+		RETURNING id
 	`
-	err := r.db.QueryRow(query, card.UserID, card.AccountID, card.CardNumber, card.ExpirationDate, card.CVVHash, card.CreatedAt).
+	err = tx.QueryRow(query, card.UserID, card.AccountID, card.CardNumber, card.CardNumberMAC,
+		card.ExpirationDate, card.ExpirationMAC, card.CVVHash, card.PANIndex, card.Status, card.KeyID,
+		card.ProductCode, card.PVV, card.PVKI, sql.NullTime{Time: card.ExpiresAt, Valid: !card.ExpiresAt.IsZero()},
+		reissuedFrom, card.PendingCVV, card.PendingCVVMAC, card.Kind, card.SpendCap, card.ValidUntil, card.CreatedAt).
 		Scan(&card.ID)
 	if err != nil {
 		return fmt.Errorf("error inserting card: %w", err)
 	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
 	return nil
 }
 
 // GetByID возвращает карту по ID.
 func (r *cardRepository) GetByID(id int) (*models.Card, error) {
-	var card models.Card
-	query := `
-		SELECT id, user_id, account_id, card_number, expiration_date, cvv_hash, created_at
-		FROM cards WHERE id = $1
-	`
-	row := r.db.QueryRow(query, id)
-	if err := row.Scan(&card.ID, &card.UserID, &card.AccountID, &card.CardNumber, &card.ExpirationDate, &card.CVVHash, &card.CreatedAt); err != nil {
+	return r.getCard(`SELECT `+cardColumns+` FROM cards WHERE id = $1`, id)
+}
+
+// GetByPANIndex возвращает карту по слепому индексу номера.
+func (r *cardRepository) GetByPANIndex(index string) (*models.Card, error) {
+	return r.getCard(`SELECT `+cardColumns+` FROM cards WHERE pan_index = $1 ORDER BY id DESC LIMIT 1`, index)
+}
+
+// ListByPANIndex возвращает карты с номером по слепому индексу, новые первыми.
+func (r *cardRepository) ListByPANIndex(index string) ([]*models.Card, error) {
+	return r.listCards(`SELECT `+cardColumns+` FROM cards WHERE pan_index = $1 ORDER BY id DESC`, index)
+}
+
+// ListByUser возвращает карты держателя в порядке выпуска.
+func (r *cardRepository) ListByUser(userID int) ([]*models.Card, error) {
+	return r.listCards(`SELECT `+cardColumns+` FROM cards WHERE user_id = $1 ORDER BY id`, userID)
+}
+
+// ListByAccount возвращает карты счета в порядке выпуска.
+func (r *cardRepository) ListByAccount(accountID int) ([]*models.Card, error) {
+	return r.listCards(`SELECT `+cardColumns+` FROM cards WHERE account_id = $1 ORDER BY id`, accountID)
+}
+
+// RecordReveal сохраняет попытку раскрыть реквизиты карты.
+func (r *cardRepository) RecordReveal(userID, cardID int, success bool) error {
+	if _, err := r.db.Exec(
+		`INSERT INTO card_reveals (user_id, card_id, success, created_at) VALUES ($1, $2, $3, NOW())`,
+		userID, cardID, success,
+	); err != nil {
+		return fmt.Errorf("error recording card reveal: %w", err)
+	}
+	return nil
+}
+
+// CountReveals возвращает число попыток раскрытия реквизитов пользователем с since.
+func (r *cardRepository) CountReveals(userID int, since time.Time) (int, error) {
+	var n int
+	if err := r.db.QueryRow(
+		`SELECT COUNT(*) FROM card_reveals WHERE user_id = $1 AND created_at >= $2`, userID, since,
+	).Scan(&n); err != nil {
+		return 0, fmt.Errorf("error counting card reveals: %w", err)
+	}
+	return n, nil
+}
+
+// CheckCVV считает, проверяет и записывает попытку в одной транзакции под блокировкой карты.
+func (r *cardRepository) CheckCVV(ctx context.Context, check *models.CVVCheck, since time.Time, limit int, match func(card *models.Card) bool) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	card, err := lockCard(ctx, tx, check.CardID)
+	if err != nil {
+		return 0, err
+	}
+	var mismatches int
+	if err := tx.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM card_cvv_checks WHERE card_id = $1 AND result = $2 AND created_at >= $3`,
+		card.ID, models.CVVCheckMismatch, since,
+	).Scan(&mismatches); err != nil {
+		return 0, fmt.Errorf("error counting CVV checks: %w", err)
+	}
+	switch {
+	case mismatches >= limit:
+		check.Result = models.CVVCheckLimited
+	case card.Status != models.CardStatusActive:
+		check.Result = models.CVVCheckCardInactive
+	case match(card):
+		check.Result = models.CVVCheckMatch
+	default:
+		check.Result = models.CVVCheckMismatch
+		mismatches++
+	}
+	if err := tx.QueryRowContext(ctx,
+		`INSERT INTO card_cvv_checks (card_id, result, client_addr, created_at)
+		 VALUES ($1, $2, $3, NOW()) RETURNING id, created_at`,
+		card.ID, check.Result, check.ClientAddr,
+	).Scan(&check.ID, &check.CreatedAt); err != nil {
+		return 0, fmt.Errorf("error recording CVV check: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit tx: %w", err)
+	}
+	return mismatches, nil
+}
+
+// TakePendingCVV выдает CVV перевыпущенной карты один раз: параллельный запрос получит пустые строки.
+func (r *cardRepository) TakePendingCVV(cardID int) (string, string, error) {
+	var cvv, mac string
+	err := r.db.QueryRow(
+		`UPDATE cards c SET pending_cvv = '', pending_cvv_mac = ''
+		 FROM (SELECT id, pending_cvv, pending_cvv_mac FROM cards WHERE id = $1 FOR UPDATE) old
+		 WHERE c.id = old.id AND old.pending_cvv <> ''
+		 RETURNING old.pending_cvv, old.pending_cvv_mac`, cardID,
+	).Scan(&cvv, &mac)
+	if err == sql.ErrNoRows {
+		return "", "", nil
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("error taking pending CVV: %w", err)
+	}
+	return cvv, mac, nil
+}
+
+// ChangeStatus меняет статус карты и пишет историю в одной транзакции.
+func (r *cardRepository) ChangeStatus(ctx context.Context, change *models.CardStatusChange, from ...string) (*models.Card, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	card, err := lockCard(ctx, tx, change.CardID)
+	if err != nil {
+		return nil, err
+	}
+	allowed := false
+	for _, status := range from {
+		allowed = allowed || card.Status == status
+	}
+	if !allowed {
+		return nil, fmt.Errorf("%w: card is %s", models.ErrInvalidStatusTransition, card.Status)
+	}
+
+	if err := changeCardStatus(ctx, tx, card, change); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit tx: %w", err)
+	}
+	return card, nil
+}
+
+// changeCardStatus переводит заблокированную в tx карту в статус change.ToStatus
+// и записывает смену в историю. Счетчик неверных PIN сбрасывается только при снятии
+// блокировки по PIN: блокировка и разблокировка держателем его не обнуляют.
+func changeCardStatus(ctx context.Context, tx *sql.Tx, card *models.Card, change *models.CardStatusChange) error {
+	attempts := card.PINAttempts
+	if card.Status == models.CardStatusPINLocked {
+		attempts = 0
+	}
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE cards SET status = $1, pin_attempts = $2 WHERE id = $3`, change.ToStatus, attempts, card.ID,
+	); err != nil {
+		return fmt.Errorf("update card status: %w", err)
+	}
+	change.CardID = card.ID
+	change.FromStatus = card.Status
+	if err := tx.QueryRowContext(ctx,
+		`INSERT INTO card_status_history (card_id, from_status, to_status, reason, actor_id, actor_role, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, NOW()) RETURNING id, created_at`,
+		card.ID, change.FromStatus, change.ToStatus, change.Reason, change.ActorID, change.ActorRole,
+	).Scan(&change.ID, &change.CreatedAt); err != nil {
+		return fmt.Errorf("insert card status history: %w", err)
+	}
+	card.Status = change.ToStatus
+	card.PINAttempts = attempts
+	return nil
+}
+
+// lockCard читает карту с блокировкой строки до конца транзакции.
+func lockCard(ctx context.Context, tx *sql.Tx, id int) (*models.Card, error) {
+	card, err := scanCard(tx.QueryRowContext(ctx, `SELECT `+cardColumns+` FROM cards WHERE id = $1 FOR UPDATE`, id))
+	if err == sql.ErrNoRows {
+		return nil, models.ErrCardNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("lock card %d: %w", id, err)
+	}
+	return card, nil
+}
+
+// ListStatusHistory возвращает историю статусов карты.
+func (r *cardRepository) ListStatusHistory(cardID int) ([]models.CardStatusChange, error) {
+	rows, err := r.db.Query(
+		`SELECT id, card_id, from_status, to_status, reason, actor_id, actor_role, created_at
+		 FROM card_status_history WHERE card_id = $1 ORDER BY created_at, id`, cardID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching card status history: %w", err)
+	}
+	defer rows.Close()
+
+	history := []models.CardStatusChange{}
+	for rows.Next() {
+		var c models.CardStatusChange
+		if err := rows.Scan(&c.ID, &c.CardID, &c.FromStatus, &c.ToStatus, &c.Reason, &c.ActorID,
+			&c.ActorRole, &c.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning card status change: %w", err)
+		}
+		history = append(history, c)
+	}
+	return history, rows.Err()
+}
+
+// ListForReencryption возвращает очередную пачку карт для перешифрования.
+func (r *cardRepository) ListForReencryption(keyID string, afterID, limit int) ([]*models.Card, error) {
+	rows, err := r.db.Query(
+		`SELECT `+cardColumns+` FROM cards
+		 WHERE (key_id <> $1 OR (pending_cvv <> '' AND split_part(pending_cvv, ':', 1) <> $1)) AND id > $2
+		 ORDER BY id LIMIT $3`,
+		keyID, afterID, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching cards for re-encryption: %w", err)
+	}
+	defer rows.Close()
+
+	cards := []*models.Card{}
+	for rows.Next() {
+		card, err := scanCard(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning card: %w", err)
+		}
+		cards = append(cards, card)
+	}
+	return cards, rows.Err()
+}
+
+// UpdateEncryption заменяет шифротексты карты при условии, что их не изменили параллельно.
+func (r *cardRepository) UpdateEncryption(card, prev *models.Card) (bool, error) {
+	res, err := r.db.Exec(
+		`UPDATE cards SET card_number = $1, card_number_mac = $2, expiration_date = $3, expiration_mac = $4, key_id = $5,
+			pending_cvv = $6, pending_cvv_mac = $7
+		 WHERE id = $8 AND card_number = $9 AND expiration_date = $10 AND pending_cvv = $11`,
+		card.CardNumber, card.CardNumberMAC, card.ExpirationDate, card.ExpirationMAC, card.KeyID,
+		card.PendingCVV, card.PendingCVVMAC, card.ID, prev.CardNumber, prev.ExpirationDate, prev.PendingCVV,
+	)
+	if err != nil {
+		return false, fmt.Errorf("error updating card encryption: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+func (r *cardRepository) ListWithoutExpiry(limit int) ([]*models.Card, error) {
+	return r.listCards(`SELECT `+cardColumns+` FROM cards WHERE expires_at IS NULL ORDER BY id LIMIT $1`, limit)
+}
+
+func (r *cardRepository) SetExpiresAt(cardID int, expiresAt time.Time) error {
+	if _, err := r.db.Exec(`UPDATE cards SET expires_at = $1 WHERE id = $2`, expiresAt, cardID); err != nil {
+		return fmt.Errorf("error setting card expiry: %w", err)
+	}
+	return nil
+}
+
+func (r *cardRepository) ListExpiring(before time.Time, limit int) ([]*models.Card, error) {
+	return r.listCards(
+		`SELECT `+cardColumns+` FROM cards c
+		 WHERE status = 'active' AND kind = 'standard' AND expires_at < $1
+		   AND NOT EXISTS (SELECT 1 FROM cards n WHERE n.reissued_from = c.id)
+		 ORDER BY expires_at, id LIMIT $2`, before, limit)
+}
+
+func (r *cardRepository) ExpireDue(ctx context.Context, now time.Time, limit int) ([]*models.Card, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx,
+		`SELECT `+cardColumns+` FROM cards
+		 WHERE status IN ('active', 'blocked', 'pin_locked') AND (expires_at <= $1 OR valid_until <= $1)
+		 ORDER BY id LIMIT $2 FOR UPDATE SKIP LOCKED`, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching expired cards: %w", err)
+	}
+	cards := []*models.Card{}
+	for rows.Next() {
+		card, err := scanCard(rows)
+		if err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("error scanning card: %w", err)
+		}
+		cards = append(cards, card)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, card := range cards {
+		if err := changeCardStatus(ctx, tx, card, &models.CardStatusChange{
+			ToStatus:  models.CardStatusExpired,
+			Reason:    expiryReason(card, now),
+			ActorID:   card.UserID,
+			ActorRole: models.CardActorSystem,
+		}); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit tx: %w", err)
+	}
+	return cards, nil
+}
+
+// expiryReason объясняет в истории статусов, какой из сроков карты наступил.
+func expiryReason(card *models.Card, now time.Time) string {
+	if card.ValidUntil != nil && !card.ValidUntil.After(now) {
+		return "virtual card validity period ended"
+	}
+	return "card validity period ended"
+}
+
+// SetPVV сохраняет PVV нового PIN.
+func (r *cardRepository) SetPVV(cardID int, pvv string, pvki int) error {
+	res, err := r.db.Exec(`UPDATE cards SET pvv = $1, pvki = $2, pin_attempts = 0 WHERE id = $3`, pvv, pvki, cardID)
+	if err != nil {
+		return fmt.Errorf("error setting card PIN: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return models.ErrCardNotFound
+	}
+	return nil
+}
+
+// RecordPINAttempt учитывает проверку PIN и блокирует карту по PIN после maxAttempts неудач подряд.
+func (r *cardRepository) RecordPINAttempt(ctx context.Context, cardID int, success bool, maxAttempts, actorID int) (*models.Card, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	card, err := lockCard(ctx, tx, cardID)
+	if err != nil {
+		return nil, err
+	}
+	if card.Status != models.CardStatusActive {
+		return nil, models.ErrCardInactive
+	}
+	if success {
+		card.PINAttempts = 0
+	} else {
+		card.PINAttempts++
+	}
+	if card.PINAttempts >= maxAttempts {
+		err = changeCardStatus(ctx, tx, card, &models.CardStatusChange{
+			ToStatus:  models.CardStatusPINLocked,
+			Reason:    fmt.Sprintf("%d wrong PINs in a row", card.PINAttempts),
+			ActorID:   actorID,
+			ActorRole: models.RoleCustomer,
+		})
+	} else {
+		_, err = tx.ExecContext(ctx, `UPDATE cards SET pin_attempts = $1 WHERE id = $2`, card.PINAttempts, card.ID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("record PIN attempt: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit tx: %w", err)
+	}
+	return card, nil
+}
+
+func (r *cardRepository) getCard(query string, arg interface{}) (*models.Card, error) {
+	card, err := scanCard(r.db.QueryRow(query, arg))
+	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("card not found")
+			return nil, models.ErrCardNotFound
 		}
 		return nil, fmt.Errorf("error fetching card: %w", err)
 	}
+	return card, nil
+}
+
+func (r *cardRepository) listCards(query string, args ...interface{}) ([]*models.Card, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching cards: %w", err)
+	}
+	defer rows.Close()
+
+	cards := []*models.Card{}
+	for rows.Next() {
+		card, err := scanCard(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning card: %w", err)
+		}
+		cards = append(cards, card)
+	}
+	return cards, rows.Err()
+}
+
+// scanCard читает строку cardColumns.
+func scanCard(row rowScanner) (*models.Card, error) {
+	var (
+		card      models.Card
+		expiresAt sql.NullTime
+	)
+	if err := row.Scan(&card.ID, &card.UserID, &card.AccountID, &card.CardNumber, &card.CardNumberMAC,
+		&card.ExpirationDate, &card.ExpirationMAC, &card.CVVHash, &card.PANIndex, &card.Status,
+		&card.KeyID, &card.PVV, &card.PVKI, &card.PINAttempts, &card.ProductCode, &expiresAt,
+		&card.ReissuedFrom, &card.PendingCVV, &card.PendingCVVMAC, &card.Kind, &card.SpendCap,
+		&card.ValidUntil, &card.LockedMerchant, &card.CreatedAt); err != nil {
+		return nil, err
+	}
+	card.ExpiresAt = expiresAt.Time
 	return &card, nil
 }
@@ -0,0 +1,71 @@
+package repositories_test
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"bank-api/models"
+	"bank-api/repositories"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+const cardNumberTakenQuery = `SELECT EXISTS (SELECT 1 FROM cards WHERE pan_index = $1 AND id NOT IN (SELECT id FROM chain))`
+
+func TestCardCreate_RejectsNumberOutsideReissueChain(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %v", err)
+	}
+	defer db.Close()
+	repo := repositories.NewCardRepository(db)
+
+	// Новая карта получила номер, который уже выдан другой карте.
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`SELECT pg_advisory_xact_lock(hashtext($1))`)).WithArgs("index").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta(cardNumberTakenQuery)).WithArgs("index", nil).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectRollback()
+
+	card := &models.Card{UserID: 1, AccountID: 3, PANIndex: "index", Status: models.CardStatusActive}
+	if err := repo.Create(card); !errors.Is(err, models.ErrCardNumberTaken) {
+		t.Errorf("expected ErrCardNumberTaken, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestCardCreate_KeepsNumberOnReissue(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %v", err)
+	}
+	defer db.Close()
+	repo := repositories.NewCardRepository(db)
+
+	// Номер принадлежит только карте 7 и ее предшественницам — перевыпуск его сохраняет.
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`SELECT pg_advisory_xact_lock(hashtext($1))`)).WithArgs("index").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta(cardNumberTakenQuery)).WithArgs("index", 7).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO cards`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(8))
+	mock.ExpectCommit()
+
+	card := &models.Card{UserID: 1, AccountID: 3, PANIndex: "index", Status: models.CardStatusActive,
+		ReissuedFrom: 7, ExpiresAt: time.Now().AddDate(3, 0, 0)}
+	if err := repo.Create(card); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if card.ID != 8 {
+		t.Errorf("expected card 8, got %d", card.ID)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
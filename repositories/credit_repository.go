@@ -1,6 +1,7 @@
 package repositories
 
 import (
+    "context"
     "database/sql"
     "fmt"
 
@@ -9,11 +10,15 @@ import (
 
 type CreditRepository interface {
     Create(c *models.Credit) error
+    // Создать кредит и провести его выдачу на счет в одной транзакции
+    CreateWithDisbursement(ctx context.Context, c *models.Credit) error
     GetByID(id int) (*models.Credit, error)
     // Новый метод: получить все кредиты пользователя
     GetByUserID(userID int) ([]*models.Credit, error)
     // Обновить сумму кредита после штрафа
-    UpdateAmount(creditID int, newAmount float64) error
+    UpdateAmount(creditID int, newAmount models.Money) error
+    // Начислить штраф: увеличить сумму кредита и провести доход в одной транзакции
+    AddPenalty(ctx context.Context, creditID int, penalty models.Money) error
 }
 
 type creditRepository struct {
@@ -25,29 +30,64 @@ func NewCreditRepository(db *sql.DB) CreditRepository {
 }
 
 func (r *creditRepository) Create(c *models.Credit) error {
-    _, err := r.db.Exec(
-        `INSERT INTO credits (user_id, account_id, amount, interest_rate, created_at)
-         VALUES ($1, $2, $3, $4, NOW())`,
-        c.UserID, c.AccountID, c.Amount, c.InterestRate,
-    )
-    return err
+    return r.db.QueryRow(
+        `INSERT INTO credits (user_id, account_id, amount, currency, interest_rate, created_at)
+         VALUES ($1, $2, $3, $4, $5, NOW()) RETURNING id`,
+        c.UserID, c.AccountID, c.Amount, c.Currency, c.InterestRate,
+    ).Scan(&c.ID)
+}
+
+// CreateWithDisbursement сохраняет кредит и зачисляет его сумму на счет:
+// проводка «ссудная задолженность → счет клиента» выполняется в той же транзакции.
+func (r *creditRepository) CreateWithDisbursement(ctx context.Context, c *models.Credit) error {
+    tx, err := r.db.BeginTx(ctx, nil)
+    if err != nil {
+        return fmt.Errorf("begin tx: %w", err)
+    }
+    defer tx.Rollback()
+
+    if err := tx.QueryRowContext(ctx,
+        `INSERT INTO credits (user_id, account_id, amount, currency, interest_rate, created_at)
+         VALUES ($1, $2, $3, $4, $5, NOW()) RETURNING id`,
+        c.UserID, c.AccountID, c.Amount, c.Currency, c.InterestRate,
+    ).Scan(&c.ID); err != nil {
+        return fmt.Errorf("insert credit: %w", err)
+    }
+
+    amount := c.Amount.WithCurrency(c.Currency)
+    entry := &models.JournalEntry{
+        Type:        models.EntryTypeCreditDisbursement,
+        Description: fmt.Sprintf("credit %d disbursement", c.ID),
+        Postings: []models.Posting{
+            {SystemAccount: models.SystemAccountLoans, Amount: amount.Neg()},
+            {AccountID: c.AccountID, Amount: amount},
+        },
+    }
+    if err := postEntry(ctx, tx, entry, nil); err != nil {
+        return err
+    }
+    if err := tx.Commit(); err != nil {
+        return fmt.Errorf("commit tx: %w", err)
+    }
+    return nil
 }
 
 func (r *creditRepository) GetByID(id int) (*models.Credit, error) {
     row := r.db.QueryRow(
-        `SELECT id, user_id, account_id, amount, interest_rate, created_at
+        `SELECT id, user_id, account_id, amount, currency, interest_rate, created_at
          FROM credits WHERE id = $1`, id,
     )
     cr := &models.Credit{}
-    if err := row.Scan(&cr.ID, &cr.UserID, &cr.AccountID, &cr.Amount, &cr.InterestRate, &cr.CreatedAt); err != nil {
+    if err := row.Scan(&cr.ID, &cr.UserID, &cr.AccountID, &cr.Amount, &cr.Currency, &cr.InterestRate, &cr.CreatedAt); err != nil {
         return nil, err
     }
+    cr.Amount.Currency = cr.Currency
     return cr, nil
 }
 
 func (r *creditRepository) GetByUserID(userID int) ([]*models.Credit, error) {
     rows, err := r.db.Query(
-        `SELECT id, user_id, account_id, amount, interest_rate, created_at
+        `SELECT id, user_id, account_id, amount, currency, interest_rate, created_at
          FROM credits WHERE user_id = $1`, userID,
     )
     if err != nil {
@@ -58,15 +98,16 @@ func (r *creditRepository) GetByUserID(userID int) ([]*models.Credit, error) {
     var list []*models.Credit
     for rows.Next() {
         cr := &models.Credit{}
-        if err := rows.Scan(&cr.ID, &cr.UserID, &cr.AccountID, &cr.Amount, &cr.InterestRate, &cr.CreatedAt); err != nil {
+        if err := rows.Scan(&cr.ID, &cr.UserID, &cr.AccountID, &cr.Amount, &cr.Currency, &cr.InterestRate, &cr.CreatedAt); err != nil {
             return nil, err
         }
+        cr.Amount.Currency = cr.Currency
         list = append(list, cr)
     }
     return list, nil
 }
 
-func (r *creditRepository) UpdateAmount(creditID int, newAmount float64) error {
+func (r *creditRepository) UpdateAmount(creditID int, newAmount models.Money) error {
     res, err := r.db.Exec(
         `UPDATE credits SET amount = $1 WHERE id = $2`,
         newAmount, creditID,
@@ -78,4 +119,42 @@ func (r *creditRepository) UpdateAmount(creditID int, newAmount float64) error {
         return fmt.Errorf("credit %d not found", creditID)
     }
     return nil
-}
\ No newline at end of file
+}
+
+// AddPenalty увеличивает долг по кредиту на сумму штрафа и проводит
+// запись «ссудная задолженность → доход от штрафов» в той же транзакции.
+func (r *creditRepository) AddPenalty(ctx context.Context, creditID int, penalty models.Money) error {
+    tx, err := r.db.BeginTx(ctx, nil)
+    if err != nil {
+        return fmt.Errorf("begin tx: %w", err)
+    }
+    defer tx.Rollback()
+
+    var currency string
+    if err := tx.QueryRowContext(ctx,
+        `UPDATE credits SET amount = amount + $1 WHERE id = $2 RETURNING currency`,
+        penalty, creditID,
+    ).Scan(&currency); err != nil {
+        if err == sql.ErrNoRows {
+            return fmt.Errorf("credit %d not found", creditID)
+        }
+        return fmt.Errorf("update credit %d: %w", creditID, err)
+    }
+
+    amount := penalty.WithCurrency(currency)
+    entry := &models.JournalEntry{
+        Type:        models.EntryTypePenalty,
+        Description: fmt.Sprintf("credit %d overdue penalty", creditID),
+        Postings: []models.Posting{
+            {SystemAccount: models.SystemAccountLoans, Amount: amount.Neg()},
+            {SystemAccount: models.SystemAccountPenaltyIncome, Amount: amount},
+        },
+    }
+    if err := postEntry(ctx, tx, entry, nil); err != nil {
+        return err
+    }
+    if err := tx.Commit(); err != nil {
+        return fmt.Errorf("commit tx: %w", err)
+    }
+    return nil
+}
@@ -0,0 +1,65 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"bank-api/models"
+)
+
+// CurrencyRateRepository хранит курсы валют ЦБ РФ по датам.
+type CurrencyRateRepository interface {
+	// SaveRates сохраняет курсы; существующие курсы на ту же дату перезаписываются.
+	SaveRates(rates []models.CurrencyRate) error
+	// GetRate возвращает последний курс валюты на дату date или ранее.
+	GetRate(currency string, date time.Time) (*models.CurrencyRate, error)
+}
+
+type currencyRateRepository struct {
+	db *sql.DB
+}
+
+// NewCurrencyRateRepository возвращает реализацию CurrencyRateRepository.
+func NewCurrencyRateRepository(db *sql.DB) CurrencyRateRepository {
+	return &currencyRateRepository{db: db}
+}
+
+func (r *currencyRateRepository) SaveRates(rates []models.CurrencyRate) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, rate := range rates {
+		if _, err := tx.Exec(
+			`INSERT INTO currency_rates (rate_date, currency, nominal, value)
+			 VALUES ($1, $2, $3, $4)
+			 ON CONFLICT (rate_date, currency) DO UPDATE
+			 SET nominal = EXCLUDED.nominal, value = EXCLUDED.value`,
+			rate.Date, rate.Currency, rate.Nominal, rate.Value,
+		); err != nil {
+			return fmt.Errorf("save rate %s: %w", rate.Currency, err)
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *currencyRateRepository) GetRate(currency string, date time.Time) (*models.CurrencyRate, error) {
+	rate := &models.CurrencyRate{}
+	err := r.db.QueryRow(
+		`SELECT rate_date, currency, nominal, value::text
+		 FROM currency_rates
+		 WHERE currency = $1 AND rate_date <= $2
+		 ORDER BY rate_date DESC LIMIT 1`,
+		currency, date,
+	).Scan(&rate.Date, &rate.Currency, &rate.Nominal, &rate.Value)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s on %s", models.ErrRateUnavailable, currency, date.Format("2006-01-02"))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error fetching rate: %w", err)
+	}
+	return rate, nil
+}
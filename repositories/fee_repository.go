@@ -0,0 +1,148 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"bank-api/models"
+)
+
+// FeeRepository хранит правила тарифа и учет начисленных комиссий.
+type FeeRepository interface {
+	// ListRules возвращает правила тарифа; activeOnly — только действующие.
+	ListRules(activeOnly bool) ([]*models.FeeRule, error)
+	CreateRule(rule *models.FeeRule) error
+	// DeactivateRule выключает правило; ErrFeeRuleNotFound, если его нет.
+	DeactivateRule(id int) error
+	// MonthlyVolume возвращает сумму операций operation по счету начиная с since.
+	MonthlyVolume(accountID int, operation string, since time.Time) (models.Money, error)
+}
+
+type feeRepository struct {
+	db *sql.DB
+}
+
+// NewFeeRepository возвращает реализацию FeeRepository.
+func NewFeeRepository(db *sql.DB) FeeRepository {
+	return &feeRepository{db: db}
+}
+
+// feeRuleColumns — столбцы, которые читает scanFeeRule.
+const feeRuleColumns = `id, operation, currency, account_type, volume_from, volume_to, percent,
+	fixed, min_fee, max_fee, tiers, active, created_at`
+
+func (r *feeRepository) ListRules(activeOnly bool) ([]*models.FeeRule, error) {
+	query := `SELECT ` + feeRuleColumns + ` FROM fee_rules`
+	if activeOnly {
+		query += ` WHERE active`
+	}
+	rows, err := r.db.Query(query + ` ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching fee rules: %w", err)
+	}
+	defer rows.Close()
+
+	rules := []*models.FeeRule{}
+	for rows.Next() {
+		rule, err := scanFeeRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning fee rule: %w", err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+func (r *feeRepository) CreateRule(rule *models.FeeRule) error {
+	tiers, err := json.Marshal(rule.Tiers)
+	if err != nil {
+		return err
+	}
+	if rule.Tiers == nil {
+		tiers = []byte("[]")
+	}
+	err = r.db.QueryRow(
+		`INSERT INTO fee_rules (operation, currency, account_type, volume_from, volume_to, percent,
+			fixed, min_fee, max_fee, tiers, active, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NOW()) RETURNING id, created_at`,
+		rule.Operation, rule.Currency, rule.AccountType, rule.VolumeFrom, rule.VolumeTo, rule.Percent,
+		rule.Fixed, rule.Min, rule.Max, string(tiers), rule.Active,
+	).Scan(&rule.ID, &rule.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("error inserting fee rule: %w", err)
+	}
+	return nil
+}
+
+func (r *feeRepository) DeactivateRule(id int) error {
+	res, err := r.db.Exec(`UPDATE fee_rules SET active = FALSE WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("error deactivating fee rule: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return models.ErrFeeRuleNotFound
+	}
+	return nil
+}
+
+func (r *feeRepository) MonthlyVolume(accountID int, operation string, since time.Time) (models.Money, error) {
+	var volume models.Money
+	err := r.db.QueryRow(
+		`SELECT COALESCE(SUM(amount), 0) FROM fee_charges
+		 WHERE account_id = $1 AND operation = $2 AND created_at >= $3`,
+		accountID, operation, since,
+	).Scan(&volume)
+	if err != nil {
+		return models.Money{}, fmt.Errorf("error fetching monthly volume: %w", err)
+	}
+	return volume, nil
+}
+
+// scanFeeRule читает строку feeRuleColumns.
+func scanFeeRule(row rowScanner) (*models.FeeRule, error) {
+	rule := &models.FeeRule{}
+	var tiers []byte
+	if err := row.Scan(&rule.ID, &rule.Operation, &rule.Currency, &rule.AccountType, &rule.VolumeFrom,
+		&rule.VolumeTo, &rule.Percent, &rule.Fixed, &rule.Min, &rule.Max, &tiers, &rule.Active,
+		&rule.CreatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(tiers, &rule.Tiers); err != nil {
+		return nil, fmt.Errorf("fee rule %d tiers: %w", rule.ID, err)
+	}
+	return rule, nil
+}
+
+// chargeFee записывает операцию в учет комиссий и, если комиссия не нулевая,
+// проводит ее списание со счета в доход банка в рамках tx. Счет уже должен быть в locked;
+// если остатка не хватает на комиссию, возвращается ErrInsufficientFunds.
+func chargeFee(ctx context.Context, tx *sql.Tx, fee *models.FeeCharge, locked map[int]*models.Account) error {
+	var entryID sql.NullInt64
+	if fee.Fee.IsPositive() {
+		entry := &models.JournalEntry{
+			Type:        models.EntryTypeFee,
+			Description: fmt.Sprintf("%s fee, account %d", fee.Operation, fee.AccountID),
+			Postings: []models.Posting{
+				{AccountID: fee.AccountID, Amount: fee.Fee.Neg()},
+				{SystemAccount: models.SystemAccountFeeIncome, Amount: fee.Fee},
+			},
+		}
+		if err := postEntry(ctx, tx, entry, locked); err != nil {
+			return err
+		}
+		fee.EntryID = entry.ID
+		entryID = sql.NullInt64{Int64: int64(entry.ID), Valid: true}
+	}
+	ruleID := sql.NullInt64{Int64: int64(fee.RuleID), Valid: fee.RuleID != 0}
+	if err := tx.QueryRowContext(ctx,
+		`INSERT INTO fee_charges (account_id, operation, amount, currency, fee, rule_id, entry_id, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, NOW()) RETURNING id, created_at`,
+		fee.AccountID, fee.Operation, fee.Amount, fee.Amount.Currency, fee.Fee, ruleID, entryID,
+	).Scan(&fee.ID, &fee.CreatedAt); err != nil {
+		return fmt.Errorf("insert fee charge: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+
+	"bank-api/models"
+)
+
+// IdempotencyRepository хранит ключи идемпотентности в таблице idempotency_keys.
+// Реализует middleware.IdempotencyStore.
+type IdempotencyRepository interface {
+	Reserve(rec *models.IdempotencyRecord) (*models.IdempotencyRecord, error)
+	Complete(rec *models.IdempotencyRecord) error
+	Release(userID int, key string) error
+	// DeleteExpired удаляет ключи с истекшим сроком хранения.
+	DeleteExpired() (int64, error)
+}
+
+type idempotencyRepository struct {
+	db *sql.DB
+}
+
+// NewIdempotencyRepository возвращает реализацию IdempotencyRepository.
+func NewIdempotencyRepository(db *sql.DB) IdempotencyRepository {
+	return &idempotencyRepository{db: db}
+}
+
+// Reserve вставляет ключ в состоянии «выполняется». Истекший ключ
+// перезаписывается; действующий возвращается без изменений.
+func (r *idempotencyRepository) Reserve(rec *models.IdempotencyRecord) (*models.IdempotencyRecord, error) {
+	var userID int
+	err := r.db.QueryRow(
+		`INSERT INTO idempotency_keys (user_id, key, request_hash, completed, created_at, expires_at)
+		 VALUES ($1, $2, $3, false, $4, $5)
+		 ON CONFLICT (user_id, key) DO UPDATE
+		 SET request_hash = EXCLUDED.request_hash, completed = false,
+		     status_code = NULL, content_type = NULL, response_body = NULL,
+		     created_at = EXCLUDED.created_at, expires_at = EXCLUDED.expires_at
+		 WHERE idempotency_keys.expires_at < NOW()
+		 RETURNING user_id`,
+		rec.UserID, rec.Key, rec.RequestHash, rec.CreatedAt, rec.ExpiresAt,
+	).Scan(&userID)
+	if err == nil {
+		return nil, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, fmt.Errorf("reserve idempotency key: %w", err)
+	}
+
+	existing := &models.IdempotencyRecord{}
+	var (
+		statusCode  sql.NullInt64
+		contentType sql.NullString
+	)
+	err = r.db.QueryRow(
+		`SELECT user_id, key, request_hash, completed, status_code, content_type, response_body, created_at, expires_at
+		 FROM idempotency_keys WHERE user_id = $1 AND key = $2`,
+		rec.UserID, rec.Key,
+	).Scan(&existing.UserID, &existing.Key, &existing.RequestHash, &existing.Completed,
+		&statusCode, &contentType, &existing.ResponseBody, &existing.CreatedAt, &existing.ExpiresAt)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching idempotency key: %w", err)
+	}
+	existing.StatusCode = int(statusCode.Int64)
+	existing.ContentType = contentType.String
+	return existing, nil
+}
+
+func (r *idempotencyRepository) Complete(rec *models.IdempotencyRecord) error {
+	_, err := r.db.Exec(
+		`UPDATE idempotency_keys
+		 SET completed = true, status_code = $1, content_type = $2, response_body = $3
+		 WHERE user_id = $4 AND key = $5`,
+		rec.StatusCode, rec.ContentType, rec.ResponseBody, rec.UserID, rec.Key,
+	)
+	return err
+}
+
+func (r *idempotencyRepository) Release(userID int, key string) error {
+	_, err := r.db.Exec(
+		`DELETE FROM idempotency_keys WHERE user_id = $1 AND key = $2`,
+		userID, key,
+	)
+	return err
+}
+
+func (r *idempotencyRepository) DeleteExpired() (int64, error) {
+	res, err := r.db.Exec(`DELETE FROM idempotency_keys WHERE expires_at < NOW()`)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,201 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+
+	"bank-api/models"
+)
+
+// LedgerRepository описывает работу с журналом двойной записи.
+type LedgerRepository interface {
+	// Post проводит запись журнала и обновляет балансы затронутых счетов в одной транзакции.
+	Post(ctx context.Context, entry *models.JournalEntry) error
+	// GetEntry возвращает запись журнала вместе с проводками.
+	GetEntry(id int) (*models.JournalEntry, error)
+	// RebuildBalances пересчитывает accounts.balance по проводкам журнала.
+	RebuildBalances(ctx context.Context) error
+}
+
+type ledgerRepository struct {
+	db *sql.DB
+}
+
+// NewLedgerRepository возвращает реализацию LedgerRepository.
+func NewLedgerRepository(db *sql.DB) LedgerRepository {
+	return &ledgerRepository{db: db}
+}
+
+func (r *ledgerRepository) Post(ctx context.Context, entry *models.JournalEntry) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := postEntry(ctx, tx, entry, nil); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
+
+func (r *ledgerRepository) GetEntry(id int) (*models.JournalEntry, error) {
+	entry := &models.JournalEntry{}
+	err := r.db.QueryRow(
+		`SELECT id, type, description, COALESCE(fx_rate::text, ''), COALESCE(reversal_of, 0), created_at
+		 FROM journal_entries WHERE id = $1`, id,
+	).Scan(&entry.ID, &entry.Type, &entry.Description, &entry.FXRate, &entry.ReversalOf, &entry.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(
+		`SELECT id, entry_id, account_id, system_account, amount, currency
+		 FROM postings WHERE entry_id = $1 ORDER BY id`, id,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching postings: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			p             models.Posting
+			accountID     sql.NullInt64
+			systemAccount sql.NullString
+		)
+		if err := rows.Scan(&p.ID, &p.EntryID, &accountID, &systemAccount, &p.Amount, &p.Amount.Currency); err != nil {
+			return nil, fmt.Errorf("error scanning posting: %w", err)
+		}
+		p.AccountID = int(accountID.Int64)
+		p.SystemAccount = systemAccount.String
+		entry.Postings = append(entry.Postings, p)
+	}
+	return entry, rows.Err()
+}
+
+func (r *ledgerRepository) RebuildBalances(ctx context.Context) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE accounts a SET balance = COALESCE(
+			(SELECT SUM(p.amount) FROM postings p WHERE p.account_id = a.id), 0)`,
+	)
+	return err
+}
+
+// postEntry записывает запись журнала и ее проводки в рамках tx и обновляет
+// кешированные балансы клиентских счетов. Счета блокируются в порядке
+// возрастания ID; уже заблокированные вызывающим кодом передаются в locked.
+// По каждой проводке клиентского счета создается строка transactions;
+// проводки по неактивным (замороженным, закрытым) счетам отклоняются.
+// Время записи берется clock_timestamp() уже под блокировками счетов, а не NOW()
+// начала транзакции: иначе параллельные операции по счету получили бы created_at
+// в порядке, обратном цепочке balance_after, и история, курсор и остатки выписки
+// разошлись бы с ней.
+func postEntry(ctx context.Context, tx *sql.Tx, entry *models.JournalEntry, locked map[int]*models.Account) error {
+	if err := entry.Validate(); err != nil {
+		return err
+	}
+
+	var ids []int
+	for _, p := range entry.Postings {
+		if p.AccountID != 0 {
+			if _, ok := locked[p.AccountID]; !ok {
+				ids = append(ids, p.AccountID)
+			}
+		}
+	}
+	sort.Ints(ids)
+	if locked == nil {
+		locked = make(map[int]*models.Account, len(ids))
+	}
+	for _, id := range ids {
+		if _, ok := locked[id]; ok {
+			continue
+		}
+		acc, err := lockAccount(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		locked[id] = acc
+	}
+
+	fxRate := sql.NullString{String: entry.FXRate, Valid: entry.FXRate != ""}
+	reversalOf := sql.NullInt64{Int64: int64(entry.ReversalOf), Valid: entry.ReversalOf != 0}
+	if err := tx.QueryRowContext(ctx,
+		`INSERT INTO journal_entries (type, description, fx_rate, reversal_of, created_at)
+		 VALUES ($1, $2, $3, $4, clock_timestamp()) RETURNING id, created_at`,
+		entry.Type, entry.Description, fxRate, reversalOf,
+	).Scan(&entry.ID, &entry.CreatedAt); err != nil {
+		return fmt.Errorf("insert journal entry: %w", err)
+	}
+
+	for i := range entry.Postings {
+		p := &entry.Postings[i]
+		p.EntryID = entry.ID
+
+		var accountID sql.NullInt64
+		var systemAccount sql.NullString
+		if p.AccountID != 0 {
+			acc := locked[p.AccountID]
+			if acc.Status != models.AccountStatusActive {
+				return fmt.Errorf("%w: account %d is %s", models.ErrAccountInactive, acc.ID, acc.Status)
+			}
+			if p.Amount.Currency != acc.Currency {
+				return fmt.Errorf("%w: posting in %s to account %d in %s",
+					models.ErrCurrencyMismatch, p.Amount.Currency, acc.ID, acc.Currency)
+			}
+			accountID = sql.NullInt64{Int64: int64(p.AccountID), Valid: true}
+		} else {
+			systemAccount = sql.NullString{String: p.SystemAccount, Valid: true}
+		}
+
+		if err := tx.QueryRowContext(ctx,
+			`INSERT INTO postings (entry_id, account_id, system_account, amount, currency)
+			 VALUES ($1, $2, $3, $4, $5) RETURNING id`,
+			entry.ID, accountID, systemAccount, p.Amount, p.Amount.Currency,
+		).Scan(&p.ID); err != nil {
+			return fmt.Errorf("insert posting: %w", err)
+		}
+
+		if p.AccountID == 0 {
+			continue
+		}
+		if err := applyPosting(ctx, tx, entry, p, locked[p.AccountID]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyPosting обновляет кешированный баланс счета и пишет строку выписки
+// с остатком после операции.
+// Клиентские счета не могут уходить в минус, а списания — в заблокированную сумму.
+func applyPosting(ctx context.Context, tx *sql.Tx, entry *models.JournalEntry, p *models.Posting, acc *models.Account) error {
+	var balance models.Money
+	if err := tx.QueryRowContext(ctx,
+		`UPDATE accounts SET balance = balance + $1 WHERE id = $2 RETURNING balance`,
+		p.Amount, p.AccountID,
+	).Scan(&balance); err != nil {
+		return fmt.Errorf("update balance of %d: %w", p.AccountID, err)
+	}
+	// Списание не может затрагивать сумму, заблокированную авторизациями по картам.
+	if balance.IsNegative() || (p.Amount.IsNegative() && balance.Minor < acc.Held.Minor) {
+		return fmt.Errorf("%w on account %d", models.ErrInsufficientFunds, p.AccountID)
+	}
+	acc.Balance = balance.WithCurrency(acc.Currency)
+
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO transactions (account_id, entry_id, amount, balance_after, type, fx_rate, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		p.AccountID, entry.ID, p.Amount, balance, entry.Type,
+		sql.NullString{String: entry.FXRate, Valid: entry.FXRate != ""}, entry.CreatedAt,
+	); err != nil {
+		return fmt.Errorf("insert transaction: %w", err)
+	}
+	return nil
+}
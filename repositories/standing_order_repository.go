@@ -0,0 +1,230 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"bank-api/models"
+)
+
+// StandingOrderRepository хранит постоянные поручения и историю их запусков.
+type StandingOrderRepository interface {
+	Create(o *models.StandingOrder) error
+	// GetByID возвращает поручение; ErrStandingOrderNotFound, если его нет.
+	GetByID(id int) (*models.StandingOrder, error)
+	ListByUser(userID int) ([]*models.StandingOrder, error)
+	// Update сохраняет параметры, расписание и статус поручения.
+	Update(o *models.StandingOrder) error
+	// ClaimDue выбирает до limit активных поручений, срок которых наступил к now,
+	// и арендует их на lease, чтобы параллельные экземпляры шедулера их пропустили.
+	ClaimDue(now time.Time, lease time.Duration, limit int) ([]*models.StandingOrder, error)
+	// StartRun записывает запуск run со статусом started до перевода. Если у поручения уже
+	// есть незавершенный запуск (прежнее исполнение прервалось), возвращает false и
+	// заполняет run его данными.
+	StartRun(run *models.StandingOrderRun) (bool, error)
+	// RecordRun сохраняет результат начатого запуска вместе с новым состоянием поручения
+	// и снимает аренду. Статус поручения меняется, только если оно все еще активно:
+	// отмена или приостановка во время исполнения сохраняются.
+	RecordRun(o *models.StandingOrder, run *models.StandingOrderRun) error
+	// ListRuns возвращает последние limit запусков поручения, от новых к старым.
+	ListRuns(orderID, limit int) ([]models.StandingOrderRun, error)
+}
+
+type standingOrderRepository struct {
+	db *sql.DB
+}
+
+// NewStandingOrderRepository возвращает реализацию StandingOrderRepository.
+func NewStandingOrderRepository(db *sql.DB) StandingOrderRepository {
+	return &standingOrderRepository{db: db}
+}
+
+// standingOrderColumns — столбцы, которые читает scanStandingOrder.
+const standingOrderColumns = `id, user_id, from_account_id, to_account_id, amount, currency, frequency, cron_expr,
+	start_at, next_run_at, retry_at, failure_count, status, created_at, updated_at`
+
+func (r *standingOrderRepository) Create(o *models.StandingOrder) error {
+	err := r.db.QueryRow(
+		`INSERT INTO standing_orders (user_id, from_account_id, to_account_id, amount, currency,
+			frequency, cron_expr, start_at, next_run_at, status, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NOW(), NOW())
+		 RETURNING id, created_at, updated_at`,
+		o.UserID, o.FromAccountID, o.ToAccountID, o.Amount, o.Amount.Currency,
+		o.Frequency, o.CronExpr, o.StartAt, o.NextRunAt, o.Status,
+	).Scan(&o.ID, &o.CreatedAt, &o.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("error inserting standing order: %w", err)
+	}
+	return nil
+}
+
+func (r *standingOrderRepository) GetByID(id int) (*models.StandingOrder, error) {
+	row := r.db.QueryRow(`SELECT `+standingOrderColumns+` FROM standing_orders WHERE id = $1`, id)
+	o, err := scanStandingOrder(row)
+	if err == sql.ErrNoRows {
+		return nil, models.ErrStandingOrderNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error fetching standing order: %w", err)
+	}
+	return o, nil
+}
+
+func (r *standingOrderRepository) ListByUser(userID int) ([]*models.StandingOrder, error) {
+	rows, err := r.db.Query(
+		`SELECT `+standingOrderColumns+` FROM standing_orders WHERE user_id = $1 ORDER BY id`, userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching standing orders: %w", err)
+	}
+	return collectStandingOrders(rows)
+}
+
+func (r *standingOrderRepository) Update(o *models.StandingOrder) error {
+	err := r.db.QueryRow(
+		`UPDATE standing_orders SET to_account_id = $1, amount = $2, frequency = $3, cron_expr = $4,
+			start_at = $5, next_run_at = $6, retry_at = $7, failure_count = $8, status = $9, updated_at = NOW()
+		 WHERE id = $10 RETURNING updated_at`,
+		o.ToAccountID, o.Amount, o.Frequency, o.CronExpr,
+		o.StartAt, o.NextRunAt, o.RetryAt, o.FailureCount, o.Status, o.ID,
+	).Scan(&o.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return models.ErrStandingOrderNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("error updating standing order: %w", err)
+	}
+	return nil
+}
+
+func (r *standingOrderRepository) ClaimDue(now time.Time, lease time.Duration, limit int) ([]*models.StandingOrder, error) {
+	rows, err := r.db.Query(
+		`UPDATE standing_orders SET claimed_until = $2
+		 WHERE id IN (
+			SELECT id FROM standing_orders
+			WHERE status = 'active' AND COALESCE(retry_at, next_run_at) <= $1
+			  AND (claimed_until IS NULL OR claimed_until < $1)
+			ORDER BY COALESCE(retry_at, next_run_at)
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED)
+		 RETURNING `+standingOrderColumns,
+		now, now.Add(lease), limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error claiming standing orders: %w", err)
+	}
+	return collectStandingOrders(rows)
+}
+
+func (r *standingOrderRepository) StartRun(run *models.StandingOrderRun) (bool, error) {
+	run.Status = models.StandingOrderRunStarted
+	err := r.db.QueryRow(
+		`INSERT INTO standing_order_runs (order_id, scheduled_at, attempt, status, created_at)
+		 VALUES ($1, $2, $3, $4, NOW())
+		 ON CONFLICT (order_id) WHERE status = 'started' DO NOTHING
+		 RETURNING id, created_at`,
+		run.OrderID, run.ScheduledAt, run.Attempt, run.Status,
+	).Scan(&run.ID, &run.CreatedAt)
+	if err == nil {
+		return true, nil
+	}
+	if err != sql.ErrNoRows {
+		return false, fmt.Errorf("insert standing order run: %w", err)
+	}
+	if err := r.db.QueryRow(
+		`SELECT id, scheduled_at, attempt, created_at FROM standing_order_runs
+		 WHERE order_id = $1 AND status = 'started'`, run.OrderID,
+	).Scan(&run.ID, &run.ScheduledAt, &run.Attempt, &run.CreatedAt); err != nil {
+		return false, fmt.Errorf("fetch interrupted standing order run: %w", err)
+	}
+	return false, nil
+}
+
+func (r *standingOrderRepository) RecordRun(o *models.StandingOrder, run *models.StandingOrderRun) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	entryID := sql.NullInt64{Int64: int64(run.EntryID), Valid: run.EntryID != 0}
+	if _, err := tx.Exec(
+		`UPDATE standing_order_runs SET status = $1, error = $2, entry_id = $3 WHERE id = $4`,
+		run.Status, run.Error, entryID, run.ID,
+	); err != nil {
+		return fmt.Errorf("update standing order run: %w", err)
+	}
+	if err := tx.QueryRow(
+		`UPDATE standing_orders SET next_run_at = $1, retry_at = $2, failure_count = $3,
+			status = CASE WHEN status = 'active' THEN $4 ELSE status END,
+			claimed_until = NULL, updated_at = NOW()
+		 WHERE id = $5 RETURNING status, updated_at`,
+		o.NextRunAt, o.RetryAt, o.FailureCount, o.Status, o.ID,
+	).Scan(&o.Status, &o.UpdatedAt); err != nil {
+		return fmt.Errorf("update standing order: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
+
+func (r *standingOrderRepository) ListRuns(orderID, limit int) ([]models.StandingOrderRun, error) {
+	rows, err := r.db.Query(
+		`SELECT id, order_id, scheduled_at, attempt, status, error, COALESCE(entry_id, 0), created_at
+		 FROM standing_order_runs WHERE order_id = $1
+		 ORDER BY created_at DESC, id DESC LIMIT $2`,
+		orderID, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching standing order runs: %w", err)
+	}
+	defer rows.Close()
+
+	runs := []models.StandingOrderRun{}
+	for rows.Next() {
+		var run models.StandingOrderRun
+		if err := rows.Scan(&run.ID, &run.OrderID, &run.ScheduledAt, &run.Attempt,
+			&run.Status, &run.Error, &run.EntryID, &run.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning standing order run: %w", err)
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
+
+// rowScanner — общий интерфейс *sql.Row и *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanStandingOrder читает строку standingOrderColumns.
+func scanStandingOrder(row rowScanner) (*models.StandingOrder, error) {
+	o := &models.StandingOrder{}
+	var currency string
+	var retryAt sql.NullTime
+	if err := row.Scan(&o.ID, &o.UserID, &o.FromAccountID, &o.ToAccountID, &o.Amount, &currency,
+		&o.Frequency, &o.CronExpr, &o.StartAt, &o.NextRunAt, &retryAt, &o.FailureCount,
+		&o.Status, &o.CreatedAt, &o.UpdatedAt); err != nil {
+		return nil, err
+	}
+	o.Amount.Currency = currency
+	if retryAt.Valid {
+		o.RetryAt = &retryAt.Time
+	}
+	return o, nil
+}
+
+func collectStandingOrders(rows *sql.Rows) ([]*models.StandingOrder, error) {
+	defer rows.Close()
+	orders := []*models.StandingOrder{}
+	for rows.Next() {
+		o, err := scanStandingOrder(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning standing order: %w", err)
+		}
+		orders = append(orders, o)
+	}
+	return orders, rows.Err()
+}
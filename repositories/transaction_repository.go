@@ -1,8 +1,10 @@
 package repositories
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
 	"bank-api/models"
 )
@@ -11,7 +13,15 @@ import (
 type TransactionRepository interface {
 	Create(transaction *models.Transaction) error
 	GetByAccountID(accountID int) ([]models.Transaction, error)
-	SumByType(userID int, txType string, since time.Time) (float64, error)
+	// ListByAccount возвращает операции счета от новых к старым с учетом фильтра.
+	ListByAccount(accountID int, filter models.TransactionFilter) ([]models.Transaction, error)
+	// SumByType суммирует модули операций типа txType по счетам, в которых участвует пользователь.
+	SumByType(userID int, txType string, since time.Time) (models.Money, error)
+	// Statement читает выписку счета за [from, to) в одном снимке БД: begin получает
+	// остатки на начало и конец периода, затем each вызывается для каждой операции
+	// по возрастанию времени без загрузки всего периода в память.
+	Statement(ctx context.Context, accountID int, from, to time.Time,
+		begin func(opening, closing models.Money) error, each func(models.Transaction) error) error
 
 }
 
@@ -38,13 +48,62 @@ func (r *transactionRepository) Create(transaction *models.Transaction) error {
 	return nil
 }
 
-// GetByAccountID возвращает все транзакции по ID счета.
+// transactionColumns — столбцы, которые читает scanTransaction.
+const transactionColumns = `t.id, t.account_id, COALESCE(t.entry_id, 0), t.amount, COALESCE(t.balance_after, 0), a.currency, t.type, COALESCE(t.fx_rate::text, ''), t.created_at`
+
+// GetByAccountID возвращает все транзакции по ID счета, от новых к старым.
 func (r *transactionRepository) GetByAccountID(accountID int) ([]models.Transaction, error) {
 	query := `
-		SELECT id, account_id, amount, type, created_at
-		FROM transactions WHERE account_id = $1
+		SELECT ` + transactionColumns + `
+		FROM transactions t JOIN accounts a ON a.id = t.account_id
+		WHERE t.account_id = $1
+		ORDER BY t.created_at DESC, t.id DESC
 	`
-	rows, err := r.db.Query(query, accountID)
+	return r.queryTransactions(query, accountID)
+}
+
+// ListByAccount строит выборку с keyset-пагинацией по (created_at, id).
+// Фильтр по сумме применяется к модулю суммы операции.
+func (r *transactionRepository) ListByAccount(accountID int, filter models.TransactionFilter) ([]models.Transaction, error) {
+	conds := []string{"t.account_id = $1"}
+	args := []interface{}{accountID}
+	add := func(cond string, arg interface{}) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+	if !filter.From.IsZero() {
+		add("t.created_at >= $%d", filter.From)
+	}
+	if !filter.To.IsZero() {
+		add("t.created_at < $%d", filter.To)
+	}
+	if filter.Type != "" {
+		add("t.type = $%d", filter.Type)
+	}
+	if filter.MinAmount != nil {
+		add("ABS(t.amount) >= $%d", *filter.MinAmount)
+	}
+	if filter.MaxAmount != nil {
+		add("ABS(t.amount) <= $%d", *filter.MaxAmount)
+	}
+	if filter.After != nil {
+		args = append(args, filter.After.CreatedAt, filter.After.ID)
+		conds = append(conds, fmt.Sprintf("(t.created_at, t.id) < ($%d, $%d)", len(args)-1, len(args)))
+	}
+	query := `
+		SELECT ` + transactionColumns + `
+		FROM transactions t JOIN accounts a ON a.id = t.account_id
+		WHERE ` + strings.Join(conds, " AND ") + `
+		ORDER BY t.created_at DESC, t.id DESC`
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+	return r.queryTransactions(query, args...)
+}
+
+func (r *transactionRepository) queryTransactions(query string, args ...interface{}) ([]models.Transaction, error) {
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("error fetching transactions: %w", err)
 	}
@@ -52,24 +111,90 @@ func (r *transactionRepository) GetByAccountID(accountID int) ([]models.Transact
 
 	var transactions []models.Transaction
 	for rows.Next() {
-		var t models.Transaction
-		if err := rows.Scan(&t.ID, &t.AccountID, &t.Amount, &t.Type, &t.CreatedAt); err != nil {
-			return nil, fmt.Errorf("error scanning transaction: %w", err)
+		t, err := scanTransaction(rows)
+		if err != nil {
+			return nil, err
 		}
 		transactions = append(transactions, t)
 	}
-	return transactions, nil
+	return transactions, rows.Err()
+}
+
+// scanTransaction читает строку transactionColumns; валюта сумм берется из счета.
+func scanTransaction(rows *sql.Rows) (models.Transaction, error) {
+	var t models.Transaction
+	var currency string
+	if err := rows.Scan(&t.ID, &t.AccountID, &t.EntryID, &t.Amount, &t.BalanceAfter, &currency, &t.Type, &t.FXRate, &t.CreatedAt); err != nil {
+		return t, fmt.Errorf("error scanning transaction: %w", err)
+	}
+	t.Amount.Currency = currency
+	t.BalanceAfter.Currency = currency
+	return t, nil
 }
 
-func (r *transactionRepository) SumByType(userID int, txType string, since time.Time) (float64, error) {
-	var sum sql.NullFloat64
+// SumByType возвращает сумму модулей операций типа txType по счетам, в которых участвует пользователь.
+func (r *transactionRepository) SumByType(userID int, txType string, since time.Time) (models.Money, error) {
+	var sum models.Money
 	err := r.db.QueryRow(
-		`SELECT COALESCE(SUM(amount),0) FROM transactions
-		 WHERE user_id = $1 AND type = $2 AND created_at >= $3`,
+		`SELECT COALESCE(SUM(ABS(t.amount)),0) FROM transactions t
+		 JOIN account_members m ON m.account_id = t.account_id
+		 WHERE m.user_id = $1 AND t.type = $2 AND t.created_at >= $3`,
 		userID, txType, since,
 	).Scan(&sum)
 	if err != nil {
-		return 0, err
+		return models.Money{}, err
 	}
-	return sum.Float64, nil
-}
\ No newline at end of file
+	return sum, nil
+}
+
+// Statement читает выписку счета за период в одном снимке БД и отдает операции по одной.
+func (r *transactionRepository) Statement(ctx context.Context, accountID int, from, to time.Time,
+	begin func(opening, closing models.Money) error, each func(models.Transaction) error) error {
+	// REPEATABLE READ: остатки и строки выписки читаются из одного снимка,
+	// даже если во время выгрузки по счету проходят новые операции.
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	var opening, closing models.Money
+	if err := tx.QueryRowContext(ctx, `
+		SELECT
+			COALESCE((SELECT balance_after FROM transactions
+			          WHERE account_id = $1 AND created_at < $2
+			          ORDER BY created_at DESC, id DESC LIMIT 1), 0),
+			COALESCE((SELECT balance_after FROM transactions
+			          WHERE account_id = $1 AND created_at < $3
+			          ORDER BY created_at DESC, id DESC LIMIT 1), 0)`,
+		accountID, from, to,
+	).Scan(&opening, &closing); err != nil {
+		return fmt.Errorf("error fetching statement balances: %w", err)
+	}
+	if err := begin(opening, closing); err != nil {
+		return err
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT `+transactionColumns+`
+		FROM transactions t JOIN accounts a ON a.id = t.account_id
+		WHERE t.account_id = $1 AND t.created_at >= $2 AND t.created_at < $3
+		ORDER BY t.created_at, t.id`,
+		accountID, from, to,
+	)
+	if err != nil {
+		return fmt.Errorf("error fetching statement transactions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		t, err := scanTransaction(rows)
+		if err != nil {
+			return err
+		}
+		if err := each(t); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
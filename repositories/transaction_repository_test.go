@@ -0,0 +1,128 @@
+package repositories_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"bank-api/models"
+	"bank-api/repositories"
+
+	_ "github.com/lib/pq"
+)
+
+// openTestDB открывает базу с примененными миграциями из TEST_DATABASE_URL;
+// без нее тест пропускается.
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestConcurrentPostingsKeepBalanceChainOrder(t *testing.T) {
+	db := openTestDB(t)
+	now := time.Now()
+	user := &models.User{
+		Email:        fmt.Sprintf("postings-%d@example.com", now.UnixNano()),
+		Username:     fmt.Sprintf("postings-%d", now.UnixNano()),
+		PasswordHash: "x",
+		CreatedAt:    now,
+	}
+	if err := repositories.NewUserRepository(db).Create(user); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	accountRepo := repositories.NewAccountRepository(db)
+	acc := &models.Account{
+		UserID:   user.ID,
+		Number:   fmt.Sprintf("40817810%012d", now.UnixNano()%1e12),
+		Type:     models.AccountTypeCurrent,
+		Balance:  models.NewMoney(0, "RUB"),
+		Currency: "RUB",
+		Status:   models.AccountStatusActive,
+	}
+	if err := accountRepo.Create(acc); err != nil {
+		t.Fatalf("create account: %v", err)
+	}
+
+	// Пополнения идут параллельно и ждут друг друга на блокировке счета.
+	const postings = 20
+	from := time.Now().Add(-time.Minute)
+	var wg sync.WaitGroup
+	errs := make(chan error, postings)
+	for i := 1; i <= postings; i++ {
+		wg.Add(1)
+		go func(minor int64) {
+			defer wg.Done()
+			if _, err := accountRepo.UpdateBalance(user.ID, acc.ID, models.NewMoney(minor, ""), nil); err != nil {
+				errs <- err
+			}
+		}(int64(i * 100))
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatalf("deposit: %v", err)
+	}
+
+	// История от новых к старым: остаток каждой операции — остаток предыдущей плюс ее сумма.
+	txRepo := repositories.NewTransactionRepository(db)
+	history, err := txRepo.ListByAccount(acc.ID, models.TransactionFilter{})
+	if err != nil {
+		t.Fatalf("list transactions: %v", err)
+	}
+	if len(history) != postings {
+		t.Fatalf("expected %d transactions, got %d", postings, len(history))
+	}
+	for i := 0; i < len(history)-1; i++ {
+		newer, older := history[i], history[i+1]
+		if newer.BalanceAfter.Minor != older.BalanceAfter.Minor+newer.Amount.Minor {
+			t.Fatalf("history breaks the balance chain at %d: %s after %s + %s",
+				i, newer.BalanceAfter, older.BalanceAfter, newer.Amount)
+		}
+	}
+
+	// Курсор по (created_at, id) отдает продолжение той же цепочки.
+	page, err := txRepo.ListByAccount(acc.ID, models.TransactionFilter{Limit: 5})
+	if err != nil {
+		t.Fatalf("list first page: %v", err)
+	}
+	last := page[len(page)-1]
+	rest, err := txRepo.ListByAccount(acc.ID, models.TransactionFilter{
+		After: &models.TransactionCursor{CreatedAt: last.CreatedAt, ID: last.ID},
+	})
+	if err != nil {
+		t.Fatalf("list next page: %v", err)
+	}
+	if len(rest) != postings-5 || rest[0].ID != history[5].ID {
+		t.Errorf("cursor continues with %d rows from %d, expected %d rows from %d", len(rest), rest[0].ID, postings-5, history[5].ID)
+	}
+
+	var opening, closing models.Money
+	var balances []int64
+	err = txRepo.Statement(context.Background(), acc.ID, from, time.Now().Add(time.Minute),
+		func(o, c models.Money) error { opening, closing = o, c; return nil },
+		func(tr models.Transaction) error { balances = append(balances, tr.BalanceAfter.Minor); return nil })
+	if err != nil {
+		t.Fatalf("statement: %v", err)
+	}
+	if !opening.IsZero() || closing.Minor != history[0].BalanceAfter.Minor {
+		t.Errorf("expected statement balances 0.00 and %s, got %s and %s", history[0].BalanceAfter, opening, closing)
+	}
+	for i := 1; i < len(balances); i++ {
+		if balances[i] <= balances[i-1] {
+			t.Fatalf("statement balances are not increasing at %d: %v", i, balances)
+		}
+	}
+}
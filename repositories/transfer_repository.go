@@ -0,0 +1,189 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math/big"
+
+	"bank-api/models"
+)
+
+// TransferRepository хранит переводы между счетами и их возвраты.
+// Сами переводы создает AccountRepository.TransferTx.
+type TransferRepository interface {
+	// GetByID возвращает перевод; ErrTransferNotFound, если его нет.
+	GetByID(id int) (*models.Transfer, error)
+	// ReverseTx проводит возврат rev.Amount по переводу rev.TransferID компенсирующей
+	// записью журнала и возвращает перевод с обновленными суммами возвратов.
+	// Нулевая rev.Amount означает возврат всего остатка.
+	ReverseTx(ctx context.Context, rev *models.TransferReversal) (*models.Transfer, error)
+	// ListReversals возвращает возвраты по переводу в порядке проведения.
+	ListReversals(transferID int) ([]models.TransferReversal, error)
+}
+
+type transferRepository struct {
+	db *sql.DB
+}
+
+// NewTransferRepository возвращает реализацию TransferRepository.
+func NewTransferRepository(db *sql.DB) TransferRepository {
+	return &transferRepository{db: db}
+}
+
+// transferColumns — столбцы, которые читает scanTransfer.
+const transferColumns = `id, user_id, from_account_id, to_account_id, amount, currency,
+	credited_amount, credited_currency, COALESCE(fx_rate::text, ''), entry_id,
+	reversed_amount, reversed_credited_amount, created_at`
+
+func (r *transferRepository) GetByID(id int) (*models.Transfer, error) {
+	t, err := scanTransfer(r.db.QueryRow(`SELECT `+transferColumns+` FROM transfers WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, models.ErrTransferNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error fetching transfer: %w", err)
+	}
+	return t, nil
+}
+
+// ReverseTx блокирует запись перевода (SELECT ... FOR UPDATE), поэтому параллельные
+// возвраты одного перевода проводятся по очереди и не превышают его сумму.
+// Списание с получателя пропорционально доле возврата в невозвращенном остатке
+// и считается от невозвращенного остатка зачисления, чтобы округления частичных
+// возвратов не исчерпали его раньше времени; при возврате остатка списывается
+// весь остаток зачисления. Возврат, для которого списание округляется до нуля
+// или забирает весь остаток зачисления раньше последнего возврата, отклоняется
+// с ErrInvalidAmount.
+func (r *transferRepository) ReverseTx(ctx context.Context, rev *models.TransferReversal) (*models.Transfer, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	t, err := scanTransfer(tx.QueryRowContext(ctx,
+		`SELECT `+transferColumns+` FROM transfers WHERE id = $1 FOR UPDATE`, rev.TransferID,
+	))
+	if err == sql.ErrNoRows {
+		return nil, models.ErrTransferNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("lock transfer %d: %w", rev.TransferID, err)
+	}
+
+	remaining := t.Remaining()
+	if !remaining.IsPositive() {
+		return nil, models.ErrTransferFullyReversed
+	}
+	amount := remaining
+	if !rev.Amount.IsZero() {
+		if rev.Amount.Currency != "" && rev.Amount.Currency != t.Currency {
+			return nil, fmt.Errorf("%w: %s and %s", models.ErrCurrencyMismatch, rev.Amount.Currency, t.Currency)
+		}
+		amount = rev.Amount.WithCurrency(t.Currency)
+	}
+	if !amount.IsPositive() {
+		return nil, models.ErrInvalidAmount
+	}
+	if cmp, _ := amount.Cmp(remaining); cmp > 0 {
+		return nil, fmt.Errorf("%w: %s left", models.ErrReversalExceedsBalance, remaining)
+	}
+
+	creditedRemaining, _ := t.CreditedAmount.Sub(t.ReversedCreditedAmount)
+	debited := creditedRemaining
+	if cmp, _ := amount.Cmp(remaining); cmp < 0 {
+		share := new(big.Rat).SetFrac64(amount.Minor, remaining.Minor)
+		debited = creditedRemaining.MulRat(share, models.RoundHalfEven)
+		if cmp, _ := debited.Cmp(creditedRemaining); cmp >= 0 {
+			return nil, fmt.Errorf("%w: reversal would debit the whole %s left, reverse the remaining %s instead",
+				models.ErrInvalidAmount, creditedRemaining, remaining)
+		}
+	}
+	if !debited.IsPositive() {
+		return nil, fmt.Errorf("%w: reversal of %s debits nothing from the recipient", models.ErrInvalidAmount, amount)
+	}
+
+	entry := &models.JournalEntry{
+		Type:        models.EntryTypeReversal,
+		Description: fmt.Sprintf("reversal of transfer %d", t.ID),
+		ReversalOf:  t.EntryID,
+	}
+	if t.Currency == t.CreditedCurrency {
+		entry.Postings = []models.Posting{
+			{AccountID: t.ToAccountID, Amount: debited.Neg()},
+			{AccountID: t.FromAccountID, Amount: amount},
+		}
+	} else {
+		entry.FXRate = t.FXRate
+		entry.Postings = []models.Posting{
+			{AccountID: t.ToAccountID, Amount: debited.Neg()},
+			{SystemAccount: models.SystemAccountFXPosition, Amount: debited},
+			{SystemAccount: models.SystemAccountFXPosition, Amount: amount.Neg()},
+			{AccountID: t.FromAccountID, Amount: amount},
+		}
+	}
+	if err := postEntry(ctx, tx, entry, nil); err != nil {
+		return nil, err
+	}
+
+	if err := tx.QueryRowContext(ctx,
+		`INSERT INTO transfer_reversals (transfer_id, user_id, amount, debited_amount, reason, entry_id, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`,
+		t.ID, rev.UserID, amount, debited, rev.Reason, entry.ID, entry.CreatedAt,
+	).Scan(&rev.ID); err != nil {
+		return nil, fmt.Errorf("insert transfer reversal: %w", err)
+	}
+	t.ReversedAmount, _ = t.ReversedAmount.Add(amount)
+	t.ReversedCreditedAmount, _ = t.ReversedCreditedAmount.Add(debited)
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE transfers SET reversed_amount = $1, reversed_credited_amount = $2 WHERE id = $3`,
+		t.ReversedAmount, t.ReversedCreditedAmount, t.ID,
+	); err != nil {
+		return nil, fmt.Errorf("update transfer %d: %w", t.ID, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit tx: %w", err)
+	}
+	rev.Amount, rev.DebitedAmount = amount, debited
+	rev.EntryID, rev.CreatedAt = entry.ID, entry.CreatedAt
+	return t, nil
+}
+
+func (r *transferRepository) ListReversals(transferID int) ([]models.TransferReversal, error) {
+	rows, err := r.db.Query(
+		`SELECT tr.id, tr.transfer_id, tr.user_id, tr.amount, t.currency, tr.debited_amount, t.credited_currency,
+			tr.reason, tr.entry_id, tr.created_at
+		 FROM transfer_reversals tr JOIN transfers t ON t.id = tr.transfer_id
+		 WHERE tr.transfer_id = $1 ORDER BY tr.id`, transferID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching transfer reversals: %w", err)
+	}
+	defer rows.Close()
+
+	var reversals []models.TransferReversal
+	for rows.Next() {
+		var rev models.TransferReversal
+		if err := rows.Scan(&rev.ID, &rev.TransferID, &rev.UserID, &rev.Amount, &rev.Amount.Currency,
+			&rev.DebitedAmount, &rev.DebitedAmount.Currency, &rev.Reason, &rev.EntryID, &rev.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning transfer reversal: %w", err)
+		}
+		reversals = append(reversals, rev)
+	}
+	return reversals, rows.Err()
+}
+
+// scanTransfer читает строку transferColumns; валюта сумм берется из столбцов перевода.
+func scanTransfer(row rowScanner) (*models.Transfer, error) {
+	t := &models.Transfer{}
+	if err := row.Scan(&t.ID, &t.UserID, &t.FromAccountID, &t.ToAccountID, &t.Amount, &t.Currency,
+		&t.CreditedAmount, &t.CreditedCurrency, &t.FXRate, &t.EntryID,
+		&t.ReversedAmount, &t.ReversedCreditedAmount, &t.CreatedAt); err != nil {
+		return nil, err
+	}
+	t.Amount.Currency, t.ReversedAmount.Currency = t.Currency, t.Currency
+	t.CreditedAmount.Currency, t.ReversedCreditedAmount.Currency = t.CreditedCurrency, t.CreditedCurrency
+	return t, nil
+}
@@ -0,0 +1,229 @@
+package repositories_test
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"bank-api/models"
+	"bank-api/repositories"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+var transferColumns = []string{"id", "user_id", "from_account_id", "to_account_id", "amount", "currency",
+	"credited_amount", "credited_currency", "fx_rate", "entry_id", "reversed_amount", "reversed_credited_amount", "created_at"}
+
+const lockTransferQuery = `FROM transfers WHERE id = $1 FOR UPDATE`
+
+func TestReverseTx_PartialFXReversalIsProportional(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %v", err)
+	}
+	defer db.Close()
+	repo := repositories.NewTransferRepository(db)
+	now := time.Now()
+
+	// Перевод 10.00 USD -> 911.25 RUB; возвращается 3.33 USD, с получателя списывается 303.45 RUB.
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(lockTransferQuery)).WithArgs(5).
+		WillReturnRows(sqlmock.NewRows(transferColumns).
+			AddRow(5, 1, 1, 2, "10.00", "USD", "911.25", "RUB", "91.125601", 12, "0.00", "0.00", now))
+	mock.ExpectQuery(regexp.QuoteMeta(lockAccountQuery)).WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(accountColumns).AddRow(1, 1, "90.00", "USD", "active", now, "", "current", "0.00"))
+	mock.ExpectQuery(regexp.QuoteMeta(lockAccountQuery)).WithArgs(2).
+		WillReturnRows(sqlmock.NewRows(accountColumns).AddRow(2, 2, "911.25", "RUB", "active", now, "", "current", "0.00"))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO journal_entries`)).
+		WithArgs("reversal", sqlmock.AnyArg(), "91.125601", 12).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(20, now))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO postings`)).
+		WithArgs(20, sqlmock.AnyArg(), sqlmock.AnyArg(), "-303.45", "RUB").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery(regexp.QuoteMeta(`UPDATE accounts SET balance`)).
+		WithArgs("-303.45", 2).
+		WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow("607.80"))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO transactions`)).
+		WithArgs(2, 20, "-303.45", "607.80", "reversal", "91.125601", now).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO postings`)).
+		WithArgs(20, sqlmock.AnyArg(), "fx_position", "303.45", "RUB").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO postings`)).
+		WithArgs(20, sqlmock.AnyArg(), "fx_position", "-3.33", "USD").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO postings`)).
+		WithArgs(20, sqlmock.AnyArg(), sqlmock.AnyArg(), "3.33", "USD").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+	mock.ExpectQuery(regexp.QuoteMeta(`UPDATE accounts SET balance`)).
+		WithArgs("3.33", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow("93.33"))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO transactions`)).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO transfer_reversals`)).
+		WithArgs(5, 1, "3.33", "303.45", "duplicate", 20, now).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE transfers SET reversed_amount = $1, reversed_credited_amount = $2 WHERE id = $3`)).
+		WithArgs("3.33", "303.45", 5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	rev := &models.TransferReversal{TransferID: 5, UserID: 1, Amount: models.NewMoney(333, "USD"), Reason: "duplicate"}
+	transfer, err := repo.ReverseTx(context.Background(), rev)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rev.DebitedAmount.String() != "303.45" || rev.EntryID != 20 {
+		t.Errorf("expected 303.45 debited by entry 20, got %s by %d", rev.DebitedAmount, rev.EntryID)
+	}
+	if remaining := transfer.Remaining(); remaining.String() != "6.67" {
+		t.Errorf("expected 6.67 left to reverse, got %s", remaining)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+// expectFXReversal ожидает возврат amount RUB по переводу 5 (10.00 RUB -> 0.05 USD, уже возвращено
+// reversed / reversedCredited) со списанием debited USD с получателя записью entryID;
+// after и creditedAfter — суммы возвратов перевода после проведения.
+func expectFXReversal(mock sqlmock.Sqlmock, now time.Time, reversed, reversedCredited, amount, debited string,
+	entryID int, after, creditedAfter string) {
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(lockTransferQuery)).WithArgs(5).
+		WillReturnRows(sqlmock.NewRows(transferColumns).
+			AddRow(5, 1, 1, 2, "10.00", "RUB", "0.05", "USD", "0.005", 12, reversed, reversedCredited, now))
+	mock.ExpectQuery(regexp.QuoteMeta(lockAccountQuery)).WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(accountColumns).AddRow(1, 1, "90.00", "RUB", "active", now, "", "current", "0.00"))
+	mock.ExpectQuery(regexp.QuoteMeta(lockAccountQuery)).WithArgs(2).
+		WillReturnRows(sqlmock.NewRows(accountColumns).AddRow(2, 2, "1.00", "USD", "active", now, "", "current", "0.00"))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO journal_entries`)).
+		WithArgs("reversal", sqlmock.AnyArg(), "0.005", 12).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(entryID, now))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO postings`)).
+		WithArgs(entryID, 2, nil, "-"+debited, "USD").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery(regexp.QuoteMeta(`UPDATE accounts SET balance`)).
+		WithArgs("-"+debited, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow("0.99"))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO transactions`)).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO postings`)).
+		WithArgs(entryID, nil, "fx_position", debited, "USD").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO postings`)).
+		WithArgs(entryID, nil, "fx_position", "-"+amount, "RUB").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO postings`)).
+		WithArgs(entryID, 1, nil, amount, "RUB").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+	mock.ExpectQuery(regexp.QuoteMeta(`UPDATE accounts SET balance`)).
+		WithArgs(amount, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow("93.00"))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO transactions`)).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO transfer_reversals`)).
+		WithArgs(5, 1, amount, debited, "", entryID, now).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(entryID))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE transfers SET reversed_amount = $1, reversed_credited_amount = $2 WHERE id = $3`)).
+		WithArgs(after, creditedAfter, 5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+}
+
+func TestReverseTx_PartialFXReversalsKeepCreditedRemainder(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %v", err)
+	}
+	defer db.Close()
+	repo := repositories.NewTransferRepository(db)
+	now := time.Now()
+
+	// Доли от исходного зачисления (0.015 -> 0.02, 0.015 -> 0.02, 0.01) исчерпали бы 0.05 USD
+	// до последнего возврата; доли от остатка оставляют ему 0.01 USD.
+	steps := []struct {
+		amount, debited string
+		minor           int64
+	}{
+		{"3.00", "0.02", 300},
+		{"3.00", "0.01", 300},
+		{"2.00", "0.01", 200},
+	}
+	reversed, credited := []string{"0.00", "3.00", "6.00", "8.00"}, []string{"0.00", "0.02", "0.03", "0.04"}
+	for i, step := range steps {
+		expectFXReversal(mock, now, reversed[i], credited[i], step.amount, step.debited, 20+i, reversed[i+1], credited[i+1])
+		rev := &models.TransferReversal{TransferID: 5, UserID: 1, Amount: models.NewMoney(step.minor, "RUB")}
+		if _, err := repo.ReverseTx(context.Background(), rev); err != nil {
+			t.Fatalf("reversal %d: unexpected error: %v", i+1, err)
+		}
+		if rev.DebitedAmount.String() != step.debited {
+			t.Errorf("reversal %d: expected %s USD debited, got %s", i+1, step.debited, rev.DebitedAmount)
+		}
+	}
+
+	// Половина остатка (0.005 USD) округляется до нуля и не проводится.
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(lockTransferQuery)).WithArgs(5).
+		WillReturnRows(sqlmock.NewRows(transferColumns).
+			AddRow(5, 1, 1, 2, "10.00", "RUB", "0.05", "USD", "0.005", 12, "8.00", "0.04", now))
+	mock.ExpectRollback()
+	rev := &models.TransferReversal{TransferID: 5, UserID: 1, Amount: models.NewMoney(100, "RUB")}
+	if _, err := repo.ReverseTx(context.Background(), rev); !errors.Is(err, models.ErrInvalidAmount) {
+		t.Errorf("expected ErrInvalidAmount, got %v", err)
+	}
+
+	// Возврат остатка списывает оставшиеся 0.01 USD.
+	expectFXReversal(mock, now, "8.00", "0.04", "2.00", "0.01", 23, "10.00", "0.05")
+	rev = &models.TransferReversal{TransferID: 5, UserID: 1}
+	transfer, err := repo.ReverseTx(context.Background(), rev)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rev.DebitedAmount.String() != "0.01" || !transfer.Remaining().IsZero() {
+		t.Errorf("expected the last 0.01 USD debited and nothing left, got %s, %s", rev.DebitedAmount, transfer.Remaining())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestReverseTx_Rejections(t *testing.T) {
+	now := time.Now()
+	cases := []struct {
+		name     string
+		reversed string
+		amount   models.Money
+		wantErr  error
+	}{
+		{"fully reversed", "25.50", models.Money{}, models.ErrTransferFullyReversed},
+		{"exceeds remaining", "20.00", models.NewMoney(600, ""), models.ErrReversalExceedsBalance},
+		{"wrong currency", "0.00", models.NewMoney(100, "USD"), models.ErrCurrencyMismatch},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("failed to open sqlmock database: %v", err)
+			}
+			defer db.Close()
+			repo := repositories.NewTransferRepository(db)
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(lockTransferQuery)).WithArgs(5).
+				WillReturnRows(sqlmock.NewRows(transferColumns).
+					AddRow(5, 1, 7, 3, "25.50", "RUB", "25.50", "RUB", "", 11, tc.reversed, tc.reversed, now))
+			mock.ExpectRollback()
+
+			rev := &models.TransferReversal{TransferID: 5, UserID: 1, Amount: tc.amount}
+			if _, err := repo.ReverseTx(context.Background(), rev); !errors.Is(err, tc.wantErr) {
+				t.Errorf("expected %v, got %v", tc.wantErr, err)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
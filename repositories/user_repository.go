@@ -2,7 +2,6 @@ package repositories
 
 import (
 	"database/sql"
-	"errors"
 	"fmt"
 	"bank-api/models"
 )
@@ -42,13 +41,13 @@ func (r *userRepository) Create(user *models.User) error {
 func (r *userRepository) GetByEmail(email string) (*models.User, error) {
 	var user models.User
 	query := `
-		SELECT id, email, username, password_hash, created_at
+		SELECT id, email, username, password_hash, role, created_at
 		FROM users WHERE email = $1
 	`
 	row := r.db.QueryRow(query, email)
-	if err := row.Scan(&user.ID, &user.Email, &user.Username, &user.PasswordHash, &user.CreatedAt); err != nil {
+	if err := row.Scan(&user.ID, &user.Email, &user.Username, &user.PasswordHash, &user.Role, &user.CreatedAt); err != nil {
 		if err == sql.ErrNoRows {
-			return nil, errors.New("user not found")
+			return nil, models.ErrUserNotFound
 		}
 		return nil, fmt.Errorf("error fetching user: %w", err)
 	}
@@ -59,13 +58,13 @@ func (r *userRepository) GetByEmail(email string) (*models.User, error) {
 func (r *userRepository) GetByID(id int) (*models.User, error) {
 	var user models.User
 	query := `
-		SELECT id, email, username, password_hash, created_at
+		SELECT id, email, username, password_hash, role, created_at
 		FROM users WHERE id = $1
 	`
 	row := r.db.QueryRow(query, id)
-	if err := row.Scan(&user.ID, &user.Email, &user.Username, &user.PasswordHash, &user.CreatedAt); err != nil {
+	if err := row.Scan(&user.ID, &user.Email, &user.Username, &user.PasswordHash, &user.Role, &user.CreatedAt); err != nil {
 		if err == sql.ErrNoRows {
-			return nil, errors.New("user not found")
+			return nil, models.ErrUserNotFound
 		}
 		return nil, fmt.Errorf("error fetching user: %w", err)
 	}
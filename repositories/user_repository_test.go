@@ -44,10 +44,10 @@ func TestUserRepository_CreateAndGet(t *testing.T) {
 
 	// Ожидаем SELECT для GetByEmail.
 	mock.ExpectQuery(regexp.QuoteMeta(
-		`SELECT id, email, username, password_hash, created_at FROM users WHERE email = $1`)).
+		`SELECT id, email, username, password_hash, role, created_at FROM users WHERE email = $1`)).
 		WithArgs(user.Email).
-		WillReturnRows(sqlmock.NewRows([]string{"id", "email", "username", "password_hash", "created_at"}).
-			AddRow(1, user.Email, user.Username, user.PasswordHash, user.CreatedAt))
+		WillReturnRows(sqlmock.NewRows([]string{"id", "email", "username", "password_hash", "role", "created_at"}).
+			AddRow(1, user.Email, user.Username, user.PasswordHash, models.RoleCustomer, user.CreatedAt))
 
 	gotUser, err := repo.GetByEmail(user.Email)
 	if err != nil {
@@ -59,10 +59,10 @@ func TestUserRepository_CreateAndGet(t *testing.T) {
 
 	// Ожидаем SELECT для GetByID.
 	mock.ExpectQuery(regexp.QuoteMeta(
-		`SELECT id, email, username, password_hash, created_at FROM users WHERE id = $1`)).
+		`SELECT id, email, username, password_hash, role, created_at FROM users WHERE id = $1`)).
 		WithArgs(1).
-		WillReturnRows(sqlmock.NewRows([]string{"id", "email", "username", "password_hash", "created_at"}).
-			AddRow(1, user.Email, user.Username, user.PasswordHash, user.CreatedAt))
+		WillReturnRows(sqlmock.NewRows([]string{"id", "email", "username", "password_hash", "role", "created_at"}).
+			AddRow(1, user.Email, user.Username, user.PasswordHash, models.RoleCustomer, user.CreatedAt))
 
 	gotUser2, err := repo.GetByID(1)
 	if err != nil {
@@ -24,6 +24,18 @@ func NewPaymentScheduler(creditSvc services.CreditService, accountSvc services.A
 	}
 }
 
+// AddJob регистрирует дополнительную периодическую задачу в cron шедулера.
+// spec задается в формате cron с секундами; ошибки задачи логируются.
+func (ps *PaymentScheduler) AddJob(spec, name string, job func() error) error {
+	_, err := ps.cronScheduler.AddFunc(spec, func() {
+		log.Printf("Starting scheduled job %q at %s", name, time.Now().Format(time.RFC3339))
+		if err := job(); err != nil {
+			log.Printf("Error in scheduled job %q: %v", name, err)
+		}
+	})
+	return err
+}
+
 // Start запускает шедулер, который каждые 12 часов обрабатывает платежи.
 func (ps *PaymentScheduler) Start() {
 	// Запланировать задачу в 00:00 и 12:00 каждую сутки.
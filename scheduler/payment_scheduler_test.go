@@ -19,7 +19,7 @@ func (f *fakeCreditService) GetCreditByID(id int) (*models.Credit, error) {
 		ID:           id,
 		UserID:       0,
 		AccountID:    0,
-		Amount:       1000.0,
+		Amount:       models.NewMoney(100000, models.DefaultCurrency),
 		InterestRate: 10.0,
 		CreatedAt:    time.Now(),
 	}, nil
@@ -36,16 +36,44 @@ func (f *fakeAccountService) CreateAccount(a *models.Account) error {
 	return nil
 }
 
-func (f *fakeAccountService) Deposit(accountID int, amount float64) error {
-	return nil
+func (f *fakeAccountService) AssignMissingNumbers() (int, error) {
+	return 0, nil
 }
 
-func (f *fakeAccountService) Withdraw(accountID int, amount float64) error {
-	return nil
+func (f *fakeAccountService) TransferToNumber(userID, fromAccountID int, toNumber string, amount models.Money) (*models.Transfer, error) {
+	return nil, nil
 }
 
-func (f *fakeAccountService) Transfer(fromAccountID, toAccountID int, amount float64) error {
-	return nil
+func (f *fakeAccountService) Deposit(userID, accountID int, amount models.Money) (*models.Account, error) {
+	return nil, nil
+}
+
+func (f *fakeAccountService) Withdraw(userID, accountID int, amount models.Money) (*models.Account, error) {
+	return nil, nil
+}
+
+func (f *fakeAccountService) Transfer(userID, fromAccountID, toAccountID int, amount models.Money) (*models.Transfer, error) {
+	return nil, nil
+}
+
+func (f *fakeAccountService) CardTransfer(userID, fromAccountID, toAccountID int, amount models.Money) (*models.Transfer, error) {
+	return nil, nil
+}
+
+func (f *fakeAccountService) Freeze(userID, accountID int) (*models.Account, error) {
+	return nil, nil
+}
+
+func (f *fakeAccountService) Unfreeze(userID, accountID int) (*models.Account, error) {
+	return nil, nil
+}
+
+func (f *fakeAccountService) Close(userID, accountID int) (*models.Account, error) {
+	return nil, nil
+}
+
+func (f *fakeAccountService) Reopen(userID, accountID int) (*models.Account, error) {
+	return nil, nil
 }
 
 // TestSchedulerDoesNotPanic проверяет, что запуск шедулера не вызывает panic.
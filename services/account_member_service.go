@@ -0,0 +1,83 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"bank-api/models"
+	"bank-api/repositories"
+)
+
+// AccountMemberService управляет участниками совместных счетов.
+type AccountMemberService interface {
+	// List возвращает участников счета любому его участнику.
+	List(userID, accountID int) ([]models.AccountMember, error)
+	// Invite добавляет пользователя с email в счет с ролью role; доступно владельцам.
+	// Для роли spend_with_limit обязателен месячный лимит списаний.
+	Invite(userID, accountID int, email, role string, spendLimit *models.Money) (*models.AccountMember, error)
+	// Remove удаляет участника memberID; владелец удаляет любого, остальные — только себя.
+	Remove(userID, accountID, memberID int) error
+}
+
+type accountMemberService struct {
+	accountRepo repositories.AccountRepository
+	userRepo    repositories.UserRepository
+}
+
+// NewAccountMemberService создает AccountMemberService.
+func NewAccountMemberService(accountRepo repositories.AccountRepository, userRepo repositories.UserRepository) AccountMemberService {
+	return &accountMemberService{accountRepo: accountRepo, userRepo: userRepo}
+}
+
+func (s *accountMemberService) List(userID, accountID int) ([]models.AccountMember, error) {
+	if _, _, err := accountAccess(s.accountRepo, accountID, userID); err != nil {
+		return nil, err
+	}
+	return s.accountRepo.ListMembers(accountID)
+}
+
+func (s *accountMemberService) Invite(userID, accountID int, email, role string, spendLimit *models.Money) (*models.AccountMember, error) {
+	if !models.ValidMemberRole(role) {
+		return nil, fmt.Errorf("%w: %q", models.ErrInvalidMemberRole, role)
+	}
+	account, member, err := accountAccess(s.accountRepo, accountID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !member.CanManageMembers() {
+		return nil, models.ErrNotAccountOwner
+	}
+
+	m := &models.AccountMember{AccountID: accountID, Role: role, InvitedBy: userID}
+	if role == models.MemberRoleSpendWithLimit {
+		if spendLimit == nil || !spendLimit.IsPositive() {
+			return nil, models.ErrInvalidAmount
+		}
+		if spendLimit.Currency != "" && spendLimit.Currency != account.Currency {
+			return nil, fmt.Errorf("%w: %s and %s", models.ErrCurrencyMismatch, spendLimit.Currency, account.Currency)
+		}
+		limit := spendLimit.WithCurrency(account.Currency)
+		m.SpendLimit = &limit
+	}
+
+	invitee, err := s.userRepo.GetByEmail(email)
+	if err != nil {
+		return nil, err
+	}
+	m.UserID, m.Email = invitee.ID, invitee.Email
+	if err := s.accountRepo.AddMember(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (s *accountMemberService) Remove(userID, accountID, memberID int) error {
+	_, member, err := accountAccess(s.accountRepo, accountID, userID)
+	if err != nil {
+		return err
+	}
+	if memberID != userID && !member.CanManageMembers() {
+		return models.ErrNotAccountOwner
+	}
+	return s.accountRepo.RemoveMember(context.Background(), accountID, memberID)
+}
@@ -0,0 +1,93 @@
+package services_test
+
+import (
+	"errors"
+	"testing"
+
+	"bank-api/models"
+	"bank-api/services"
+)
+
+func TestJointAccountMembers(t *testing.T) {
+	accountRepo := &fakeAccountRepo{accounts: map[int]*models.Account{
+		1: {ID: 1, UserID: 7, Balance: models.NewMoney(100000, "RUB"), Currency: "RUB", Status: models.AccountStatusActive},
+	}}
+	userRepo := newFakeUserRepo()
+	for _, email := range []string{"owner@example.com", "spouse@example.com", "child@example.com"} {
+		userRepo.Create(&models.User{Email: email})
+	}
+	spouse, _ := userRepo.GetByEmail("spouse@example.com")
+	child, _ := userRepo.GetByEmail("child@example.com")
+
+	members := services.NewAccountMemberService(accountRepo, userRepo)
+	accounts := services.NewAccountService(accountRepo, nil, noFees(accountRepo), nil, testBankBIK)
+
+	limit := models.NewMoney(50000, "")
+	m, err := members.Invite(7, 1, "child@example.com", models.MemberRoleSpendWithLimit, &limit)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if m.UserID != child.ID || m.SpendLimit.String() != "500.00" || m.SpendLimit.Currency != "RUB" {
+		t.Errorf("unexpected member %+v", m)
+	}
+	if _, err := members.Invite(7, 1, "spouse@example.com", models.MemberRoleViewOnly, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Участник с лимитом списывает в пределах лимита, а участник с просмотром — нет.
+	if _, err := accounts.Withdraw(child.ID, 1, models.NewMoney(30000, "")); err != nil {
+		t.Errorf("expected withdrawal within limit, got %v", err)
+	}
+	if _, err := accounts.Withdraw(child.ID, 1, models.NewMoney(60000, "")); !errors.Is(err, models.ErrSpendLimitExceeded) {
+		t.Errorf("expected ErrSpendLimitExceeded, got %v", err)
+	}
+	if _, err := accounts.Withdraw(spouse.ID, 1, models.NewMoney(100, "")); !errors.Is(err, models.ErrNotAccountOwner) {
+		t.Errorf("expected view-only member to be refused, got %v", err)
+	}
+	list, err := members.List(spouse.ID, 1)
+	if err != nil || len(list) != 3 {
+		t.Errorf("expected 3 members visible to view-only member, got %d, %v", len(list), err)
+	}
+
+	cases := []struct {
+		name    string
+		err     error
+		wantErr error
+	}{
+		{"invite by non-owner", func() error {
+			_, err := members.Invite(spouse.ID, 1, "owner@example.com", models.MemberRoleFullAccess, nil)
+			return err
+		}(), models.ErrNotAccountOwner},
+		{"duplicate member", func() error {
+			_, err := members.Invite(7, 1, "spouse@example.com", models.MemberRoleFullAccess, nil)
+			return err
+		}(), models.ErrMemberAlreadyExists},
+		{"unknown role", func() error {
+			_, err := members.Invite(7, 1, "owner@example.com", "admin", nil)
+			return err
+		}(), models.ErrInvalidMemberRole},
+		{"limit required", func() error {
+			_, err := members.Invite(7, 1, "owner@example.com", models.MemberRoleSpendWithLimit, nil)
+			return err
+		}(), models.ErrInvalidAmount},
+		{"remove other as non-owner", members.Remove(spouse.ID, 1, child.ID), models.ErrNotAccountOwner},
+		{"remove last owner", members.Remove(7, 1, 7), models.ErrLastAccountOwner},
+		{"outsider lists members", func() error {
+			_, err := members.List(99, 1)
+			return err
+		}(), models.ErrNotAccountOwner},
+	}
+	for _, tc := range cases {
+		if !errors.Is(tc.err, tc.wantErr) {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.wantErr, tc.err)
+		}
+	}
+
+	// Участник может выйти из счета сам.
+	if err := members.Remove(spouse.ID, 1, spouse.ID); err != nil {
+		t.Errorf("expected member to leave, got %v", err)
+	}
+	if _, err := members.List(spouse.ID, 1); !errors.Is(err, models.ErrNotAccountOwner) {
+		t.Errorf("expected removed member to lose access, got %v", err)
+	}
+}
@@ -3,42 +3,215 @@ package services
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"time"
 
 	"bank-api/models"
 	"bank-api/repositories"
+	"bank-api/utils"
 )
 
 // AccountService описывает операции над банковскими счетами.
 type AccountService interface {
+	// CreateAccount открывает счет с нулевым остатком и присваивает ему 20-значный номер
+	// по типу и валюте счета. Деньги поступают на счет только проводками.
 	CreateAccount(a *models.Account) error
-	Deposit(accountID int, amount float64) error
-	Withdraw(accountID int, amount float64) error
-	Transfer(fromAccountID, toAccountID int, amount float64) error
+	// AssignMissingNumbers присваивает номера счетам, открытым до их введения, и возвращает их количество.
+	AssignMissingNumbers() (int, error)
+	// Deposit пополняет счет пользователя и возвращает счет с новым остатком.
+	Deposit(userID, accountID int, amount models.Money) (*models.Account, error)
+	// Withdraw снимает средства со счета пользователя и возвращает счет с новым остатком.
+	Withdraw(userID, accountID int, amount models.Money) (*models.Account, error)
+	// Transfer переводит amount в валюте счета-источника; между валютами — с конвертацией по курсу ЦБ.
+	// Комиссия по тарифу списывается со счета-источника в той же транзакции.
+	Transfer(userID, fromAccountID, toAccountID int, amount models.Money) (*models.Transfer, error)
+	// CardTransfer — Transfer по реквизитам карт с комиссией по тарифу card_transfer.
+	CardTransfer(userID, fromAccountID, toAccountID int, amount models.Money) (*models.Transfer, error)
+	// TransferToNumber переводит amount на счет банка с 20-значным номером toNumber.
+	TransferToNumber(userID, fromAccountID int, toNumber string, amount models.Money) (*models.Transfer, error)
+	// Freeze замораживает активный счет: операции по нему запрещены до разморозки.
+	Freeze(userID, accountID int) (*models.Account, error)
+	// Unfreeze возвращает замороженный счет в активный статус.
+	Unfreeze(userID, accountID int) (*models.Account, error)
+	// Close закрывает активный счет с нулевым остатком без кредитов и карт.
+	Close(userID, accountID int) (*models.Account, error)
+	// Reopen повторно открывает закрытый счет.
+	Reopen(userID, accountID int) (*models.Account, error)
 }
 
 type accountService struct {
 	accountRepo repositories.AccountRepository
+	fxService   FXService
+	feeService  FeeService
 	db          *sql.DB
+	// БИК банка для контрольного ключа номеров счетов
+	bik string
 }
 
 // NewAccountService создает AccountService.
-func NewAccountService(repo repositories.AccountRepository, db *sql.DB) AccountService {
-	return &accountService{accountRepo: repo, db: db}
+func NewAccountService(repo repositories.AccountRepository, fxService FXService, feeService FeeService, db *sql.DB, bik string) AccountService {
+	return &accountService{accountRepo: repo, fxService: fxService, feeService: feeService, db: db, bik: bik}
 }
 
 func (s *accountService) CreateAccount(a *models.Account) error {
+	if a.Type == "" {
+		a.Type = models.AccountTypeCurrent
+	}
+	number, err := s.newAccountNumber(a.Type, a.Currency)
+	if err != nil {
+		return err
+	}
+	a.Number = number
+	a.Status = models.AccountStatusActive
+	a.Balance = models.NewMoney(0, a.Currency)
+	a.Held = models.NewMoney(0, a.Currency)
 	return s.accountRepo.Create(a)
 }
 
-func (s *accountService) Deposit(id int, amt float64) error {
-	return s.accountRepo.UpdateBalance(id, amt)
+func (s *accountService) AssignMissingNumbers() (int, error) {
+	accounts, err := s.accountRepo.ListWithoutNumber()
+	if err != nil {
+		return 0, err
+	}
+	for i, acc := range accounts {
+		number, err := s.newAccountNumber(acc.Type, acc.Currency)
+		if err != nil {
+			return i, fmt.Errorf("account %d: %w", acc.ID, err)
+		}
+		if err := s.accountRepo.SetNumber(acc.ID, number); err != nil {
+			return i, err
+		}
+	}
+	return len(accounts), nil
 }
 
-func (s *accountService) Withdraw(id int, amt float64) error {
-	return s.accountRepo.UpdateBalance(id, -amt)
+// newAccountNumber собирает номер счета из балансового счета типа, кода валюты
+// и очередного порядкового номера; подразделение — головной офис (0000).
+func (s *accountService) newAccountNumber(accountType, currency string) (string, error) {
+	prefix, ok := models.BalanceAccountPrefix(accountType)
+	if !ok {
+		return "", fmt.Errorf("%w: %q", models.ErrInvalidAccountType, accountType)
+	}
+	currencyCode, ok := models.AccountCurrencyCode(currency)
+	if !ok {
+		return "", fmt.Errorf("%w: %q", models.ErrUnsupportedCurrency, currency)
+	}
+	serial, err := s.accountRepo.NextNumberSerial()
+	if err != nil {
+		return "", err
+	}
+	return utils.BuildAccountNumber(s.bik, prefix, currencyCode, 0, serial)
 }
 
-func (s *accountService) Transfer(fromID, toID int, amt float64) error {
-	ctx := context.Background()
-	return s.accountRepo.TransferTx(ctx, fromID, toID, amt)
+func (s *accountService) Deposit(userID, id int, amt models.Money) (*models.Account, error) {
+	acc, member, err := s.checkCashOperation(userID, id, amt)
+	if err != nil {
+		return nil, err
+	}
+	if !member.CanTransact() {
+		return nil, models.ErrNotAccountOwner
+	}
+	fee, err := s.feeService.Quote(acc, models.FeeOperationDeposit, amt)
+	if err != nil {
+		return nil, err
+	}
+	return s.accountRepo.UpdateBalance(userID, id, amt, fee)
+}
+
+func (s *accountService) Withdraw(userID, id int, amt models.Money) (*models.Account, error) {
+	acc, member, err := s.checkCashOperation(userID, id, amt)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSpend(member, amt); err != nil {
+		return nil, err
+	}
+	fee, err := s.feeService.Quote(acc, models.FeeOperationWithdrawal, amt)
+	if err != nil {
+		return nil, err
+	}
+	return s.accountRepo.UpdateBalance(userID, id, amt.Neg(), fee)
+}
+
+// checkCashOperation проверяет сумму и членство пользователя в счете перед пополнением или снятием.
+// Статус и остаток проверяются репозиторием под блокировкой счета.
+func (s *accountService) checkCashOperation(userID, accountID int, amt models.Money) (*models.Account, *models.AccountMember, error) {
+	if !amt.IsPositive() {
+		return nil, nil, models.ErrInvalidAmount
+	}
+	return accountAccess(s.accountRepo, accountID, userID)
+}
+
+// Transfer переводит средства между счетами; userID должен иметь право списания со счета-источника.
+// Курс для счетов в разных валютах фиксируется до начала транзакции; владелец,
+// статусы и остаток проверяются репозиторием под блокировкой.
+func (s *accountService) Transfer(userID, fromID, toID int, amt models.Money) (*models.Transfer, error) {
+	return s.transfer(models.FeeOperationTransfer, userID, fromID, toID, amt)
+}
+
+func (s *accountService) CardTransfer(userID, fromID, toID int, amt models.Money) (*models.Transfer, error) {
+	return s.transfer(models.FeeOperationCardTransfer, userID, fromID, toID, amt)
+}
+
+// transfer проводит перевод; комиссия рассчитывается по тарифу операции operation.
+func (s *accountService) transfer(operation string, userID, fromID, toID int, amt models.Money) (*models.Transfer, error) {
+	t := &models.Transfer{UserID: userID, FromAccountID: fromID, ToAccountID: toID, Amount: amt}
+	if fromID != toID && amt.IsPositive() {
+		from, err := s.accountRepo.GetByID(fromID)
+		if err != nil {
+			return nil, err
+		}
+		to, err := s.accountRepo.GetByID(toID)
+		if err != nil {
+			return nil, err
+		}
+		if amt.Currency != "" && amt.Currency != from.Currency {
+			return nil, fmt.Errorf("%w: %s and %s", models.ErrCurrencyMismatch, amt.Currency, from.Currency)
+		}
+		t.Amount = amt.WithCurrency(from.Currency)
+		if from.Currency != to.Currency {
+			quote, err := s.fxService.Quote(t.Amount, to.Currency, time.Now())
+			if err != nil {
+				return nil, err
+			}
+			t.CreditedAmount = quote.Converted
+			t.FXRate = quote.Rate
+		}
+		if t.Fee, err = s.feeService.Quote(from, operation, t.Amount); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.accountRepo.TransferTx(context.Background(), t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// TransferToNumber проверяет контрольный ключ номера по БИК банка, находит счет и переводит на него.
+func (s *accountService) TransferToNumber(userID, fromID int, toNumber string, amt models.Money) (*models.Transfer, error) {
+	toNumber = utils.NormalizeAccountNumber(toNumber)
+	if !utils.ValidateAccountNumber(s.bik, toNumber) {
+		return nil, models.ErrInvalidAccountNumber
+	}
+	to, err := s.accountRepo.GetByNumber(toNumber)
+	if err != nil {
+		return nil, err
+	}
+	return s.Transfer(userID, fromID, to.ID, amt)
+}
+
+func (s *accountService) Freeze(userID, accountID int) (*models.Account, error) {
+	return s.accountRepo.ChangeStatus(context.Background(), userID, accountID, models.AccountStatusActive, models.AccountStatusFrozen)
+}
+
+func (s *accountService) Unfreeze(userID, accountID int) (*models.Account, error) {
+	return s.accountRepo.ChangeStatus(context.Background(), userID, accountID, models.AccountStatusFrozen, models.AccountStatusActive)
+}
+
+func (s *accountService) Close(userID, accountID int) (*models.Account, error) {
+	return s.accountRepo.ChangeStatus(context.Background(), userID, accountID, models.AccountStatusActive, models.AccountStatusClosed)
+}
+
+func (s *accountService) Reopen(userID, accountID int) (*models.Account, error) {
+	return s.accountRepo.ChangeStatus(context.Background(), userID, accountID, models.AccountStatusClosed, models.AccountStatusActive)
 }
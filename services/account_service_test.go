@@ -0,0 +1,105 @@
+package services_test
+
+import (
+	"errors"
+	"testing"
+
+	"bank-api/models"
+	"bank-api/services"
+	"bank-api/utils"
+)
+
+const testBankBIK = "044525225"
+
+func TestDepositAndWithdraw(t *testing.T) {
+	accountRepo := &fakeAccountRepo{accounts: map[int]*models.Account{
+		1: {ID: 1, UserID: 7, Balance: models.NewMoney(10000, "RUB"), Currency: "RUB", Status: models.AccountStatusActive},
+	}}
+	svc := services.NewAccountService(accountRepo, nil, noFees(accountRepo), nil, testBankBIK)
+
+	acc, err := svc.Deposit(7, 1, models.NewMoney(2550, ""))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if acc.Balance.String() != "125.50" {
+		t.Errorf("expected balance 125.50 after deposit, got %s", acc.Balance)
+	}
+	acc, err = svc.Withdraw(7, 1, models.NewMoney(550, ""))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if acc.Balance.String() != "120.00" {
+		t.Errorf("expected balance 120.00 after withdrawal, got %s", acc.Balance)
+	}
+
+	cases := []struct {
+		name    string
+		op      func(userID, accountID int, amount models.Money) (*models.Account, error)
+		userID  int
+		amount  models.Money
+		wantErr error
+	}{
+		{"foreign account", svc.Deposit, 8, models.NewMoney(100, ""), models.ErrNotAccountOwner},
+		{"zero deposit", svc.Deposit, 7, models.NewMoney(0, ""), models.ErrInvalidAmount},
+		{"negative withdrawal", svc.Withdraw, 7, models.NewMoney(-100, ""), models.ErrInvalidAmount},
+		{"overdraft", svc.Withdraw, 7, models.NewMoney(12001, ""), models.ErrInsufficientFunds},
+	}
+	for _, tc := range cases {
+		if _, err := tc.op(tc.userID, 1, tc.amount); !errors.Is(err, tc.wantErr) {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.wantErr, err)
+		}
+	}
+	if accountRepo.accounts[1].Balance.String() != "120.00" {
+		t.Errorf("rejected operations must not change the balance, got %s", accountRepo.accounts[1].Balance)
+	}
+}
+
+func TestCreateAccountAssignsNumber(t *testing.T) {
+	accountRepo := &fakeAccountRepo{accounts: map[int]*models.Account{
+		1: {ID: 1, UserID: 7, Type: models.AccountTypeCurrent, Currency: "RUB", Status: models.AccountStatusActive},
+	}}
+	svc := services.NewAccountService(accountRepo, nil, noFees(accountRepo), nil, testBankBIK)
+
+	usd := &models.Account{UserID: 7, Type: models.AccountTypeDeposit, Currency: "USD",
+		Balance: models.NewMoney(100000, "USD"), Held: models.NewMoney(-5000, "USD")}
+	if err := svc.CreateAccount(usd); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !usd.Balance.IsZero() || !usd.Held.IsZero() {
+		t.Errorf("expected new account to open empty, got balance %s, held %s", usd.Balance, usd.Held)
+	}
+	if usd.Number[:8] != "42301840" || !utils.ValidateAccountNumber(testBankBIK, usd.Number) {
+		t.Errorf("unexpected account number %s", usd.Number)
+	}
+	rub := &models.Account{UserID: 7, Currency: "RUB"}
+	if err := svc.CreateAccount(rub); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rub.Type != models.AccountTypeCurrent || rub.Number[:8] != "40817810" || rub.Number == usd.Number {
+		t.Errorf("unexpected current account %s of type %s", rub.Number, rub.Type)
+	}
+	if err := svc.CreateAccount(&models.Account{UserID: 7, Currency: "XXX"}); !errors.Is(err, models.ErrUnsupportedCurrency) {
+		t.Errorf("expected ErrUnsupportedCurrency, got %v", err)
+	}
+	if err := svc.CreateAccount(&models.Account{UserID: 7, Type: "loan", Currency: "RUB"}); !errors.Is(err, models.ErrInvalidAccountType) {
+		t.Errorf("expected ErrInvalidAccountType, got %v", err)
+	}
+
+	// Счет, открытый до введения номеров, получает номер при миграции.
+	if n, err := svc.AssignMissingNumbers(); err != nil || n != 1 {
+		t.Fatalf("expected one number assigned, got %d, %v", n, err)
+	}
+	if accountRepo.accounts[1].Number == "" {
+		t.Error("expected legacy account to get a number")
+	}
+
+	if _, err := svc.TransferToNumber(7, 1, "4081781070000000000X", models.NewMoney(100, "")); !errors.Is(err, models.ErrInvalidAccountNumber) {
+		t.Errorf("expected ErrInvalidAccountNumber, got %v", err)
+	}
+	if _, err := svc.TransferToNumber(7, 1, rub.Number[:5]+" "+rub.Number[5:], models.NewMoney(100, "")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if last := accountRepo.transfers[len(accountRepo.transfers)-1]; last.ToAccountID != rub.ID {
+		t.Errorf("expected transfer to account %d, got %d", rub.ID, last.ToAccountID)
+	}
+}
@@ -1,21 +1,27 @@
 package services
 
 import (
+	"bank-api/models"
 	"bank-api/repositories"
+	"math/big"
 	"time"
 )
 
+// predictedDailySpend — ожидаемый ежедневный расход (в минорных единицах) для прогноза баланса.
+const predictedDailySpend = 5000
+
 // Добавили CreditLoad
 type AnalyticsData struct {
-	TotalDeposits    float64 `json:"total_deposits"`
-	TotalWithdrawals float64 `json:"total_withdrawals"`
-	NetChange        float64 `json:"net_change"`
-	CreditLoad       float64 `json:"credit_load"` // доля платежей от доходов
+	TotalDeposits    models.Money `json:"total_deposits"`
+	TotalWithdrawals models.Money `json:"total_withdrawals"`
+	NetChange        models.Money `json:"net_change"`
+	CreditLoad       float64      `json:"credit_load"` // доля платежей от доходов
 }
 
 type AnalyticsService interface {
 	GetAnalytics(userID int) (*AnalyticsData, error)
-	PredictBalance(accountID int, days int) (float64, error)
+	// PredictBalance прогнозирует остаток счета, доступного пользователю, через days дней.
+	PredictBalance(userID, accountID int, days int) (models.Money, error)
 }
 
 type analyticsService struct {
@@ -42,35 +48,40 @@ func NewAnalyticsService(
 func (s *analyticsService) GetAnalytics(userID int) (*AnalyticsData, error) {
 	// Получаем доходы/расходы за текущий месяц из транзакций
 	start := time.Now().Truncate(24 * time.Hour).AddDate(0, 0, -time.Now().Day()+1)
-	deposits, err := s.transactionRepo.SumByType(userID, "deposit", start)
+	deposits, err := s.transactionRepo.SumByType(userID, models.EntryTypeDeposit, start)
+	if err != nil {
+		return nil, err
+	}
+	withdrawals, err := s.transactionRepo.SumByType(userID, models.EntryTypeWithdrawal, start)
 	if err != nil {
 		return nil, err
 	}
-	withdrawals, err := s.transactionRepo.SumByType(userID, "withdraw", start)
+	net, err := deposits.Sub(withdrawals)
 	if err != nil {
 		return nil, err
 	}
-	net := deposits - withdrawals
 
 	// Считаем суммарные ежемесячные платежи по всем кредитам
 	credits, err := s.creditRepo.GetByUserID(userID)
 	if err != nil {
 		return nil, err
 	}
-	var totalMonthlyPayments float64
+	var totalMonthlyPayments models.Money
 	for _, c := range credits {
 		// Находим платежи в этом месяце для кредита
 		schedules, _ := s.paymentScheduleRepo.GetByCreditID(c.ID)
 		for _, p := range schedules {
 			if p.DueDate.After(start) && p.DueDate.Before(time.Now().AddDate(0, 1, 0)) {
-				totalMonthlyPayments += p.Amount
+				if totalMonthlyPayments, err = totalMonthlyPayments.Add(p.Amount); err != nil {
+					return nil, err
+				}
 			}
 		}
 	}
 
 	creditLoad := 0.0
-	if deposits > 0 {
-		creditLoad = totalMonthlyPayments / deposits
+	if deposits.IsPositive() {
+		creditLoad, _ = new(big.Rat).Quo(totalMonthlyPayments.Rat(), deposits.Rat()).Float64()
 	}
 
 	return &AnalyticsData{
@@ -81,10 +92,11 @@ func (s *analyticsService) GetAnalytics(userID int) (*AnalyticsData, error) {
 	}, nil
 }
 
-func (s *analyticsService) PredictBalance(accountID int, days int) (float64, error) {
-	acc, err := s.accountRepo.GetByID(accountID)
+func (s *analyticsService) PredictBalance(userID, accountID int, days int) (models.Money, error) {
+	acc, _, err := accountAccess(s.accountRepo, accountID, userID)
 	if err != nil {
-		return 0, err
+		return models.Money{}, err
 	}
-	return acc.Balance - float64(days)*50.0, nil
+	spend := models.NewMoney(int64(days)*predictedDailySpend, acc.Currency)
+	return acc.Balance.Sub(spend)
 }
@@ -0,0 +1,374 @@
+package services
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"bank-api/models"
+	"bank-api/repositories"
+	"bank-api/utils"
+)
+
+const (
+	// batchTransferClaimLimit — сколько пакетов обрабатывается за один запуск задачи.
+	batchTransferClaimLimit = 5
+	// batchTransferLease — аренда пакета на время обработки всех его строк.
+	batchTransferLease = 30 * time.Minute
+)
+
+// BatchTransferService принимает пакетные переводы из файла и исполняет их в фоне.
+type BatchTransferService interface {
+	// Create разбирает файл в формате format (csv или ndjson), проверяет пакет целиком
+	// и ставит его в очередь. Пакет с ошибками в строках отклоняется с *BatchValidationError.
+	Create(userID, fromAccountID int, format string, r io.Reader) (*models.BatchTransfer, error)
+	// Get возвращает пакет со статусами строк.
+	Get(userID, id int) (*models.BatchTransfer, error)
+	// WriteReport пишет CSV-отчет о результатах строк пакета.
+	WriteReport(userID, id int, w io.Writer) error
+	// ProcessPending исполняет строки пакетов, ожидающих обработки.
+	ProcessPending(now time.Time) error
+}
+
+type batchTransferService struct {
+	batchRepo      repositories.BatchTransferRepository
+	accountRepo    repositories.AccountRepository
+	accountService AccountService
+	// Комиссии строк учитываются при проверке остатка пакета
+	feeService FeeService
+	// БИК банка для проверки номеров счетов получателей
+	bik string
+	// Наибольшее число строк в пакете
+	maxLines int
+}
+
+// NewBatchTransferService создает BatchTransferService.
+func NewBatchTransferService(
+	batchRepo repositories.BatchTransferRepository,
+	accountRepo repositories.AccountRepository,
+	accountService AccountService,
+	feeService FeeService,
+	bik string,
+	maxLines int,
+) BatchTransferService {
+	return &batchTransferService{
+		batchRepo:      batchRepo,
+		accountRepo:    accountRepo,
+		accountService: accountService,
+		feeService:     feeService,
+		bik:            bik,
+		maxLines:       maxLines,
+	}
+}
+
+// batchInput — строка файла до проверки.
+type batchInput struct {
+	lineNo      int
+	destination string
+	amount      string
+	err         error
+}
+
+func (s *batchTransferService) Create(userID, fromAccountID int, format string, r io.Reader) (*models.BatchTransfer, error) {
+	var inputs []batchInput
+	var err error
+	switch format {
+	case models.BatchFormatCSV:
+		inputs, err = parseBatchCSV(r, s.maxLines)
+	case models.BatchFormatNDJSON:
+		inputs, err = parseBatchNDJSON(r, s.maxLines)
+	default:
+		return nil, fmt.Errorf("%w: %q", models.ErrUnsupportedBatchFormat, format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(inputs) == 0 {
+		return nil, fmt.Errorf("%w: no lines", models.ErrInvalidBatch)
+	}
+
+	from, member, err := accountAccess(s.accountRepo, fromAccountID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if from.Status != models.AccountStatusActive {
+		return nil, models.ErrAccountInactive
+	}
+
+	b := &models.BatchTransfer{
+		UserID:        userID,
+		FromAccountID: fromAccountID,
+		Total:         models.NewMoney(0, from.Currency),
+		Currency:      from.Currency,
+		LineCount:     len(inputs),
+		Status:        models.BatchStatusPending,
+	}
+	fees := models.NewMoney(0, from.Currency)
+	var invalid []models.BatchLineError
+	for _, in := range inputs {
+		line, err := s.validateLine(from, member, in)
+		if err != nil {
+			invalid = append(invalid, models.BatchLineError{LineNo: in.lineNo, Error: err.Error()})
+			continue
+		}
+		if b.Total, err = b.Total.Add(line.Amount); err != nil {
+			return nil, err
+		}
+		fee, err := s.feeService.Quote(from, models.FeeOperationTransfer, line.Amount)
+		if err != nil {
+			return nil, err
+		}
+		if fees, err = fees.Add(fee.Fee); err != nil {
+			return nil, err
+		}
+		b.Lines = append(b.Lines, *line)
+	}
+	if len(invalid) > 0 {
+		return nil, &models.BatchValidationError{Lines: invalid}
+	}
+	// Доступный остаток проверяется заранее вместе с комиссиями строк по тарифу,
+	// чтобы не исполнить пакет наполовину; каждая строка все равно проверяется при переводе.
+	required, err := b.Total.Add(fees)
+	if err != nil {
+		return nil, err
+	}
+	if cmp, err := from.Available().Cmp(required); err != nil {
+		return nil, err
+	} else if cmp < 0 {
+		return nil, fmt.Errorf("%w: batch total %s with fees %s exceeds available balance %s",
+			models.ErrInsufficientFunds, b.Total, fees, from.Available())
+	}
+
+	if err := s.batchRepo.Create(b); err != nil {
+		return nil, err
+	}
+	b.Summarize()
+	return b, nil
+}
+
+// validateLine проверяет сумму строки и находит счет получателя по номеру или ID.
+func (s *batchTransferService) validateLine(from *models.Account, member *models.AccountMember, in batchInput) (*models.BatchTransferLine, error) {
+	if in.err != nil {
+		return nil, in.err
+	}
+	amount, err := models.ParseMoney(in.amount, from.Currency)
+	if err != nil {
+		return nil, err
+	}
+	if !amount.IsPositive() {
+		return nil, models.ErrInvalidAmount
+	}
+	if err := checkSpend(member, amount); err != nil {
+		return nil, err
+	}
+	to, err := s.resolveDestination(in.destination)
+	if err != nil {
+		return nil, err
+	}
+	if to.ID == from.ID {
+		return nil, models.ErrSameAccount
+	}
+	if to.Status != models.AccountStatusActive {
+		return nil, models.ErrAccountInactive
+	}
+	return &models.BatchTransferLine{
+		LineNo:      in.lineNo,
+		Destination: in.destination,
+		ToAccountID: to.ID,
+		Amount:      amount,
+		Status:      models.BatchLinePending,
+	}, nil
+}
+
+// resolveDestination принимает 20-значный номер счета с контрольным ключом или ID счета.
+func (s *batchTransferService) resolveDestination(destination string) (*models.Account, error) {
+	number := utils.NormalizeAccountNumber(destination)
+	if len(number) == 20 {
+		if !utils.ValidateAccountNumber(s.bik, number) {
+			return nil, models.ErrInvalidAccountNumber
+		}
+		return s.accountRepo.GetByNumber(number)
+	}
+	id, err := strconv.Atoi(destination)
+	if err != nil || id <= 0 {
+		return nil, fmt.Errorf("%w: %q", models.ErrInvalidAccountNumber, destination)
+	}
+	return s.accountRepo.GetByID(id)
+}
+
+func (s *batchTransferService) Get(userID, id int) (*models.BatchTransfer, error) {
+	b, err := s.batchRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	// Чужие пакеты не раскрываются.
+	if b.UserID != userID {
+		return nil, models.ErrBatchTransferNotFound
+	}
+	if b.Lines, err = s.batchRepo.ListLines(id); err != nil {
+		return nil, err
+	}
+	b.Summarize()
+	return b, nil
+}
+
+func (s *batchTransferService) WriteReport(userID, id int, w io.Writer) error {
+	b, err := s.Get(userID, id)
+	if err != nil {
+		return err
+	}
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"line_no", "destination", "amount", "currency", "status", "transfer_id", "error"})
+	for _, line := range b.Lines {
+		transferID := ""
+		if line.TransferID != 0 {
+			transferID = strconv.Itoa(line.TransferID)
+		}
+		cw.Write([]string{
+			strconv.Itoa(line.LineNo), line.Destination, line.Amount.String(), b.Currency,
+			line.Status, transferID, line.Error,
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func (s *batchTransferService) ProcessPending(now time.Time) error {
+	batches, err := s.batchRepo.ClaimOpen(now, batchTransferLease, batchTransferClaimLimit)
+	if err != nil {
+		return err
+	}
+	for _, b := range batches {
+		if err := s.process(b); err != nil {
+			log.Printf("batch transfer %d: %v", b.ID, err)
+		}
+	}
+	return nil
+}
+
+// process исполняет ожидающие строки пакета по одной через AccountService.Transfer.
+// Строка помечается начатой до перевода: если процесс упадет между переводом
+// и сохранением результата, строка станет interrupted, а не будет оплачена повторно.
+func (s *batchTransferService) process(b *models.BatchTransfer) error {
+	lines, err := s.batchRepo.ListLines(b.ID)
+	if err != nil {
+		return err
+	}
+	for i := range lines {
+		line := &lines[i]
+		switch line.Status {
+		case models.BatchLineProcessing:
+			line.Status = models.BatchLineInterrupted
+			line.Error = "processing was interrupted; check the account history before resending"
+		case models.BatchLinePending:
+			started, err := s.batchRepo.StartLine(line.ID)
+			if err != nil {
+				return err
+			}
+			if !started {
+				continue
+			}
+			transfer, err := s.accountService.Transfer(b.UserID, b.FromAccountID, line.ToAccountID, line.Amount)
+			if err != nil {
+				line.Status = models.BatchLineFailed
+				line.Error = err.Error()
+			} else {
+				line.Status = models.BatchLineSucceeded
+				line.TransferID = transfer.ID
+			}
+		default:
+			continue
+		}
+		if err := s.batchRepo.FinishLine(line); err != nil {
+			return err
+		}
+	}
+	return s.batchRepo.Complete(b.ID)
+}
+
+// parseBatchCSV читает строки "получатель,сумма"; первая строка может быть заголовком
+// destination,amount.
+func parseBatchCSV(r io.Reader, maxLines int) ([]batchInput, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	var inputs []batchInput
+	for first := true; ; first = false {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return inputs, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", models.ErrInvalidBatch, err)
+		}
+		if first && len(record) > 0 && strings.EqualFold(strings.TrimSpace(record[0]), "destination") {
+			continue
+		}
+		if len(inputs) == maxLines {
+			return nil, fmt.Errorf("%w: more than %d lines", models.ErrInvalidBatch, maxLines)
+		}
+		in := batchInput{lineNo: len(inputs) + 1}
+		if len(record) != 2 {
+			in.err = fmt.Errorf("expected 2 fields, got %d", len(record))
+		} else {
+			in.destination = strings.TrimSpace(record[0])
+			in.amount = strings.TrimSpace(record[1])
+		}
+		inputs = append(inputs, in)
+	}
+}
+
+// parseBatchNDJSON читает по одному объекту {"destination": ..., "amount": ...} на строку;
+// пустые строки пропускаются. Получатель и сумма принимаются строкой или числом.
+func parseBatchNDJSON(r io.Reader, maxLines int) ([]batchInput, error) {
+	scanner := bufio.NewScanner(r)
+	var inputs []batchInput
+	for scanner.Scan() {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		if len(inputs) == maxLines {
+			return nil, fmt.Errorf("%w: more than %d lines", models.ErrInvalidBatch, maxLines)
+		}
+		in := batchInput{lineNo: len(inputs) + 1}
+		var raw struct {
+			Destination json.RawMessage `json:"destination"`
+			Amount      json.RawMessage `json:"amount"`
+		}
+		if err := json.Unmarshal([]byte(text), &raw); err != nil {
+			in.err = fmt.Errorf("invalid JSON: %v", err)
+		} else {
+			in.destination = jsonScalar(raw.Destination)
+			in.amount = jsonScalar(raw.Amount)
+			if in.destination == "" || in.amount == "" {
+				in.err = errors.New("destination and amount are required")
+			}
+		}
+		inputs = append(inputs, in)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", models.ErrInvalidBatch, err)
+	}
+	return inputs, nil
+}
+
+// jsonScalar возвращает JSON-строку или число как текст.
+func jsonScalar(raw json.RawMessage) string {
+	s := strings.TrimSpace(string(raw))
+	if s == "null" {
+		return ""
+	}
+	var str string
+	if err := json.Unmarshal(raw, &str); err == nil {
+		return strings.TrimSpace(str)
+	}
+	return s
+}
@@ -0,0 +1,222 @@
+package services_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"bank-api/models"
+	"bank-api/services"
+	"bank-api/utils"
+)
+
+// fakeBatchTransferRepo хранит пакеты и строки в памяти.
+type fakeBatchTransferRepo struct {
+	batches map[int]*models.BatchTransfer
+}
+
+func (r *fakeBatchTransferRepo) Create(b *models.BatchTransfer) error {
+	b.ID = len(r.batches) + 1
+	for i := range b.Lines {
+		b.Lines[i].ID = b.ID*1000 + i
+		b.Lines[i].BatchID = b.ID
+	}
+	r.batches[b.ID] = b
+	return nil
+}
+
+func (r *fakeBatchTransferRepo) GetByID(id int) (*models.BatchTransfer, error) {
+	b, ok := r.batches[id]
+	if !ok {
+		return nil, models.ErrBatchTransferNotFound
+	}
+	copied := *b
+	copied.Lines = nil
+	return &copied, nil
+}
+
+func (r *fakeBatchTransferRepo) ListLines(batchID int) ([]models.BatchTransferLine, error) {
+	return append([]models.BatchTransferLine(nil), r.batches[batchID].Lines...), nil
+}
+
+func (r *fakeBatchTransferRepo) ClaimOpen(now time.Time, lease time.Duration, limit int) ([]*models.BatchTransfer, error) {
+	var open []*models.BatchTransfer
+	for _, b := range r.batches {
+		if b.Status != models.BatchStatusCompleted {
+			b.Status = models.BatchStatusProcessing
+			open = append(open, b)
+		}
+	}
+	return open, nil
+}
+
+func (r *fakeBatchTransferRepo) line(lineID int) *models.BatchTransferLine {
+	b := r.batches[lineID/1000]
+	return &b.Lines[lineID%1000]
+}
+
+func (r *fakeBatchTransferRepo) StartLine(lineID int) (bool, error) {
+	line := r.line(lineID)
+	if line.Status != models.BatchLinePending {
+		return false, nil
+	}
+	line.Status = models.BatchLineProcessing
+	return true, nil
+}
+
+func (r *fakeBatchTransferRepo) FinishLine(line *models.BatchTransferLine) error {
+	*r.line(line.ID) = *line
+	return nil
+}
+
+func (r *fakeBatchTransferRepo) Complete(batchID int) error {
+	r.batches[batchID].Status = models.BatchStatusCompleted
+	return nil
+}
+
+// newBatchTransferFixture создает сервис над счетом-источником 1 (1000.00) с комиссиями по rules.
+func newBatchTransferFixture(t *testing.T, rules ...*models.FeeRule) (services.BatchTransferService, *fakeBatchTransferRepo, *fakeAccountRepo, string) {
+	number, err := utils.BuildAccountNumber(testBankBIK, "40817", "810", 0, 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	accountRepo := &fakeAccountRepo{accounts: map[int]*models.Account{
+		1: {ID: 1, UserID: 7, Currency: "RUB", Balance: models.NewMoney(100000, "RUB"), Status: models.AccountStatusActive},
+		2: {ID: 2, UserID: 8, Currency: "RUB", Number: number, Status: models.AccountStatusActive},
+		3: {ID: 3, UserID: 9, Currency: "RUB", Status: models.AccountStatusActive},
+	}}
+	batchRepo := &fakeBatchTransferRepo{batches: map[int]*models.BatchTransfer{}}
+	feeService := services.NewFeeService(&fakeFeeRepo{rules: rules}, accountRepo)
+	accountService := services.NewAccountService(accountRepo, nil, feeService, nil, testBankBIK)
+	svc := services.NewBatchTransferService(batchRepo, accountRepo, accountService, feeService, testBankBIK, 100)
+	return svc, batchRepo, accountRepo, number
+}
+
+func TestBatchTransferCSVIsProcessedLineByLine(t *testing.T) {
+	svc, _, accountRepo, number := newBatchTransferFixture(t)
+
+	file := "destination,amount\n" + number + ",250.00\n3,100.50\n"
+	batch, err := svc.Create(7, 1, models.BatchFormatCSV, strings.NewReader(file))
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if batch.LineCount != 2 || batch.Total.String() != "350.50" || batch.PendingCount != 2 {
+		t.Fatalf("unexpected batch: %+v", batch)
+	}
+
+	// Вторая строка не пройдет: счет получателя не найдется при исполнении.
+	delete(accountRepo.accounts, 3)
+	if err := svc.ProcessPending(time.Now()); err != nil {
+		t.Fatalf("ProcessPending failed: %v", err)
+	}
+
+	got, err := svc.Get(7, batch.ID)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if got.Status != models.BatchStatusCompleted || got.SucceededCount != 1 || got.FailedCount != 1 {
+		t.Fatalf("unexpected result: %+v", got)
+	}
+	if got.Lines[0].ToAccountID != 2 || got.Lines[0].TransferID != 1 {
+		t.Errorf("unexpected first line: %+v", got.Lines[0])
+	}
+	if got.Lines[1].Status != models.BatchLineFailed || got.Lines[1].Error == "" {
+		t.Errorf("unexpected second line: %+v", got.Lines[1])
+	}
+	if len(accountRepo.transfers) != 1 {
+		t.Errorf("expected 1 transfer, got %d", len(accountRepo.transfers))
+	}
+
+	var report strings.Builder
+	if err := svc.WriteReport(7, batch.ID, &report); err != nil {
+		t.Fatalf("WriteReport failed: %v", err)
+	}
+	if !strings.Contains(report.String(), "1,"+number+",250.00,RUB,succeeded,1,") {
+		t.Errorf("unexpected report:\n%s", report.String())
+	}
+
+	if _, err := svc.Get(8, batch.ID); !errors.Is(err, models.ErrBatchTransferNotFound) {
+		t.Errorf("expected ErrBatchTransferNotFound for another user, got %v", err)
+	}
+}
+
+func TestBatchTransferRejectsInvalidBatchAsAWhole(t *testing.T) {
+	svc, batchRepo, _, number := newBatchTransferFixture(t)
+
+	file := `{"destination": "` + number + `", "amount": "10.00"}
+{"destination": 99, "amount": 5}
+{"destination": 1, "amount": 5}
+{"destination": 3, "amount": "-1"}
+`
+	_, err := svc.Create(7, 1, models.BatchFormatNDJSON, strings.NewReader(file))
+	var validationErr *models.BatchValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected BatchValidationError, got %v", err)
+	}
+	if len(validationErr.Lines) != 3 || validationErr.Lines[0].LineNo != 2 {
+		t.Errorf("unexpected line errors: %+v", validationErr.Lines)
+	}
+
+	// Сумма пакета больше остатка счета.
+	_, err = svc.Create(7, 1, models.BatchFormatNDJSON, strings.NewReader(
+		`{"destination": 2, "amount": 600}`+"\n"+`{"destination": 3, "amount": 400.01}`))
+	if !errors.Is(err, models.ErrInsufficientFunds) {
+		t.Errorf("expected ErrInsufficientFunds, got %v", err)
+	}
+
+	// Пакет может загрузить только участник счета.
+	_, err = svc.Create(8, 1, models.BatchFormatCSV, strings.NewReader("2,1.00\n"))
+	if !errors.Is(err, models.ErrNotAccountOwner) {
+		t.Errorf("expected ErrNotAccountOwner, got %v", err)
+	}
+	if len(batchRepo.batches) != 0 {
+		t.Errorf("expected no batches to be saved, got %d", len(batchRepo.batches))
+	}
+}
+
+func TestBatchTransferChecksAvailableBalanceWithFees(t *testing.T) {
+	svc, batchRepo, accountRepo, _ := newBatchTransferFixture(t,
+		&models.FeeRule{ID: 1, Operation: models.FeeOperationTransfer, Fixed: rub(100), Active: true})
+	// Из 1000.00 заблокировано 100.00 авторизацией по карте.
+	accountRepo.accounts[1].Held = models.NewMoney(10000, "RUB")
+
+	// 899.00 помещаются в доступный остаток, но не вместе с двумя комиссиями по 1.00.
+	_, err := svc.Create(7, 1, models.BatchFormatCSV, strings.NewReader("2,499.00\n3,400.00\n"))
+	if !errors.Is(err, models.ErrInsufficientFunds) {
+		t.Errorf("expected ErrInsufficientFunds, got %v", err)
+	}
+	if len(batchRepo.batches) != 0 {
+		t.Errorf("expected no batches to be saved, got %d", len(batchRepo.batches))
+	}
+
+	batch, err := svc.Create(7, 1, models.BatchFormatCSV, strings.NewReader("2,498.00\n3,400.00\n"))
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if batch.Total.String() != "898.00" {
+		t.Errorf("expected total 898.00 without fees, got %s", batch.Total)
+	}
+}
+
+func TestBatchTransferDoesNotRetryInterruptedLine(t *testing.T) {
+	svc, batchRepo, accountRepo, _ := newBatchTransferFixture(t)
+
+	batch, err := svc.Create(7, 1, models.BatchFormatCSV, strings.NewReader("2,1.00\n3,2.00\n"))
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	// Предыдущий запуск упал после начала первой строки.
+	batchRepo.batches[batch.ID].Lines[0].Status = models.BatchLineProcessing
+
+	if err := svc.ProcessPending(time.Now()); err != nil {
+		t.Fatalf("ProcessPending failed: %v", err)
+	}
+	lines := batchRepo.batches[batch.ID].Lines
+	if lines[0].Status != models.BatchLineInterrupted || lines[1].Status != models.BatchLineSucceeded {
+		t.Errorf("unexpected line statuses: %s, %s", lines[0].Status, lines[1].Status)
+	}
+	if len(accountRepo.transfers) != 1 || accountRepo.transfers[0].ToAccountID != 3 {
+		t.Errorf("expected a single transfer to account 3, got %+v", accountRepo.transfers)
+	}
+}
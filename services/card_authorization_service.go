@@ -0,0 +1,217 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"bank-api/models"
+	"bank-api/repositories"
+	"bank-api/utils"
+)
+
+// CardAuthorizationService авторизует оплаты картами для торговых точек
+// и завершает авторизации списанием или отменой блокировки.
+type CardAuthorizationService interface {
+	// Authorize проверяет целостность реквизитов карты, CVV (с общим лимитом неверных попыток), срок действия, статус карты,
+	// ограничения виртуальной карты и доступный остаток и блокирует на счете сумму вместе с комиссией за оплату картой
+	// по тарифу, чтобы списание всей суммы ее покрыло. Отказ не считается ошибкой:
+	// возвращается авторизация со статусом declined и причиной отказа.
+	Authorize(req models.AuthorizationRequest) (*models.CardAuthorization, error)
+	GetAuthorization(id int) (*models.CardAuthorization, error)
+	// Capture списывает amount (nil — всю заблокированную сумму) вместе с комиссией
+	// за оплату картой по тарифу и снимает блокировку.
+	Capture(id int, amount *models.Money) (*models.CardAuthorization, error)
+	// Release отменяет блокировку без списания.
+	Release(id int) (*models.CardAuthorization, error)
+}
+
+type cardAuthorizationService struct {
+	authRepo    repositories.CardAuthorizationRepository
+	cardRepo    repositories.CardRepository
+	accountRepo repositories.AccountRepository
+	feeService  FeeService
+	// Ключ слепого индекса номеров карт (тот же, что у CardService)
+	indexKey []byte
+	// Лимит неверных CVV по карте за окно, общий с CVVService
+	cvvLimit  int
+	cvvWindow time.Duration
+}
+
+// NewCardAuthorizationService создает CardAuthorizationService.
+func NewCardAuthorizationService(
+	authRepo repositories.CardAuthorizationRepository,
+	cardRepo repositories.CardRepository,
+	accountRepo repositories.AccountRepository,
+	feeService FeeService,
+	indexKey []byte,
+	cvvLimit int,
+	cvvWindow time.Duration,
+) CardAuthorizationService {
+	return &cardAuthorizationService{
+		authRepo:    authRepo,
+		cardRepo:    cardRepo,
+		accountRepo: accountRepo,
+		feeService:  feeService,
+		indexKey:    indexKey,
+		cvvLimit:    cvvLimit,
+		cvvWindow:   cvvWindow,
+	}
+}
+
+func (s *cardAuthorizationService) Authorize(req models.AuthorizationRequest) (*models.CardAuthorization, error) {
+	auth := &models.CardAuthorization{Merchant: strings.TrimSpace(req.Merchant), Amount: req.Amount.WithCurrency(req.Currency)}
+	pan := utils.NormalizePAN(req.PAN)
+	if !utils.ValidateLuhn(pan) || !auth.Amount.IsPositive() || req.Currency == "" {
+		return s.decline(auth, models.DeclineInvalidRequest)
+	}
+
+	cards, err := s.cardRepo.ListByPANIndex(utils.PANBlindIndex(pan, s.indexKey))
+	if err != nil {
+		return nil, err
+	}
+	if len(cards) == 0 {
+		return s.decline(auth, models.DeclineCardNotFound)
+	}
+	card := selectCardByExpiry(cards, req.Expiry)
+	auth.CardID, auth.AccountID = card.ID, card.AccountID
+
+	// Реквизиты, не прошедшие проверку HMAC или расшифровку, считаются подмененными.
+	storedPAN, err := utils.DecryptPGP(card.CardNumber, card.CardNumberMAC)
+	if err != nil || storedPAN != pan {
+		return s.decline(auth, models.DeclineIntegrityFailure)
+	}
+	storedExpiry, err := utils.DecryptPGP(card.ExpirationDate, card.ExpirationMAC)
+	if err != nil {
+		return s.decline(auth, models.DeclineIntegrityFailure)
+	}
+	// CVV проверяется под тем же лимитом неверных попыток, что и проверки партнеров,
+	// иначе авторизации позволили бы перебрать CVV.
+	check := &models.CVVCheck{CardID: card.ID, ClientAddr: req.ClientAddr}
+	if _, err := s.cardRepo.CheckCVV(context.Background(), check, time.Now().Add(-s.cvvWindow), s.cvvLimit,
+		func(card *models.Card) bool { return utils.CheckCVV(req.CVV, card.CVVHash) }); err != nil {
+		return nil, err
+	}
+	switch check.Result {
+	case models.CVVCheckLimited:
+		return s.decline(auth, models.DeclineCVVAttempts)
+	case models.CVVCheckCardInactive:
+		return s.decline(auth, models.DeclineCardInactive)
+	case models.CVVCheckMismatch:
+		return s.decline(auth, models.DeclineInvalidCVV)
+	}
+	if req.Expiry != storedExpiry {
+		return s.decline(auth, models.DeclineInvalidExpiry)
+	}
+	expiresAt, err := utils.CardExpiresAt(storedExpiry)
+	if err != nil {
+		return s.decline(auth, models.DeclineIntegrityFailure)
+	}
+	if now := time.Now(); !now.Before(expiresAt) || (card.ValidUntil != nil && !now.Before(*card.ValidUntil)) {
+		return s.decline(auth, models.DeclineExpiredCard)
+	}
+	if card.Status != models.CardStatusActive {
+		return s.decline(auth, models.DeclineCardInactive)
+	}
+	// Без торговой точки карту не к чему привязать.
+	if card.Kind == models.CardKindMerchantLocked && auth.Merchant == "" {
+		return s.decline(auth, models.DeclineInvalidRequest)
+	}
+
+	acc, err := s.accountRepo.GetByID(auth.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	fee, err := s.feeService.Quote(acc, models.FeeOperationCardPurchase, auth.Amount)
+	if errors.Is(err, models.ErrCurrencyMismatch) {
+		return s.decline(auth, models.DeclineCurrencyMismatch)
+	}
+	if err != nil {
+		return nil, err
+	}
+	auth.HeldFee = fee.Fee
+
+	if auth.ApprovalCode, err = utils.GenerateApprovalCode(); err != nil {
+		return nil, err
+	}
+	err = s.authRepo.Hold(context.Background(), auth)
+	switch {
+	case errors.Is(err, models.ErrCardInactive):
+		return s.decline(auth, models.DeclineCardInactive)
+	case errors.Is(err, models.ErrCardAlreadyUsed):
+		return s.decline(auth, models.DeclineCardUsed)
+	case errors.Is(err, models.ErrCardMerchantLocked):
+		return s.decline(auth, models.DeclineMerchantLocked)
+	case errors.Is(err, models.ErrSpendCapExceeded):
+		return s.decline(auth, models.DeclineSpendCapExceeded)
+	case errors.Is(err, models.ErrAccountInactive):
+		return s.decline(auth, models.DeclineAccountInactive)
+	case errors.Is(err, models.ErrCurrencyMismatch):
+		return s.decline(auth, models.DeclineCurrencyMismatch)
+	case errors.Is(err, models.ErrInsufficientFunds):
+		return s.decline(auth, models.DeclineInsufficientFunds)
+	case err != nil:
+		return nil, err
+	}
+	return auth, nil
+}
+
+// selectCardByExpiry выбирает среди карт с одним номером (перевыпущенных с сохранением номера)
+// карту со сроком действия expiry; если такой нет — самую новую.
+func selectCardByExpiry(cards []*models.Card, expiry string) *models.Card {
+	if expiresAt, err := utils.CardExpiresAt(expiry); err == nil {
+		for _, card := range cards {
+			if card.ExpiresAt.Equal(expiresAt) {
+				return card
+			}
+		}
+	}
+	return cards[0]
+}
+
+// decline сохраняет отказ в авторизации с причиной reason.
+func (s *cardAuthorizationService) decline(auth *models.CardAuthorization, reason string) (*models.CardAuthorization, error) {
+	auth.ApprovalCode = ""
+	auth.DeclineReason = reason
+	if err := s.authRepo.RecordDecline(auth); err != nil {
+		return nil, err
+	}
+	return auth, nil
+}
+
+func (s *cardAuthorizationService) GetAuthorization(id int) (*models.CardAuthorization, error) {
+	return s.authRepo.GetByID(id)
+}
+
+// Capture рассчитывает комиссию до начала транзакции; статус авторизации и сумма
+// повторно проверяются репозиторием под блокировкой.
+func (s *cardAuthorizationService) Capture(id int, amount *models.Money) (*models.CardAuthorization, error) {
+	auth, err := s.authRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if auth.Status != models.AuthorizationStatusApproved {
+		return nil, fmt.Errorf("%w: authorization is %s", models.ErrInvalidStatusTransition, auth.Status)
+	}
+	if amount == nil {
+		amount = &auth.Amount
+	}
+	if !amount.IsPositive() {
+		return nil, models.ErrInvalidAmount
+	}
+	acc, err := s.accountRepo.GetByID(auth.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	fee, err := s.feeService.Quote(acc, models.FeeOperationCardPurchase, *amount)
+	if err != nil {
+		return nil, err
+	}
+	return s.authRepo.Capture(context.Background(), id, *amount, fee)
+}
+
+func (s *cardAuthorizationService) Release(id int) (*models.CardAuthorization, error) {
+	return s.authRepo.Release(context.Background(), id)
+}
@@ -0,0 +1,392 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"bank-api/models"
+	"bank-api/services"
+	"bank-api/utils"
+)
+
+// fakeCardAuthorizationRepo блокирует суммы на счетах fakeAccountRepo
+// и проверяет ограничения карт fakeCardRepo.
+type fakeCardAuthorizationRepo struct {
+	accounts *fakeAccountRepo
+	cards    *fakeCardRepo
+	auths    []*models.CardAuthorization
+}
+
+func (r *fakeCardAuthorizationRepo) Hold(ctx context.Context, auth *models.CardAuthorization) error {
+	card, err := r.cards.GetByID(auth.CardID)
+	if err != nil {
+		return err
+	}
+	if card.Status != models.CardStatusActive {
+		return models.ErrCardInactive
+	}
+	acc, err := r.accounts.GetByID(auth.AccountID)
+	if err != nil {
+		return err
+	}
+	if acc.Status != models.AccountStatusActive {
+		return models.ErrAccountInactive
+	}
+	if auth.Amount.Currency != acc.Currency {
+		return models.ErrCurrencyMismatch
+	}
+	uses, used := 0, models.NewMoney(0, acc.Currency)
+	for _, a := range r.auths {
+		if a.CardID != card.ID {
+			continue
+		}
+		switch a.Status {
+		case models.AuthorizationStatusApproved:
+			uses, used.Minor = uses+1, used.Minor+a.Amount.Minor
+		case models.AuthorizationStatusCaptured:
+			uses, used.Minor = uses+1, used.Minor+a.CapturedAmount.Minor
+		}
+	}
+	if err := card.CheckUsage(auth.Merchant, auth.Amount, used, uses); err != nil {
+		return err
+	}
+	if acc.Available().Minor < auth.Held().Minor {
+		return models.ErrInsufficientFunds
+	}
+	acc.Held = models.NewMoney(acc.Held.Minor+auth.Held().Minor, acc.Currency)
+	if card.Kind == models.CardKindMerchantLocked && card.LockedMerchant == "" {
+		card.LockedMerchant = auth.Merchant
+	}
+	auth.Status = models.AuthorizationStatusApproved
+	return r.RecordDecline(auth)
+}
+
+func (r *fakeCardAuthorizationRepo) RecordDecline(auth *models.CardAuthorization) error {
+	if auth.Status == "" {
+		auth.Status = models.AuthorizationStatusDeclined
+	}
+	auth.ID = len(r.auths) + 1
+	r.auths = append(r.auths, auth)
+	return nil
+}
+
+func (r *fakeCardAuthorizationRepo) GetByID(id int) (*models.CardAuthorization, error) {
+	if id < 1 || id > len(r.auths) {
+		return nil, models.ErrAuthorizationNotFound
+	}
+	return r.auths[id-1], nil
+}
+
+func (r *fakeCardAuthorizationRepo) Capture(ctx context.Context, id int, amount models.Money, fee *models.FeeCharge) (*models.CardAuthorization, error) {
+	auth, err := r.settle(id)
+	if err != nil {
+		return nil, err
+	}
+	if amount.Minor > auth.Amount.Minor {
+		return nil, models.ErrCaptureExceedsHold
+	}
+	acc, _ := r.accounts.GetByID(auth.AccountID)
+	charged := amount.Minor
+	if fee != nil {
+		charged += fee.Fee.Minor
+	}
+	if acc.Available().Minor+auth.Held().Minor < charged {
+		return nil, models.ErrInsufficientFunds
+	}
+	acc.Held = models.NewMoney(acc.Held.Minor-auth.Held().Minor, acc.Currency)
+	acc.Balance = models.NewMoney(acc.Balance.Minor-charged, acc.Currency)
+	auth.Status = models.AuthorizationStatusCaptured
+	auth.CapturedAmount = amount.WithCurrency(acc.Currency)
+	if card, _ := r.cards.GetByID(auth.CardID); card != nil && card.Kind == models.CardKindSingleUse &&
+		card.Status == models.CardStatusActive {
+		_, err = r.cards.ChangeStatus(ctx, &models.CardStatusChange{
+			CardID: card.ID, ToStatus: models.CardStatusExpired, ActorID: card.UserID, ActorRole: models.CardActorSystem,
+		}, models.CardStatusActive)
+	}
+	return auth, err
+}
+
+func (r *fakeCardAuthorizationRepo) Release(ctx context.Context, id int) (*models.CardAuthorization, error) {
+	auth, err := r.settle(id)
+	if err != nil {
+		return nil, err
+	}
+	acc, _ := r.accounts.GetByID(auth.AccountID)
+	acc.Held = models.NewMoney(acc.Held.Minor-auth.Held().Minor, acc.Currency)
+	auth.Status = models.AuthorizationStatusReleased
+	return auth, nil
+}
+
+func (r *fakeCardAuthorizationRepo) settle(id int) (*models.CardAuthorization, error) {
+	auth, err := r.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if auth.Status != models.AuthorizationStatusApproved {
+		return nil, models.ErrInvalidStatusTransition
+	}
+	return auth, nil
+}
+
+func TestAuthorizeCaptureAndRelease(t *testing.T) {
+	accountRepo := &fakeAccountRepo{accounts: map[int]*models.Account{
+		101: {ID: 101, UserID: 42, Balance: models.NewMoney(10000, "RUB"), Currency: "RUB", Status: models.AccountStatusActive},
+	}}
+	cardRepo := &fakeCardRepo{}
+	cardService := services.NewCardService(cardRepo, accountRepo, newFakeCardProductRepo(), newFakeUserRepo(), testCardIndexKey, 3, time.Minute)
+	if _, err := cardService.CreateCard(42, 101, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	card, err := cardService.GetCardByID(42, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cardRepo.cards[0].CVVHash, err = utils.HashCVV("123"); err != nil {
+		t.Fatalf("hash CVV: %v", err)
+	}
+	authRepo := &fakeCardAuthorizationRepo{accounts: accountRepo, cards: cardRepo}
+	feeService := services.NewFeeService(&fakeFeeRepo{rules: []*models.FeeRule{
+		{ID: 1, Operation: models.FeeOperationCardPurchase, Fixed: rub(100), Active: true},
+	}}, accountRepo)
+	svc := services.NewCardAuthorizationService(authRepo, cardRepo, accountRepo, feeService, testCardIndexKey, 3, time.Hour)
+
+	request := func(cvv, expiry string, minor int64) models.AuthorizationRequest {
+		return models.AuthorizationRequest{PAN: card.CardNumber, Expiry: expiry, CVV: cvv,
+			Amount: models.NewMoney(minor, ""), Currency: "RUB", Merchant: "coffee shop"}
+	}
+	declines := []struct {
+		name   string
+		req    models.AuthorizationRequest
+		reason string
+	}{
+		{"wrong CVV", request("999", card.ExpirationDate, 1000), models.DeclineInvalidCVV},
+		{"wrong expiry", request("123", "01/20", 1000), models.DeclineInvalidExpiry},
+		{"unknown card", models.AuthorizationRequest{PAN: "4111111111111111", Expiry: "01/30", CVV: "123",
+			Amount: models.NewMoney(1000, ""), Currency: "RUB"}, models.DeclineCardNotFound},
+		{"over balance", request("123", card.ExpirationDate, 10001), models.DeclineInsufficientFunds},
+		{"wrong currency", models.AuthorizationRequest{PAN: card.CardNumber, Expiry: card.ExpirationDate, CVV: "123",
+			Amount: models.NewMoney(1000, ""), Currency: "USD"}, models.DeclineCurrencyMismatch},
+	}
+	for _, tc := range declines {
+		auth, err := svc.Authorize(tc.req)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.name, err)
+		}
+		if auth.Status != models.AuthorizationStatusDeclined || auth.DeclineReason != tc.reason || auth.ApprovalCode != "" {
+			t.Errorf("%s: expected decline %q, got %s %q", tc.name, tc.reason, auth.Status, auth.DeclineReason)
+		}
+	}
+
+	first, err := svc.Authorize(request("123", card.ExpirationDate, 6000))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if first.Status != models.AuthorizationStatusApproved || len(first.ApprovalCode) != 6 {
+		t.Fatalf("expected approval with code, got %+v", first)
+	}
+	// Заблокированная сумма недоступна следующей авторизации.
+	if second, _ := svc.Authorize(request("123", card.ExpirationDate, 5000)); second.DeclineReason != models.DeclineInsufficientFunds {
+		t.Errorf("expected insufficient funds while held, got %+v", second)
+	}
+
+	partial := models.NewMoney(4500, "")
+	captured, err := svc.Capture(first.ID, &partial)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	acc := accountRepo.accounts[101]
+	// Вместе со списанием взимается комиссия за оплату картой.
+	if captured.CapturedAmount.String() != "45.00" || acc.Balance.String() != "54.00" || !acc.Held.IsZero() {
+		t.Errorf("expected 45.00 captured with 1.00 fee, balance 54.00 and no hold, got %s, %s, %s", captured.CapturedAmount, acc.Balance, acc.Held)
+	}
+	if _, err := svc.Release(first.ID); !errors.Is(err, models.ErrInvalidStatusTransition) {
+		t.Errorf("expected ErrInvalidStatusTransition, got %v", err)
+	}
+
+	second, err := svc.Authorize(request("123", card.ExpirationDate, 5000))
+	if err != nil || second.Status != models.AuthorizationStatusApproved {
+		t.Fatalf("expected approval, got %+v, %v", second, err)
+	}
+	if _, err := svc.Release(second.ID); err != nil || !acc.Held.IsZero() {
+		t.Errorf("expected hold released, got %s, %v", acc.Held, err)
+	}
+
+	// Комиссия блокируется вместе с суммой: из 54.00 авторизуется не больше 53.00,
+	// и их списание покрывает комиссию.
+	if over, _ := svc.Authorize(request("123", card.ExpirationDate, 5301)); over.DeclineReason != models.DeclineInsufficientFunds {
+		t.Errorf("expected insufficient funds for the fee, got %+v", over)
+	}
+	whole, err := svc.Authorize(request("123", card.ExpirationDate, 5300))
+	if err != nil || whole.Status != models.AuthorizationStatusApproved || whole.HeldFee.String() != "1.00" {
+		t.Fatalf("expected approval holding 1.00 fee, got %+v, %v", whole, err)
+	}
+	if !acc.Available().IsZero() {
+		t.Errorf("expected the whole balance held, got %s available", acc.Available())
+	}
+	if _, err := svc.Capture(whole.ID, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !acc.Balance.IsZero() || !acc.Held.IsZero() {
+		t.Errorf("expected balance and hold 0.00, got %s, %s", acc.Balance, acc.Held)
+	}
+
+	// Заблокированная карта не авторизуется.
+	if _, err := cardService.BlockCard(42, 1, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if blocked, _ := svc.Authorize(request("123", card.ExpirationDate, 100)); blocked.DeclineReason != models.DeclineCardInactive {
+		t.Errorf("expected card_inactive, got %+v", blocked)
+	}
+}
+
+func TestAuthorizeVirtualCards(t *testing.T) {
+	accountRepo := &fakeAccountRepo{accounts: map[int]*models.Account{
+		101: {ID: 101, UserID: 42, Balance: models.NewMoney(10000, "RUB"), Currency: "RUB", Status: models.AccountStatusActive},
+	}}
+	cardRepo := &fakeCardRepo{}
+	cardService := services.NewCardService(cardRepo, accountRepo, newFakeCardProductRepo(), newFakeUserRepo(), testCardIndexKey, 3, time.Minute)
+	authRepo := &fakeCardAuthorizationRepo{accounts: accountRepo, cards: cardRepo}
+	svc := services.NewCardAuthorizationService(authRepo, cardRepo, accountRepo, noFees(accountRepo), testCardIndexKey, 3, time.Hour)
+
+	past := time.Now().Add(-time.Hour)
+	invalid := []struct {
+		name string
+		req  models.VirtualCardRequest
+		want error
+	}{
+		{"standard kind", models.VirtualCardRequest{Kind: models.CardKindStandard, SpendCap: models.NewMoney(100, "")}, models.ErrInvalidVirtualCard},
+		{"no spend cap", models.VirtualCardRequest{Kind: models.CardKindSingleUse}, models.ErrInvalidVirtualCard},
+		{"past validity", models.VirtualCardRequest{Kind: models.CardKindSingleUse, SpendCap: models.NewMoney(100, ""), ValidUntil: &past}, models.ErrInvalidVirtualCard},
+		{"cap currency", models.VirtualCardRequest{Kind: models.CardKindSingleUse, SpendCap: models.NewMoney(100, "USD")}, models.ErrCurrencyMismatch},
+	}
+	for _, tc := range invalid {
+		if _, err := cardService.CreateVirtualCard(42, 101, tc.req); !errors.Is(err, tc.want) {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.want, err)
+		}
+	}
+
+	// issue выпускает виртуальную карту и возвращает функцию авторизации по ней.
+	issue := func(req models.VirtualCardRequest) (*models.Card, func(merchant string, minor int64) *models.CardAuthorization) {
+		created, err := cardService.CreateVirtualCard(42, 101, req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		card, err := cardService.GetCardByID(42, created.ID)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if req.ValidUntil == nil && (card.ValidUntil == nil || !card.ValidUntil.Equal(card.ExpiresAt)) {
+			t.Errorf("expected validity until card expiry, got %v", card.ValidUntil)
+		}
+		return created, func(merchant string, minor int64) *models.CardAuthorization {
+			auth, err := svc.Authorize(models.AuthorizationRequest{PAN: card.CardNumber, Expiry: card.ExpirationDate,
+				CVV: created.CVV, Amount: models.NewMoney(minor, ""), Currency: "RUB", Merchant: merchant})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			return auth
+		}
+	}
+	expect := func(name string, auth *models.CardAuthorization, reason string) {
+		t.Helper()
+		if reason == "" && auth.Status != models.AuthorizationStatusApproved {
+			t.Errorf("%s: expected approval, got %s %q", name, auth.Status, auth.DeclineReason)
+		}
+		if reason != "" && auth.DeclineReason != reason {
+			t.Errorf("%s: expected decline %q, got %s %q", name, reason, auth.Status, auth.DeclineReason)
+		}
+	}
+
+	// Одноразовая карта: одна оплата в пределах лимита, после списания карта выведена из обращения.
+	single, authorize := issue(models.VirtualCardRequest{Kind: models.CardKindSingleUse, SpendCap: models.NewMoney(3000, "")})
+	expect("over cap", authorize("shop", 4000), models.DeclineSpendCapExceeded)
+	first := authorize("shop", 2000)
+	expect("first use", first, "")
+	expect("second use", authorize("shop", 500), models.DeclineCardUsed)
+	if _, err := svc.Capture(first.ID, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if single.Status != models.CardStatusExpired {
+		t.Errorf("expected single-use card expired after capture, got %s", single.Status)
+	}
+	expect("after capture", authorize("shop", 500), models.DeclineCardInactive)
+
+	// Карта с привязкой: торговая точка первой оплаты, лимит на все оплаты вместе.
+	validUntil := time.Now().Add(time.Hour)
+	locked, authorize := issue(models.VirtualCardRequest{Kind: models.CardKindMerchantLocked,
+		SpendCap: models.NewMoney(5000, ""), ValidUntil: &validUntil})
+	expect("no merchant", authorize(" ", 1000), models.DeclineInvalidRequest)
+	expect("first merchant", authorize("Coffee Shop", 2000), "")
+	if locked.LockedMerchant != "Coffee Shop" {
+		t.Errorf("expected card locked to Coffee Shop, got %q", locked.LockedMerchant)
+	}
+	expect("other merchant", authorize("book store", 1000), models.DeclineMerchantLocked)
+	second := authorize(" coffee shop ", 1000)
+	expect("same merchant", second, "")
+	expect("over cap", authorize("Coffee Shop", 2500), models.DeclineSpendCapExceeded)
+	// Отмененная авторизация не расходует лимит.
+	if _, err := svc.Release(second.ID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expect("after release", authorize("Coffee Shop", 2500), "")
+
+	// По истечении срока использования карта не авторизуется и выводится из обращения.
+	*locked.ValidUntil = past
+	expect("validity passed", authorize("Coffee Shop", 100), models.DeclineExpiredCard)
+	expired, err := cardRepo.ExpireDue(context.Background(), time.Now(), 10)
+	if err != nil || len(expired) != 1 || expired[0].ID != locked.ID {
+		t.Errorf("expected only the merchant-locked card expired, got %v, %v", expired, err)
+	}
+}
+
+func TestAuthorizeSharesCVVLimit(t *testing.T) {
+	accountRepo := &fakeAccountRepo{accounts: map[int]*models.Account{
+		101: {ID: 101, UserID: 42, Balance: models.NewMoney(10000, "RUB"), Currency: "RUB", Status: models.AccountStatusActive},
+	}}
+	cardRepo := &fakeCardRepo{}
+	cardService := services.NewCardService(cardRepo, accountRepo, newFakeCardProductRepo(), newFakeUserRepo(), testCardIndexKey, 3, time.Minute)
+	created, err := cardService.CreateCard(42, 101, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	card, err := cardService.GetCardByID(42, created.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	wrong := "000"
+	if created.CVV == wrong {
+		wrong = "111"
+	}
+	authRepo := &fakeCardAuthorizationRepo{accounts: accountRepo, cards: cardRepo}
+	svc := services.NewCardAuthorizationService(authRepo, cardRepo, accountRepo, noFees(accountRepo), testCardIndexKey, 3, time.Hour)
+	cvvService := services.NewCVVService(cardRepo, 3, time.Hour)
+	authorize := func(cvv string) *models.CardAuthorization {
+		auth, err := svc.Authorize(models.AuthorizationRequest{PAN: card.CardNumber, Expiry: card.ExpirationDate, CVV: cvv,
+			Amount: models.NewMoney(100, ""), Currency: "RUB", Merchant: "shop", ClientAddr: "10.0.0.2:4000"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return auth
+	}
+
+	// Неверные CVV в авторизациях и в проверках партнеров расходуют один лимит.
+	for i := 0; i < 2; i++ {
+		if auth := authorize(wrong); auth.DeclineReason != models.DeclineInvalidCVV {
+			t.Fatalf("expected invalid_cvv, got %+v", auth)
+		}
+	}
+	if result, err := cvvService.VerifyCVV(card.ID, wrong, ""); err != nil || result.AttemptsLeft != 0 {
+		t.Fatalf("expected the last attempt spent, got %+v, %v", result, err)
+	}
+	if auth := authorize(created.CVV); auth.DeclineReason != models.DeclineCVVAttempts {
+		t.Errorf("expected cvv_attempts_exceeded with the right CVV, got %+v", auth)
+	}
+	if _, err := cvvService.VerifyCVV(card.ID, created.CVV, ""); !errors.Is(err, models.ErrTooManyAttempts) {
+		t.Errorf("expected ErrTooManyAttempts, got %v", err)
+	}
+	if cardRepo.cvvChecks[0].ClientAddr != "10.0.0.2:4000" {
+		t.Errorf("expected authorization CVV checks logged with the client address, got %+v", cardRepo.cvvChecks[0])
+	}
+}
@@ -0,0 +1,70 @@
+package services_test
+
+import (
+	"testing"
+
+	"bank-api/models"
+	"bank-api/services"
+	"bank-api/utils"
+)
+
+func TestReencryptCardsMovesCardsToCurrentKey(t *testing.T) {
+	oldKey, err := utils.GenerateCardKey("k1")
+	if err != nil {
+		t.Fatalf("GenerateCardKey error: %v", err)
+	}
+	newKey, err := utils.GenerateCardKey("k2")
+	if err != nil {
+		t.Fatalf("GenerateCardKey error: %v", err)
+	}
+	oldKeyring, _ := utils.NewKeyring("k1", oldKey)
+	rotated, err := utils.NewKeyring("k2", oldKey, newKey)
+	if err != nil {
+		t.Fatalf("NewKeyring error: %v", err)
+	}
+
+	repo := &fakeCardRepo{}
+	for i := 0; i < 3; i++ {
+		number, numberMAC, _ := oldKeyring.Encrypt("220012000000000" + string(rune('1'+i)))
+		expiry, expiryMAC, _ := oldKeyring.Encrypt("05/30")
+		repo.Create(&models.Card{UserID: 1, AccountID: 1, CardNumber: number, CardNumberMAC: numberMAC,
+			ExpirationDate: expiry, ExpirationMAC: expiryMAC, KeyID: "k1"})
+	}
+	// Карта с шифротекстом до введения связки ключей расшифровке не подлежит.
+	repo.Create(&models.Card{UserID: 1, AccountID: 1, CardNumber: "abcd", CardNumberMAC: "00", ExpirationDate: "abcd"})
+	// Перевыпущенная карта уже на новом ключе, но ее невыданный CVV зашифрован прежним.
+	number, numberMAC, _ := rotated.Encrypt("2200120000000009")
+	expiry, expiryMAC, _ := rotated.Encrypt("05/30")
+	pendingCVV, pendingCVVMAC, _ := oldKeyring.Encrypt("123")
+	repo.Create(&models.Card{UserID: 1, AccountID: 1, CardNumber: number, CardNumberMAC: numberMAC,
+		ExpirationDate: expiry, ExpirationMAC: expiryMAC, KeyID: "k2", PendingCVV: pendingCVV, PendingCVVMAC: pendingCVVMAC})
+
+	svc := services.NewCardKeyService(repo, rotated)
+	result, err := svc.ReencryptCards(2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.KeyID != "k2" || result.Reencrypted != 4 || result.Failed != 1 || result.Skipped != 0 {
+		t.Errorf("expected 4 re-encrypted and 1 failed under k2, got %+v", result)
+	}
+	reissued := repo.cards[4]
+	if utils.CipherKeyID(reissued.PendingCVV) != "k2" {
+		t.Errorf("expected pending CVV moved to k2, got %q", utils.CipherKeyID(reissued.PendingCVV))
+	}
+	if cvv, err := rotated.Decrypt(reissued.PendingCVV, reissued.PendingCVVMAC); err != nil || cvv != "123" {
+		t.Errorf("expected pending CVV to survive re-encryption, got %q, %v", cvv, err)
+	}
+	for _, card := range repo.cards[:3] {
+		if card.KeyID != "k2" || utils.CipherKeyID(card.CardNumber) != "k2" {
+			t.Errorf("card %d: expected key k2, got %q", card.ID, card.KeyID)
+		}
+		if expiry, err := rotated.Decrypt(card.ExpirationDate, card.ExpirationMAC); err != nil || expiry != "05/30" {
+			t.Errorf("card %d: expected expiry to survive re-encryption, got %q, %v", card.ID, expiry, err)
+		}
+	}
+
+	// Повторный запуск не трогает уже перешифрованные карты.
+	if result, err = svc.ReencryptCards(2); err != nil || result.Reencrypted != 0 {
+		t.Errorf("expected nothing left to re-encrypt, got %+v, %v", result, err)
+	}
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+
+	"bank-api/models"
+	"bank-api/repositories"
+	"bank-api/utils"
+)
+
+// CardProductService управляет карточными продуктами.
+type CardProductService interface {
+	// ListProducts возвращает продукты, доступные для выпуска карт.
+	ListProducts() ([]*models.CardProduct, error)
+	// CreateProduct добавляет продукт; доступно только операционисту.
+	CreateProduct(role string, product *models.CardProduct) error
+	// DeactivateProduct прекращает выпуск карт по продукту; доступно только операционисту.
+	DeactivateProduct(role, code string) error
+}
+
+type cardProductService struct {
+	productRepo repositories.CardProductRepository
+}
+
+// NewCardProductService создает CardProductService.
+func NewCardProductService(productRepo repositories.CardProductRepository) CardProductService {
+	return &cardProductService{productRepo: productRepo}
+}
+
+func (s *cardProductService) ListProducts() ([]*models.CardProduct, error) {
+	return s.productRepo.List(true)
+}
+
+func (s *cardProductService) CreateProduct(role string, product *models.CardProduct) error {
+	if role != models.RoleOperator {
+		return models.ErrOperatorRequired
+	}
+	if err := validateCardProduct(product); err != nil {
+		return err
+	}
+	if _, err := s.productRepo.GetByCode(product.Code); err == nil {
+		return fmt.Errorf("%w: code %q is already taken", models.ErrInvalidCardProduct, product.Code)
+	} else if !errors.Is(err, models.ErrCardProductNotFound) {
+		return err
+	}
+	product.Active = true
+	return s.productRepo.Create(product)
+}
+
+func (s *cardProductService) DeactivateProduct(role, code string) error {
+	if role != models.RoleOperator {
+		return models.ErrOperatorRequired
+	}
+	return s.productRepo.Deactivate(code)
+}
+
+var cardProductCode = regexp.MustCompile(`^[a-z0-9_]{2,32}$`)
+
+func validateCardProduct(p *models.CardProduct) error {
+	if !cardProductCode.MatchString(p.Code) {
+		return fmt.Errorf("%w: code must be 2-32 lowercase letters, digits or underscores", models.ErrInvalidCardProduct)
+	}
+	if p.Name == "" {
+		return fmt.Errorf("%w: name is required", models.ErrInvalidCardProduct)
+	}
+	switch p.PaymentSystem {
+	case models.PaymentSystemMir, models.PaymentSystemVisa, models.PaymentSystemMastercard:
+	default:
+		return fmt.Errorf("%w: unknown payment system %q", models.ErrInvalidCardProduct, p.PaymentSystem)
+	}
+	switch p.Type {
+	case models.CardTypeDebit, models.CardTypeCredit, models.CardTypePrepaid:
+	default:
+		return fmt.Errorf("%w: unknown card type %q", models.ErrInvalidCardProduct, p.Type)
+	}
+	if err := utils.ValidateBINRange(p.BINFrom, p.BINTo, p.PANLength); err != nil {
+		return fmt.Errorf("%w: %v", models.ErrInvalidCardProduct, err)
+	}
+	if p.ValidityYears < 1 || p.ValidityYears > 10 {
+		return fmt.Errorf("%w: validity must be 1 to 10 years", models.ErrInvalidCardProduct)
+	}
+	return nil
+}
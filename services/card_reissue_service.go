@@ -0,0 +1,150 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"bank-api/models"
+	"bank-api/repositories"
+	"bank-api/utils"
+)
+
+// CardReissueNotifier сообщает держателю о перевыпуске карты.
+type CardReissueNotifier func(email string, oldCard, newCard models.CardSummary) error
+
+// CardReissueService перевыпускает карты с истекающим сроком действия.
+type CardReissueService interface {
+	// Run за один запуск обрабатывает не больше batchSize карт на каждом шаге:
+	// заполняет expires_at картам, выпущенным до его появления; перевыпускает активные
+	// карты, срок которых истекает в пределах окна, и уведомляет держателей;
+	// переводит в статус expired карты, срок которых наступил к now.
+	Run(now time.Time, batchSize int) (*models.CardReissueResult, error)
+}
+
+type cardReissueService struct {
+	cardRepo    repositories.CardRepository
+	productRepo repositories.CardProductRepository
+	userRepo    repositories.UserRepository
+	// Ключ слепого индекса номеров карт (тот же, что у CardService)
+	indexKey []byte
+	// За сколько до окончания срока карта перевыпускается
+	window time.Duration
+	notify CardReissueNotifier
+}
+
+// NewCardReissueService создает CardReissueService.
+func NewCardReissueService(
+	cardRepo repositories.CardRepository,
+	productRepo repositories.CardProductRepository,
+	userRepo repositories.UserRepository,
+	indexKey []byte,
+	window time.Duration,
+	notify CardReissueNotifier,
+) CardReissueService {
+	return &cardReissueService{
+		cardRepo:    cardRepo,
+		productRepo: productRepo,
+		userRepo:    userRepo,
+		indexKey:    indexKey,
+		window:      window,
+		notify:      notify,
+	}
+}
+
+// Run обрабатывает ошибки отдельных карт, не прерывая запуск: такие карты
+// учитываются в Failed и попадут в следующий запуск.
+func (s *cardReissueService) Run(now time.Time, batchSize int) (*models.CardReissueResult, error) {
+	result := &models.CardReissueResult{}
+
+	cards, err := s.cardRepo.ListWithoutExpiry(batchSize)
+	if err != nil {
+		return result, err
+	}
+	for _, card := range cards {
+		if err := s.backfillExpiry(card); err != nil {
+			log.Printf("card %d: expiry backfill failed: %v", card.ID, err)
+			result.Failed++
+			continue
+		}
+		result.Backfilled++
+	}
+
+	if cards, err = s.cardRepo.ListExpiring(now.Add(s.window), batchSize); err != nil {
+		return result, err
+	}
+	for _, card := range cards {
+		if err := s.reissue(card); err != nil {
+			log.Printf("card %d: reissue failed: %v", card.ID, err)
+			result.Failed++
+			continue
+		}
+		result.Reissued++
+	}
+
+	expired, err := s.cardRepo.ExpireDue(context.Background(), now, batchSize)
+	result.Expired = len(expired)
+	return result, err
+}
+
+// backfillExpiry заполняет expires_at по расшифрованному сроку действия карты.
+func (s *cardReissueService) backfillExpiry(card *models.Card) error {
+	expiry, err := utils.DecryptPGP(card.ExpirationDate, card.ExpirationMAC)
+	if err != nil {
+		return err
+	}
+	expiresAt, err := utils.CardExpiresAt(expiry)
+	if err != nil {
+		return err
+	}
+	return s.cardRepo.SetExpiresAt(card.ID, expiresAt)
+}
+
+// reissue выпускает карту взамен card к тому же счету и по тому же продукту с новыми
+// сроком действия и CVV. Номер и PIN сохраняются, если этого требует продукт.
+// CVV не отправляется в письме: держатель получает его через RevealCard.
+func (s *cardReissueService) reissue(card *models.Card) error {
+	product, err := s.productRepo.GetByCode(card.ProductCode)
+	if err != nil {
+		return err
+	}
+	old, err := decryptCard(card)
+	if err != nil {
+		return err
+	}
+	pan := ""
+	if product.ReissueKeepPAN {
+		pan = old.CardNumber
+	}
+	replacement, err := issueCard(s.cardRepo, s.indexKey, product, pan)
+	if err != nil {
+		return err
+	}
+	replacement.UserID = card.UserID
+	replacement.AccountID = card.AccountID
+	replacement.ReissuedFrom = card.ID
+	if product.ReissueKeepPAN {
+		// PVV зависит только от номера и PIN, поэтому PIN продолжает действовать.
+		replacement.PVV, replacement.PVKI = card.PVV, card.PVKI
+	}
+	// CVV новой карты держатель получит один раз при раскрытии реквизитов.
+	if replacement.PendingCVV, replacement.PendingCVVMAC, err = utils.EncryptPGP(replacement.CVV); err != nil {
+		return err
+	}
+	if err := s.cardRepo.Create(replacement); err != nil {
+		return err
+	}
+
+	// Карта уже выпущена: ошибка уведомления не отменяет перевыпуск.
+	decrypted, err := decryptCard(replacement)
+	if err == nil {
+		var user *models.User
+		if user, err = s.userRepo.GetByID(card.UserID); err == nil {
+			err = s.notify(user.Email, summarizeCard(old), summarizeCard(decrypted))
+		}
+	}
+	if err != nil {
+		log.Printf("card %d: reissue notification failed: %v", card.ID, err)
+	}
+	return nil
+}
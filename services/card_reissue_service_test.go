@@ -0,0 +1,116 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"bank-api/models"
+	"bank-api/services"
+	"bank-api/utils"
+)
+
+func TestCardReissue(t *testing.T) {
+	accountRepo := &fakeAccountRepo{accounts: map[int]*models.Account{
+		101: {ID: 101, UserID: 1, Currency: "RUB", Status: models.AccountStatusActive},
+	}}
+	hash, err := utils.HashPassword("password123")
+	if err != nil {
+		t.Fatalf("hash password: %v", err)
+	}
+	userRepo := newFakeUserRepo()
+	userRepo.Create(&models.User{Email: "holder@example.com", PasswordHash: hash})
+	productRepo := newFakeCardProductRepo()
+	productRepo.products[models.DefaultCardProduct].ReissueKeepPAN = true
+	cardRepo := &fakeCardRepo{}
+	cardService := services.NewCardService(cardRepo, accountRepo, productRepo, userRepo, testCardIndexKey, 3, time.Minute)
+	for _, product := range []string{models.DefaultCardProduct, "mir_prepaid", models.DefaultCardProduct} {
+		if _, err := cardService.CreateCard(1, 101, product); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	now := time.Now()
+	keepPAN, newPAN, legacy := cardRepo.cards[0], cardRepo.cards[1], cardRepo.cards[2]
+	keepPAN.ExpiresAt = now.Add(10 * 24 * time.Hour)
+	keepPAN.PVV, keepPAN.PVKI = "1234", 1
+	newPAN.ExpiresAt = now.Add(20 * 24 * time.Hour)
+	legacy.ExpiresAt = time.Time{}
+
+	type notification struct {
+		email    string
+		old, new models.CardSummary
+	}
+	var sent []notification
+	svc := services.NewCardReissueService(cardRepo, productRepo, userRepo, testCardIndexKey, 30*24*time.Hour,
+		func(email string, oldCard, newCard models.CardSummary) error {
+			sent = append(sent, notification{email, oldCard, newCard})
+			return nil
+		})
+
+	result, err := svc.Run(now, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if *result != (models.CardReissueResult{Backfilled: 1, Reissued: 2}) {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	if legacy.ExpiresAt.IsZero() {
+		t.Error("expected expiry of legacy card to be backfilled")
+	}
+	if len(cardRepo.cards) != 5 {
+		t.Fatalf("expected 2 reissued cards, got %d cards", len(cardRepo.cards))
+	}
+	for i, old := range []*models.Card{keepPAN, newPAN} {
+		reissued := cardRepo.cards[3+i]
+		if reissued.ReissuedFrom != old.ID || reissued.AccountID != old.AccountID ||
+			reissued.ProductCode != old.ProductCode || reissued.CVVHash == old.CVVHash ||
+			!reissued.ExpiresAt.After(old.ExpiresAt) || reissued.Status != models.CardStatusActive {
+			t.Errorf("unexpected reissued card %+v for card %d", reissued, old.ID)
+		}
+	}
+	pan := func(card *models.Card) string {
+		number, err := utils.DecryptPGP(card.CardNumber, card.CardNumberMAC)
+		if err != nil {
+			t.Fatalf("decrypt PAN: %v", err)
+		}
+		return number
+	}
+	if pan(cardRepo.cards[3]) != pan(keepPAN) || cardRepo.cards[3].PVV != "1234" {
+		t.Error("expected PAN and PIN kept by product policy")
+	}
+	if pan(cardRepo.cards[4]) == pan(newPAN) || cardRepo.cards[4].PVV != "" {
+		t.Error("expected a new PAN without PIN")
+	}
+	if len(sent) != 2 || sent[0].email != "holder@example.com" || sent[0].new.ReissuedFrom != keepPAN.ID {
+		t.Errorf("unexpected notifications %+v", sent)
+	}
+
+	// CVV новой карты держатель получает один раз при раскрытии реквизитов.
+	details, err := cardService.RevealCard(1, cardRepo.cards[4].ID, "password123")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(details.CVV) != 3 || !utils.CheckCVV(details.CVV, cardRepo.cards[4].CVVHash) {
+		t.Errorf("expected CVV of the reissued card, got %q", details.CVV)
+	}
+	if details, err = cardService.RevealCard(1, cardRepo.cards[4].ID, "password123"); err != nil || details.CVV != "" {
+		t.Errorf("expected CVV to be revealed only once, got %+v, %v", details, err)
+	}
+
+	// Перевыпущенные карты повторно не перевыпускаются.
+	if result, err = svc.Run(now, 10); err != nil || result.Reissued != 0 {
+		t.Fatalf("expected no reissue on second run, got %+v, %v", result, err)
+	}
+
+	// В день окончания срока старые карты переходят в expired.
+	result, err = svc.Run(now.Add(15*24*time.Hour), 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Expired != 1 || keepPAN.Status != models.CardStatusExpired || newPAN.Status != models.CardStatusActive {
+		t.Errorf("expected only the first card expired, got %+v", result)
+	}
+	last := cardRepo.history[len(cardRepo.history)-1]
+	if last.CardID != keepPAN.ID || last.ToStatus != models.CardStatusExpired || last.ActorRole != models.CardActorSystem {
+		t.Errorf("unexpected status change %+v", last)
+	}
+}
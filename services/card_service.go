@@ -1,6 +1,8 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -11,30 +13,177 @@ import (
 
 // CardService описывает методы работы с картами.
 type CardService interface {
-	CreateCard(userID, accountID int) (*models.Card, error)
-	GetCardByID(id int) (*models.Card, error)
+	// CreateCard выпускает карту к счету по продукту productCode
+	// (пустой — models.DefaultCardProduct). CVV возвращается в открытом виде
+	// только здесь и нигде не сохраняется.
+	CreateCard(userID, accountID int, productCode string) (*models.Card, error)
+	// CreateVirtualCard выпускает одноразовую карту или карту с привязкой к торговой точке
+	// с лимитом суммы оплат req.SpendCap и сроком использования req.ValidUntil
+	// (пустой — до окончания срока действия карты).
+	CreateVirtualCard(userID, accountID int, req models.VirtualCardRequest) (*models.Card, error)
+	// GetCardByID возвращает карту с расшифрованными реквизитами держателю
+	// или участнику счета с полным доступом.
+	GetCardByID(userID, id int) (*models.Card, error)
+	// GetCard возвращает карту с замаскированным номером.
+	GetCard(userID, id int) (*models.CardSummary, error)
+	// ListCards возвращает карты, держателем которых является пользователь.
+	ListCards(userID int) ([]models.CardSummary, error)
+	// ListAccountCards возвращает карты счета: участникам с полным доступом — все,
+	// остальным участникам — только свои.
+	ListAccountCards(userID, accountID int) ([]models.CardSummary, error)
+	// RevealCard раскрывает реквизиты карты после повторной проверки пароля;
+	// CVV перевыпущенной карты отдается только при первом раскрытии.
+	// Не больше revealLimit попыток пользователя за revealWindow, иначе ErrTooManyAttempts.
+	RevealCard(userID, id int, password string) (*models.CardDetails, error)
+	// BlockCard временно блокирует активную карту по запросу держателя.
+	BlockCard(userID, id int, reason string) (*models.CardSummary, error)
+	// UnblockCard снимает временную блокировку карты.
+	UnblockCard(userID, id int, reason string) (*models.CardSummary, error)
+	// CancelCard окончательно блокирует карту со статусом lost, stolen или expired;
+	// доступно только операционисту.
+	CancelCard(operatorID int, role string, id int, status, reason string) (*models.CardSummary, error)
+	// UnlockCardPIN снимает блокировку карты по неверным PIN и сбрасывает счетчик попыток;
+	// доступно только операционисту.
+	UnlockCardPIN(operatorID int, role string, id int, reason string) (*models.CardSummary, error)
+	// CardStatusHistory возвращает историю статусов карты держателю, участнику с полным
+	// доступом или операционисту.
+	CardStatusHistory(userID int, role string, id int) ([]models.CardStatusChange, error)
 }
 
 type cardService struct {
-	cardRepo repositories.CardRepository
+	cardRepo    repositories.CardRepository
+	accountRepo repositories.AccountRepository
+	productRepo repositories.CardProductRepository
+	userRepo    repositories.UserRepository
+	// Ключ слепого индекса номеров карт
+	indexKey []byte
+	// Ограничение частоты раскрытия реквизитов: попыток за окно
+	revealLimit  int
+	revealWindow time.Duration
 }
 
 // NewCardService возвращает CardService.
-func NewCardService(repo repositories.CardRepository) CardService {
-	return &cardService{cardRepo: repo}
+func NewCardService(
+	repo repositories.CardRepository,
+	accountRepo repositories.AccountRepository,
+	productRepo repositories.CardProductRepository,
+	userRepo repositories.UserRepository,
+	indexKey []byte,
+	revealLimit int,
+	revealWindow time.Duration,
+) CardService {
+	return &cardService{
+		cardRepo:     repo,
+		accountRepo:  accountRepo,
+		productRepo:  productRepo,
+		userRepo:     userRepo,
+		indexKey:     indexKey,
+		revealLimit:  revealLimit,
+		revealWindow: revealWindow,
+	}
+}
+
+// cardNumberAttempts — сколько раз генерируется номер, прежде чем признать диапазон BIN исчерпанным.
+const cardNumberAttempts = 10
+
+// CreateCard генерирует виртуальную карту к активному счету пользователя и сохраняет в БД.
+func (s *cardService) CreateCard(userID, accountID int, productCode string) (*models.Card, error) {
+	return s.createCard(userID, accountID, productCode, nil)
+}
+
+func (s *cardService) CreateVirtualCard(userID, accountID int, req models.VirtualCardRequest) (*models.Card, error) {
+	if req.Kind != models.CardKindSingleUse && req.Kind != models.CardKindMerchantLocked {
+		return nil, fmt.Errorf("%w: unknown card kind %q", models.ErrInvalidVirtualCard, req.Kind)
+	}
+	if !req.SpendCap.IsPositive() {
+		return nil, fmt.Errorf("%w: spend cap must be positive", models.ErrInvalidVirtualCard)
+	}
+	return s.createCard(userID, accountID, req.ProductCode, func(card *models.Card, account *models.Account) error {
+		if req.SpendCap.Currency != "" && req.SpendCap.Currency != account.Currency {
+			return fmt.Errorf("%w: %s and %s", models.ErrCurrencyMismatch, req.SpendCap.Currency, account.Currency)
+		}
+		validUntil := card.ExpiresAt
+		if req.ValidUntil != nil {
+			validUntil = *req.ValidUntil
+		}
+		if !validUntil.After(time.Now()) || validUntil.After(card.ExpiresAt) {
+			return fmt.Errorf("%w: valid_until must be in the future and not after the card expiry",
+				models.ErrInvalidVirtualCard)
+		}
+		spendCap := req.SpendCap.WithCurrency(account.Currency)
+		card.Kind = req.Kind
+		card.SpendCap = &spendCap
+		card.ValidUntil = &validUntil
+		return nil
+	})
+}
+
+// createCard выпускает карту по продукту к активному счету; setup, если задан,
+// настраивает ограничения карты до сохранения.
+func (s *cardService) createCard(userID, accountID int, productCode string, setup func(card *models.Card, account *models.Account) error) (*models.Card, error) {
+	account, member, err := accountAccess(s.accountRepo, accountID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !member.CanOperate() {
+		return nil, models.ErrNotAccountOwner
+	}
+	if account.Status != models.AccountStatusActive {
+		return nil, models.ErrAccountInactive
+	}
+
+	if productCode == "" {
+		productCode = models.DefaultCardProduct
+	}
+	product, err := s.productRepo.GetByCode(productCode)
+	if err != nil {
+		return nil, err
+	}
+	if !product.Active {
+		return nil, fmt.Errorf("%w: %s is no longer issued", models.ErrCardProductNotFound, product.Code)
+	}
+
+	card, err := issueCard(s.cardRepo, s.indexKey, product, "")
+	if err != nil {
+		return nil, err
+	}
+	card.UserID = userID
+	card.AccountID = accountID
+	if setup != nil {
+		if err := setup(card, account); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.cardRepo.Create(card); err != nil {
+		return nil, fmt.Errorf("save card: %w", err)
+	}
+	return card, nil
 }
 
-// CreateCard генерирует виртуальную карту и сохраняет в БД.
-func (s *cardService) CreateCard(userID, accountID int) (*models.Card, error) {
+// issueCard генерирует и шифрует реквизиты карты по продукту: номер pan (пустой — новый
+// из диапазона BIN продукта), срок действия и CVV. Карта не сохраняется; CVV в открытом
+// виде остается только в card.CVV.
+func issueCard(cardRepo repositories.CardRepository, indexKey []byte, product *models.CardProduct, pan string) (*models.Card, error) {
 	// 1. генерация данных
-	number := utils.GenerateCardNumber()
-	exp := utils.GenerateExpirationDate(5)
+	number := pan
+	if number == "" {
+		var err error
+		if number, err = uniqueCardNumber(cardRepo, indexKey, product); err != nil {
+			return nil, err
+		}
+	}
+	exp := utils.GenerateExpirationDate(product.ValidityYears)
+	expiresAt, err := utils.CardExpiresAt(exp)
+	if err != nil {
+		return nil, err
+	}
 	cvvPlain, err := utils.GenerateCVV()
 	if err != nil {
 		return nil, err
 	}
 
-	// 2. шифрование (PGP + HMAC)
+	// 2. шифрование (PGP + HMAC)
 	encNum, macNum, err := utils.EncryptPGP(number)
 	if err != nil {
 		return nil, err
@@ -50,30 +199,233 @@ func (s *cardService) CreateCard(userID, accountID int) (*models.Card, error) {
 		return nil, err
 	}
 
-	card := &models.Card{
-		UserID:         userID,
-		AccountID:      accountID,
+	return &models.Card{
 		CardNumber:     encNum,
 		CardNumberMAC:  macNum,
 		ExpirationDate: encExp,
 		ExpirationMAC:  macExp,
 		CVVHash:        cvvHash,
+		CVV:            cvvPlain,
+		PANIndex:       utils.PANBlindIndex(number, indexKey),
+		KeyID:          utils.CipherKeyID(encNum),
+		ProductCode:    product.Code,
+		Kind:           models.CardKindStandard,
+		ExpiresAt:      expiresAt,
+		Status:         models.CardStatusActive,
 		CreatedAt:      time.Now(),
-	}
+	}, nil
+}
 
-	if err := s.cardRepo.Create(card); err != nil {
-		return nil, fmt.Errorf("save card: %w", err)
+// uniqueCardNumber генерирует номер в диапазоне BIN продукта, которого еще нет у выпущенных карт.
+// Номер, выпущенный параллельно между проверкой и сохранением, отклоняет
+// CardRepository.Create с ErrCardNumberTaken.
+func uniqueCardNumber(cardRepo repositories.CardRepository, indexKey []byte, product *models.CardProduct) (string, error) {
+	for i := 0; i < cardNumberAttempts; i++ {
+		number, err := utils.GenerateCardNumberInRange(product.BINFrom, product.BINTo, product.PANLength)
+		if err != nil {
+			return "", fmt.Errorf("card product %s: %w", product.Code, err)
+		}
+		_, err = cardRepo.GetByPANIndex(utils.PANBlindIndex(number, indexKey))
+		if errors.Is(err, models.ErrCardNotFound) {
+			return number, nil
+		}
+		if err != nil {
+			return "", err
+		}
 	}
-	return card, nil
+	return "", fmt.Errorf("no free card number in BIN range of %s after %d attempts", product.Code, cardNumberAttempts)
 }
 
 // GetCardByID возвращает карту с расшифрованными полями.
-func (s *cardService) GetCardByID(id int) (*models.Card, error) {
+func (s *cardService) GetCardByID(userID, id int) (*models.Card, error) {
+	card, err := s.accessibleCard(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	return decryptCard(card)
+}
+
+func (s *cardService) GetCard(userID, id int) (*models.CardSummary, error) {
+	card, err := s.GetCardByID(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	summary := summarizeCard(card)
+	return &summary, nil
+}
+
+func (s *cardService) ListCards(userID int) ([]models.CardSummary, error) {
+	cards, err := s.cardRepo.ListByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	summaries := []models.CardSummary{}
+	for _, card := range cards {
+		// Карты счетов, из которых держатель вышел, ему больше не показываются.
+		if _, err := s.accountRepo.GetMember(card.AccountID, userID); errors.Is(err, models.ErrMemberNotFound) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		if card, err = decryptCard(card); err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, summarizeCard(card))
+	}
+	return summaries, nil
+}
+
+func (s *cardService) ListAccountCards(userID, accountID int) ([]models.CardSummary, error) {
+	_, member, err := accountAccess(s.accountRepo, accountID, userID)
+	if err != nil {
+		return nil, err
+	}
+	cards, err := s.cardRepo.ListByAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+	summaries := []models.CardSummary{}
+	for _, card := range cards {
+		if card.UserID != userID && !member.CanOperate() {
+			continue
+		}
+		if card, err = decryptCard(card); err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, summarizeCard(card))
+	}
+	return summaries, nil
+}
+
+// RevealCard проверяет частоту попыток, доступ к карте и пароль пользователя.
+// Каждая попытка, удачная или нет, записывается в журнал раскрытий.
+func (s *cardService) RevealCard(userID, id int, password string) (*models.CardDetails, error) {
+	attempts, err := s.cardRepo.CountReveals(userID, time.Now().Add(-s.revealWindow))
+	if err != nil {
+		return nil, err
+	}
+	if attempts >= s.revealLimit {
+		return nil, models.ErrTooManyAttempts
+	}
+	card, err := s.accessibleCard(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	// Реквизиты временно заблокированной карты держатель видит; окончательно заблокированной — нет.
+	if models.CardStatusPermanent(card.Status) {
+		return nil, models.ErrCardInactive
+	}
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	ok := utils.CheckPasswordHash(password, user.PasswordHash)
+	if err := s.cardRepo.RecordReveal(userID, card.ID, ok); err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, models.ErrInvalidPassword
+	}
+	if card, err = decryptCard(card); err != nil {
+		return nil, err
+	}
+	details := &models.CardDetails{ID: card.ID, CardNumber: card.CardNumber, ExpirationDate: card.ExpirationDate}
+	if card.PendingCVV != "" {
+		encCVV, macCVV, err := s.cardRepo.TakePendingCVV(card.ID)
+		if err != nil {
+			return nil, err
+		}
+		if encCVV != "" {
+			if details.CVV, err = utils.DecryptPGP(encCVV, macCVV); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return details, nil
+}
+
+func (s *cardService) BlockCard(userID, id int, reason string) (*models.CardSummary, error) {
+	if _, err := s.accessibleCard(userID, id); err != nil {
+		return nil, err
+	}
+	return s.changeStatus(&models.CardStatusChange{
+		CardID: id, ToStatus: models.CardStatusBlocked, Reason: reason, ActorID: userID, ActorRole: models.RoleCustomer,
+	}, models.CardStatusActive)
+}
+
+func (s *cardService) UnblockCard(userID, id int, reason string) (*models.CardSummary, error) {
+	if _, err := s.accessibleCard(userID, id); err != nil {
+		return nil, err
+	}
+	return s.changeStatus(&models.CardStatusChange{
+		CardID: id, ToStatus: models.CardStatusActive, Reason: reason, ActorID: userID, ActorRole: models.RoleCustomer,
+	}, models.CardStatusBlocked)
+}
+
+func (s *cardService) CancelCard(operatorID int, role string, id int, status, reason string) (*models.CardSummary, error) {
+	if role != models.RoleOperator {
+		return nil, models.ErrOperatorRequired
+	}
+	if !models.CardStatusPermanent(status) {
+		return nil, fmt.Errorf("%w: %q", models.ErrInvalidCardStatus, status)
+	}
+	return s.changeStatus(&models.CardStatusChange{
+		CardID: id, ToStatus: status, Reason: reason, ActorID: operatorID, ActorRole: role,
+	}, models.CardStatusActive, models.CardStatusBlocked, models.CardStatusPINLocked)
+}
+
+func (s *cardService) UnlockCardPIN(operatorID int, role string, id int, reason string) (*models.CardSummary, error) {
+	if role != models.RoleOperator {
+		return nil, models.ErrOperatorRequired
+	}
+	return s.changeStatus(&models.CardStatusChange{
+		CardID: id, ToStatus: models.CardStatusActive, Reason: reason, ActorID: operatorID, ActorRole: role,
+	}, models.CardStatusPINLocked)
+}
+
+func (s *cardService) CardStatusHistory(userID int, role string, id int) ([]models.CardStatusChange, error) {
+	if role == models.RoleOperator {
+		if _, err := s.cardRepo.GetByID(id); err != nil {
+			return nil, err
+		}
+	} else if _, err := s.accessibleCard(userID, id); err != nil {
+		return nil, err
+	}
+	return s.cardRepo.ListStatusHistory(id)
+}
+
+// changeStatus меняет статус карты из одного из from и возвращает ее сводку.
+func (s *cardService) changeStatus(change *models.CardStatusChange, from ...string) (*models.CardSummary, error) {
+	card, err := s.cardRepo.ChangeStatus(context.Background(), change, from...)
+	if err != nil {
+		return nil, err
+	}
+	if card, err = decryptCard(card); err != nil {
+		return nil, err
+	}
+	summary := summarizeCard(card)
+	return &summary, nil
+}
+
+// accessibleCard возвращает карту, если пользователь может ее видеть. Держатель видит карту,
+// пока остается участником счета; остальным участникам нужен полный доступ.
+func (s *cardService) accessibleCard(userID, id int) (*models.Card, error) {
 	card, err := s.cardRepo.GetByID(id)
 	if err != nil {
 		return nil, err
 	}
+	member, err := s.accountRepo.GetMember(card.AccountID, userID)
+	if err != nil && !errors.Is(err, models.ErrMemberNotFound) {
+		return nil, err
+	}
+	if member == nil || (card.UserID != userID && !member.CanOperate()) {
+		return nil, models.ErrNotCardOwner
+	}
+	return card, nil
+}
 
+// decryptCard возвращает копию карты с расшифрованными номером и сроком действия.
+func decryptCard(card *models.Card) (*models.Card, error) {
 	num, err := utils.DecryptPGP(card.CardNumber, card.CardNumberMAC)
 	if err != nil {
 		return nil, err
@@ -83,7 +435,28 @@ func (s *cardService) GetCardByID(id int) (*models.Card, error) {
 		return nil, err
 	}
 
-	card.CardNumber = num
-	card.ExpirationDate = exp
-	return card, nil
+	decrypted := *card
+	decrypted.CardNumber = num
+	decrypted.ExpirationDate = exp
+	return &decrypted, nil
+}
+
+// summarizeCard скрывает реквизиты расшифрованной карты.
+func summarizeCard(card *models.Card) models.CardSummary {
+	return models.CardSummary{
+		ID:             card.ID,
+		UserID:         card.UserID,
+		AccountID:      card.AccountID,
+		MaskedPAN:      utils.MaskPAN(card.CardNumber),
+		ProductCode:    card.ProductCode,
+		ReissuedFrom:   card.ReissuedFrom,
+		Kind:           card.Kind,
+		SpendCap:       card.SpendCap,
+		ValidUntil:     card.ValidUntil,
+		LockedMerchant: card.LockedMerchant,
+		Status:         card.Status,
+		ExpiryMonth:    card.ExpirationDate,
+		PINSet:         card.PVV != "",
+		CreatedAt:      card.CreatedAt,
+	}
 }
@@ -3,39 +3,319 @@ package services_test
 import (
 	"bank-api/models"
 	"bank-api/services"
+	"bank-api/utils"
+	"context"
 	"errors"
+	"strings"
 	"testing"
+	"time"
 )
 
 // fakeCardRepo реализует интерфейс CardRepository для тестирования.
 type fakeCardRepo struct {
-	createdCard *models.Card
+	cards   []*models.Card
+	reveals   []bool
+	history   []models.CardStatusChange
+	cvvChecks []models.CVVCheck
 }
 
 func (f *fakeCardRepo) Create(card *models.Card) error {
-	// Простой мок: присваиваем ID и сохраняем ссылку на карту.
+	// Простой мок: присваиваем ID по порядку и сохраняем ссылку на карту.
 	if card.UserID == 0 || card.AccountID == 0 {
 		return errors.New("invalid card data")
 	}
-	card.ID = 1
-	f.createdCard = card
+	card.ID = len(f.cards) + 1
+	f.cards = append(f.cards, card)
 	return nil
 }
 
 func (f *fakeCardRepo) GetByID(id int) (*models.Card, error) {
-	if f.createdCard != nil && f.createdCard.ID == id {
-		return f.createdCard, nil
+	if id >= 1 && id <= len(f.cards) {
+		return f.cards[id-1], nil
 	}
-	return nil, errors.New("card not found")
+	return nil, models.ErrCardNotFound
 }
 
+func (f *fakeCardRepo) GetByPANIndex(index string) (*models.Card, error) {
+	cards, _ := f.ListByPANIndex(index)
+	if len(cards) == 0 {
+		return nil, models.ErrCardNotFound
+	}
+	return cards[0], nil
+}
+
+func (f *fakeCardRepo) ListByPANIndex(index string) ([]*models.Card, error) {
+	cards := []*models.Card{}
+	for i := len(f.cards) - 1; i >= 0; i-- {
+		if f.cards[i].PANIndex == index {
+			cards = append(cards, f.cards[i])
+		}
+	}
+	return cards, nil
+}
+
+func (f *fakeCardRepo) ListWithoutExpiry(limit int) ([]*models.Card, error) {
+	cards := []*models.Card{}
+	for _, card := range f.cards {
+		if card.ExpiresAt.IsZero() && len(cards) < limit {
+			cards = append(cards, card)
+		}
+	}
+	return cards, nil
+}
+
+func (f *fakeCardRepo) SetExpiresAt(cardID int, expiresAt time.Time) error {
+	card, err := f.GetByID(cardID)
+	if err != nil {
+		return err
+	}
+	card.ExpiresAt = expiresAt
+	return nil
+}
+
+func (f *fakeCardRepo) ListExpiring(before time.Time, limit int) ([]*models.Card, error) {
+	reissued := map[int]bool{}
+	for _, card := range f.cards {
+		reissued[card.ReissuedFrom] = true
+	}
+	cards := []*models.Card{}
+	for _, card := range f.cards {
+		if card.Status == models.CardStatusActive && card.Kind == models.CardKindStandard && !card.ExpiresAt.IsZero() &&
+			card.ExpiresAt.Before(before) && !reissued[card.ID] && len(cards) < limit {
+			cards = append(cards, card)
+		}
+	}
+	return cards, nil
+}
+
+func (f *fakeCardRepo) ExpireDue(ctx context.Context, now time.Time, limit int) ([]*models.Card, error) {
+	expired := []*models.Card{}
+	for _, card := range f.cards {
+		due := (!card.ExpiresAt.IsZero() && !card.ExpiresAt.After(now)) ||
+			(card.ValidUntil != nil && !card.ValidUntil.After(now))
+		if !due || len(expired) == limit {
+			continue
+		}
+		changed, err := f.ChangeStatus(ctx, &models.CardStatusChange{
+			CardID: card.ID, ToStatus: models.CardStatusExpired, ActorID: card.UserID, ActorRole: models.CardActorSystem,
+		}, models.CardStatusActive, models.CardStatusBlocked, models.CardStatusPINLocked)
+		if err == nil {
+			expired = append(expired, changed)
+		}
+	}
+	return expired, nil
+}
+
+func (f *fakeCardRepo) ListByUser(userID int) ([]*models.Card, error) {
+	cards := []*models.Card{}
+	for _, card := range f.cards {
+		if card.UserID == userID {
+			cards = append(cards, card)
+		}
+	}
+	return cards, nil
+}
+
+func (f *fakeCardRepo) ListByAccount(accountID int) ([]*models.Card, error) {
+	cards := []*models.Card{}
+	for _, card := range f.cards {
+		if card.AccountID == accountID {
+			cards = append(cards, card)
+		}
+	}
+	return cards, nil
+}
+
+func (f *fakeCardRepo) RecordReveal(userID, cardID int, success bool) error {
+	f.reveals = append(f.reveals, success)
+	return nil
+}
+
+func (f *fakeCardRepo) CountReveals(userID int, since time.Time) (int, error) {
+	return len(f.reveals), nil
+}
+
+func (f *fakeCardRepo) CheckCVV(ctx context.Context, check *models.CVVCheck, since time.Time, limit int, match func(card *models.Card) bool) (int, error) {
+	card, err := f.GetByID(check.CardID)
+	if err != nil {
+		return 0, err
+	}
+	mismatches := 0
+	for _, c := range f.cvvChecks {
+		if c.CardID == card.ID && c.Result == models.CVVCheckMismatch {
+			mismatches++
+		}
+	}
+	switch {
+	case mismatches >= limit:
+		check.Result = models.CVVCheckLimited
+	case card.Status != models.CardStatusActive:
+		check.Result = models.CVVCheckCardInactive
+	case match(card):
+		check.Result = models.CVVCheckMatch
+	default:
+		check.Result = models.CVVCheckMismatch
+		mismatches++
+	}
+	check.ID = len(f.cvvChecks) + 1
+	f.cvvChecks = append(f.cvvChecks, *check)
+	return mismatches, nil
+}
+
+func (f *fakeCardRepo) TakePendingCVV(cardID int) (string, string, error) {
+	card, err := f.GetByID(cardID)
+	if err != nil {
+		return "", "", err
+	}
+	cvv, mac := card.PendingCVV, card.PendingCVVMAC
+	card.PendingCVV, card.PendingCVVMAC = "", ""
+	return cvv, mac, nil
+}
+
+func (f *fakeCardRepo) ChangeStatus(ctx context.Context, change *models.CardStatusChange, from ...string) (*models.Card, error) {
+	card, err := f.GetByID(change.CardID)
+	if err != nil {
+		return nil, err
+	}
+	for _, status := range from {
+		if card.Status == status {
+			change.FromStatus = card.Status
+			change.ID = len(f.history) + 1
+			f.history = append(f.history, *change)
+			if card.Status == models.CardStatusPINLocked {
+				card.PINAttempts = 0
+			}
+			card.Status = change.ToStatus
+			return card, nil
+		}
+	}
+	return nil, models.ErrInvalidStatusTransition
+}
+
+func (f *fakeCardRepo) ListStatusHistory(cardID int) ([]models.CardStatusChange, error) {
+	history := []models.CardStatusChange{}
+	for _, c := range f.history {
+		if c.CardID == cardID {
+			history = append(history, c)
+		}
+	}
+	return history, nil
+}
+
+func (f *fakeCardRepo) ListForReencryption(keyID string, afterID, limit int) ([]*models.Card, error) {
+	cards := []*models.Card{}
+	for _, card := range f.cards {
+		stale := card.KeyID != keyID || (card.PendingCVV != "" && utils.CipherKeyID(card.PendingCVV) != keyID)
+		if stale && card.ID > afterID && len(cards) < limit {
+			copied := *card
+			cards = append(cards, &copied)
+		}
+	}
+	return cards, nil
+}
+
+func (f *fakeCardRepo) UpdateEncryption(card, prev *models.Card) (bool, error) {
+	stored := f.cards[card.ID-1]
+	if stored.CardNumber != prev.CardNumber || stored.ExpirationDate != prev.ExpirationDate || stored.PendingCVV != prev.PendingCVV {
+		return false, nil
+	}
+	*stored = *card
+	return true, nil
+}
+
+func (f *fakeCardRepo) SetPVV(cardID int, pvv string, pvki int) error {
+	card, err := f.GetByID(cardID)
+	if err != nil {
+		return err
+	}
+	card.PVV, card.PVKI, card.PINAttempts = pvv, pvki, 0
+	return nil
+}
+
+func (f *fakeCardRepo) RecordPINAttempt(ctx context.Context, cardID int, success bool, maxAttempts, actorID int) (*models.Card, error) {
+	card, err := f.GetByID(cardID)
+	if err != nil {
+		return nil, err
+	}
+	if card.Status != models.CardStatusActive {
+		return nil, models.ErrCardInactive
+	}
+	if success {
+		card.PINAttempts = 0
+	} else {
+		card.PINAttempts++
+	}
+	if card.PINAttempts >= maxAttempts {
+		return f.ChangeStatus(ctx, &models.CardStatusChange{
+			CardID: cardID, ToStatus: models.CardStatusPINLocked, ActorID: actorID, ActorRole: models.RoleCustomer,
+		}, models.CardStatusActive)
+	}
+	return card, nil
+}
+
+// fakeCardProductRepo реализует интерфейс CardProductRepository для тестирования.
+type fakeCardProductRepo struct {
+	products map[string]*models.CardProduct
+}
+
+// newFakeCardProductRepo возвращает репозиторий с дебетовой картой «Мир» и
+// предоплаченной картой с 19-значным номером.
+func newFakeCardProductRepo() *fakeCardProductRepo {
+	return &fakeCardProductRepo{products: map[string]*models.CardProduct{
+		models.DefaultCardProduct: {
+			Code: models.DefaultCardProduct, Name: "Мир дебетовая", PaymentSystem: models.PaymentSystemMir,
+			Type: models.CardTypeDebit, BINFrom: "2200700", BINTo: "2200799", PANLength: 16, ValidityYears: 5, Active: true,
+		},
+		"mir_prepaid": {
+			Code: "mir_prepaid", Name: "Мир предоплаченная", PaymentSystem: models.PaymentSystemMir,
+			Type: models.CardTypePrepaid, BINFrom: "2200850", BINTo: "2200850", PANLength: 19, ValidityYears: 3, Active: true,
+		},
+	}}
+}
+
+func (f *fakeCardProductRepo) List(activeOnly bool) ([]*models.CardProduct, error) {
+	products := []*models.CardProduct{}
+	for _, p := range f.products {
+		if p.Active || !activeOnly {
+			products = append(products, p)
+		}
+	}
+	return products, nil
+}
+
+func (f *fakeCardProductRepo) GetByCode(code string) (*models.CardProduct, error) {
+	if p, ok := f.products[code]; ok {
+		return p, nil
+	}
+	return nil, models.ErrCardProductNotFound
+}
+
+func (f *fakeCardProductRepo) Create(product *models.CardProduct) error {
+	f.products[product.Code] = product
+	return nil
+}
+
+func (f *fakeCardProductRepo) Deactivate(code string) error {
+	p, ok := f.products[code]
+	if !ok {
+		return models.ErrCardProductNotFound
+	}
+	p.Active = false
+	return nil
+}
+
+var testCardIndexKey = []byte("test-index-key")
+
 func TestCreateCard(t *testing.T) {
 	repo := &fakeCardRepo{}
-	cardService := services.NewCardService(repo)
 	userID := 42
 	accountID := 101
+	accountRepo := &fakeAccountRepo{accounts: map[int]*models.Account{
+		accountID: {ID: accountID, UserID: userID, Currency: "RUB", Status: models.AccountStatusActive},
+	}}
+	cardService := services.NewCardService(repo, accountRepo, newFakeCardProductRepo(), newFakeUserRepo(), testCardIndexKey, 3, time.Minute)
 
-	card, err := cardService.CreateCard(userID, accountID)
+	card, err := cardService.CreateCard(userID, accountID, "")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -60,4 +340,221 @@ func TestCreateCard(t *testing.T) {
 	if card.CreatedAt.IsZero() {
 		t.Error("expected CreatedAt to be set")
 	}
+	if card.ProductCode != models.DefaultCardProduct {
+		t.Errorf("expected default product, got %q", card.ProductCode)
+	}
+	// CVV возвращается один раз при выпуске и совпадает с сохраненным хешем.
+	if len(card.CVV) != 3 || !utils.CheckCVV(card.CVV, card.CVVHash) {
+		t.Errorf("expected plaintext CVV matching the hash, got %q", card.CVV)
+	}
+}
+
+func TestCreateCardByProduct(t *testing.T) {
+	accountRepo := &fakeAccountRepo{accounts: map[int]*models.Account{
+		101: {ID: 101, UserID: 42, Currency: "RUB", Status: models.AccountStatusActive},
+	}}
+	productRepo := newFakeCardProductRepo()
+	cardService := services.NewCardService(&fakeCardRepo{}, accountRepo, productRepo, newFakeUserRepo(), testCardIndexKey, 3, time.Minute)
+
+	card, err := cardService.CreateCard(42, 101, "mir_prepaid")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	pan, err := utils.DecryptPGP(card.CardNumber, card.CardNumberMAC)
+	if err != nil {
+		t.Fatalf("decrypt PAN: %v", err)
+	}
+	if len(pan) != 19 || !strings.HasPrefix(pan, "2200850") || !utils.ValidateLuhn(pan) {
+		t.Errorf("expected 19-digit PAN in BIN 2200850, got %s", pan)
+	}
+	if card.ProductCode != "mir_prepaid" {
+		t.Errorf("expected product mir_prepaid, got %q", card.ProductCode)
+	}
+
+	if _, err := cardService.CreateCard(42, 101, "unknown"); !errors.Is(err, models.ErrCardProductNotFound) {
+		t.Errorf("expected ErrCardProductNotFound, got %v", err)
+	}
+	productRepo.Deactivate("mir_prepaid")
+	if _, err := cardService.CreateCard(42, 101, "mir_prepaid"); !errors.Is(err, models.ErrCardProductNotFound) {
+		t.Errorf("expected ErrCardProductNotFound for deactivated product, got %v", err)
+	}
+}
+
+func TestCreateCardProduct(t *testing.T) {
+	productService := services.NewCardProductService(newFakeCardProductRepo())
+	product := &models.CardProduct{
+		Code: "visa_classic", Name: "Visa Classic", PaymentSystem: models.PaymentSystemVisa,
+		Type: models.CardTypeCredit, BINFrom: "427600", BINTo: "427699", PANLength: 16, ValidityYears: 4,
+	}
+	if err := productService.CreateProduct(models.RoleCustomer, product); !errors.Is(err, models.ErrOperatorRequired) {
+		t.Errorf("expected ErrOperatorRequired, got %v", err)
+	}
+	if err := productService.CreateProduct(models.RoleOperator, product); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !product.Active {
+		t.Error("expected new product to be active")
+	}
+	if err := productService.CreateProduct(models.RoleOperator, product); !errors.Is(err, models.ErrInvalidCardProduct) {
+		t.Errorf("expected ErrInvalidCardProduct for taken code, got %v", err)
+	}
+	invalid := *product
+	invalid.Code, invalid.PANLength = "visa_gold", 18
+	if err := productService.CreateProduct(models.RoleOperator, &invalid); !errors.Is(err, models.ErrInvalidCardProduct) {
+		t.Errorf("expected ErrInvalidCardProduct for PAN length 18, got %v", err)
+	}
+}
+
+func TestCreateCardRequiresActiveAccount(t *testing.T) {
+	accountRepo := &fakeAccountRepo{accounts: map[int]*models.Account{
+		101: {ID: 101, UserID: 42, Currency: "RUB", Status: models.AccountStatusFrozen},
+	}}
+	cardService := services.NewCardService(&fakeCardRepo{}, accountRepo, newFakeCardProductRepo(), newFakeUserRepo(), testCardIndexKey, 3, time.Minute)
+
+	if _, err := cardService.CreateCard(42, 101, ""); !errors.Is(err, models.ErrAccountInactive) {
+		t.Errorf("expected ErrAccountInactive, got %v", err)
+	}
+	if _, err := cardService.CreateCard(7, 101, ""); !errors.Is(err, models.ErrNotAccountOwner) {
+		t.Errorf("expected ErrNotAccountOwner, got %v", err)
+	}
+}
+
+func TestListCardsMasksPAN(t *testing.T) {
+	accountRepo := &fakeAccountRepo{accounts: map[int]*models.Account{
+		101: {ID: 101, UserID: 42, Currency: "RUB", Status: models.AccountStatusActive},
+	}}
+	cardService := services.NewCardService(&fakeCardRepo{}, accountRepo, newFakeCardProductRepo(), newFakeUserRepo(), testCardIndexKey, 3, time.Minute)
+	if _, err := cardService.CreateCard(42, 101, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	full, err := cardService.GetCardByID(42, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cards, err := cardService.ListCards(42)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cards) != 1 {
+		t.Fatalf("expected 1 card, got %d", len(cards))
+	}
+	masked := cards[0].MaskedPAN
+	if masked != full.CardNumber[:6]+strings.Repeat("*", len(full.CardNumber)-10)+full.CardNumber[len(full.CardNumber)-4:] {
+		t.Errorf("unexpected masked PAN %q for %q", masked, full.CardNumber)
+	}
+	if cards[0].Status != models.CardStatusActive {
+		t.Errorf("expected active status, got %q", cards[0].Status)
+	}
+	if cards[0].ExpiryMonth != full.ExpirationDate {
+		t.Errorf("expected expiry %q, got %q", full.ExpirationDate, cards[0].ExpiryMonth)
+	}
+
+	accountCards, err := cardService.ListAccountCards(42, 101)
+	if err != nil || len(accountCards) != 1 {
+		t.Fatalf("expected 1 account card, got %v, %v", accountCards, err)
+	}
+	if _, err := cardService.ListAccountCards(7, 101); !errors.Is(err, models.ErrNotAccountOwner) {
+		t.Errorf("expected ErrNotAccountOwner, got %v", err)
+	}
+	if others, _ := cardService.ListCards(7); len(others) != 0 {
+		t.Errorf("expected no cards for another user, got %d", len(others))
+	}
+}
+
+func TestRevealCard(t *testing.T) {
+	accountRepo := &fakeAccountRepo{accounts: map[int]*models.Account{
+		101: {ID: 101, UserID: 1, Currency: "RUB", Status: models.AccountStatusActive},
+	}}
+	hash, err := utils.HashPassword("password123")
+	if err != nil {
+		t.Fatalf("hash password: %v", err)
+	}
+	userRepo := newFakeUserRepo()
+	userRepo.Create(&models.User{Email: "holder@example.com", PasswordHash: hash})
+	cardRepo := &fakeCardRepo{}
+	cardService := services.NewCardService(cardRepo, accountRepo, newFakeCardProductRepo(), userRepo, testCardIndexKey, 3, time.Minute)
+	if _, err := cardService.CreateCard(1, 101, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := cardService.RevealCard(1, 1, "wrong"); !errors.Is(err, models.ErrInvalidPassword) {
+		t.Errorf("expected ErrInvalidPassword, got %v", err)
+	}
+	details, err := cardService.RevealCard(1, 1, "password123")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(details.CardNumber) != 16 || details.ExpirationDate == "" {
+		t.Errorf("expected decrypted details, got %+v", details)
+	}
+	if len(cardRepo.reveals) != 2 || cardRepo.reveals[0] || !cardRepo.reveals[1] {
+		t.Errorf("expected failed and successful reveals recorded, got %v", cardRepo.reveals)
+	}
+
+	// Третья попытка исчерпывает лимит; четвертая отклоняется без проверки пароля.
+	if _, err := cardService.RevealCard(1, 1, "password123"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := cardService.RevealCard(1, 1, "password123"); !errors.Is(err, models.ErrTooManyAttempts) {
+		t.Errorf("expected ErrTooManyAttempts, got %v", err)
+	}
+}
+
+func TestCardStatusLifecycle(t *testing.T) {
+	accountRepo := &fakeAccountRepo{accounts: map[int]*models.Account{
+		101: {ID: 101, UserID: 42, Currency: "RUB", Status: models.AccountStatusActive},
+	}}
+	cardRepo := &fakeCardRepo{}
+	cardService := services.NewCardService(cardRepo, accountRepo, newFakeCardProductRepo(), newFakeUserRepo(), testCardIndexKey, 3, time.Minute)
+	if _, err := cardService.CreateCard(42, 101, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := cardService.BlockCard(7, 1, "not mine"); !errors.Is(err, models.ErrNotCardOwner) {
+		t.Errorf("expected ErrNotCardOwner, got %v", err)
+	}
+	card, err := cardService.BlockCard(42, 1, "misplaced")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if card.Status != models.CardStatusBlocked {
+		t.Errorf("expected blocked card, got %q", card.Status)
+	}
+	if _, err := cardService.BlockCard(42, 1, "again"); !errors.Is(err, models.ErrInvalidStatusTransition) {
+		t.Errorf("expected ErrInvalidStatusTransition, got %v", err)
+	}
+	if _, err := cardService.UnblockCard(42, 1, "found"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := cardService.CancelCard(42, models.RoleCustomer, 1, models.CardStatusStolen, ""); !errors.Is(err, models.ErrOperatorRequired) {
+		t.Errorf("expected ErrOperatorRequired, got %v", err)
+	}
+	if _, err := cardService.CancelCard(99, models.RoleOperator, 1, models.CardStatusBlocked, ""); !errors.Is(err, models.ErrInvalidCardStatus) {
+		t.Errorf("expected ErrInvalidCardStatus, got %v", err)
+	}
+	if _, err := cardService.CancelCard(99, models.RoleOperator, 1, models.CardStatusStolen, "reported by phone"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Окончательная блокировка не снимается.
+	if _, err := cardService.UnblockCard(42, 1, ""); !errors.Is(err, models.ErrInvalidStatusTransition) {
+		t.Errorf("expected ErrInvalidStatusTransition, got %v", err)
+	}
+	if _, err := cardService.RevealCard(42, 1, "password"); !errors.Is(err, models.ErrCardInactive) {
+		t.Errorf("expected ErrCardInactive, got %v", err)
+	}
+
+	history, err := cardService.CardStatusHistory(42, models.RoleCustomer, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(history) != 3 {
+		t.Fatalf("expected 3 status changes, got %d", len(history))
+	}
+	last := history[2]
+	if last.FromStatus != models.CardStatusActive || last.ToStatus != models.CardStatusStolen ||
+		last.ActorID != 99 || last.ActorRole != models.RoleOperator || last.Reason != "reported by phone" {
+		t.Errorf("unexpected status change %+v", last)
+	}
 }
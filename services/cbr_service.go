@@ -6,11 +6,18 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
+	"bank-api/models"
+
 	"github.com/beevik/etree"
 )
 
+// cbrDailyInfoURL — адрес SOAP-сервиса DailyInfo ЦБ РФ.
+const cbrDailyInfoURL = "https://www.cbr.ru/DailyInfoWebServ/DailyInfo.asmx"
+
 // buildSOAPRequest формирует SOAP-запрос для получения ключевой ставки.
 // Формируется запрос за последние 30 дней.
 func buildSOAPRequest() string {
@@ -27,15 +34,28 @@ func buildSOAPRequest() string {
 		</soap12:Envelope>`, fromDate, toDate)
 }
 
+// buildCursOnDateRequest формирует SOAP-запрос курсов валют на дату.
+func buildCursOnDateRequest(date time.Time) string {
+	return fmt.Sprintf(`<?xml version="1.0" encoding="utf-8"?>
+		<soap12:Envelope xmlns:soap12="http://www.w3.org/2003/05/soap-envelope">
+			<soap12:Body>
+				<GetCursOnDate xmlns="http://web.cbr.ru/">
+					<On_date>%s</On_date>
+				</GetCursOnDate>
+			</soap12:Body>
+		</soap12:Envelope>`, date.Format("2006-01-02"))
+}
+
 // sendSOAPRequest отправляет SOAP-запрос к ЦБ РФ и возвращает сырой ответ.
-func sendSOAPRequest(soapRequest string) ([]byte, error) {
+// action — имя метода DailyInfo (KeyRate, GetCursOnDate).
+func sendSOAPRequest(soapRequest, action string) ([]byte, error) {
 	client := &http.Client{Timeout: 10 * time.Second}
-	req, err := http.NewRequest("POST", "https://www.cbr.ru/DailyInfoWebServ/DailyInfo.asmx", bytes.NewBuffer([]byte(soapRequest)))
+	req, err := http.NewRequest("POST", cbrDailyInfoURL, bytes.NewBuffer([]byte(soapRequest)))
 	if err != nil {
 		return nil, err
 	}
 	req.Header.Set("Content-Type", "application/soap+xml; charset=utf-8")
-	req.Header.Set("SOAPAction", "http://web.cbr.ru/KeyRate")
+	req.Header.Set("SOAPAction", "http://web.cbr.ru/"+action)
 
 	resp, err := client.Do(req)
 	if err != nil {
@@ -77,7 +97,7 @@ func parseSOAPResponse(rawBody []byte) (float64, error) {
 // GetCentralBankRate обращается к ЦБ РФ, получает ключевую ставку, и добавляет маржу банка (например, +5%).
 func GetCentralBankRate() (float64, error) {
 	soapRequest := buildSOAPRequest()
-	rawBody, err := sendSOAPRequest(soapRequest)
+	rawBody, err := sendSOAPRequest(soapRequest, "KeyRate")
 	if err != nil {
 		return 0, err
 	}
@@ -89,3 +109,49 @@ func GetCentralBankRate() (float64, error) {
 	rate += 5
 	return rate, nil
 }
+
+// parseCursOnDateResponse извлекает курсы валют из ответа GetCursOnDate.
+func parseCursOnDateResponse(rawBody []byte, date time.Time) ([]models.CurrencyRate, error) {
+	doc := etree.NewDocument()
+	if err := doc.ReadFromBytes(rawBody); err != nil {
+		return nil, fmt.Errorf("failed to parse XML: %v", err)
+	}
+	items := doc.FindElements("//diffgram/ValuteData/ValuteCursOnDate")
+	if len(items) == 0 {
+		return nil, errors.New("currency rates not found")
+	}
+	rates := make([]models.CurrencyRate, 0, len(items))
+	for _, item := range items {
+		code := item.FindElement("./VchCode")
+		nominal := item.FindElement("./Vnom")
+		value := item.FindElement("./Vcurs")
+		if code == nil || nominal == nil || value == nil {
+			return nil, errors.New("incomplete ValuteCursOnDate element")
+		}
+		nom, err := strconv.ParseFloat(strings.TrimSpace(nominal.Text()), 64)
+		if err != nil || nom <= 0 {
+			return nil, fmt.Errorf("invalid nominal %q", nominal.Text())
+		}
+		rate := models.CurrencyRate{
+			Date:     date,
+			Currency: strings.TrimSpace(code.Text()),
+			Nominal:  int(nom),
+			Value:    strings.TrimSpace(value.Text()),
+		}
+		if _, err := rate.PerUnit(); err != nil {
+			return nil, err
+		}
+		rates = append(rates, rate)
+	}
+	return rates, nil
+}
+
+// GetCurrencyRates запрашивает у ЦБ РФ официальные курсы валют на дату.
+func GetCurrencyRates(date time.Time) ([]models.CurrencyRate, error) {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	rawBody, err := sendSOAPRequest(buildCursOnDateRequest(day), "GetCursOnDate")
+	if err != nil {
+		return nil, err
+	}
+	return parseCursOnDateResponse(rawBody, day)
+}
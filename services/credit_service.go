@@ -1,8 +1,9 @@
 package services
 
 import (
+	"context"
 	"fmt"
-	"math"
+	"math/big"
 	"time"
 
 	"bank-api/models"
@@ -11,6 +12,18 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+const (
+	// creditTermMonths — срок кредита в месяцах.
+	creditTermMonths = 12
+	// interestRounding — правило округления процентов (банковское).
+	interestRounding = models.RoundHalfEven
+	// penaltyRounding — правило округления штрафа за просрочку.
+	penaltyRounding = models.RoundHalfUp
+)
+
+// penaltyRate — штраф за просрочку: 10% от суммы платежа.
+var penaltyRate = big.NewRat(10, 100)
+
 // CreditService — базовый интерфейс для кредитов (только что нужно Scheduler, Service и т.д.)
 type CreditService interface {
 	ApplyForCredit(credit *models.Credit) error
@@ -22,31 +35,54 @@ type CreditService interface {
 type creditService struct {
 	creditRepo          repositories.CreditRepository
 	paymentScheduleRepo repositories.PaymentScheduleRepository
+	accountRepo         repositories.AccountRepository
 }
 
 // NewCreditService возвращает CreditService
 func NewCreditService(
 	creditRepo repositories.CreditRepository,
 	paymentScheduleRepo repositories.PaymentScheduleRepository,
+	accountRepo repositories.AccountRepository,
 ) CreditService {
 	return &creditService{
 		creditRepo:          creditRepo,
 		paymentScheduleRepo: paymentScheduleRepo,
+		accountRepo:         accountRepo,
 	}
 }
 
+// ApplyForCredit оформляет кредит, зачисляет его сумму на счет заемщика
+// и строит график платежей.
 func (s *creditService) ApplyForCredit(credit *models.Credit) error {
+	account, member, err := accountAccess(s.accountRepo, credit.AccountID, credit.UserID)
+	if err != nil {
+		return err
+	}
+	if !member.CanOperate() {
+		return models.ErrNotAccountOwner
+	}
+	if account.Status != models.AccountStatusActive {
+		return models.ErrAccountInactive
+	}
+	if credit.Amount.Currency != "" && credit.Amount.Currency != account.Currency {
+		return fmt.Errorf("%w: %s and %s", models.ErrCurrencyMismatch, credit.Amount.Currency, account.Currency)
+	}
+	credit.Currency = account.Currency
+	credit.Amount = credit.Amount.WithCurrency(account.Currency)
+
+	payments, err := annuityPayments(credit.Amount, credit.InterestRate, creditTermMonths)
+	if err != nil {
+		return err
+	}
+
 	credit.CreatedAt = time.Now()
-	if err := s.creditRepo.Create(credit); err != nil {
+	if err := s.creditRepo.CreateWithDisbursement(context.Background(), credit); err != nil {
 		return err
 	}
-	months := 12
-	monthlyRate := credit.InterestRate/100/float64(months)
-	payment := (credit.Amount*monthlyRate)/(1-math.Pow(1+monthlyRate, -float64(months)))
-	for i := 1; i <= months; i++ {
+	for i, payment := range payments {
 		schedule := &models.PaymentSchedule{
 			CreditID:  credit.ID,
-			DueDate:   credit.CreatedAt.AddDate(0, i, 0),
+			DueDate:   credit.CreatedAt.AddDate(0, i+1, 0),
 			Amount:    payment,
 			IsPaid:    false,
 			CreatedAt: time.Now(),
@@ -58,6 +94,51 @@ func (s *creditService) ApplyForCredit(credit *models.Credit) error {
 	return nil
 }
 
+// annuityPayments рассчитывает аннуитетный график в точной арифметике.
+// Проценты за каждый месяц начисляются на остаток долга и округляются
+// по-банковски, а последний платеж закрывает остаток целиком, поэтому
+// сумма погашенного основного долга всегда равна сумме кредита.
+func annuityPayments(principal models.Money, annualRatePercent float64, months int) ([]models.Money, error) {
+	if !principal.IsPositive() {
+		return nil, models.ErrInvalidAmount
+	}
+	annualRate, ok := decimalRat(annualRatePercent)
+	if !ok || annualRate.Sign() < 0 {
+		return nil, fmt.Errorf("%w: %v", models.ErrInvalidInterestRate, annualRatePercent)
+	}
+	monthlyRate := new(big.Rat).Quo(annualRate, big.NewRat(100*12, 1))
+
+	// payment = P * r * (1+r)^n / ((1+r)^n - 1); при нулевой ставке — P / n.
+	var exact *big.Rat
+	if monthlyRate.Sign() == 0 {
+		exact = new(big.Rat).Quo(principal.Rat(), big.NewRat(int64(months), 1))
+	} else {
+		growth := new(big.Rat).Add(big.NewRat(1, 1), monthlyRate)
+		pow := big.NewRat(1, 1)
+		for i := 0; i < months; i++ {
+			pow.Mul(pow, growth)
+		}
+		exact = new(big.Rat).Mul(principal.Rat(), monthlyRate)
+		exact.Mul(exact, pow)
+		exact.Quo(exact, new(big.Rat).Sub(pow, big.NewRat(1, 1)))
+	}
+	payment := models.MoneyFromRat(exact, principal.Currency, interestRounding)
+
+	payments := make([]models.Money, 0, months)
+	remaining := principal
+	for i := 1; i <= months; i++ {
+		interest := remaining.MulRat(monthlyRate, interestRounding)
+		current := payment
+		if i == months {
+			current, _ = remaining.Add(interest)
+		}
+		principalPart, _ := current.Sub(interest)
+		remaining, _ = remaining.Sub(principalPart)
+		payments = append(payments, current)
+	}
+	return payments, nil
+}
+
 func (s *creditService) GetCreditByID(id int) (*models.Credit, error) {
 	return s.creditRepo.GetByID(id)
 }
@@ -76,17 +157,15 @@ func (s *creditService) ProcessOverduePayments() error {
 			continue
 		}
 
-		penalty := p.Amount * 0.10
-		newAmount := credit.Amount + penalty
-
-		if err := s.creditRepo.UpdateAmount(credit.ID, newAmount); err != nil {
+		penalty := p.Amount.MulRat(penaltyRate, penaltyRounding)
+		if err := s.creditRepo.AddPenalty(context.Background(), credit.ID, penalty); err != nil {
 			logrus.WithField("creditID", credit.ID).Errorf("failed to update credit: %v", err)
 			continue
 		}
 
 		logrus.WithFields(logrus.Fields{
 			"creditID": credit.ID,
-			"penalty":  penalty,
+			"penalty":  penalty.String(),
 		}).Info("Applied overdue penalty")
 	}
 
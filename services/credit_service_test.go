@@ -0,0 +1,273 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"bank-api/models"
+	"bank-api/services"
+)
+
+// fakeCreditRepo хранит кредиты в памяти.
+type fakeCreditRepo struct {
+	credits map[int]*models.Credit
+}
+
+func (r *fakeCreditRepo) Create(c *models.Credit) error {
+	if r.credits == nil {
+		r.credits = make(map[int]*models.Credit)
+	}
+	c.ID = len(r.credits) + 1
+	r.credits[c.ID] = c
+	return nil
+}
+
+func (r *fakeCreditRepo) CreateWithDisbursement(ctx context.Context, c *models.Credit) error {
+	return r.Create(c)
+}
+
+func (r *fakeCreditRepo) AddPenalty(ctx context.Context, creditID int, penalty models.Money) error {
+	r.credits[creditID].Amount, _ = r.credits[creditID].Amount.Add(penalty)
+	return nil
+}
+
+func (r *fakeCreditRepo) GetByID(id int) (*models.Credit, error) {
+	return r.credits[id], nil
+}
+
+func (r *fakeCreditRepo) GetByUserID(userID int) ([]*models.Credit, error) {
+	var list []*models.Credit
+	for _, c := range r.credits {
+		if c.UserID == userID {
+			list = append(list, c)
+		}
+	}
+	return list, nil
+}
+
+func (r *fakeCreditRepo) UpdateAmount(creditID int, newAmount models.Money) error {
+	r.credits[creditID].Amount = newAmount
+	return nil
+}
+
+// fakeAccountRepo хранит счета в памяти.
+type fakeAccountRepo struct {
+	accounts  map[int]*models.Account
+	transfers []*models.Transfer
+	// Ошибка, которую вернет TransferTx
+	transferErr error
+	// Вызывается из TransferTx перед переводом
+	onTransfer func()
+	// Последний выделенный порядковый номер счета
+	serial int64
+	// Участники счетов помимо владельцев из Account.UserID
+	members []*models.AccountMember
+}
+
+func (r *fakeAccountRepo) Create(a *models.Account) error {
+	a.ID = len(r.accounts) + 1
+	r.accounts[a.ID] = a
+	return nil
+}
+
+// GetMember считает владельцем создателя счета (Account.UserID), как миграция account_members.
+func (r *fakeAccountRepo) GetMember(accountID, userID int) (*models.AccountMember, error) {
+	for _, m := range r.members {
+		if m.AccountID == accountID && m.UserID == userID {
+			return m, nil
+		}
+	}
+	if acc, ok := r.accounts[accountID]; ok && acc.UserID == userID {
+		return &models.AccountMember{AccountID: accountID, UserID: userID, Role: models.MemberRoleOwner}, nil
+	}
+	return nil, models.ErrMemberNotFound
+}
+
+func (r *fakeAccountRepo) ListMembers(accountID int) ([]models.AccountMember, error) {
+	var result []models.AccountMember
+	if acc, ok := r.accounts[accountID]; ok {
+		result = append(result, models.AccountMember{AccountID: accountID, UserID: acc.UserID, Role: models.MemberRoleOwner})
+	}
+	for _, m := range r.members {
+		if m.AccountID == accountID {
+			result = append(result, *m)
+		}
+	}
+	return result, nil
+}
+
+func (r *fakeAccountRepo) AddMember(m *models.AccountMember) error {
+	if _, err := r.GetMember(m.AccountID, m.UserID); err == nil {
+		return models.ErrMemberAlreadyExists
+	}
+	r.members = append(r.members, m)
+	return nil
+}
+
+func (r *fakeAccountRepo) RemoveMember(ctx context.Context, accountID, userID int) error {
+	for i, m := range r.members {
+		if m.AccountID == accountID && m.UserID == userID {
+			r.members = append(r.members[:i], r.members[i+1:]...)
+			return nil
+		}
+	}
+	if acc, ok := r.accounts[accountID]; ok && acc.UserID == userID {
+		return models.ErrLastAccountOwner
+	}
+	return models.ErrMemberNotFound
+}
+
+func (r *fakeAccountRepo) GetByNumber(number string) (*models.Account, error) {
+	for _, acc := range r.accounts {
+		if acc.Number == number {
+			return acc, nil
+		}
+	}
+	return nil, models.ErrAccountNotFound
+}
+
+func (r *fakeAccountRepo) NextNumberSerial() (int64, error) {
+	r.serial++
+	return r.serial, nil
+}
+
+func (r *fakeAccountRepo) ListWithoutNumber() ([]*models.Account, error) {
+	var result []*models.Account
+	for _, acc := range r.accounts {
+		if acc.Number == "" {
+			result = append(result, acc)
+		}
+	}
+	return result, nil
+}
+
+func (r *fakeAccountRepo) SetNumber(accountID int, number string) error {
+	r.accounts[accountID].Number = number
+	return nil
+}
+
+func (r *fakeAccountRepo) GetByID(id int) (*models.Account, error) {
+	acc, ok := r.accounts[id]
+	if !ok {
+		return nil, models.ErrAccountNotFound
+	}
+	return acc, nil
+}
+
+func (r *fakeAccountRepo) UpdateBalance(userID, accountID int, delta models.Money, fee *models.FeeCharge) (*models.Account, error) {
+	acc := r.accounts[accountID]
+	balance, err := acc.Balance.Add(delta)
+	if err != nil {
+		return nil, err
+	}
+	if fee != nil {
+		if balance, err = balance.Sub(fee.Fee); err != nil {
+			return nil, err
+		}
+	}
+	if balance.IsNegative() {
+		return nil, models.ErrInsufficientFunds
+	}
+	acc.Balance = balance
+	return acc, nil
+}
+
+func (r *fakeAccountRepo) TransferTx(ctx context.Context, t *models.Transfer) error {
+	if r.onTransfer != nil {
+		r.onTransfer()
+	}
+	if r.transferErr != nil {
+		return r.transferErr
+	}
+	r.transfers = append(r.transfers, t)
+	t.ID = len(r.transfers)
+	t.EntryID = len(r.transfers)
+	return nil
+}
+
+func (r *fakeAccountRepo) ChangeStatus(ctx context.Context, userID, accountID int, from, to string) (*models.Account, error) {
+	acc := r.accounts[accountID]
+	if acc.Status != from {
+		return nil, models.ErrInvalidStatusTransition
+	}
+	acc.Status = to
+	return acc, nil
+}
+
+// fakeScheduleRepo хранит график платежей в памяти.
+type fakeScheduleRepo struct {
+	items []*models.PaymentSchedule
+}
+
+func (r *fakeScheduleRepo) Create(ps *models.PaymentSchedule) error {
+	ps.ID = len(r.items) + 1
+	r.items = append(r.items, ps)
+	return nil
+}
+
+func (r *fakeScheduleRepo) GetByID(id int) (*models.PaymentSchedule, error) {
+	return r.items[id-1], nil
+}
+
+func (r *fakeScheduleRepo) GetOverdueUnpaid(cutoff time.Time) ([]*models.PaymentSchedule, error) {
+	var list []*models.PaymentSchedule
+	for _, ps := range r.items {
+		if !ps.IsPaid && ps.DueDate.Before(cutoff) {
+			list = append(list, ps)
+		}
+	}
+	return list, nil
+}
+
+func (r *fakeScheduleRepo) GetByCreditID(creditID int) ([]*models.PaymentSchedule, error) {
+	var list []*models.PaymentSchedule
+	for _, ps := range r.items {
+		if ps.CreditID == creditID {
+			list = append(list, ps)
+		}
+	}
+	return list, nil
+}
+
+func (r *fakeScheduleRepo) Update(ps *models.PaymentSchedule) error {
+	return nil
+}
+
+// TestApplyForCreditSchedule проверяет, что аннуитетный график считается без дрейфа копеек.
+func TestApplyForCreditSchedule(t *testing.T) {
+	creditRepo := &fakeCreditRepo{}
+	scheduleRepo := &fakeScheduleRepo{}
+	accountRepo := &fakeAccountRepo{accounts: map[int]*models.Account{
+		1: {ID: 1, UserID: 1, Currency: "RUB", Status: models.AccountStatusActive},
+	}}
+	svc := services.NewCreditService(creditRepo, scheduleRepo, accountRepo)
+
+	credit := &models.Credit{
+		UserID:       1,
+		AccountID:    1,
+		Amount:       models.NewMoney(10000000, "RUB"), // 100 000.00
+		InterestRate: 12,
+	}
+	if err := svc.ApplyForCredit(credit); err != nil {
+		t.Fatalf("ApplyForCredit error: %v", err)
+	}
+	if credit.Currency != "RUB" {
+		t.Errorf("expected credit currency RUB, got %q", credit.Currency)
+	}
+	if len(scheduleRepo.items) != 12 {
+		t.Fatalf("expected 12 payments, got %d", len(scheduleRepo.items))
+	}
+	var total models.Money
+	for i, ps := range scheduleRepo.items {
+		if i < 11 && ps.Amount.Minor != 888488 {
+			t.Errorf("payment %d: expected 8884.88, got %s", i+1, ps.Amount)
+		}
+		total, _ = total.Add(ps.Amount)
+	}
+	// Последний платеж закрывает остаток, поэтому сумма отличается от 12 × 8884.88
+	// не более чем на несколько копеек.
+	if diff := total.Minor - 12*888488; diff < -12 || diff > 12 {
+		t.Errorf("unexpected total %s", total)
+	}
+}
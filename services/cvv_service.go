@@ -0,0 +1,51 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"bank-api/models"
+	"bank-api/repositories"
+	"bank-api/utils"
+)
+
+// CVVService проверяет CVV карт по запросам партнеров.
+type CVVService interface {
+	// VerifyCVV сверяет cvv с хешем CVV карты. Каждая попытка пишется в журнал вместе
+	// с адресом клиента clientAddr. После limit неверных CVV по карте за window —
+	// здесь или при авторизациях по карте — попытки отклоняются с ErrTooManyAttempts
+	// без проверки, даже с верным CVV.
+	VerifyCVV(cardID int, cvv, clientAddr string) (*models.CVVVerification, error)
+}
+
+type cvvService struct {
+	cardRepo repositories.CardRepository
+	// Неверных CVV по карте за окно
+	limit  int
+	window time.Duration
+}
+
+// NewCVVService создает CVVService.
+func NewCVVService(cardRepo repositories.CardRepository, limit int, window time.Duration) CVVService {
+	return &cvvService{cardRepo: cardRepo, limit: limit, window: window}
+}
+
+func (s *cvvService) VerifyCVV(cardID int, cvv, clientAddr string) (*models.CVVVerification, error) {
+	check := &models.CVVCheck{CardID: cardID, ClientAddr: clientAddr}
+	mismatches, err := s.cardRepo.CheckCVV(context.Background(), check, time.Now().Add(-s.window), s.limit,
+		func(card *models.Card) bool { return utils.CheckCVV(cvv, card.CVVHash) })
+	if err != nil {
+		return nil, err
+	}
+
+	switch check.Result {
+	case models.CVVCheckLimited:
+		return nil, models.ErrTooManyAttempts
+	case models.CVVCheckCardInactive:
+		return nil, models.ErrCardInactive
+	}
+	return &models.CVVVerification{
+		Valid:        check.Result == models.CVVCheckMatch,
+		AttemptsLeft: s.limit - mismatches,
+	}, nil
+}
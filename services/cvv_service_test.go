@@ -0,0 +1,63 @@
+package services_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"bank-api/models"
+	"bank-api/services"
+)
+
+func TestVerifyCVV(t *testing.T) {
+	accountRepo := &fakeAccountRepo{accounts: map[int]*models.Account{
+		101: {ID: 101, UserID: 42, Currency: "RUB", Status: models.AccountStatusActive},
+	}}
+	cardRepo := &fakeCardRepo{}
+	cardService := services.NewCardService(cardRepo, accountRepo, newFakeCardProductRepo(), newFakeUserRepo(), testCardIndexKey, 3, time.Minute)
+	card, err := cardService.CreateCard(42, 101, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cvv := card.CVV
+	wrong := "000"
+	if cvv == wrong {
+		wrong = "111"
+	}
+	svc := services.NewCVVService(cardRepo, 3, time.Hour)
+
+	steps := []struct {
+		cvv          string
+		valid        bool
+		attemptsLeft int
+	}{
+		{wrong, false, 2},
+		{cvv, true, 2},
+		{wrong, false, 1},
+		{wrong, false, 0},
+	}
+	for i, step := range steps {
+		result, err := svc.VerifyCVV(card.ID, step.cvv, "10.0.0.1:5000")
+		if err != nil {
+			t.Fatalf("step %d: unexpected error: %v", i, err)
+		}
+		if result.Valid != step.valid || result.AttemptsLeft != step.attemptsLeft {
+			t.Errorf("step %d: expected valid=%v left=%d, got %+v", i, step.valid, step.attemptsLeft, result)
+		}
+	}
+	// Лимит исчерпан: верный CVV тоже отклоняется.
+	if _, err := svc.VerifyCVV(card.ID, cvv, "10.0.0.1:5000"); !errors.Is(err, models.ErrTooManyAttempts) {
+		t.Errorf("expected ErrTooManyAttempts, got %v", err)
+	}
+	if len(cardRepo.cvvChecks) != 5 {
+		t.Fatalf("expected every attempt logged, got %d", len(cardRepo.cvvChecks))
+	}
+	last := cardRepo.cvvChecks[4]
+	if last.Result != models.CVVCheckLimited || last.CardID != card.ID || last.ClientAddr != "10.0.0.1:5000" {
+		t.Errorf("unexpected CVV check %+v", last)
+	}
+
+	if _, err := svc.VerifyCVV(99, cvv, ""); !errors.Is(err, models.ErrCardNotFound) {
+		t.Errorf("expected ErrCardNotFound, got %v", err)
+	}
+}
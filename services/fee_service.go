@@ -0,0 +1,199 @@
+package services
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	"bank-api/models"
+	"bank-api/repositories"
+)
+
+// FeeService рассчитывает комиссии по тарифу и управляет его правилами.
+type FeeService interface {
+	// Quote рассчитывает комиссию за операцию operation на amount по счету account
+	// по действующим правилам и обороту счета с начала текущего месяца.
+	Quote(account *models.Account, operation string, amount models.Money) (*models.FeeCharge, error)
+	// QuoteForUser рассчитывает комиссию для участника счета без исполнения операции.
+	QuoteForUser(userID, accountID int, operation string, amount models.Money) (*models.FeeCharge, error)
+	ListRules() ([]*models.FeeRule, error)
+	// CreateRule добавляет правило тарифа; доступно только операционисту.
+	CreateRule(role string, rule *models.FeeRule) error
+	// DeactivateRule выключает правило тарифа; доступно только операционисту.
+	DeactivateRule(role string, id int) error
+}
+
+type feeService struct {
+	feeRepo     repositories.FeeRepository
+	accountRepo repositories.AccountRepository
+}
+
+// NewFeeService создает FeeService.
+func NewFeeService(feeRepo repositories.FeeRepository, accountRepo repositories.AccountRepository) FeeService {
+	return &feeService{feeRepo: feeRepo, accountRepo: accountRepo}
+}
+
+func (s *feeService) Quote(account *models.Account, operation string, amount models.Money) (*models.FeeCharge, error) {
+	if !models.ValidFeeOperation(operation) {
+		return nil, fmt.Errorf("%w: %q", models.ErrInvalidOperation, operation)
+	}
+	if !amount.IsPositive() {
+		return nil, models.ErrInvalidAmount
+	}
+	if amount.Currency != "" && amount.Currency != account.Currency {
+		return nil, fmt.Errorf("%w: %s and %s", models.ErrCurrencyMismatch, amount.Currency, account.Currency)
+	}
+	amount = amount.WithCurrency(account.Currency)
+
+	now := time.Now()
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	volume, err := s.feeRepo.MonthlyVolume(account.ID, operation, monthStart)
+	if err != nil {
+		return nil, err
+	}
+	volume = volume.WithCurrency(account.Currency)
+	rules, err := s.feeRepo.ListRules(true)
+	if err != nil {
+		return nil, err
+	}
+
+	charge := &models.FeeCharge{
+		AccountID:     account.ID,
+		Operation:     operation,
+		Amount:        amount,
+		Fee:           models.NewMoney(0, account.Currency),
+		MonthlyVolume: volume,
+	}
+	rule := selectFeeRule(rules, operation, account.Currency, account.Type, volume)
+	if rule == nil {
+		return charge, nil
+	}
+	fee, err := calculateFee(rule, amount)
+	if err != nil {
+		return nil, err
+	}
+	charge.Fee = fee
+	charge.RuleID = rule.ID
+	return charge, nil
+}
+
+func (s *feeService) QuoteForUser(userID, accountID int, operation string, amount models.Money) (*models.FeeCharge, error) {
+	account, _, err := accountAccess(s.accountRepo, accountID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.Quote(account, operation, amount)
+}
+
+func (s *feeService) ListRules() ([]*models.FeeRule, error) {
+	return s.feeRepo.ListRules(false)
+}
+
+func (s *feeService) CreateRule(role string, rule *models.FeeRule) error {
+	if role != models.RoleOperator {
+		return models.ErrOperatorRequired
+	}
+	if err := validateFeeRule(rule); err != nil {
+		return err
+	}
+	rule.Active = true
+	return s.feeRepo.CreateRule(rule)
+}
+
+func (s *feeService) DeactivateRule(role string, id int) error {
+	if role != models.RoleOperator {
+		return models.ErrOperatorRequired
+	}
+	return s.feeRepo.DeactivateRule(id)
+}
+
+// selectFeeRule выбирает самое узкое из подходящих правил: с валютой и типом счета,
+// затем с большей нижней границей оборота, затем более новое. nil — операция бесплатна.
+func selectFeeRule(rules []*models.FeeRule, operation, currency, accountType string, volume models.Money) *models.FeeRule {
+	var best *models.FeeRule
+	for _, rule := range rules {
+		if !rule.Matches(operation, currency, accountType, volume) {
+			continue
+		}
+		if best == nil || feeRuleNarrower(rule, best) {
+			best = rule
+		}
+	}
+	return best
+}
+
+func feeRuleNarrower(a, b *models.FeeRule) bool {
+	if a.Specificity() != b.Specificity() {
+		return a.Specificity() > b.Specificity()
+	}
+	if a.VolumeFrom.Minor != b.VolumeFrom.Minor {
+		return a.VolumeFrom.Minor > b.VolumeFrom.Minor
+	}
+	return a.ID > b.ID
+}
+
+// calculateFee считает комиссию по правилу: процент от суммы (с округлением
+// половины копейки вверх) плюс фиксированная часть, в пределах [Min, Max].
+func calculateFee(rule *models.FeeRule, amount models.Money) (models.Money, error) {
+	percent, fixed := rule.Percent, rule.Fixed
+	for _, tier := range rule.Tiers {
+		if tier.UpTo.IsZero() || amount.Minor <= tier.UpTo.Minor {
+			percent, fixed = tier.Percent, tier.Fixed
+			break
+		}
+	}
+	rate, ok := decimalRat(percent)
+	if !ok {
+		return models.Money{}, fmt.Errorf("%w: percent %v", models.ErrInvalidFeeRule, percent)
+	}
+	rate.Quo(rate, big.NewRat(100, 1))
+	fee := amount.MulRat(rate, models.RoundHalfUp)
+	fee.Minor += fixed.Minor
+	if fee.Minor < rule.Min.Minor {
+		fee.Minor = rule.Min.Minor
+	}
+	if rule.Max.IsPositive() && fee.Minor > rule.Max.Minor {
+		fee.Minor = rule.Max.Minor
+	}
+	return fee.WithCurrency(amount.Currency), nil
+}
+
+// validateFeeRule проверяет операцию, ключи и неотрицательность сумм правила,
+// порядок ступеней и границ оборота.
+func validateFeeRule(rule *models.FeeRule) error {
+	if !models.ValidFeeOperation(rule.Operation) {
+		return fmt.Errorf("%w: unknown operation %q", models.ErrInvalidFeeRule, rule.Operation)
+	}
+	if rule.Currency != "" {
+		if _, ok := models.AccountCurrencyCode(rule.Currency); !ok {
+			return fmt.Errorf("%w: %q", models.ErrUnsupportedCurrency, rule.Currency)
+		}
+	}
+	if rule.AccountType != "" {
+		if _, ok := models.BalanceAccountPrefix(rule.AccountType); !ok {
+			return fmt.Errorf("%w: %q", models.ErrInvalidAccountType, rule.AccountType)
+		}
+	}
+	if rule.Percent < 0 || rule.Fixed.IsNegative() || rule.Min.IsNegative() || rule.Max.IsNegative() ||
+		rule.VolumeFrom.IsNegative() || rule.VolumeTo.IsNegative() {
+		return fmt.Errorf("%w: negative percent or amount", models.ErrInvalidFeeRule)
+	}
+	if rule.Max.IsPositive() && rule.Max.Minor < rule.Min.Minor {
+		return fmt.Errorf("%w: max is below min", models.ErrInvalidFeeRule)
+	}
+	if !rule.VolumeTo.IsZero() && rule.VolumeTo.Minor <= rule.VolumeFrom.Minor {
+		return fmt.Errorf("%w: volume_to must exceed volume_from", models.ErrInvalidFeeRule)
+	}
+	for i, tier := range rule.Tiers {
+		if tier.Percent < 0 || tier.Fixed.IsNegative() || tier.UpTo.IsNegative() {
+			return fmt.Errorf("%w: negative tier %d", models.ErrInvalidFeeRule, i+1)
+		}
+		if tier.UpTo.IsZero() && i != len(rule.Tiers)-1 {
+			return fmt.Errorf("%w: only the last tier may be unbounded", models.ErrInvalidFeeRule)
+		}
+		if i > 0 && !tier.UpTo.IsZero() && tier.UpTo.Minor <= rule.Tiers[i-1].UpTo.Minor {
+			return fmt.Errorf("%w: tiers must be in ascending order", models.ErrInvalidFeeRule)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package services_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"bank-api/models"
+	"bank-api/services"
+)
+
+// fakeFeeRepo хранит правила тарифа и месячные обороты в памяти.
+type fakeFeeRepo struct {
+	rules []*models.FeeRule
+	// Оборот по операции с начала месяца
+	volumes map[string]models.Money
+}
+
+func (r *fakeFeeRepo) ListRules(activeOnly bool) ([]*models.FeeRule, error) {
+	var rules []*models.FeeRule
+	for _, rule := range r.rules {
+		if rule.Active || !activeOnly {
+			rules = append(rules, rule)
+		}
+	}
+	return rules, nil
+}
+
+func (r *fakeFeeRepo) CreateRule(rule *models.FeeRule) error {
+	rule.ID = len(r.rules) + 1
+	r.rules = append(r.rules, rule)
+	return nil
+}
+
+func (r *fakeFeeRepo) DeactivateRule(id int) error {
+	for _, rule := range r.rules {
+		if rule.ID == id {
+			rule.Active = false
+			return nil
+		}
+	}
+	return models.ErrFeeRuleNotFound
+}
+
+func (r *fakeFeeRepo) MonthlyVolume(accountID int, operation string, since time.Time) (models.Money, error) {
+	return r.volumes[operation], nil
+}
+
+// noFees возвращает FeeService с пустым тарифом: все операции бесплатны.
+func noFees(accountRepo *fakeAccountRepo) services.FeeService {
+	return services.NewFeeService(&fakeFeeRepo{}, accountRepo)
+}
+
+func rub(minor int64) models.Money { return models.NewMoney(minor, "") }
+
+func TestFeeQuote(t *testing.T) {
+	feeRepo := &fakeFeeRepo{rules: []*models.FeeRule{
+		// Переводы: 1% не меньше 30 и не больше 1000 рублей.
+		{ID: 1, Operation: models.FeeOperationTransfer, Percent: 1, Min: rub(3000), Max: rub(100000), Active: true},
+		// Первые 100 000 рублей в месяц по рублевым текущим счетам — бесплатно.
+		{ID: 2, Operation: models.FeeOperationTransfer, Currency: "RUB", AccountType: models.AccountTypeCurrent,
+			VolumeTo: rub(10000000), Active: true},
+		// Снятие: до 5000 — 50 рублей, до 50 000 — 0.5%, дальше 1% + 100 рублей.
+		{ID: 3, Operation: models.FeeOperationWithdrawal, Percent: 1, Fixed: rub(10000), Active: true, Tiers: []models.FeeTier{
+			{UpTo: rub(500000), Fixed: rub(5000)},
+			{UpTo: rub(5000000), Percent: 0.5},
+		}},
+		{ID: 4, Operation: models.FeeOperationDeposit, Fixed: rub(100), Active: false},
+	}, volumes: map[string]models.Money{}}
+	svc := services.NewFeeService(feeRepo, &fakeAccountRepo{})
+	current := &models.Account{ID: 1, Currency: "RUB", Type: models.AccountTypeCurrent}
+	business := &models.Account{ID: 2, Currency: "RUB", Type: models.AccountTypeBusiness}
+
+	cases := []struct {
+		name      string
+		account   *models.Account
+		operation string
+		amount    int64
+		volume    int64
+		wantFee   string
+		wantRule  int
+	}{
+		{"free monthly allowance", current, models.FeeOperationTransfer, 500000, 0, "0.00", 2},
+		{"allowance used up", current, models.FeeOperationTransfer, 500000, 10000000, "50.00", 1},
+		{"minimum fee", business, models.FeeOperationTransfer, 100000, 0, "30.00", 1},
+		{"maximum fee", business, models.FeeOperationTransfer, 50000000, 0, "1000.00", 1},
+		{"percent rounds half up", business, models.FeeOperationTransfer, 1234550, 0, "123.46", 1},
+		{"first tier", current, models.FeeOperationWithdrawal, 500000, 0, "50.00", 3},
+		{"second tier", current, models.FeeOperationWithdrawal, 2000000, 0, "100.00", 3},
+		{"above tiers", current, models.FeeOperationWithdrawal, 10000000, 0, "1100.00", 3},
+		{"inactive rule", current, models.FeeOperationDeposit, 10000, 0, "0.00", 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			feeRepo.volumes[tc.operation] = rub(tc.volume)
+			quote, err := svc.Quote(tc.account, tc.operation, rub(tc.amount))
+			if err != nil {
+				t.Fatalf("Quote failed: %v", err)
+			}
+			if quote.Fee.String() != tc.wantFee || quote.Fee.Currency != "RUB" || quote.RuleID != tc.wantRule {
+				t.Errorf("expected fee %s by rule %d, got %s by rule %d", tc.wantFee, tc.wantRule, quote.Fee, quote.RuleID)
+			}
+		})
+	}
+
+	if _, err := svc.Quote(current, "loan", rub(100)); !errors.Is(err, models.ErrInvalidOperation) {
+		t.Errorf("expected ErrInvalidOperation, got %v", err)
+	}
+}
+
+func TestTransferChargesFee(t *testing.T) {
+	accountRepo := &fakeAccountRepo{accounts: map[int]*models.Account{
+		1: {ID: 1, UserID: 7, Currency: "RUB", Type: models.AccountTypeCurrent, Balance: models.NewMoney(100000, "RUB"), Status: models.AccountStatusActive},
+		2: {ID: 2, UserID: 8, Currency: "RUB", Type: models.AccountTypeCurrent, Status: models.AccountStatusActive},
+	}}
+	feeRepo := &fakeFeeRepo{rules: []*models.FeeRule{
+		{ID: 1, Operation: models.FeeOperationCardTransfer, Percent: 1.5, Active: true},
+	}}
+	feeService := services.NewFeeService(feeRepo, accountRepo)
+	svc := services.NewAccountService(accountRepo, nil, feeService, nil, testBankBIK)
+
+	transfer, err := svc.CardTransfer(7, 1, 2, models.NewMoney(20000, ""))
+	if err != nil {
+		t.Fatalf("CardTransfer failed: %v", err)
+	}
+	if transfer.Fee == nil || transfer.Fee.Fee.String() != "3.00" || transfer.Fee.Operation != models.FeeOperationCardTransfer {
+		t.Errorf("unexpected fee: %+v", transfer.Fee)
+	}
+
+	// Обычный перевод тарифицируется отдельно и по этому тарифу бесплатен.
+	transfer, err = svc.Transfer(7, 1, 2, models.NewMoney(20000, ""))
+	if err != nil {
+		t.Fatalf("Transfer failed: %v", err)
+	}
+	if !transfer.Fee.Fee.IsZero() {
+		t.Errorf("expected free transfer, got fee %s", transfer.Fee.Fee)
+	}
+}
+
+func TestFeeRulesRequireOperator(t *testing.T) {
+	feeRepo := &fakeFeeRepo{}
+	svc := services.NewFeeService(feeRepo, &fakeAccountRepo{})
+	rule := &models.FeeRule{Operation: models.FeeOperationTransfer, Percent: 1}
+
+	if err := svc.CreateRule(models.RoleCustomer, rule); !errors.Is(err, models.ErrOperatorRequired) {
+		t.Errorf("expected ErrOperatorRequired, got %v", err)
+	}
+	if err := svc.CreateRule(models.RoleOperator, rule); err != nil {
+		t.Fatalf("CreateRule failed: %v", err)
+	}
+	if !rule.Active || rule.ID == 0 {
+		t.Errorf("expected an active saved rule, got %+v", rule)
+	}
+
+	invalid := []*models.FeeRule{
+		{Operation: "loan"},
+		{Operation: models.FeeOperationTransfer, Min: rub(1000), Max: rub(500)},
+		{Operation: models.FeeOperationTransfer, VolumeFrom: rub(1000), VolumeTo: rub(1000)},
+		{Operation: models.FeeOperationTransfer, Tiers: []models.FeeTier{{UpTo: rub(1000)}, {UpTo: rub(500)}}},
+		{Operation: models.FeeOperationTransfer, Tiers: []models.FeeTier{{}, {UpTo: rub(500)}}},
+	}
+	for i, rule := range invalid {
+		if err := svc.CreateRule(models.RoleOperator, rule); !errors.Is(err, models.ErrInvalidFeeRule) {
+			t.Errorf("rule %d: expected ErrInvalidFeeRule, got %v", i, err)
+		}
+	}
+
+	if err := svc.DeactivateRule(models.RoleOperator, 1); err != nil {
+		t.Fatalf("DeactivateRule failed: %v", err)
+	}
+	if err := svc.DeactivateRule(models.RoleOperator, 42); !errors.Is(err, models.ErrFeeRuleNotFound) {
+		t.Errorf("expected ErrFeeRuleNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,127 @@
+package services
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	"bank-api/models"
+	"bank-api/repositories"
+)
+
+// baseCurrency — валюта, к которой ЦБ РФ публикует курсы.
+const baseCurrency = "RUB"
+
+// fxRounding — правило округления суммы после конвертации (в пользу банка).
+const fxRounding = models.RoundDown
+
+// FXService конвертирует суммы между валютами по курсам ЦБ РФ.
+type FXService interface {
+	// RefreshRates загружает курсы ЦБ на дату и сохраняет их.
+	RefreshRates(date time.Time) error
+	// Quote рассчитывает конвертацию amount в валюту toCurrency по курсу на дату с учетом спреда.
+	// Курс старше maxRateAge рабочих дней считается недоступным (ErrRateUnavailable).
+	Quote(amount models.Money, toCurrency string, date time.Time) (*models.FXQuote, error)
+}
+
+type fxService struct {
+	rateRepo repositories.CurrencyRateRepository
+	// Доля спреда банка, например 0.015 для 1.5%
+	spread *big.Rat
+	// Сколько рабочих дней после даты курса он еще применяется
+	maxRateAge int
+	fetchRates func(date time.Time) ([]models.CurrencyRate, error)
+}
+
+// NewFXService создает FXService; spreadPercent — спред банка в процентах от курса ЦБ,
+// maxRateAge — сколько рабочих дней после своей даты курс пригоден для конвертации.
+func NewFXService(rateRepo repositories.CurrencyRateRepository, spreadPercent float64, maxRateAge int) (FXService, error) {
+	spread, ok := decimalRat(spreadPercent)
+	if !ok || spread.Sign() < 0 || spread.Cmp(big.NewRat(100, 1)) >= 0 {
+		return nil, fmt.Errorf("invalid FX spread %v%%", spreadPercent)
+	}
+	if maxRateAge < 0 {
+		return nil, fmt.Errorf("invalid FX rate max age %d", maxRateAge)
+	}
+	return &fxService{
+		rateRepo:   rateRepo,
+		spread:     spread.Quo(spread, big.NewRat(100, 1)),
+		maxRateAge: maxRateAge,
+		fetchRates: GetCurrencyRates,
+	}, nil
+}
+
+func (s *fxService) RefreshRates(date time.Time) error {
+	rates, err := s.fetchRates(date)
+	if err != nil {
+		return fmt.Errorf("fetch CBR rates: %w", err)
+	}
+	return s.rateRepo.SaveRates(rates)
+}
+
+func (s *fxService) Quote(amount models.Money, toCurrency string, date time.Time) (*models.FXQuote, error) {
+	fromPerUnit, fromDate, err := s.rubPerUnit(amount.Currency, date)
+	if err != nil {
+		return nil, err
+	}
+	toPerUnit, toDate, err := s.rubPerUnit(toCurrency, date)
+	if err != nil {
+		return nil, err
+	}
+
+	mid := new(big.Rat).Quo(fromPerUnit, toPerUnit)
+	rate := new(big.Rat).Sub(big.NewRat(1, 1), s.spread)
+	rate.Mul(rate, mid)
+	// Сумма зачисления считается по округленному курсу, который сохраняется в журнале.
+	applied := models.FormatRate(rate)
+	rate.SetString(applied)
+
+	rateDate := fromDate
+	if rateDate.IsZero() || (!toDate.IsZero() && toDate.Before(rateDate)) {
+		rateDate = toDate
+	}
+	return &models.FXQuote{
+		FromCurrency: amount.Currency,
+		ToCurrency:   toCurrency,
+		MidRate:      models.FormatRate(mid),
+		Rate:         applied,
+		Amount:       amount,
+		Converted:    models.MoneyFromRat(new(big.Rat).Mul(amount.Rat(), rate), toCurrency, fxRounding),
+		RateDate:     rateDate,
+	}, nil
+}
+
+// rubPerUnit возвращает рублевую стоимость единицы валюты и дату курса.
+func (s *fxService) rubPerUnit(currency string, date time.Time) (*big.Rat, time.Time, error) {
+	if currency == baseCurrency {
+		return big.NewRat(1, 1), time.Time{}, nil
+	}
+	rate, err := s.rateRepo.GetRate(currency, date)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	if businessDaysAfter(rate.Date, date, s.maxRateAge) > s.maxRateAge {
+		return nil, time.Time{}, fmt.Errorf("%w: %s rate of %s is stale on %s", models.ErrRateUnavailable,
+			currency, rate.Date.Format("2006-01-02"), date.Format("2006-01-02"))
+	}
+	perUnit, err := rate.PerUnit()
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return perUnit, rate.Date, nil
+}
+
+// businessDaysAfter считает рабочие дни (пн–пт) после календарной даты from до даты to
+// включительно; подсчет останавливается, как только превышен limit.
+func businessDaysAfter(from, to time.Time, limit int) int {
+	day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	end := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	n := 0
+	for day.Before(end) && n <= limit {
+		day = day.AddDate(0, 0, 1)
+		if wd := day.Weekday(); wd != time.Saturday && wd != time.Sunday {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,133 @@
+package services_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"bank-api/models"
+	"bank-api/services"
+)
+
+// fakeRateRepo хранит курсы в памяти без учета дат; курс без даты
+// возвращается как курс на запрошенную дату.
+type fakeRateRepo struct {
+	rates map[string]models.CurrencyRate
+}
+
+func (r *fakeRateRepo) SaveRates(rates []models.CurrencyRate) error {
+	for _, rate := range rates {
+		r.rates[rate.Currency] = rate
+	}
+	return nil
+}
+
+func (r *fakeRateRepo) GetRate(currency string, date time.Time) (*models.CurrencyRate, error) {
+	rate, ok := r.rates[currency]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", models.ErrRateUnavailable, currency)
+	}
+	if rate.Date.IsZero() {
+		rate.Date = date
+	}
+	return &rate, nil
+}
+
+func newFakeRateRepo() *fakeRateRepo {
+	return &fakeRateRepo{rates: map[string]models.CurrencyRate{
+		"USD": {Currency: "USD", Nominal: 1, Value: "92.5133"},
+		"JPY": {Currency: "JPY", Nominal: 100, Value: "60.1234"},
+	}}
+}
+
+func TestFXQuote(t *testing.T) {
+	fx, err := services.NewFXService(newFakeRateRepo(), 1.5, 7)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cases := []struct {
+		amount    models.Money
+		to        string
+		rate      string
+		converted string
+	}{
+		{models.NewMoney(10000, "USD"), "RUB", "91.125601", "9112.56"},
+		{models.NewMoney(100000, "RUB"), "USD", "0.010647", "10.64"},
+		{models.NewMoney(1000, "USD"), "JPY", "151.564284", "1515.64"},
+	}
+	for _, tc := range cases {
+		quote, err := fx.Quote(tc.amount, tc.to, time.Now())
+		if err != nil {
+			t.Fatalf("%s->%s: unexpected error: %v", tc.amount.Currency, tc.to, err)
+		}
+		if quote.Rate != tc.rate {
+			t.Errorf("%s->%s: expected rate %s, got %s", tc.amount.Currency, tc.to, tc.rate, quote.Rate)
+		}
+		if quote.Converted.String() != tc.converted || quote.Converted.Currency != tc.to {
+			t.Errorf("%s->%s: expected %s %s, got %s %s", tc.amount.Currency, tc.to,
+				tc.converted, tc.to, quote.Converted, quote.Converted.Currency)
+		}
+	}
+
+	// Сумма зачисления считается по округленному курсу: 1 000 000.00 RUB по 0.010647
+	// дают ровно 10 647.00 USD, а по неокругленному курсу 0.01064711... было бы 10 647.11.
+	quote, err := fx.Quote(models.NewMoney(100000000, "RUB"), "USD", time.Now())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if quote.Converted.String() != "10647.00" {
+		t.Errorf("expected 10647.00 USD at the applied rate %s, got %s", quote.Rate, quote.Converted)
+	}
+
+	if _, err := fx.Quote(models.NewMoney(100, "EUR"), "RUB", time.Now()); !errors.Is(err, models.ErrRateUnavailable) {
+		t.Errorf("expected ErrRateUnavailable, got %v", err)
+	}
+}
+
+func TestFXQuoteRejectsStaleRates(t *testing.T) {
+	rates := newFakeRateRepo()
+	usd := rates.rates["USD"]
+	// Пятница: в понедельник и вторник курс еще применяется, в среду — уже нет.
+	usd.Date = time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	rates.rates["USD"] = usd
+	fx, err := services.NewFXService(rates, 1.5, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := fx.Quote(models.NewMoney(10000, "USD"), "RUB", time.Date(2024, 3, 5, 15, 0, 0, 0, time.UTC)); err != nil {
+		t.Errorf("expected the rate to apply on Tuesday, got %v", err)
+	}
+	if _, err := fx.Quote(models.NewMoney(10000, "USD"), "RUB", time.Date(2024, 3, 6, 9, 0, 0, 0, time.UTC)); !errors.Is(err, models.ErrRateUnavailable) {
+		t.Errorf("expected ErrRateUnavailable for a stale rate, got %v", err)
+	}
+}
+
+func TestTransferConvertsBetweenCurrencies(t *testing.T) {
+	fx, err := services.NewFXService(newFakeRateRepo(), 1.5, 7)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	accountRepo := &fakeAccountRepo{accounts: map[int]*models.Account{
+		1: {ID: 1, UserID: 7, Currency: "USD", Status: models.AccountStatusActive},
+		2: {ID: 2, UserID: 8, Currency: "RUB", Status: models.AccountStatusActive},
+	}}
+	svc := services.NewAccountService(accountRepo, fx, noFees(accountRepo), nil, testBankBIK)
+
+	transfer, err := svc.Transfer(7, 1, 2, models.NewMoney(10000, ""))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(accountRepo.transfers) != 1 {
+		t.Fatalf("expected one transfer, got %d", len(accountRepo.transfers))
+	}
+	if transfer.Amount != models.NewMoney(10000, "USD") {
+		t.Errorf("expected 100.00 USD debited, got %s %s", transfer.Amount, transfer.Amount.Currency)
+	}
+	if transfer.CreditedAmount != models.NewMoney(911256, "RUB") || transfer.FXRate != "91.125601" {
+		t.Errorf("expected 9112.56 RUB at 91.125601, got %s %s at %s",
+			transfer.CreditedAmount, transfer.CreditedAmount.Currency, transfer.FXRate)
+	}
+}
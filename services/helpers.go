@@ -0,0 +1,44 @@
+package services
+
+import (
+	"errors"
+	"math/big"
+	"strconv"
+
+	"bank-api/models"
+	"bank-api/repositories"
+)
+
+// decimalRat переводит число из конфигурации или запроса в точное рациональное,
+// используя его кратчайшую десятичную запись (0.1 -> 1/10, а не двоичное приближение).
+func decimalRat(f float64) (*big.Rat, bool) {
+	return new(big.Rat).SetString(strconv.FormatFloat(f, 'f', -1, 64))
+}
+
+// accountAccess возвращает счет и членство в нем пользователя userID.
+// Пользователю, не участвующему в счете, возвращается ErrNotAccountOwner.
+func accountAccess(repo repositories.AccountRepository, accountID, userID int) (*models.Account, *models.AccountMember, error) {
+	account, err := repo.GetByID(accountID)
+	if err != nil {
+		return nil, nil, err
+	}
+	member, err := repo.GetMember(accountID, userID)
+	if errors.Is(err, models.ErrMemberNotFound) {
+		return nil, nil, models.ErrNotAccountOwner
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return account, member, nil
+}
+
+// checkSpend проверяет право участника списать amount со счета.
+func checkSpend(member *models.AccountMember, amount models.Money) error {
+	if member.CanSpend(amount) {
+		return nil
+	}
+	if member.Role == models.MemberRoleSpendWithLimit {
+		return models.ErrSpendLimitExceeded
+	}
+	return models.ErrNotAccountOwner
+}
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+
+	"bank-api/models"
+	"bank-api/repositories"
+	"bank-api/utils"
+)
+
+// PINService описывает управление PIN карты. PIN передается только в виде
+// PIN-блока ISO 9564 под зональным ключом, в БД хранится лишь PVV.
+type PINService interface {
+	// SetPIN устанавливает первый PIN карты.
+	SetPIN(userID, cardID int, block models.PINBlock) error
+	// ChangePIN меняет PIN после проверки текущего; неверный текущий PIN
+	// учитывается как неудачная попытка.
+	ChangePIN(userID, cardID int, current, next models.PINBlock) error
+	// VerifyPIN проверяет PIN. После maxAttempts неверных PIN подряд карта блокируется.
+	VerifyPIN(userID, cardID int, block models.PINBlock) error
+}
+
+type pinService struct {
+	cardRepo    repositories.CardRepository
+	accountRepo repositories.AccountRepository
+	// Зональные ключи, под которыми клиент шифрует PIN-блоки формата 0 (TDES)
+	// и формата 4 (AES); формат без ключа не принимается
+	tdesZoneKey []byte
+	aesZoneKey  []byte
+	// Ключ проверки PIN и его индекс
+	pvk  []byte
+	pvki int
+	// Неверных PIN подряд до блокировки карты
+	maxAttempts int
+}
+
+// NewPINService возвращает PINService. Один из зональных ключей может быть пустым,
+// тогда PIN-блоки его формата отклоняются.
+func NewPINService(
+	cardRepo repositories.CardRepository,
+	accountRepo repositories.AccountRepository,
+	tdesZoneKey, aesZoneKey, pvk []byte,
+	pvki, maxAttempts int,
+) PINService {
+	return &pinService{
+		cardRepo:    cardRepo,
+		accountRepo: accountRepo,
+		tdesZoneKey: tdesZoneKey,
+		aesZoneKey:  aesZoneKey,
+		pvk:         pvk,
+		pvki:        pvki,
+		maxAttempts: maxAttempts,
+	}
+}
+
+func (s *pinService) SetPIN(userID, cardID int, block models.PINBlock) error {
+	card, pan, err := s.holderCard(userID, cardID)
+	if err != nil {
+		return err
+	}
+	if card.PVV != "" {
+		return models.ErrPINAlreadySet
+	}
+	return s.storePIN(card, pan, block)
+}
+
+func (s *pinService) ChangePIN(userID, cardID int, current, next models.PINBlock) error {
+	card, pan, err := s.holderCard(userID, cardID)
+	if err != nil {
+		return err
+	}
+	if err := s.checkPIN(userID, card, pan, current); err != nil {
+		return err
+	}
+	return s.storePIN(card, pan, next)
+}
+
+func (s *pinService) VerifyPIN(userID, cardID int, block models.PINBlock) error {
+	card, pan, err := s.holderCard(userID, cardID)
+	if err != nil {
+		return err
+	}
+	return s.checkPIN(userID, card, pan, block)
+}
+
+// holderCard возвращает активную карту держателя, остающегося участником счета,
+// и ее расшифрованный номер.
+func (s *pinService) holderCard(userID, cardID int) (*models.Card, string, error) {
+	card, err := s.cardRepo.GetByID(cardID)
+	if err != nil {
+		return nil, "", err
+	}
+	if card.UserID != userID {
+		return nil, "", models.ErrNotCardOwner
+	}
+	if _, err := s.accountRepo.GetMember(card.AccountID, userID); errors.Is(err, models.ErrMemberNotFound) {
+		return nil, "", models.ErrNotCardOwner
+	} else if err != nil {
+		return nil, "", err
+	}
+	if card.Status != models.CardStatusActive {
+		return nil, "", models.ErrCardInactive
+	}
+	pan, err := utils.DecryptPGP(card.CardNumber, card.CardNumberMAC)
+	if err != nil {
+		return nil, "", err
+	}
+	return card, pan, nil
+}
+
+// checkPIN сверяет PVV введенного PIN с сохраненным и записывает попытку.
+func (s *pinService) checkPIN(userID int, card *models.Card, pan string, block models.PINBlock) error {
+	if card.PVV == "" {
+		return models.ErrPINNotSet
+	}
+	pin, err := s.decodePIN(pan, block)
+	if err != nil {
+		return err
+	}
+	pvv, err := utils.ComputePVV(pan, pin, card.PVKI, s.pvk)
+	if err != nil {
+		return err
+	}
+	ok := subtle.ConstantTimeCompare([]byte(pvv), []byte(card.PVV)) == 1
+	card, err = s.cardRepo.RecordPINAttempt(context.Background(), card.ID, ok, s.maxAttempts, userID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		if card.Status != models.CardStatusActive {
+			return fmt.Errorf("%w: card blocked after %d attempts", models.ErrInvalidPIN, s.maxAttempts)
+		}
+		return fmt.Errorf("%w: %d attempts left", models.ErrInvalidPIN, s.maxAttempts-card.PINAttempts)
+	}
+	return nil
+}
+
+// storePIN сохраняет PVV нового PIN, вычисленный текущим ключом PVK.
+func (s *pinService) storePIN(card *models.Card, pan string, block models.PINBlock) error {
+	pin, err := s.decodePIN(pan, block)
+	if err != nil {
+		return err
+	}
+	pvv, err := utils.ComputePVV(pan, pin, s.pvki, s.pvk)
+	if err != nil {
+		return err
+	}
+	return s.cardRepo.SetPVV(card.ID, pvv, s.pvki)
+}
+
+func (s *pinService) decodePIN(pan string, block models.PINBlock) (string, error) {
+	// Ключ выпускается для одного алгоритма и не используется в другом.
+	zoneKey := s.tdesZoneKey
+	if block.Format == utils.PINBlockFormat4 {
+		zoneKey = s.aesZoneKey
+	}
+	if len(zoneKey) == 0 {
+		return "", fmt.Errorf("%w: PIN block format %d is not accepted", models.ErrInvalidPINBlock, block.Format)
+	}
+	pin, err := utils.DecodePINBlock(block.Format, block.Block, pan, zoneKey)
+	if errors.Is(err, utils.ErrInvalidPINBlock) {
+		return "", fmt.Errorf("%w: %v", models.ErrInvalidPINBlock, err)
+	}
+	return pin, err
+}
@@ -0,0 +1,132 @@
+package services_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"bank-api/models"
+	"bank-api/services"
+	"bank-api/utils"
+)
+
+var (
+	testTDESZoneKey = []byte("0123456789abcdef")
+	testAESZoneKey  = []byte("0123456789abcdef0123456789abcdef")
+	testPVK         = []byte("fedcba9876543210")
+)
+
+// pinBlock шифрует PIN так, как это делает клиент перед отправкой: формат 0 — TDES-ключом,
+// формат 4 — AES-ключом.
+func pinBlock(t *testing.T, card *models.Card, format int, pin string) models.PINBlock {
+	t.Helper()
+	pan, err := utils.DecryptPGP(card.CardNumber, card.CardNumberMAC)
+	if err != nil {
+		t.Fatalf("decrypt PAN: %v", err)
+	}
+	zoneKey := testTDESZoneKey
+	if format == utils.PINBlockFormat4 {
+		zoneKey = testAESZoneKey
+	}
+	block, err := utils.EncodePINBlock(format, pin, pan, zoneKey)
+	if err != nil {
+		t.Fatalf("encode PIN block: %v", err)
+	}
+	return models.PINBlock{Format: format, Block: block}
+}
+
+func TestPINLifecycle(t *testing.T) {
+	accountRepo := &fakeAccountRepo{accounts: map[int]*models.Account{
+		101: {ID: 101, UserID: 42, Currency: "RUB", Status: models.AccountStatusActive},
+	}}
+	cardRepo := &fakeCardRepo{}
+	cardService := services.NewCardService(cardRepo, accountRepo, newFakeCardProductRepo(), newFakeUserRepo(), testCardIndexKey, 3, time.Minute)
+	card, err := cardService.CreateCard(42, 101, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	pinService := services.NewPINService(cardRepo, accountRepo, testTDESZoneKey, testAESZoneKey, testPVK, 1, 3)
+
+	if err := pinService.VerifyPIN(42, card.ID, pinBlock(t, card, utils.PINBlockFormat0, "1234")); !errors.Is(err, models.ErrPINNotSet) {
+		t.Errorf("expected ErrPINNotSet, got %v", err)
+	}
+	if err := pinService.SetPIN(7, card.ID, pinBlock(t, card, utils.PINBlockFormat0, "1234")); !errors.Is(err, models.ErrNotCardOwner) {
+		t.Errorf("expected ErrNotCardOwner, got %v", err)
+	}
+	if err := pinService.SetPIN(42, card.ID, models.PINBlock{Format: 0, Block: "00"}); !errors.Is(err, models.ErrInvalidPINBlock) {
+		t.Errorf("expected ErrInvalidPINBlock, got %v", err)
+	}
+	if err := pinService.SetPIN(42, card.ID, pinBlock(t, card, utils.PINBlockFormat0, "1234")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Хранится только PVV, а не PIN.
+	if len(card.PVV) != 4 || card.PVV == "1234" {
+		t.Errorf("expected 4-digit PVV, got %q", card.PVV)
+	}
+	if err := pinService.SetPIN(42, card.ID, pinBlock(t, card, utils.PINBlockFormat0, "1111")); !errors.Is(err, models.ErrPINAlreadySet) {
+		t.Errorf("expected ErrPINAlreadySet, got %v", err)
+	}
+	if err := pinService.ChangePIN(42, card.ID,
+		pinBlock(t, card, utils.PINBlockFormat0, "1234"), pinBlock(t, card, utils.PINBlockFormat4, "5678")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := pinService.VerifyPIN(42, card.ID, pinBlock(t, card, utils.PINBlockFormat4, "5678")); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	// Без AES-ключа блоки формата 4 не принимаются, и TDES-ключ вместо него не используется.
+	tdesOnly := services.NewPINService(cardRepo, accountRepo, testTDESZoneKey, nil, testPVK, 1, 3)
+	if err := tdesOnly.VerifyPIN(42, card.ID, pinBlock(t, card, utils.PINBlockFormat4, "5678")); !errors.Is(err, models.ErrInvalidPINBlock) {
+		t.Errorf("expected ErrInvalidPINBlock, got %v", err)
+	}
+
+	// Удачная проверка сбрасывает счетчик; третья неудача подряд блокирует карту.
+	wrong := pinBlock(t, card, utils.PINBlockFormat0, "0000")
+	for i := 0; i < 2; i++ {
+		if err := pinService.VerifyPIN(42, card.ID, wrong); !errors.Is(err, models.ErrInvalidPIN) {
+			t.Fatalf("expected ErrInvalidPIN, got %v", err)
+		}
+	}
+	if err := pinService.VerifyPIN(42, card.ID, pinBlock(t, card, utils.PINBlockFormat0, "5678")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := pinService.VerifyPIN(42, card.ID, wrong); !errors.Is(err, models.ErrInvalidPIN) {
+			t.Fatalf("expected ErrInvalidPIN, got %v", err)
+		}
+	}
+	if card.Status != models.CardStatusPINLocked {
+		t.Fatalf("expected card PIN-locked after 3 wrong PINs, got %q", card.Status)
+	}
+	if err := pinService.VerifyPIN(42, card.ID, pinBlock(t, card, utils.PINBlockFormat0, "5678")); !errors.Is(err, models.ErrCardInactive) {
+		t.Errorf("expected ErrCardInactive, got %v", err)
+	}
+	// Держатель не снимает блокировку по PIN сам; операционист снимает ее со сбросом счетчика.
+	if _, err := cardService.UnblockCard(42, card.ID, "remembered PIN"); !errors.Is(err, models.ErrInvalidStatusTransition) {
+		t.Errorf("expected ErrInvalidStatusTransition, got %v", err)
+	}
+	if _, err := cardService.UnlockCardPIN(42, models.RoleCustomer, card.ID, ""); !errors.Is(err, models.ErrOperatorRequired) {
+		t.Errorf("expected ErrOperatorRequired, got %v", err)
+	}
+	if _, err := cardService.UnlockCardPIN(99, models.RoleOperator, card.ID, "identity confirmed"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := pinService.VerifyPIN(42, card.ID, pinBlock(t, card, utils.PINBlockFormat0, "5678")); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// Блокировка и разблокировка держателем не обнуляют счетчик неверных PIN.
+	for i := 0; i < 2; i++ {
+		if err := pinService.VerifyPIN(42, card.ID, wrong); !errors.Is(err, models.ErrInvalidPIN) {
+			t.Fatalf("expected ErrInvalidPIN, got %v", err)
+		}
+	}
+	if _, err := cardService.BlockCard(42, card.ID, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := cardService.UnblockCard(42, card.ID, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := pinService.VerifyPIN(42, card.ID, wrong); !errors.Is(err, models.ErrInvalidPIN) || card.Status != models.CardStatusPINLocked {
+		t.Errorf("expected card PIN-locked on the third wrong PIN, got %q, %v", card.Status, err)
+	}
+}
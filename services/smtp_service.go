@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"log"
 
+	"bank-api/models"
+
 	"github.com/go-mail/mail/v2"
 )
 
@@ -37,12 +39,16 @@ func createDialer() *mail.Dialer {
 }
 
 // SendPaymentEmail отправляет уведомление об успешном платеже.
-func SendPaymentEmail(userEmail string, amount float64) error {
+func SendPaymentEmail(userEmail string, amount models.Money) error {
+	currency := amount.Currency
+	if currency == "" {
+		currency = models.DefaultCurrency
+	}
 	content := fmt.Sprintf(`
 		<h1>Спасибо за оплату!</h1>
-		<p>Сумма: <strong>%.2f RUB</strong></p>
+		<p>Сумма: <strong>%s %s</strong></p>
 		<small>Это автоматическое уведомление</small>
-	`, amount)
+	`, amount, currency)
 	
 	msg := createMessage(userEmail, "Платеж успешно проведен", content)
 	dialer := createDialer()
@@ -54,3 +60,21 @@ func SendPaymentEmail(userEmail string, amount float64) error {
 	log.Printf("Email sent to %s", userEmail)
 	return nil
 }
+
+// SendCardReissueEmail сообщает держателю о перевыпуске карты с истекающим сроком.
+func SendCardReissueEmail(userEmail string, oldCard, newCard models.CardSummary) error {
+	content := fmt.Sprintf(`
+		<h1>Ваша карта перевыпущена</h1>
+		<p>Срок действия карты <strong>%s</strong> истекает %s, взамен выпущена карта <strong>%s</strong> со сроком действия до %s.</p>
+		<p>Старая карта действует до конца срока.</p>
+		<small>Это автоматическое уведомление</small>
+	`, oldCard.MaskedPAN, oldCard.ExpiryMonth, newCard.MaskedPAN, newCard.ExpiryMonth)
+
+	msg := createMessage(userEmail, "Карта перевыпущена", content)
+	if err := createDialer().DialAndSend(msg); err != nil {
+		log.Printf("SMTP error: %v", err)
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	log.Printf("Email sent to %s", userEmail)
+	return nil
+}
@@ -0,0 +1,318 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"bank-api/models"
+	"bank-api/repositories"
+
+	"github.com/robfig/cron/v3"
+)
+
+const (
+	// standingOrderBatch — сколько поручений исполняется за один запуск задачи.
+	standingOrderBatch = 100
+	// standingOrderLease — аренда поручения на время исполнения.
+	standingOrderLease = 10 * time.Minute
+	// standingOrderRunsLimit — сколько последних запусков отдается в истории.
+	standingOrderRunsLimit = 50
+)
+
+// StandingOrderService управляет постоянными поручениями и исполняет их по расписанию.
+type StandingOrderService interface {
+	// Create проверяет счета и расписание и сохраняет поручение пользователя o.UserID.
+	Create(o *models.StandingOrder) error
+	Get(userID, id int) (*models.StandingOrder, error)
+	List(userID int) ([]*models.StandingOrder, error)
+	// Update меняет получателя, сумму, расписание или статус (active/suspended) поручения.
+	Update(userID, id int, changes *models.StandingOrder) (*models.StandingOrder, error)
+	// Cancel отменяет поручение; история запусков сохраняется.
+	Cancel(userID, id int) error
+	Runs(userID, id int) ([]models.StandingOrderRun, error)
+	// RunDue исполняет поручения, срок которых наступил к now.
+	RunDue(now time.Time) error
+}
+
+type standingOrderService struct {
+	orderRepo      repositories.StandingOrderRepository
+	accountRepo    repositories.AccountRepository
+	accountService AccountService
+	// Число неудачных запусков подряд, после которого поручение приостанавливается
+	maxFailures int
+	// Задержка повтора при нехватке средств
+	retryDelay time.Duration
+}
+
+// NewStandingOrderService создает StandingOrderService.
+func NewStandingOrderService(
+	orderRepo repositories.StandingOrderRepository,
+	accountRepo repositories.AccountRepository,
+	accountService AccountService,
+	maxFailures int,
+	retryDelay time.Duration,
+) StandingOrderService {
+	return &standingOrderService{
+		orderRepo:      orderRepo,
+		accountRepo:    accountRepo,
+		accountService: accountService,
+		maxFailures:    maxFailures,
+		retryDelay:     retryDelay,
+	}
+}
+
+func (s *standingOrderService) Create(o *models.StandingOrder) error {
+	if err := s.validate(o); err != nil {
+		return err
+	}
+	if o.StartAt.IsZero() {
+		o.StartAt = time.Now()
+	}
+	next := o.StartAt
+	if o.Frequency == models.FrequencyCron {
+		// Для cron первым запуском считается ближайшее совпадение после start_at.
+		var err error
+		if next, err = nextStandingOrderRun(o, o.StartAt.Add(-time.Second)); err != nil {
+			return err
+		}
+	}
+	o.NextRunAt = next
+	o.RetryAt = nil
+	o.FailureCount = 0
+	o.Status = models.StandingOrderStatusActive
+	return s.orderRepo.Create(o)
+}
+
+func (s *standingOrderService) Get(userID, id int) (*models.StandingOrder, error) {
+	o, err := s.orderRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	// Чужие поручения не раскрываются.
+	if o.UserID != userID {
+		return nil, models.ErrStandingOrderNotFound
+	}
+	return o, nil
+}
+
+func (s *standingOrderService) List(userID int) ([]*models.StandingOrder, error) {
+	return s.orderRepo.ListByUser(userID)
+}
+
+func (s *standingOrderService) Update(userID, id int, changes *models.StandingOrder) (*models.StandingOrder, error) {
+	o, err := s.Get(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if o.Status == models.StandingOrderStatusCancelled {
+		return nil, fmt.Errorf("%w: standing order is cancelled", models.ErrInvalidStatusTransition)
+	}
+
+	rescheduled := changes.Frequency != o.Frequency || changes.CronExpr != o.CronExpr ||
+		(!changes.StartAt.IsZero() && !changes.StartAt.Equal(o.StartAt))
+	o.ToAccountID = changes.ToAccountID
+	o.Amount = changes.Amount
+	o.Frequency = changes.Frequency
+	o.CronExpr = changes.CronExpr
+	if !changes.StartAt.IsZero() {
+		o.StartAt = changes.StartAt
+	}
+	if err := s.validate(o); err != nil {
+		return nil, err
+	}
+
+	switch changes.Status {
+	case "", o.Status:
+	case models.StandingOrderStatusActive, models.StandingOrderStatusSuspended:
+		o.Status = changes.Status
+		// Возобновление сбрасывает счетчик неудач и пропущенные запуски.
+		if o.Status == models.StandingOrderStatusActive {
+			o.FailureCount = 0
+			o.RetryAt = nil
+			rescheduled = true
+		}
+	default:
+		return nil, fmt.Errorf("%w: %s", models.ErrInvalidStatusTransition, changes.Status)
+	}
+
+	if rescheduled {
+		now := time.Now()
+		if o.StartAt.After(now) {
+			now = o.StartAt.Add(-time.Second)
+		}
+		if o.NextRunAt, err = nextStandingOrderRun(o, now); err != nil {
+			return nil, err
+		}
+		o.RetryAt = nil
+	}
+	if err := s.orderRepo.Update(o); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+func (s *standingOrderService) Cancel(userID, id int) error {
+	o, err := s.Get(userID, id)
+	if err != nil {
+		return err
+	}
+	o.Status = models.StandingOrderStatusCancelled
+	o.RetryAt = nil
+	return s.orderRepo.Update(o)
+}
+
+func (s *standingOrderService) Runs(userID, id int) ([]models.StandingOrderRun, error) {
+	if _, err := s.Get(userID, id); err != nil {
+		return nil, err
+	}
+	return s.orderRepo.ListRuns(id, standingOrderRunsLimit)
+}
+
+// validate проверяет сумму, расписание и доступ к счетам; валюта суммы — валюта счета-источника.
+func (s *standingOrderService) validate(o *models.StandingOrder) error {
+	if !o.Amount.IsPositive() {
+		return models.ErrInvalidAmount
+	}
+	if o.FromAccountID == o.ToAccountID {
+		return models.ErrSameAccount
+	}
+	switch o.Frequency {
+	case models.FrequencyDaily, models.FrequencyWeekly, models.FrequencyMonthly:
+		o.CronExpr = ""
+	case models.FrequencyCron:
+		if _, err := cron.ParseStandard(o.CronExpr); err != nil {
+			return fmt.Errorf("%w: %v", models.ErrInvalidSchedule, err)
+		}
+	default:
+		return fmt.Errorf("%w: unknown frequency %q", models.ErrInvalidSchedule, o.Frequency)
+	}
+
+	from, member, err := accountAccess(s.accountRepo, o.FromAccountID, o.UserID)
+	if err != nil {
+		return err
+	}
+	if err := checkSpend(member, o.Amount.WithCurrency(from.Currency)); err != nil {
+		return err
+	}
+	if from.Status != models.AccountStatusActive {
+		return models.ErrAccountInactive
+	}
+	if _, err := s.accountRepo.GetByID(o.ToAccountID); err != nil {
+		return err
+	}
+	if o.Amount.Currency != "" && o.Amount.Currency != from.Currency {
+		return fmt.Errorf("%w: %s and %s", models.ErrCurrencyMismatch, o.Amount.Currency, from.Currency)
+	}
+	o.Amount = o.Amount.WithCurrency(from.Currency)
+	return nil
+}
+
+func (s *standingOrderService) RunDue(now time.Time) error {
+	orders, err := s.orderRepo.ClaimDue(now, standingOrderLease, standingOrderBatch)
+	if err != nil {
+		return err
+	}
+	for _, o := range orders {
+		if err := s.run(o, now); err != nil {
+			log.Printf("standing order %d: %v", o.ID, err)
+		}
+	}
+	return nil
+}
+
+// run исполняет одно поручение и сохраняет результат. Нехватка средств
+// (и отсутствие курса для валютных переводов) повторяется через retryDelay;
+// прочие ошибки переносят поручение на следующий плановый запуск.
+// После maxFailures неудач подряд поручение приостанавливается.
+// Запуск записывается до перевода: если прежнее исполнение прервалось после его начала,
+// перевод мог пройти, поэтому поручение не исполняется повторно, а приостанавливается
+// до проверки владельцем.
+func (s *standingOrderService) run(o *models.StandingOrder, now time.Time) error {
+	run := &models.StandingOrderRun{
+		OrderID:     o.ID,
+		ScheduledAt: o.DueAt(),
+		Attempt:     o.FailureCount + 1,
+	}
+	started, err := s.orderRepo.StartRun(run)
+	if err != nil {
+		return err
+	}
+	if !started {
+		run.Status = models.StandingOrderRunFailed
+		run.Error = "previous run was interrupted, check the account history before resuming"
+		o.Status = models.StandingOrderStatusSuspended
+		o.RetryAt = nil
+	} else if transfer, err := s.accountService.Transfer(o.UserID, o.FromAccountID, o.ToAccountID, o.Amount); err == nil {
+		run.Status = models.StandingOrderRunSucceeded
+		run.EntryID = transfer.EntryID
+		o.FailureCount = 0
+		o.RetryAt = nil
+	} else {
+		run.Status = models.StandingOrderRunFailed
+		run.Error = err.Error()
+		o.FailureCount++
+		o.RetryAt = nil
+		retriable := errors.Is(err, models.ErrInsufficientFunds) || errors.Is(err, models.ErrRateUnavailable)
+		switch {
+		case o.FailureCount >= s.maxFailures:
+			o.Status = models.StandingOrderStatusSuspended
+		case retriable:
+			retryAt := now.Add(s.retryDelay)
+			o.RetryAt = &retryAt
+		}
+	}
+
+	// Плановый запуск сдвигается, если он исполнен или не будет повторяться.
+	// Запуски, пропущенные во время простоя, не догоняются.
+	if o.RetryAt == nil && !o.NextRunAt.After(now) {
+		next, err := nextStandingOrderRun(o, now)
+		if err != nil {
+			return err
+		}
+		o.NextRunAt = next
+	}
+	return s.orderRepo.RecordRun(o, run)
+}
+
+// nextStandingOrderRun возвращает первый плановый запуск поручения строго после after.
+// Ежедневные, еженедельные и ежемесячные запуски отсчитываются от StartAt;
+// ежемесячный запуск на 29–31 число в коротком месяце переносится на последний день.
+func nextStandingOrderRun(o *models.StandingOrder, after time.Time) (time.Time, error) {
+	if o.Frequency == models.FrequencyCron {
+		schedule, err := cron.ParseStandard(o.CronExpr)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%w: %v", models.ErrInvalidSchedule, err)
+		}
+		return schedule.Next(after), nil
+	}
+	for k := 0; ; k++ {
+		t, err := standingOrderOccurrence(o.StartAt, o.Frequency, k)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if t.After(after) {
+			return t, nil
+		}
+	}
+}
+
+// standingOrderOccurrence возвращает k-й плановый запуск, начиная с start (k = 0).
+func standingOrderOccurrence(start time.Time, frequency string, k int) (time.Time, error) {
+	switch frequency {
+	case models.FrequencyDaily:
+		return start.AddDate(0, 0, k), nil
+	case models.FrequencyWeekly:
+		return start.AddDate(0, 0, 7*k), nil
+	case models.FrequencyMonthly:
+		first := time.Date(start.Year(), start.Month()+time.Month(k), 1,
+			start.Hour(), start.Minute(), start.Second(), start.Nanosecond(), start.Location())
+		day := start.Day()
+		if last := first.AddDate(0, 1, -1).Day(); day > last {
+			day = last
+		}
+		return first.AddDate(0, 0, day-1), nil
+	}
+	return time.Time{}, fmt.Errorf("%w: unknown frequency %q", models.ErrInvalidSchedule, frequency)
+}
@@ -0,0 +1,281 @@
+package services_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"bank-api/models"
+	"bank-api/services"
+)
+
+// fakeStandingOrderRepo хранит поручения и запуски в памяти.
+type fakeStandingOrderRepo struct {
+	orders map[int]*models.StandingOrder
+	runs   []models.StandingOrderRun
+}
+
+func (r *fakeStandingOrderRepo) Create(o *models.StandingOrder) error {
+	o.ID = len(r.orders) + 1
+	r.orders[o.ID] = o
+	return nil
+}
+
+func (r *fakeStandingOrderRepo) GetByID(id int) (*models.StandingOrder, error) {
+	o, ok := r.orders[id]
+	if !ok {
+		return nil, models.ErrStandingOrderNotFound
+	}
+	return o, nil
+}
+
+func (r *fakeStandingOrderRepo) ListByUser(userID int) ([]*models.StandingOrder, error) {
+	var list []*models.StandingOrder
+	for _, o := range r.orders {
+		if o.UserID == userID {
+			list = append(list, o)
+		}
+	}
+	return list, nil
+}
+
+func (r *fakeStandingOrderRepo) Update(o *models.StandingOrder) error {
+	r.orders[o.ID] = o
+	return nil
+}
+
+// ClaimDue возвращает копии поручений, как чтение из БД.
+func (r *fakeStandingOrderRepo) ClaimDue(now time.Time, lease time.Duration, limit int) ([]*models.StandingOrder, error) {
+	var due []*models.StandingOrder
+	for _, o := range r.orders {
+		if o.Status == models.StandingOrderStatusActive && !o.DueAt().After(now) {
+			claimed := *o
+			due = append(due, &claimed)
+		}
+	}
+	return due, nil
+}
+
+func (r *fakeStandingOrderRepo) StartRun(run *models.StandingOrderRun) (bool, error) {
+	for _, existing := range r.runs {
+		if existing.OrderID == run.OrderID && existing.Status == models.StandingOrderRunStarted {
+			*run = existing
+			return false, nil
+		}
+	}
+	run.ID = len(r.runs) + 1
+	run.Status = models.StandingOrderRunStarted
+	r.runs = append(r.runs, *run)
+	return true, nil
+}
+
+func (r *fakeStandingOrderRepo) RecordRun(o *models.StandingOrder, run *models.StandingOrderRun) error {
+	r.runs[run.ID-1] = *run
+	stored := r.orders[o.ID]
+	stored.NextRunAt, stored.RetryAt, stored.FailureCount = o.NextRunAt, o.RetryAt, o.FailureCount
+	if stored.Status == models.StandingOrderStatusActive {
+		stored.Status = o.Status
+	}
+	o.Status = stored.Status
+	return nil
+}
+
+func (r *fakeStandingOrderRepo) ListRuns(orderID, limit int) ([]models.StandingOrderRun, error) {
+	return r.runs, nil
+}
+
+func newStandingOrderFixture() (services.StandingOrderService, *fakeStandingOrderRepo, *fakeAccountRepo) {
+	orderRepo := &fakeStandingOrderRepo{orders: map[int]*models.StandingOrder{}}
+	accountRepo := &fakeAccountRepo{accounts: map[int]*models.Account{
+		1: {ID: 1, UserID: 7, Currency: "RUB", Status: models.AccountStatusActive},
+		2: {ID: 2, UserID: 8, Currency: "RUB", Status: models.AccountStatusActive},
+	}}
+	accountService := services.NewAccountService(accountRepo, nil, noFees(accountRepo), nil, testBankBIK)
+	svc := services.NewStandingOrderService(orderRepo, accountRepo, accountService, 3, time.Hour)
+	return svc, orderRepo, accountRepo
+}
+
+func TestStandingOrderMonthlyScheduleClampsToMonthEnd(t *testing.T) {
+	svc, orderRepo, accountRepo := newStandingOrderFixture()
+	start := time.Date(2024, 1, 31, 9, 0, 0, 0, time.UTC)
+	order := &models.StandingOrder{
+		UserID: 7, FromAccountID: 1, ToAccountID: 2,
+		Amount: models.NewMoney(3000000, ""), Frequency: models.FrequencyMonthly, StartAt: start,
+	}
+	if err := svc.Create(order); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []time.Time{
+		time.Date(2024, 2, 29, 9, 0, 0, 0, time.UTC),
+		time.Date(2024, 3, 31, 9, 0, 0, 0, time.UTC),
+		time.Date(2024, 4, 30, 9, 0, 0, 0, time.UTC),
+	}
+	now := start
+	for i, want := range expected {
+		if err := svc.RunDue(now); err != nil {
+			t.Fatalf("run %d: unexpected error: %v", i, err)
+		}
+		if !order.NextRunAt.Equal(want) {
+			t.Errorf("run %d: expected next run %s, got %s", i, want, order.NextRunAt)
+		}
+		now = order.NextRunAt
+	}
+	if len(accountRepo.transfers) != 3 || len(orderRepo.runs) != 3 {
+		t.Fatalf("expected 3 transfers and runs, got %d and %d", len(accountRepo.transfers), len(orderRepo.runs))
+	}
+	if orderRepo.runs[0].Status != models.StandingOrderRunSucceeded || orderRepo.runs[0].EntryID != 1 {
+		t.Errorf("unexpected run record: %+v", orderRepo.runs[0])
+	}
+
+	// До наступления срока поручение не исполняется.
+	if err := svc.RunDue(now.Add(-time.Minute)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(accountRepo.transfers) != 3 {
+		t.Errorf("order must not run before it is due")
+	}
+}
+
+func TestStandingOrderRetriesThenSuspends(t *testing.T) {
+	svc, orderRepo, accountRepo := newStandingOrderFixture()
+	accountRepo.transferErr = models.ErrInsufficientFunds
+	start := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	order := &models.StandingOrder{
+		UserID: 7, FromAccountID: 1, ToAccountID: 2,
+		Amount: models.NewMoney(10000, ""), Frequency: models.FrequencyWeekly, StartAt: start,
+	}
+	if err := svc.Create(order); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	now := start
+	for attempt := 1; attempt <= 2; attempt++ {
+		if err := svc.RunDue(now); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if order.RetryAt == nil || !order.RetryAt.Equal(now.Add(time.Hour)) {
+			t.Fatalf("attempt %d: expected retry in an hour, got %v", attempt, order.RetryAt)
+		}
+		if !order.NextRunAt.Equal(start) {
+			t.Errorf("attempt %d: planned run must stay until retried, got %s", attempt, order.NextRunAt)
+		}
+		now = *order.RetryAt
+	}
+
+	if err := svc.RunDue(now); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if order.Status != models.StandingOrderStatusSuspended || order.FailureCount != 3 {
+		t.Errorf("expected suspension after 3 failures, got %s with %d failures", order.Status, order.FailureCount)
+	}
+	if len(orderRepo.runs) != 3 || orderRepo.runs[2].Attempt != 3 || orderRepo.runs[2].Status != models.StandingOrderRunFailed {
+		t.Errorf("unexpected runs: %+v", orderRepo.runs)
+	}
+
+	// Возобновление сбрасывает счетчик неудач.
+	accountRepo.transferErr = nil
+	resumed, err := svc.Update(7, order.ID, &models.StandingOrder{
+		ToAccountID: 2, Amount: models.NewMoney(10000, ""), Frequency: models.FrequencyWeekly,
+		Status: models.StandingOrderStatusActive,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resumed.Status != models.StandingOrderStatusActive || resumed.FailureCount != 0 || resumed.RetryAt != nil {
+		t.Errorf("unexpected resumed order: %+v", resumed)
+	}
+}
+
+func TestStandingOrderKeepsStatusChangedDuringRun(t *testing.T) {
+	svc, orderRepo, accountRepo := newStandingOrderFixture()
+	start := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	order := &models.StandingOrder{
+		UserID: 7, FromAccountID: 1, ToAccountID: 2,
+		Amount: models.NewMoney(10000, ""), Frequency: models.FrequencyWeekly, StartAt: start,
+	}
+	if err := svc.Create(order); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Владелец отменяет поручение, пока шедулер исполняет перевод.
+	accountRepo.onTransfer = func() {
+		if err := svc.Cancel(7, order.ID); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := svc.RunDue(start); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if order.Status != models.StandingOrderStatusCancelled {
+		t.Errorf("cancellation must survive the run, got %s", order.Status)
+	}
+	if len(orderRepo.runs) != 1 || orderRepo.runs[0].Status != models.StandingOrderRunSucceeded {
+		t.Errorf("unexpected runs: %+v", orderRepo.runs)
+	}
+}
+
+func TestStandingOrderInterruptedRunIsNotRepeated(t *testing.T) {
+	svc, orderRepo, accountRepo := newStandingOrderFixture()
+	start := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	order := &models.StandingOrder{
+		UserID: 7, FromAccountID: 1, ToAccountID: 2,
+		Amount: models.NewMoney(10000, ""), Frequency: models.FrequencyWeekly, StartAt: start,
+	}
+	if err := svc.Create(order); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Прежнее исполнение начало запуск и прервалось, не записав результат.
+	orderRepo.runs = append(orderRepo.runs, models.StandingOrderRun{
+		ID: 1, OrderID: order.ID, ScheduledAt: start, Attempt: 1, Status: models.StandingOrderRunStarted,
+	})
+
+	if err := svc.RunDue(start.Add(5 * time.Minute)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(accountRepo.transfers) != 0 {
+		t.Fatalf("interrupted run must not be repeated, got %d transfers", len(accountRepo.transfers))
+	}
+	if order.Status != models.StandingOrderStatusSuspended {
+		t.Errorf("expected the order to be suspended, got %s", order.Status)
+	}
+	if len(orderRepo.runs) != 1 || orderRepo.runs[0].Status != models.StandingOrderRunFailed {
+		t.Errorf("unexpected runs: %+v", orderRepo.runs)
+	}
+}
+
+func TestStandingOrderValidation(t *testing.T) {
+	svc, _, _ := newStandingOrderFixture()
+	cases := []struct {
+		name    string
+		order   models.StandingOrder
+		wantErr error
+	}{
+		{"bad cron", models.StandingOrder{UserID: 7, FromAccountID: 1, ToAccountID: 2, Amount: models.NewMoney(100, ""),
+			Frequency: models.FrequencyCron, CronExpr: "every day"}, models.ErrInvalidSchedule},
+		{"unknown frequency", models.StandingOrder{UserID: 7, FromAccountID: 1, ToAccountID: 2, Amount: models.NewMoney(100, ""),
+			Frequency: "yearly"}, models.ErrInvalidSchedule},
+		{"foreign account", models.StandingOrder{UserID: 8, FromAccountID: 1, ToAccountID: 2, Amount: models.NewMoney(100, ""),
+			Frequency: models.FrequencyDaily}, models.ErrNotAccountOwner},
+		{"zero amount", models.StandingOrder{UserID: 7, FromAccountID: 1, ToAccountID: 2,
+			Frequency: models.FrequencyDaily}, models.ErrInvalidAmount},
+	}
+	for _, tc := range cases {
+		order := tc.order
+		if err := svc.Create(&order); !errors.Is(err, tc.wantErr) {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.wantErr, err)
+		}
+	}
+
+	order := &models.StandingOrder{UserID: 7, FromAccountID: 1, ToAccountID: 2, Amount: models.NewMoney(100, ""),
+		Frequency: models.FrequencyCron, CronExpr: "0 10 * * 1", StartAt: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)}
+	if err := svc.Create(order); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := time.Date(2024, 5, 6, 10, 0, 0, 0, time.UTC); !order.NextRunAt.Equal(want) {
+		t.Errorf("expected first cron run %s, got %s", want, order.NextRunAt)
+	}
+	if _, err := svc.Get(8, order.ID); !errors.Is(err, models.ErrStandingOrderNotFound) {
+		t.Errorf("foreign order must look missing, got %v", err)
+	}
+}
@@ -0,0 +1,201 @@
+package services
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"bank-api/models"
+)
+
+// statementWriter пишет выписку потоком: заголовок с остатками, операции, окончание.
+type statementWriter interface {
+	begin(st *models.Statement) error
+	entry(t models.Transaction) error
+	end() error
+}
+
+func newStatementWriter(format string, w io.Writer, bankID string) (statementWriter, error) {
+	switch format {
+	case models.StatementFormatCSV:
+		return &csvStatementWriter{w: csv.NewWriter(w)}, nil
+	case models.StatementFormatOFX:
+		return &ofxStatementWriter{w: bufio.NewWriter(w), bankID: bankID}, nil
+	case models.StatementFormatCamt053:
+		return &camtStatementWriter{w: bufio.NewWriter(w), bankID: bankID}, nil
+	}
+	return nil, models.ErrUnsupportedStatementFmt
+}
+
+// xmlText экранирует строку для вставки в текст XML-элемента.
+func xmlText(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// csvStatementWriter пишет CSV: строка входящего остатка, операции, строка исходящего остатка.
+type csvStatementWriter struct {
+	w  *csv.Writer
+	st *models.Statement
+}
+
+func (c *csvStatementWriter) begin(st *models.Statement) error {
+	c.st = st
+	c.w.Write([]string{"date", "transaction_id", "type", "amount", "currency", "balance_after", "fx_rate"})
+	return c.balance(st.From, "opening_balance", st.OpeningBalance)
+}
+
+func (c *csvStatementWriter) entry(t models.Transaction) error {
+	return c.w.Write([]string{
+		t.CreatedAt.UTC().Format(time.RFC3339),
+		strconv.Itoa(t.ID),
+		t.Type,
+		t.Amount.String(),
+		t.Amount.Currency,
+		t.BalanceAfter.String(),
+		t.FXRate,
+	})
+}
+
+func (c *csvStatementWriter) end() error {
+	if err := c.balance(c.st.To, "closing_balance", c.st.ClosingBalance); err != nil {
+		return err
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvStatementWriter) balance(at time.Time, kind string, amount models.Money) error {
+	return c.w.Write([]string{at.UTC().Format(time.RFC3339), "", kind, "", amount.Currency, amount.String(), ""})
+}
+
+// ofxTime форматирует время в формате OFX в UTC.
+func ofxTime(t time.Time) string {
+	return t.UTC().Format("20060102150405") + "[0:GMT]"
+}
+
+// ofxStatementWriter пишет выписку OFX 2.1.1 (STMTRS).
+type ofxStatementWriter struct {
+	w      *bufio.Writer
+	bankID string
+	st     *models.Statement
+}
+
+func (o *ofxStatementWriter) begin(st *models.Statement) error {
+	o.st = st
+	_, err := fmt.Fprintf(o.w, `<?xml version="1.0" encoding="UTF-8" standalone="no"?>
+<?OFX OFXHEADER="200" VERSION="211" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>
+<OFX>
+<SIGNONMSGSRSV1><SONRS><STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS><DTSERVER>%s</DTSERVER><LANGUAGE>RUS</LANGUAGE></SONRS></SIGNONMSGSRSV1>
+<BANKMSGSRSV1><STMTTRNRS><TRNUID>0</TRNUID><STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS>
+<STMTRS><CURDEF>%s</CURDEF>
+<BANKACCTFROM><BANKID>%s</BANKID><ACCTID>%s</ACCTID><ACCTTYPE>CHECKING</ACCTTYPE></BANKACCTFROM>
+<BANKTRANLIST><DTSTART>%s</DTSTART><DTEND>%s</DTEND>
+`,
+		ofxTime(st.GeneratedAt), xmlText(st.Account.Currency), xmlText(o.bankID), xmlText(st.Account.Number),
+		ofxTime(st.From), ofxTime(st.To))
+	return err
+}
+
+func (o *ofxStatementWriter) entry(t models.Transaction) error {
+	trnType := "CREDIT"
+	if t.Amount.IsNegative() {
+		trnType = "DEBIT"
+	}
+	memo := ""
+	if t.FXRate != "" {
+		memo = "<MEMO>FX rate " + xmlText(t.FXRate) + "</MEMO>"
+	}
+	_, err := fmt.Fprintf(o.w,
+		"<STMTTRN><TRNTYPE>%s</TRNTYPE><DTPOSTED>%s</DTPOSTED><TRNAMT>%s</TRNAMT><FITID>%d</FITID><NAME>%s</NAME>%s</STMTTRN>\n",
+		trnType, ofxTime(t.CreatedAt), t.Amount, t.ID, xmlText(t.Type), memo)
+	return err
+}
+
+func (o *ofxStatementWriter) end() error {
+	fmt.Fprintf(o.w, `</BANKTRANLIST>
+<LEDGERBAL><BALAMT>%s</BALAMT><DTASOF>%s</DTASOF></LEDGERBAL>
+</STMTRS></STMTTRNRS></BANKMSGSRSV1>
+</OFX>
+`, o.st.ClosingBalance, ofxTime(o.st.To))
+	return o.w.Flush()
+}
+
+// camtTime форматирует время как ISODateTime в UTC.
+func camtTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05Z")
+}
+
+// camtAmount возвращает модуль суммы и признак кредита/дебета ISO 20022.
+func camtAmount(m models.Money) (string, string) {
+	if m.IsNegative() {
+		return m.Abs().String(), "DBIT"
+	}
+	return m.String(), "CRDT"
+}
+
+// camtStatementWriter пишет выписку ISO 20022 camt.053.001.02 (BkToCstmrStmt).
+type camtStatementWriter struct {
+	w      *bufio.Writer
+	bankID string
+	st     *models.Statement
+}
+
+func (c *camtStatementWriter) begin(st *models.Statement) error {
+	c.st = st
+	id := fmt.Sprintf("STMT-%d-%s", st.Account.ID, st.GeneratedAt.UTC().Format("20060102150405"))
+	currency := xmlText(st.Account.Currency)
+
+	fmt.Fprintf(c.w, `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.053.001.02">
+<BkToCstmrStmt>
+<GrpHdr><MsgId>%s</MsgId><CreDtTm>%s</CreDtTm></GrpHdr>
+<Stmt>
+<Id>%s</Id><CreDtTm>%s</CreDtTm>
+<FrToDt><FrDtTm>%s</FrDtTm><ToDtTm>%s</ToDtTm></FrToDt>
+<Acct><Id><Othr><Id>%s</Id></Othr></Id><Ccy>%s</Ccy>`,
+		id, camtTime(st.GeneratedAt), id, camtTime(st.GeneratedAt),
+		camtTime(st.From), camtTime(st.To), xmlText(st.Account.Number), currency)
+	if c.bankID != "" {
+		fmt.Fprintf(c.w,
+			"<Svcr><FinInstnId><ClrSysMmbId><ClrSysId><Cd>RUCBC</Cd></ClrSysId><MmbId>%s</MmbId></ClrSysMmbId></FinInstnId></Svcr>",
+			xmlText(c.bankID))
+	}
+	c.w.WriteString("</Acct>\n")
+	c.balance("OPBD", st.OpeningBalance, st.From)
+	return c.balance("CLBD", st.ClosingBalance, st.To)
+}
+
+func (c *camtStatementWriter) balance(code string, amount models.Money, at time.Time) error {
+	amt, ind := camtAmount(amount)
+	_, err := fmt.Fprintf(c.w,
+		"<Bal><Tp><CdOrPrtry><Cd>%s</Cd></CdOrPrtry></Tp><Amt Ccy=\"%s\">%s</Amt><CdtDbtInd>%s</CdtDbtInd><Dt><DtTm>%s</DtTm></Dt></Bal>\n",
+		code, xmlText(amount.Currency), amt, ind, camtTime(at))
+	return err
+}
+
+func (c *camtStatementWriter) entry(t models.Transaction) error {
+	amt, ind := camtAmount(t.Amount)
+	info := t.Type
+	if t.FXRate != "" {
+		info += ", FX rate " + t.FXRate
+	}
+	_, err := fmt.Fprintf(c.w,
+		"<Ntry><NtryRef>%d</NtryRef><Amt Ccy=\"%s\">%s</Amt><CdtDbtInd>%s</CdtDbtInd><Sts>BOOK</Sts>"+
+			"<BookgDt><DtTm>%s</DtTm></BookgDt><ValDt><DtTm>%s</DtTm></ValDt>"+
+			"<BkTxCd><Prtry><Cd>%s</Cd></Prtry></BkTxCd><AddtlNtryInf>%s</AddtlNtryInf></Ntry>\n",
+		t.ID, xmlText(t.Amount.Currency), amt, ind,
+		camtTime(t.CreatedAt), camtTime(t.CreatedAt), xmlText(t.Type), xmlText(info))
+	return err
+}
+
+func (c *camtStatementWriter) end() error {
+	c.w.WriteString("</Stmt>\n</BkToCstmrStmt>\n</Document>\n")
+	return c.w.Flush()
+}
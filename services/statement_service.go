@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"bank-api/models"
+	"bank-api/repositories"
+)
+
+// StatementService формирует выписки по счетам для импорта в учетные системы.
+type StatementService interface {
+	// WriteStatement проверяет доступ к счету и потоково пишет выписку за [from, to)
+	// в w в формате format (models.StatementFormat*). Нулевой from означает начало
+	// текущего месяца, нулевой to — текущий момент.
+	WriteStatement(ctx context.Context, userID, accountID int, from, to time.Time, format string, w io.Writer) error
+}
+
+type statementService struct {
+	transactionRepo repositories.TransactionRepository
+	accountRepo     repositories.AccountRepository
+	// БИК банка для реквизитов счета в OFX и camt.053
+	bankID string
+}
+
+// NewStatementService возвращает StatementService.
+func NewStatementService(
+	transactionRepo repositories.TransactionRepository,
+	accountRepo repositories.AccountRepository,
+	bankID string,
+) StatementService {
+	return &statementService{
+		transactionRepo: transactionRepo,
+		accountRepo:     accountRepo,
+		bankID:          bankID,
+	}
+}
+
+// StatementContentType возвращает MIME-тип и расширение файла для формата выписки.
+func StatementContentType(format string) (contentType, ext string, err error) {
+	switch format {
+	case models.StatementFormatCSV:
+		return "text/csv; charset=utf-8", "csv", nil
+	case models.StatementFormatOFX:
+		return "application/x-ofx", "ofx", nil
+	case models.StatementFormatCamt053:
+		return "application/xml", "xml", nil
+	}
+	return "", "", models.ErrUnsupportedStatementFmt
+}
+
+func (s *statementService) WriteStatement(ctx context.Context, userID, accountID int, from, to time.Time, format string, w io.Writer) error {
+	now := time.Now().UTC()
+	if to.IsZero() {
+		to = now
+	}
+	if from.IsZero() {
+		from = time.Date(to.Year(), to.Month(), 1, 0, 0, 0, 0, to.Location())
+	}
+	if !from.Before(to) {
+		return models.ErrInvalidPeriod
+	}
+
+	account, _, err := accountAccess(s.accountRepo, accountID, userID)
+	if err != nil {
+		return err
+	}
+	sw, err := newStatementWriter(format, w, s.bankID)
+	if err != nil {
+		return err
+	}
+
+	st := &models.Statement{Account: account, From: from, To: to, GeneratedAt: now}
+	err = s.transactionRepo.Statement(ctx, accountID, from, to,
+		func(opening, closing models.Money) error {
+			st.OpeningBalance = opening.WithCurrency(account.Currency)
+			st.ClosingBalance = closing.WithCurrency(account.Currency)
+			return sw.begin(st)
+		},
+		sw.entry,
+	)
+	if err != nil {
+		return err
+	}
+	return sw.end()
+}
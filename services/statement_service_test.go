@@ -0,0 +1,130 @@
+package services_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"bank-api/models"
+	"bank-api/services"
+)
+
+func newStatementFixture() (*fakeTransactionRepo, *fakeAccountRepo) {
+	day := func(d int) time.Time { return time.Date(2024, 3, d, 10, 0, 0, 0, time.UTC) }
+	rub := func(minor int64) models.Money { return models.NewMoney(minor, "RUB") }
+	// Операции от новых к старым, как их хранит fakeTransactionRepo.
+	txRepo := &fakeTransactionRepo{items: []models.Transaction{
+		{ID: 4, AccountID: 1, Amount: rub(5000), BalanceAfter: rub(95000), Type: "deposit", CreatedAt: day(20)},
+		{ID: 3, AccountID: 1, Amount: rub(-20000), BalanceAfter: rub(90000), Type: "transfer", CreatedAt: day(10)},
+		{ID: 2, AccountID: 1, Amount: rub(10000), BalanceAfter: rub(110000), Type: "deposit", CreatedAt: day(5)},
+		{ID: 1, AccountID: 1, Amount: rub(100000), BalanceAfter: rub(100000), Type: "deposit", CreatedAt: day(1)},
+	}}
+	accountRepo := &fakeAccountRepo{accounts: map[int]*models.Account{
+		1: {ID: 1, UserID: 7, Number: "40817810000000000001", Currency: "RUB", Status: models.AccountStatusActive},
+	}}
+	return txRepo, accountRepo
+}
+
+func TestWriteStatementCSV(t *testing.T) {
+	txRepo, accountRepo := newStatementFixture()
+	svc := services.NewStatementService(txRepo, accountRepo, "044525225")
+
+	var buf bytes.Buffer
+	from := time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+	if err := svc.WriteStatement(context.Background(), 7, 1, from, to, models.StatementFormatCSV, &buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := `date,transaction_id,type,amount,currency,balance_after,fx_rate
+2024-03-02T00:00:00Z,,opening_balance,,RUB,1000.00,
+2024-03-05T10:00:00Z,2,deposit,100.00,RUB,1100.00,
+2024-03-10T10:00:00Z,3,transfer,-200.00,RUB,900.00,
+2024-03-15T00:00:00Z,,closing_balance,,RUB,900.00,
+`
+	if buf.String() != want {
+		t.Errorf("unexpected CSV:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestWriteStatementCamt053Balances(t *testing.T) {
+	txRepo, accountRepo := newStatementFixture()
+	svc := services.NewStatementService(txRepo, accountRepo, "044525225")
+
+	var buf bytes.Buffer
+	from := time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+	if err := svc.WriteStatement(context.Background(), 7, 1, from, to, models.StatementFormatCamt053, &buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := buf.String()
+	for _, fragment := range []string{
+		`<Cd>OPBD</Cd></CdOrPrtry></Tp><Amt Ccy="RUB">1000.00</Amt><CdtDbtInd>CRDT</CdtDbtInd>`,
+		`<Cd>CLBD</Cd></CdOrPrtry></Tp><Amt Ccy="RUB">900.00</Amt><CdtDbtInd>CRDT</CdtDbtInd>`,
+		`<NtryRef>3</NtryRef><Amt Ccy="RUB">200.00</Amt><CdtDbtInd>DBIT</CdtDbtInd>`,
+		`<Acct><Id><Othr><Id>40817810000000000001</Id></Othr></Id>`,
+		`<MmbId>044525225</MmbId>`,
+	} {
+		if !strings.Contains(out, fragment) {
+			t.Errorf("camt.053 output lacks %q", fragment)
+		}
+	}
+	if strings.Count(out, "<Ntry>") != 2 {
+		t.Errorf("expected 2 entries, got %d", strings.Count(out, "<Ntry>"))
+	}
+}
+
+func TestWriteStatementOFX(t *testing.T) {
+	txRepo, accountRepo := newStatementFixture()
+	svc := services.NewStatementService(txRepo, accountRepo, "044525225")
+
+	var buf bytes.Buffer
+	from := time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+	if err := svc.WriteStatement(context.Background(), 7, 1, from, to, models.StatementFormatOFX, &buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := buf.String()
+	// Счет указывается номером, а не внутренним ID.
+	for _, fragment := range []string{
+		`<BANKID>044525225</BANKID><ACCTID>40817810000000000001</ACCTID>`,
+		`<TRNTYPE>DEBIT</TRNTYPE><DTPOSTED>20240310100000[0:GMT]</DTPOSTED><TRNAMT>-200.00</TRNAMT><FITID>3</FITID>`,
+		`<LEDGERBAL><BALAMT>900.00</BALAMT>`,
+	} {
+		if !strings.Contains(out, fragment) {
+			t.Errorf("OFX output lacks %q", fragment)
+		}
+	}
+}
+
+func TestWriteStatementRejections(t *testing.T) {
+	txRepo, accountRepo := newStatementFixture()
+	svc := services.NewStatementService(txRepo, accountRepo, "")
+	from := time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name     string
+		userID   int
+		from, to time.Time
+		format   string
+		wantErr  error
+	}{
+		{"not owner", 8, from, to, models.StatementFormatOFX, models.ErrNotAccountOwner},
+		{"bad period", 7, to, from, models.StatementFormatOFX, models.ErrInvalidPeriod},
+		{"bad format", 7, from, to, "pdf", models.ErrUnsupportedStatementFmt},
+	}
+	for _, tc := range cases {
+		var buf bytes.Buffer
+		err := svc.WriteStatement(context.Background(), tc.userID, 1, tc.from, tc.to, tc.format, &buf)
+		if !errors.Is(err, tc.wantErr) {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.wantErr, err)
+		}
+		if buf.Len() != 0 {
+			t.Errorf("%s: nothing should be written, got %q", tc.name, buf.String())
+		}
+	}
+}
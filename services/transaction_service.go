@@ -0,0 +1,66 @@
+package services
+
+import (
+	"bank-api/models"
+	"bank-api/repositories"
+)
+
+const (
+	// defaultTransactionPageSize — размер страницы истории по умолчанию.
+	defaultTransactionPageSize = 50
+	// maxTransactionPageSize — максимальный размер страницы истории.
+	maxTransactionPageSize = 200
+)
+
+// TransactionService описывает чтение истории операций по счетам.
+type TransactionService interface {
+	// GetAccountTransactions возвращает страницу истории счета; доступна только владельцу.
+	GetAccountTransactions(userID, accountID int, filter models.TransactionFilter) (*models.TransactionPage, error)
+}
+
+type transactionService struct {
+	transactionRepo repositories.TransactionRepository
+	accountRepo     repositories.AccountRepository
+}
+
+// NewTransactionService возвращает TransactionService.
+func NewTransactionService(
+	transactionRepo repositories.TransactionRepository,
+	accountRepo repositories.AccountRepository,
+) TransactionService {
+	return &transactionService{
+		transactionRepo: transactionRepo,
+		accountRepo:     accountRepo,
+	}
+}
+
+func (s *transactionService) GetAccountTransactions(userID, accountID int, filter models.TransactionFilter) (*models.TransactionPage, error) {
+	if _, _, err := accountAccess(s.accountRepo, accountID, userID); err != nil {
+		return nil, err
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultTransactionPageSize
+	}
+	if limit > maxTransactionPageSize {
+		limit = maxTransactionPageSize
+	}
+	// Запрашиваем на одну запись больше, чтобы понять, есть ли следующая страница.
+	filter.Limit = limit + 1
+	list, err := s.transactionRepo.ListByAccount(accountID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &models.TransactionPage{Transactions: list}
+	if len(list) > limit {
+		page.Transactions = list[:limit]
+		last := page.Transactions[limit-1]
+		page.NextCursor = models.TransactionCursor{CreatedAt: last.CreatedAt, ID: last.ID}.Encode()
+	}
+	if page.Transactions == nil {
+		page.Transactions = []models.Transaction{}
+	}
+	return page, nil
+}
@@ -0,0 +1,105 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"bank-api/models"
+	"bank-api/services"
+)
+
+// fakeTransactionRepo отдает заранее заготовленные операции, отсортированные от новых к старым.
+type fakeTransactionRepo struct {
+	items      []models.Transaction
+	lastFilter models.TransactionFilter
+}
+
+func (r *fakeTransactionRepo) Create(t *models.Transaction) error { return nil }
+
+func (r *fakeTransactionRepo) GetByAccountID(accountID int) ([]models.Transaction, error) {
+	return r.items, nil
+}
+
+func (r *fakeTransactionRepo) ListByAccount(accountID int, filter models.TransactionFilter) ([]models.Transaction, error) {
+	r.lastFilter = filter
+	var list []models.Transaction
+	for _, t := range r.items {
+		if filter.After != nil && t.ID >= filter.After.ID {
+			continue
+		}
+		list = append(list, t)
+		if len(list) == filter.Limit {
+			break
+		}
+	}
+	return list, nil
+}
+
+func (r *fakeTransactionRepo) SumByType(userID int, txType string, since time.Time) (models.Money, error) {
+	return models.Money{}, nil
+}
+
+func (r *fakeTransactionRepo) Statement(ctx context.Context, accountID int, from, to time.Time,
+	begin func(opening, closing models.Money) error, each func(models.Transaction) error) error {
+	var opening, closing models.Money
+	var period []models.Transaction
+	for i := len(r.items) - 1; i >= 0; i-- {
+		t := r.items[i]
+		switch {
+		case t.CreatedAt.Before(from):
+			opening, closing = t.BalanceAfter, t.BalanceAfter
+		case t.CreatedAt.Before(to):
+			closing = t.BalanceAfter
+			period = append(period, t)
+		}
+	}
+	if err := begin(opening, closing); err != nil {
+		return err
+	}
+	for _, t := range period {
+		if err := each(t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestGetAccountTransactionsPagination(t *testing.T) {
+	txRepo := &fakeTransactionRepo{}
+	for id := 5; id >= 1; id-- {
+		txRepo.items = append(txRepo.items, models.Transaction{ID: id, AccountID: 1, CreatedAt: time.Unix(int64(id), 0)})
+	}
+	accountRepo := &fakeAccountRepo{accounts: map[int]*models.Account{
+		1: {ID: 1, UserID: 7, Currency: "RUB", Status: models.AccountStatusActive},
+	}}
+	svc := services.NewTransactionService(txRepo, accountRepo)
+
+	page, err := svc.GetAccountTransactions(7, 1, models.TransactionFilter{Limit: 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(page.Transactions) != 2 || page.Transactions[0].ID != 5 || page.NextCursor == "" {
+		t.Fatalf("unexpected first page: %+v", page)
+	}
+	if txRepo.lastFilter.Limit != 3 {
+		t.Errorf("expected repository limit 3, got %d", txRepo.lastFilter.Limit)
+	}
+
+	cursor, err := models.DecodeTransactionCursor(page.NextCursor)
+	if err != nil {
+		t.Fatalf("cursor decode error: %v", err)
+	}
+	page, err = svc.GetAccountTransactions(7, 1, models.TransactionFilter{Limit: 2, After: cursor})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(page.Transactions) != 2 || page.Transactions[0].ID != 3 {
+		t.Fatalf("unexpected second page: %+v", page)
+	}
+
+	if _, err := svc.GetAccountTransactions(8, 1, models.TransactionFilter{}); !errors.Is(err, models.ErrNotAccountOwner) {
+		t.Errorf("expected ErrNotAccountOwner for foreign account, got %v", err)
+	}
+}
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"bank-api/models"
+	"bank-api/repositories"
+	"bank-api/utils"
+)
+
+// TransferService описывает переводы по реквизитам карт и возвраты переводов.
+type TransferService interface {
+	// CardToCard переводит amount с карты fromCardID пользователя на карту с номером toPAN.
+	// Деньги проходят между привязанными счетами через AccountService.Transfer;
+	// обе карты должны быть активны, иначе ErrCardInactive. Одноразовые карты и карты
+	// с привязкой к торговой точке тратятся только авторизациями, иначе ErrVirtualCardSource.
+	CardToCard(userID, fromCardID int, toPAN string, amount models.Money) (*models.CardTransfer, error)
+	// GetTransfer возвращает перевод с его возвратами отправителю или операционисту.
+	GetTransfer(userID int, role string, transferID int) (*models.Transfer, []models.TransferReversal, error)
+	// Reverse возвращает amount (nil — весь остаток) по переводу компенсирующей записью.
+	// Отправитель может вернуть перевод в пределах окна отмены, операционист — в любое время.
+	Reverse(userID int, role string, transferID int, amount *models.Money, reason string) (*models.TransferReversal, *models.Transfer, error)
+}
+
+type transferService struct {
+	cardRepo       repositories.CardRepository
+	transferRepo   repositories.TransferRepository
+	accountService AccountService
+	// Ключ слепого индекса номеров карт (тот же, что у CardService)
+	indexKey []byte
+	// Срок, в течение которого отправитель может сам вернуть перевод
+	reversalWindow time.Duration
+}
+
+// NewTransferService создает TransferService.
+func NewTransferService(
+	cardRepo repositories.CardRepository,
+	transferRepo repositories.TransferRepository,
+	accountService AccountService,
+	indexKey []byte,
+	reversalWindow time.Duration,
+) TransferService {
+	return &transferService{
+		cardRepo:       cardRepo,
+		transferRepo:   transferRepo,
+		accountService: accountService,
+		indexKey:       indexKey,
+		reversalWindow: reversalWindow,
+	}
+}
+
+func (s *transferService) CardToCard(userID, fromCardID int, toPAN string, amount models.Money) (*models.CardTransfer, error) {
+	toPAN = utils.NormalizePAN(toPAN)
+	if !utils.ValidateLuhn(toPAN) {
+		return nil, models.ErrInvalidPAN
+	}
+
+	from, err := s.cardRepo.GetByID(fromCardID)
+	if err != nil {
+		return nil, err
+	}
+	if from.UserID != userID {
+		return nil, models.ErrNotCardOwner
+	}
+	if from.Status != models.CardStatusActive {
+		return nil, fmt.Errorf("%w: card %d is %s", models.ErrCardInactive, from.ID, from.Status)
+	}
+	// Лимит, срок и привязка виртуальной карты проверяются только при авторизациях.
+	if from.Kind != models.CardKindStandard {
+		return nil, fmt.Errorf("%w: card %d is %s", models.ErrVirtualCardSource, from.ID, from.Kind)
+	}
+	fromPAN, err := utils.DecryptPGP(from.CardNumber, from.CardNumberMAC)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt card %d: %w", from.ID, err)
+	}
+
+	to, err := s.cardRepo.GetByPANIndex(utils.PANBlindIndex(toPAN, s.indexKey))
+	if err != nil {
+		return nil, err
+	}
+	// Статус чужой карты не раскрывается: получателю нужна просто активная карта.
+	if to.Status != models.CardStatusActive {
+		return nil, models.ErrCardInactive
+	}
+
+	transfer, err := s.accountService.CardTransfer(userID, from.AccountID, to.AccountID, amount)
+	if err != nil {
+		return nil, err
+	}
+	return &models.CardTransfer{
+		FromCardID: from.ID,
+		FromPAN:    utils.MaskPAN(fromPAN),
+		ToPAN:      utils.MaskPAN(toPAN),
+		Transfer:   transfer,
+	}, nil
+}
+
+func (s *transferService) GetTransfer(userID int, role string, transferID int) (*models.Transfer, []models.TransferReversal, error) {
+	t, err := s.visibleTransfer(userID, role, transferID)
+	if err != nil {
+		return nil, nil, err
+	}
+	reversals, err := s.transferRepo.ListReversals(t.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return t, reversals, nil
+}
+
+func (s *transferService) Reverse(userID int, role string, transferID int, amount *models.Money, reason string) (*models.TransferReversal, *models.Transfer, error) {
+	t, err := s.visibleTransfer(userID, role, transferID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if role != models.RoleOperator && time.Since(t.CreatedAt) > s.reversalWindow {
+		return nil, nil, models.ErrReversalNotAllowed
+	}
+	if !t.Remaining().IsPositive() {
+		return nil, nil, models.ErrTransferFullyReversed
+	}
+
+	rev := &models.TransferReversal{TransferID: t.ID, UserID: userID, Reason: reason}
+	if amount != nil {
+		if !amount.IsPositive() {
+			return nil, nil, models.ErrInvalidAmount
+		}
+		rev.Amount = *amount
+	}
+	t, err = s.transferRepo.ReverseTx(context.Background(), rev)
+	if err != nil {
+		return nil, nil, err
+	}
+	return rev, t, nil
+}
+
+// visibleTransfer возвращает перевод, если пользователь — его отправитель или операционист.
+// Чужие переводы для клиента неотличимы от несуществующих.
+func (s *transferService) visibleTransfer(userID int, role string, transferID int) (*models.Transfer, error) {
+	t, err := s.transferRepo.GetByID(transferID)
+	if err != nil {
+		return nil, err
+	}
+	if role != models.RoleOperator && t.UserID != userID {
+		return nil, models.ErrTransferNotFound
+	}
+	return t, nil
+}
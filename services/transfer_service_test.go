@@ -0,0 +1,183 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"bank-api/models"
+	"bank-api/services"
+	"bank-api/utils"
+)
+
+// fakeTransferRepo хранит переводы в памяти; ReverseTx повторяет проверки репозитория.
+type fakeTransferRepo struct {
+	transfers map[int]*models.Transfer
+	reversals []models.TransferReversal
+}
+
+func (f *fakeTransferRepo) GetByID(id int) (*models.Transfer, error) {
+	if t, ok := f.transfers[id]; ok {
+		copied := *t
+		return &copied, nil
+	}
+	return nil, models.ErrTransferNotFound
+}
+
+func (f *fakeTransferRepo) ReverseTx(ctx context.Context, rev *models.TransferReversal) (*models.Transfer, error) {
+	t, ok := f.transfers[rev.TransferID]
+	if !ok {
+		return nil, models.ErrTransferNotFound
+	}
+	remaining := t.Remaining()
+	if !remaining.IsPositive() {
+		return nil, models.ErrTransferFullyReversed
+	}
+	amount := remaining
+	if !rev.Amount.IsZero() {
+		amount = rev.Amount.WithCurrency(t.Currency)
+	}
+	if cmp, _ := amount.Cmp(remaining); cmp > 0 {
+		return nil, models.ErrReversalExceedsBalance
+	}
+	t.ReversedAmount, _ = t.ReversedAmount.Add(amount)
+	rev.ID, rev.Amount, rev.DebitedAmount = len(f.reversals)+1, amount, amount
+	f.reversals = append(f.reversals, *rev)
+	copied := *t
+	return &copied, nil
+}
+
+func (f *fakeTransferRepo) ListReversals(transferID int) ([]models.TransferReversal, error) {
+	var result []models.TransferReversal
+	for _, rev := range f.reversals {
+		if rev.TransferID == transferID {
+			result = append(result, rev)
+		}
+	}
+	return result, nil
+}
+
+func TestCardToCardFindsCardByPAN(t *testing.T) {
+	cardRepo := &fakeCardRepo{}
+	accountRepo := &fakeAccountRepo{accounts: map[int]*models.Account{
+		1: {ID: 1, UserID: 7, Currency: "RUB", Status: models.AccountStatusActive},
+		2: {ID: 2, UserID: 8, Currency: "RUB", Status: models.AccountStatusActive},
+	}}
+	cardService := services.NewCardService(cardRepo, accountRepo, newFakeCardProductRepo(), newFakeUserRepo(), testCardIndexKey, 3, time.Minute)
+	from, err := cardService.CreateCard(7, 1, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := cardService.CreateCard(8, 2, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	toPAN, err := utils.DecryptPGP(cardRepo.cards[1].CardNumber, cardRepo.cards[1].CardNumberMAC)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	svc := services.NewTransferService(cardRepo, &fakeTransferRepo{},
+		services.NewAccountService(accountRepo, nil, noFees(accountRepo), nil, testBankBIK), testCardIndexKey, time.Hour)
+	// Номер можно передать с пробелами, как он напечатан на карте.
+	spaced := toPAN[:4] + " " + toPAN[4:8] + " " + toPAN[8:12] + " " + toPAN[12:]
+	result, err := svc.CardToCard(7, from.ID, spaced, models.NewMoney(50000, ""))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(accountRepo.transfers) != 1 || accountRepo.transfers[0].FromAccountID != 1 || accountRepo.transfers[0].ToAccountID != 2 {
+		t.Fatalf("expected transfer between linked accounts, got %+v", accountRepo.transfers)
+	}
+	if result.ToPAN != utils.MaskPAN(toPAN) || !strings.Contains(result.FromPAN, "******") {
+		t.Errorf("expected masked PANs, got %s and %s", result.FromPAN, result.ToPAN)
+	}
+
+	cases := []struct {
+		name    string
+		userID  int
+		pan     string
+		wantErr error
+	}{
+		{"bad checksum", 7, toPAN[:15] + string('0'+(toPAN[15]-'0'+1)%10), models.ErrInvalidPAN},
+		{"unknown card", 7, "4111111111111111", models.ErrCardNotFound},
+		{"foreign source card", 8, toPAN, models.ErrNotCardOwner},
+	}
+	for _, tc := range cases {
+		if _, err := svc.CardToCard(tc.userID, from.ID, tc.pan, models.NewMoney(100, "")); !errors.Is(err, tc.wantErr) {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.wantErr, err)
+		}
+	}
+
+	// Виртуальная карта не может быть источником перевода.
+	virtual, err := cardService.CreateVirtualCard(7, 1, models.VirtualCardRequest{
+		Kind: models.CardKindSingleUse, SpendCap: models.NewMoney(100, "")})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := svc.CardToCard(7, virtual.ID, toPAN, models.NewMoney(100, "")); !errors.Is(err, models.ErrVirtualCardSource) {
+		t.Errorf("virtual source: expected ErrVirtualCardSource, got %v", err)
+	}
+
+	// Заблокированная карта не участвует в переводах ни как источник, ни как получатель.
+	if _, err := cardService.BlockCard(8, 2, "lost wallet"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := svc.CardToCard(7, from.ID, toPAN, models.NewMoney(100, "")); !errors.Is(err, models.ErrCardInactive) {
+		t.Errorf("blocked destination: expected ErrCardInactive, got %v", err)
+	}
+	if _, err := cardService.BlockCard(7, from.ID, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := svc.CardToCard(7, from.ID, toPAN, models.NewMoney(100, "")); !errors.Is(err, models.ErrCardInactive) {
+		t.Errorf("blocked source: expected ErrCardInactive, got %v", err)
+	}
+}
+
+func TestReverseTransfer(t *testing.T) {
+	recent := &models.Transfer{ID: 1, UserID: 7, Amount: models.NewMoney(10000, "RUB"), Currency: "RUB",
+		ReversedAmount: models.NewMoney(0, "RUB"), CreatedAt: time.Now().Add(-time.Minute)}
+	old := &models.Transfer{ID: 2, UserID: 7, Amount: models.NewMoney(10000, "RUB"), Currency: "RUB",
+		ReversedAmount: models.NewMoney(0, "RUB"), CreatedAt: time.Now().Add(-48 * time.Hour)}
+	repo := &fakeTransferRepo{transfers: map[int]*models.Transfer{1: recent, 2: old}}
+	svc := services.NewTransferService(&fakeCardRepo{}, repo, nil, testCardIndexKey, 24*time.Hour)
+
+	partial := models.NewMoney(4000, "")
+	rev, transfer, err := svc.Reverse(7, models.RoleCustomer, 1, &partial, "wrong amount")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rev.Amount.String() != "40.00" || transfer.Remaining().String() != "60.00" {
+		t.Errorf("expected 40.00 reversed and 60.00 left, got %s and %s", rev.Amount, transfer.Remaining())
+	}
+	// Без суммы возвращается весь остаток, после чего перевод вернуть нельзя.
+	if rev, _, err = svc.Reverse(7, models.RoleCustomer, 1, nil, ""); err != nil || rev.Amount.String() != "60.00" {
+		t.Fatalf("expected remaining 60.00 reversed, got %v, %v", rev, err)
+	}
+
+	cases := []struct {
+		name       string
+		userID     int
+		role       string
+		transferID int
+		wantErr    error
+	}{
+		{"fully reversed", 7, models.RoleCustomer, 1, models.ErrTransferFullyReversed},
+		{"window expired", 7, models.RoleCustomer, 2, models.ErrReversalNotAllowed},
+		{"foreign transfer", 8, models.RoleCustomer, 2, models.ErrTransferNotFound},
+	}
+	for _, tc := range cases {
+		if _, _, err := svc.Reverse(tc.userID, tc.role, tc.transferID, nil, ""); !errors.Is(err, tc.wantErr) {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.wantErr, err)
+		}
+	}
+
+	// Операционист возвращает перевод и после окончания окна.
+	if _, _, err := svc.Reverse(99, models.RoleOperator, 2, nil, "chargeback"); err != nil {
+		t.Errorf("expected operator reversal to succeed, got %v", err)
+	}
+	_, reversals, err := svc.GetTransfer(7, models.RoleCustomer, 2)
+	if err != nil || len(reversals) != 1 || reversals[0].UserID != 99 {
+		t.Errorf("expected operator reversal in history, got %+v, %v", reversals, err)
+	}
+}
@@ -72,7 +72,7 @@ func (s *userService) Authenticate(email, password string) (string, error) {
 	}
 
 	// Генерация JWT-токена.
-	token, err := s.generateJWTToken(user.ID)
+	token, err := s.generateJWTToken(user.ID, user.Role)
 	if err != nil {
 		return "", err
 	}
@@ -80,12 +80,21 @@ func (s *userService) Authenticate(email, password string) (string, error) {
 	return token, nil
 }
 
-// generateJWTToken создает JWT-токен с id пользователя в качестве Subject.
-func (s *userService) generateJWTToken(userID int) (string, error) {
-	claims := jwt.RegisteredClaims{
-		Subject:   strconv.Itoa(userID),
-		ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
-		IssuedAt:  jwt.NewNumericDate(time.Now()),
+// tokenClaims — содержимое JWT: стандартные поля и роль пользователя.
+type tokenClaims struct {
+	Role string `json:"role,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// generateJWTToken создает JWT-токен с id пользователя в качестве Subject и его ролью.
+func (s *userService) generateJWTToken(userID int, role string) (string, error) {
+	claims := tokenClaims{
+		Role: role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.Itoa(userID),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
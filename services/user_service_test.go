@@ -32,7 +32,7 @@ func (r *fakeUserRepo) Create(user *models.User) error {
 func (r *fakeUserRepo) GetByEmail(email string) (*models.User, error) {
 	user, ok := r.users[email]
 	if !ok {
-		return nil, errors.New("user not found")
+		return nil, models.ErrUserNotFound
 	}
 	return user, nil
 }
@@ -43,7 +43,7 @@ func (r *fakeUserRepo) GetByID(id int) (*models.User, error) {
 			return user, nil
 		}
 	}
-	return nil, errors.New("user not found")
+	return nil, models.ErrUserNotFound
 }
 
 // TestRegisterAndAuthenticate проверяет регистрацию и аутентификацию.
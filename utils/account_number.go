@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// accountKeyWeights — весовые коэффициенты расчета контрольного ключа (7, 1, 3, ...).
+var accountKeyWeights = [3]int{7, 1, 3}
+
+// accountKeyPosition — позиция контрольного ключа в 20-значном номере счета.
+const accountKeyPosition = 8
+
+// ValidBIK проверяет, что БИК состоит из 9 цифр.
+func ValidBIK(bik string) bool {
+	return len(bik) == 9 && isDigits(bik)
+}
+
+// NormalizeAccountNumber удаляет из номера счета пробелы, точки и дефисы.
+func NormalizeAccountNumber(number string) string {
+	return strings.NewReplacer(" ", "", ".", "", "-", "").Replace(number)
+}
+
+// BuildAccountNumber собирает 20-значный номер счета: балансовый счет второго порядка (5 цифр),
+// код валюты (3), контрольный ключ (1), код подразделения (4) и порядковый номер (7).
+// Контрольный ключ рассчитывается по БИК банка (Положение Банка России № 579-П).
+func BuildAccountNumber(bik, balancePrefix, currencyCode string, branch, serial int64) (string, error) {
+	if !ValidBIK(bik) {
+		return "", fmt.Errorf("invalid BIK %q", bik)
+	}
+	if len(balancePrefix) != 5 || !isDigits(balancePrefix) || len(currencyCode) != 3 || !isDigits(currencyCode) {
+		return "", fmt.Errorf("invalid account prefix %q or currency code %q", balancePrefix, currencyCode)
+	}
+	if branch < 0 || branch > 9999 || serial < 0 || serial > 9999999 {
+		return "", fmt.Errorf("account serial %d out of range", serial)
+	}
+	number := []byte(fmt.Sprintf("%s%s0%04d%07d", balancePrefix, currencyCode, branch, serial))
+	number[accountKeyPosition] = '0' + byte(accountKeySum(bik, string(number))*3%10)
+	return string(number), nil
+}
+
+// ValidateAccountNumber проверяет, что номер из 20 цифр и его контрольный ключ верен для БИК.
+func ValidateAccountNumber(bik, number string) bool {
+	if !ValidBIK(bik) || len(number) != 20 || !isDigits(number) {
+		return false
+	}
+	return accountKeySum(bik, number)%10 == 0
+}
+
+// accountKeySum возвращает сумму младших разрядов произведений цифр условного
+// 23-значного номера на весовые коэффициенты. Условный номер — три последние цифры
+// БИК и номер счета; для подразделений Банка России (БИК оканчивается на 000–002)
+// вместо них берутся «0» и 5–6 цифры БИК.
+func accountKeySum(bik, number string) int {
+	prefix := bik[6:]
+	if prefix <= "002" {
+		prefix = "0" + bik[4:6]
+	}
+	sum := 0
+	for i, c := range prefix + number {
+		sum += int(c-'0') * accountKeyWeights[i%3] % 10
+	}
+	return sum
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
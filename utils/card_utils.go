@@ -9,6 +9,7 @@ import (
 	"golang.org/x/crypto/bcrypt"
 	"math/big"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -28,6 +29,55 @@ func GenerateCardNumber() string {
 	return number + strconv.Itoa(checkDigit)
 }
 
+// GenerateCardNumberInRange генерирует номер карты длиной length, валидный по алгоритму Луна,
+// с префиксом из диапазона BIN [binFrom, binTo] (границы одной длины).
+func GenerateCardNumberInRange(binFrom, binTo string, length int) (string, error) {
+	if err := ValidateBINRange(binFrom, binTo, length); err != nil {
+		return "", err
+	}
+	from, _ := new(big.Int).SetString(binFrom, 10)
+	to, _ := new(big.Int).SetString(binTo, 10)
+	span := new(big.Int).Sub(to, from)
+	offset, err := rand.Int(rand.Reader, span.Add(span, big.NewInt(1)))
+	if err != nil {
+		return "", err
+	}
+	bin := offset.Add(offset, from).String()
+
+	var b strings.Builder
+	b.WriteString(strings.Repeat("0", len(binFrom)-len(bin)))
+	b.WriteString(bin)
+	for b.Len() < length-1 {
+		n, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(n.String())
+	}
+	number := b.String()
+	return number + strconv.Itoa(computeLuhnCheckDigit(number)), nil
+}
+
+// ValidateBINRange проверяет диапазон BIN: границы из 6–8 цифр одной длины, binFrom <= binTo,
+// и номер длиной 16 или 19 цифр.
+func ValidateBINRange(binFrom, binTo string, length int) error {
+	if length != 16 && length != 19 {
+		return fmt.Errorf("PAN length must be 16 or 19, got %d", length)
+	}
+	if len(binFrom) < 6 || len(binFrom) > 8 || len(binFrom) != len(binTo) {
+		return fmt.Errorf("BIN range bounds must be 6 to 8 digits of equal length")
+	}
+	for _, c := range binFrom + binTo {
+		if c < '0' || c > '9' {
+			return fmt.Errorf("BIN range bounds must be digits")
+		}
+	}
+	if binFrom > binTo {
+		return fmt.Errorf("BIN range start %s is after its end %s", binFrom, binTo)
+	}
+	return nil
+}
+
 func computeLuhnCheckDigit(number string) int {
 	sum := 0
 	double := true
@@ -46,6 +96,38 @@ func computeLuhnCheckDigit(number string) int {
 	return (10 - (sum % 10)) % 10
 }
 
+// NormalizePAN удаляет из номера карты пробелы и дефисы.
+func NormalizePAN(pan string) string {
+	return strings.NewReplacer(" ", "", "-", "").Replace(pan)
+}
+
+// ValidateLuhn проверяет, что номер карты состоит из 12–19 цифр и проходит проверку по алгоритму Луна.
+func ValidateLuhn(pan string) bool {
+	if len(pan) < 12 || len(pan) > 19 {
+		return false
+	}
+	for _, c := range pan {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return computeLuhnCheckDigit(pan[:len(pan)-1]) == int(pan[len(pan)-1]-'0')
+}
+
+// MaskPAN скрывает номер карты, оставляя первые 6 и последние 4 цифры: "220012******1234".
+func MaskPAN(pan string) string {
+	if len(pan) < 10 {
+		return strings.Repeat("*", len(pan))
+	}
+	return pan[:6] + strings.Repeat("*", len(pan)-10) + pan[len(pan)-4:]
+}
+
+// PANBlindIndex возвращает слепой индекс номера карты — HMAC-SHA256 на отдельном ключе.
+// Индекс позволяет искать карту по номеру, не храня номер в открытом виде.
+func PANBlindIndex(pan string, key []byte) string {
+	return ComputeHMAC(pan, key)
+}
+
 // GenerateExpirationDate возвращает дату окончания срока действия карты в формате "MM/YY",
 // смещенную на offsetYears лет от текущей даты.
 func GenerateExpirationDate(offsetYears int) string {
@@ -53,6 +135,30 @@ func GenerateExpirationDate(offsetYears int) string {
 	return fmt.Sprintf("%02d/%02d", t.Month(), t.Year()%100)
 }
 
+// CardExpiresAt разбирает срок действия "MM/YY" и возвращает момент, с которого карта
+// недействительна: начало месяца, следующего за указанным (UTC).
+func CardExpiresAt(expiry string) (time.Time, error) {
+	t, err := time.Parse("01/06", strings.TrimSpace(expiry))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid card expiry %q", expiry)
+	}
+	return t.AddDate(0, 1, 0), nil
+}
+
+// CheckCVV сравнивает CVV с хешем, полученным через HashCVV.
+func CheckCVV(cvv, hash string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(cvv)) == nil
+}
+
+// GenerateApprovalCode возвращает случайный шестизначный код одобрения авторизации.
+func GenerateApprovalCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
 // GenerateCVV генерирует случайное трехзначное число в виде строки.
 func GenerateCVV() (string, error) {
 	n, err := rand.Int(rand.Reader, big.NewInt(900))
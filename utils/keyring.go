@@ -0,0 +1,234 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	crypto "github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+)
+
+// Файлы ключа kid в каталоге связки: <kid>.asc — закрытый PGP-ключ в ASCII-armor,
+// <kid>.hmac — HMAC-ключ в hex.
+const (
+	pgpKeyExt  = ".asc"
+	hmacKeyExt = ".hmac"
+)
+
+// keyIDSeparator отделяет идентификатор ключа от шифротекста: "<kid>:<hex>".
+const keyIDSeparator = ":"
+
+var (
+	// ErrUnknownKey возвращается для шифротекста с ключом, которого нет в связке.
+	ErrUnknownKey = errors.New("unknown encryption key")
+	// ErrMACMismatch возвращается, если HMAC шифротекста не сходится.
+	ErrMACMismatch = errors.New("HMAC mismatch")
+)
+
+// CardKey — ключ шифрования реквизитов карт: PGP-ключ и HMAC-ключ с общим идентификатором.
+type CardKey struct {
+	ID      string
+	entity  *crypto.Entity
+	hmacKey []byte
+}
+
+// GenerateCardKey создает новый ключ с идентификатором id.
+func GenerateCardKey(id string) (*CardKey, error) {
+	if err := validKeyID(id); err != nil {
+		return nil, err
+	}
+	entity, err := crypto.NewEntity("bank", "cards "+id, "bank@example.com", nil)
+	if err != nil {
+		return nil, err
+	}
+	hmacKey := make([]byte, 32)
+	if _, err := rand.Read(hmacKey); err != nil {
+		return nil, err
+	}
+	return &CardKey{ID: id, entity: entity, hmacKey: hmacKey}, nil
+}
+
+// WriteCardKey сохраняет ключ в каталог связки dir с правами 0600;
+// существующие файлы ключа не перезаписываются.
+func WriteCardKey(dir string, key *CardKey) error {
+	var armored bytes.Buffer
+	w, err := armor.Encode(&armored, crypto.PrivateKeyType, nil)
+	if err != nil {
+		return err
+	}
+	if err := key.entity.SerializePrivate(w, nil); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	if err := writeNewFile(filepath.Join(dir, key.ID+pgpKeyExt), armored.Bytes()); err != nil {
+		return err
+	}
+	return writeNewFile(filepath.Join(dir, key.ID+hmacKeyExt), []byte(hex.EncodeToString(key.hmacKey)+"\n"))
+}
+
+func writeNewFile(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// loadCardKey читает ключ id из каталога dir.
+func loadCardKey(dir, id string) (*CardKey, error) {
+	f, err := os.Open(filepath.Join(dir, id+pgpKeyExt))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	entities, err := crypto.ReadArmoredKeyRing(f)
+	if err != nil {
+		return nil, fmt.Errorf("key %s: %w", id, err)
+	}
+	if len(entities) != 1 || entities[0].PrivateKey == nil {
+		return nil, fmt.Errorf("key %s: expected exactly one private key", id)
+	}
+	hmacHex, err := os.ReadFile(filepath.Join(dir, id+hmacKeyExt))
+	if err != nil {
+		return nil, err
+	}
+	hmacKey, err := hex.DecodeString(strings.TrimSpace(string(hmacHex)))
+	if err != nil || len(hmacKey) < 32 {
+		return nil, fmt.Errorf("key %s: HMAC key must be at least 32 hex-encoded bytes", id)
+	}
+	return &CardKey{ID: id, entity: entities[0], hmacKey: hmacKey}, nil
+}
+
+// Keyring — связка ключей реквизитов карт. Новые данные шифруются текущим ключом,
+// расшифровываются — любым ключом связки, указанным в шифротексте.
+type Keyring struct {
+	keys    map[string]*CardKey
+	current string
+}
+
+// NewKeyring собирает связку из keys с текущим ключом current.
+func NewKeyring(current string, keys ...*CardKey) (*Keyring, error) {
+	k := &Keyring{keys: make(map[string]*CardKey, len(keys)), current: current}
+	for _, key := range keys {
+		k.keys[key.ID] = key
+	}
+	if _, ok := k.keys[current]; !ok {
+		return nil, fmt.Errorf("%w: current key %q", ErrUnknownKey, current)
+	}
+	return k, nil
+}
+
+// LoadKeyring загружает все ключи из каталога dir. Текущий ключ — current,
+// а если он пуст — ключ с наибольшим идентификатором.
+func LoadKeyring(dir, current string) (*Keyring, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*"+pgpKeyExt))
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no card keys found in %s", dir)
+	}
+	ids := make([]string, 0, len(paths))
+	for _, path := range paths {
+		ids = append(ids, strings.TrimSuffix(filepath.Base(path), pgpKeyExt))
+	}
+	sort.Strings(ids)
+	keys := make([]*CardKey, 0, len(ids))
+	for _, id := range ids {
+		key, err := loadCardKey(dir, id)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	if current == "" {
+		current = ids[len(ids)-1]
+	}
+	return NewKeyring(current, keys...)
+}
+
+// CurrentID возвращает идентификатор текущего ключа.
+func (k *Keyring) CurrentID() string {
+	return k.current
+}
+
+// Encrypt шифрует data текущим ключом и возвращает "<kid>:<hex>" и его HMAC.
+func (k *Keyring) Encrypt(data string) (cipherHex, macHex string, err error) {
+	key := k.keys[k.current]
+	buf := new(bytes.Buffer)
+	w, err := crypto.Encrypt(buf, []*crypto.Entity{key.entity}, nil, nil, nil)
+	if err != nil {
+		return "", "", err
+	}
+	if _, err := io.WriteString(w, data); err != nil {
+		return "", "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", "", err
+	}
+	cipherHex = key.ID + keyIDSeparator + hex.EncodeToString(buf.Bytes())
+	return cipherHex, ComputeHMAC(cipherHex, key.hmacKey), nil
+}
+
+// Decrypt проверяет HMAC шифротекста ключом из его префикса и расшифровывает его.
+func (k *Keyring) Decrypt(cipherHex, macHex string) (string, error) {
+	id := CipherKeyID(cipherHex)
+	key, ok := k.keys[id]
+	if !ok {
+		return "", fmt.Errorf("%w: %q", ErrUnknownKey, id)
+	}
+	if !hmac.Equal([]byte(macHex), []byte(ComputeHMAC(cipherHex, key.hmacKey))) {
+		return "", ErrMACMismatch
+	}
+	cipherBytes, err := hex.DecodeString(strings.TrimPrefix(cipherHex, id+keyIDSeparator))
+	if err != nil {
+		return "", err
+	}
+	md, err := crypto.ReadMessage(bytes.NewReader(cipherBytes), crypto.EntityList{key.entity}, nil, nil)
+	if err != nil {
+		return "", err
+	}
+	plain, err := io.ReadAll(md.UnverifiedBody)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
+
+// CipherKeyID возвращает идентификатор ключа шифротекста; пустой — шифротекст
+// записан до появления связки ключей и расшифровке не подлежит.
+func CipherKeyID(cipherHex string) string {
+	id, _, found := strings.Cut(cipherHex, keyIDSeparator)
+	if !found {
+		return ""
+	}
+	return id
+}
+
+// validKeyID допускает в идентификаторе ключа только буквы, цифры, '-' и '_'.
+func validKeyID(id string) error {
+	if id == "" {
+		return errors.New("key id is required")
+	}
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return fmt.Errorf("invalid key id %q", id)
+		}
+	}
+	return nil
+}
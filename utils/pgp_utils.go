@@ -1,47 +1,43 @@
 package utils
 
 import (
-	"bytes"
-	"encoding/hex"
-	"io"
-	"fmt"
-
-	crypto "github.com/ProtonMail/go-crypto/openpgp"
+	"sync/atomic"
 )
 
-var (
-	pgpEntity *crypto.Entity
-	hmacKey   = []byte("super‑secret‑hmac‑key")
-)
+// defaultKeyring — связка ключей, которой пользуются EncryptPGP и DecryptPGP.
+// До вызова SetKeyring это одноразовый ключ процесса: подходит для тестов,
+// но шифротексты с ним не переживают перезапуск.
+var defaultKeyring atomic.Pointer[Keyring]
 
 func init() {
-	var err error
-	pgpEntity, err = crypto.NewEntity("bank", "cards", "bank@example.com", nil)
+	key, err := GenerateCardKey("ephemeral")
 	if err != nil {
 		panic(err)
 	}
+	keyring, err := NewKeyring("ephemeral", key)
+	if err != nil {
+		panic(err)
+	}
+	defaultKeyring.Store(keyring)
 }
 
-func EncryptPGP(data string) (cipherHex, macHex string, err error) {
-	buf := new(bytes.Buffer)
-	w, _ := crypto.Encrypt(buf, []*crypto.Entity{pgpEntity}, nil, nil, nil)
-	io.WriteString(w, data)
-	w.Close()
+// SetKeyring заменяет связку ключей реквизитов карт; вызывается при старте процесса.
+func SetKeyring(k *Keyring) {
+	defaultKeyring.Store(k)
+}
 
-	cipherHex = hex.EncodeToString(buf.Bytes())
-	macHex = ComputeHMAC(cipherHex, hmacKey)
-	return
+// CurrentKeyring возвращает действующую связку ключей.
+func CurrentKeyring() *Keyring {
+	return defaultKeyring.Load()
 }
 
+// EncryptPGP шифрует data текущим ключом связки. Шифротекст начинается
+// с идентификатора ключа, HMAC считается по шифротексту вместе с ним.
+func EncryptPGP(data string) (cipherHex, macHex string, err error) {
+	return CurrentKeyring().Encrypt(data)
+}
+
+// DecryptPGP проверяет HMAC и расшифровывает шифротекст ключом, указанным в нем.
 func DecryptPGP(cipherHex, macHex string) (string, error) {
-	if macHex != ComputeHMAC(cipherHex, hmacKey) {
-		return "", fmt.Errorf("HMAC mismatch")
-	}
-	cipherBytes, _ := hex.DecodeString(cipherHex)
-	md, err := crypto.ReadMessage(bytes.NewReader(cipherBytes), crypto.EntityList{pgpEntity}, nil, nil)
-	if err != nil {
-		return "", err
-	}
-	plain, _ := io.ReadAll(md.UnverifiedBody)
-	return string(plain), nil
+	return CurrentKeyring().Decrypt(cipherHex, macHex)
 }
@@ -1,6 +1,7 @@
 package utils_test
 
 import (
+	"errors"
 	"testing"
 
 	"bank-api/utils"
@@ -25,3 +26,58 @@ func TestPGPEncryptDecrypt(t *testing.T) {
 		t.Fatalf("Decrypted text mismatch: want %q, got %q", plain, out)
 	}
 }
+
+func TestKeyringRotation(t *testing.T) {
+	dir := t.TempDir()
+	for _, id := range []string{"2024-01", "2025-01"} {
+		key, err := utils.GenerateCardKey(id)
+		if err != nil {
+			t.Fatalf("GenerateCardKey error: %v", err)
+		}
+		if err := utils.WriteCardKey(dir, key); err != nil {
+			t.Fatalf("WriteCardKey error: %v", err)
+		}
+	}
+
+	old, err := utils.LoadKeyring(dir, "2024-01")
+	if err != nil {
+		t.Fatalf("LoadKeyring error: %v", err)
+	}
+	cipher, mac, err := old.Encrypt("4111111111111111")
+	if err != nil {
+		t.Fatalf("Encrypt error: %v", err)
+	}
+	if utils.CipherKeyID(cipher) != "2024-01" {
+		t.Errorf("expected ciphertext under key 2024-01, got %q", utils.CipherKeyID(cipher))
+	}
+
+	// После перезагрузки связки текущим становится самый новый ключ, старые данные читаются.
+	rotated, err := utils.LoadKeyring(dir, "")
+	if err != nil {
+		t.Fatalf("LoadKeyring error: %v", err)
+	}
+	if rotated.CurrentID() != "2025-01" {
+		t.Errorf("expected current key 2025-01, got %q", rotated.CurrentID())
+	}
+	if out, err := rotated.Decrypt(cipher, mac); err != nil || out != "4111111111111111" {
+		t.Errorf("expected old ciphertext to decrypt, got %q, %v", out, err)
+	}
+	if _, err := rotated.Decrypt(cipher, mac[:len(mac)-1]+"0"); err == nil {
+		t.Error("expected HMAC mismatch for tampered MAC")
+	}
+	if _, err := utils.CurrentKeyring().Decrypt(cipher, mac); !errors.Is(err, utils.ErrUnknownKey) {
+		t.Errorf("expected ErrUnknownKey from another keyring, got %v", err)
+	}
+	if err := utils.WriteCardKey(dir, mustGenerateKey(t, "2025-01")); err == nil {
+		t.Error("expected existing key files not to be overwritten")
+	}
+}
+
+func mustGenerateKey(t *testing.T, id string) *utils.CardKey {
+	t.Helper()
+	key, err := utils.GenerateCardKey(id)
+	if err != nil {
+		t.Fatalf("GenerateCardKey error: %v", err)
+	}
+	return key
+}
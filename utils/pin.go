@@ -0,0 +1,223 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Форматы PIN-блоков ISO 9564-1.
+const (
+	// PINBlockFormat0 — 8-байтовый блок (PIN XOR PAN), шифруется TDES.
+	PINBlockFormat0 = 0
+	// PINBlockFormat4 — 16-байтовый блок для AES, PAN связывается с PIN через двойное шифрование.
+	PINBlockFormat4 = 4
+)
+
+// PINLength — длина PIN. Visa PVV строится по первым четырем цифрам PIN,
+// поэтому более длинные PIN не поддерживаются.
+const PINLength = 4
+
+// ErrInvalidPINBlock возвращается для PIN-блока, который не удалось разобрать.
+var ErrInvalidPINBlock = errors.New("invalid PIN block")
+
+// ValidPIN проверяет, что pin состоит из PINLength цифр.
+func ValidPIN(pin string) bool {
+	return len(pin) == PINLength && isDigits(pin)
+}
+
+// EncodePINBlock шифрует PIN для PAN в блок формата format под зональным ключом zoneKey
+// и возвращает его в hex. Используется терминалами и тестами.
+func EncodePINBlock(format int, pin, pan string, zoneKey []byte) (string, error) {
+	if !ValidPIN(pin) {
+		return "", fmt.Errorf("%w: PIN must be %d digits", ErrInvalidPINBlock, PINLength)
+	}
+	switch format {
+	case PINBlockFormat0:
+		block, err := tdesCipher(zoneKey)
+		if err != nil {
+			return "", err
+		}
+		clear, err := hex.DecodeString(fmt.Sprintf("0%d%s", len(pin), pin) + strings.Repeat("F", 14-len(pin)))
+		if err != nil {
+			return "", err
+		}
+		panField, err := format0PANField(pan)
+		if err != nil {
+			return "", err
+		}
+		xorBytes(clear, panField)
+		out := make([]byte, 8)
+		block.Encrypt(out, clear)
+		return strings.ToUpper(hex.EncodeToString(out)), nil
+	case PINBlockFormat4:
+		block, err := aes.NewCipher(zoneKey)
+		if err != nil {
+			return "", fmt.Errorf("zone key: %w", err)
+		}
+		pinField, err := hex.DecodeString(fmt.Sprintf("4%d%s", len(pin), pin) + strings.Repeat("A", 14-len(pin)))
+		if err != nil {
+			return "", err
+		}
+		random := make([]byte, 8)
+		if _, err := rand.Read(random); err != nil {
+			return "", err
+		}
+		pinField = append(pinField, random...)
+		panField, err := format4PANField(pan)
+		if err != nil {
+			return "", err
+		}
+		out := make([]byte, 16)
+		block.Encrypt(out, pinField)
+		xorBytes(out, panField)
+		block.Encrypt(out, out)
+		return strings.ToUpper(hex.EncodeToString(out)), nil
+	}
+	return "", fmt.Errorf("%w: unsupported format %d", ErrInvalidPINBlock, format)
+}
+
+// DecodePINBlock расшифровывает PIN-блок формата format для PAN под ключом zoneKey
+// и проверяет его структуру.
+func DecodePINBlock(format int, blockHex, pan string, zoneKey []byte) (string, error) {
+	data, err := hex.DecodeString(blockHex)
+	if err != nil {
+		return "", fmt.Errorf("%w: not hex", ErrInvalidPINBlock)
+	}
+	var pinField string
+	switch format {
+	case PINBlockFormat0:
+		if len(data) != 8 {
+			return "", fmt.Errorf("%w: format 0 block must be 8 bytes", ErrInvalidPINBlock)
+		}
+		block, err := tdesCipher(zoneKey)
+		if err != nil {
+			return "", err
+		}
+		clear := make([]byte, 8)
+		block.Decrypt(clear, data)
+		panField, err := format0PANField(pan)
+		if err != nil {
+			return "", err
+		}
+		xorBytes(clear, panField)
+		pinField = strings.ToUpper(hex.EncodeToString(clear))
+		return parsePINField(pinField, '0', 'F')
+	case PINBlockFormat4:
+		if len(data) != 16 {
+			return "", fmt.Errorf("%w: format 4 block must be 16 bytes", ErrInvalidPINBlock)
+		}
+		block, err := aes.NewCipher(zoneKey)
+		if err != nil {
+			return "", fmt.Errorf("zone key: %w", err)
+		}
+		panField, err := format4PANField(pan)
+		if err != nil {
+			return "", err
+		}
+		clear := make([]byte, 16)
+		block.Decrypt(clear, data)
+		xorBytes(clear, panField)
+		block.Decrypt(clear, clear)
+		pinField = strings.ToUpper(hex.EncodeToString(clear[:8]))
+		return parsePINField(pinField, '4', 'A')
+	}
+	return "", fmt.Errorf("%w: unsupported format %d", ErrInvalidPINBlock, format)
+}
+
+// parsePINField разбирает поле PIN "<формат><длина><PIN><заполнитель>".
+// ISO 9564 допускает PIN из 4–12 цифр, но принимаются только PIN из PINLength цифр.
+func parsePINField(field string, formatNibble, fill byte) (string, error) {
+	if field[0] != formatNibble {
+		return "", fmt.Errorf("%w: wrong format nibble", ErrInvalidPINBlock)
+	}
+	n, err := strconv.ParseUint(field[1:2], 16, 8)
+	if err != nil || n < 4 || n > 12 {
+		return "", fmt.Errorf("%w: wrong PIN length", ErrInvalidPINBlock)
+	}
+	if n != PINLength {
+		return "", fmt.Errorf("%w: PIN must be %d digits", ErrInvalidPINBlock, PINLength)
+	}
+	pin := field[2 : 2+n]
+	if !isDigits(pin) || strings.Trim(field[2+n:], string(fill)) != "" {
+		return "", fmt.Errorf("%w: malformed PIN field", ErrInvalidPINBlock)
+	}
+	return pin, nil
+}
+
+// format0PANField — 0000 и 12 правых цифр PAN без контрольной.
+func format0PANField(pan string) ([]byte, error) {
+	if len(pan) < 13 || !isDigits(pan) {
+		return nil, fmt.Errorf("%w: invalid PAN", ErrInvalidPINBlock)
+	}
+	return hex.DecodeString("0000" + pan[len(pan)-13:len(pan)-1])
+}
+
+// format4PANField — длина PAN минус 12, PAN и нули до 32 полубайт.
+func format4PANField(pan string) ([]byte, error) {
+	if len(pan) < 12 || len(pan) > 19 || !isDigits(pan) {
+		return nil, fmt.Errorf("%w: invalid PAN", ErrInvalidPINBlock)
+	}
+	field := fmt.Sprintf("%d%s", len(pan)-12, pan)
+	return hex.DecodeString(field + strings.Repeat("0", 32-len(field)))
+}
+
+// ComputePVV вычисляет Visa PIN Verification Value: TSP из 11 цифр PAN перед контрольной,
+// индекса ключа pvki и PIN шифруется TDES-ключом pvk, из результата выбираются
+// первые четыре десятичные цифры (при нехватке — шестнадцатеричные A–F минус 10).
+func ComputePVV(pan, pin string, pvki int, pvk []byte) (string, error) {
+	if !ValidPIN(pin) {
+		return "", fmt.Errorf("PIN must be %d digits", PINLength)
+	}
+	if len(pan) < 12 || !isDigits(pan) || pvki < 0 || pvki > 9 {
+		return "", errors.New("invalid PAN or PVKI")
+	}
+	block, err := tdesCipher(pvk)
+	if err != nil {
+		return "", err
+	}
+	tsp, err := hex.DecodeString(fmt.Sprintf("%s%d%s", pan[len(pan)-12:len(pan)-1], pvki, pin))
+	if err != nil {
+		return "", err
+	}
+	out := make([]byte, 8)
+	block.Encrypt(out, tsp)
+	digits := strings.ToUpper(hex.EncodeToString(out))
+
+	pvv := make([]byte, 0, 4)
+	for i := 0; i < len(digits) && len(pvv) < 4; i++ {
+		if digits[i] <= '9' {
+			pvv = append(pvv, digits[i])
+		}
+	}
+	for i := 0; i < len(digits) && len(pvv) < 4; i++ {
+		if digits[i] > '9' {
+			pvv = append(pvv, digits[i]-'A'+'0')
+		}
+	}
+	return string(pvv), nil
+}
+
+// tdesCipher создает TDES-шифр по ключу двойной (16 байт) или тройной (24 байта) длины.
+func tdesCipher(key []byte) (cipher.Block, error) {
+	switch len(key) {
+	case 16:
+		key = append(append([]byte{}, key...), key[:8]...)
+	case 24:
+	default:
+		return nil, errors.New("TDES key must be 16 or 24 bytes")
+	}
+	return des.NewTripleDESCipher(key)
+}
+
+func xorBytes(dst, src []byte) {
+	for i := range dst {
+		dst[i] ^= src[i]
+	}
+}
@@ -0,0 +1,79 @@
+package utils_test
+
+import (
+	"crypto/des"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"bank-api/utils"
+)
+
+var testZoneKey, _ = hex.DecodeString("0123456789ABCDEFFEDCBA9876543210")
+
+func TestPINBlockRoundTrip(t *testing.T) {
+	pan := "4111111111111111"
+	for _, format := range []int{utils.PINBlockFormat0, utils.PINBlockFormat4} {
+		block, err := utils.EncodePINBlock(format, "1234", pan, testZoneKey)
+		if err != nil {
+			t.Fatalf("format %d: encode error: %v", format, err)
+		}
+		pin, err := utils.DecodePINBlock(format, block, pan, testZoneKey)
+		if err != nil || pin != "1234" {
+			t.Errorf("format %d: expected PIN 1234, got %q, %v", format, pin, err)
+		}
+		// Блок привязан к PAN: с другой картой он не разбирается или дает другой PIN.
+		if pin, err := utils.DecodePINBlock(format, block, "5500000000000004", testZoneKey); err == nil && pin == "1234" {
+			t.Errorf("format %d: block must not decode to the same PIN for another PAN", format)
+		}
+	}
+
+	// Формат 4 содержит случайное заполнение: одинаковые PIN дают разные блоки.
+	a, _ := utils.EncodePINBlock(utils.PINBlockFormat4, "1234", pan, testZoneKey)
+	b, _ := utils.EncodePINBlock(utils.PINBlockFormat4, "1234", pan, testZoneKey)
+	if a == b {
+		t.Error("expected different format 4 blocks for the same PIN")
+	}
+
+	if _, err := utils.DecodePINBlock(utils.PINBlockFormat0, "zz", pan, testZoneKey); !errors.Is(err, utils.ErrInvalidPINBlock) {
+		t.Errorf("expected ErrInvalidPINBlock for non-hex block, got %v", err)
+	}
+	if _, err := utils.DecodePINBlock(1, a, pan, testZoneKey); !errors.Is(err, utils.ErrInvalidPINBlock) {
+		t.Errorf("expected ErrInvalidPINBlock for unsupported format, got %v", err)
+	}
+
+	// Корректный по ISO 9564 блок с PIN из 6 цифр отклоняется как неверный PIN-блок.
+	clear, _ := hex.DecodeString("06123456FFFFFFFF")
+	panField, _ := hex.DecodeString("0000" + pan[3:15])
+	for i := range clear {
+		clear[i] ^= panField[i]
+	}
+	tdes, err := des.NewTripleDESCipher(append(append([]byte{}, testZoneKey...), testZoneKey[:8]...))
+	if err != nil {
+		t.Fatalf("TDES key: %v", err)
+	}
+	long := make([]byte, 8)
+	tdes.Encrypt(long, clear)
+	if _, err := utils.DecodePINBlock(utils.PINBlockFormat0, hex.EncodeToString(long), pan, testZoneKey); !errors.Is(err, utils.ErrInvalidPINBlock) {
+		t.Errorf("expected ErrInvalidPINBlock for a 6-digit PIN, got %v", err)
+	}
+}
+
+func TestComputePVV(t *testing.T) {
+	pvk, _ := hex.DecodeString("0123456789ABCDEF0123456789ABCDEF")
+	pvv, err := utils.ComputePVV("4111111111111111", "1234", 1, pvk)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(pvv) != 4 || !utils.ValidPIN(pvv) {
+		t.Errorf("expected 4-digit PVV, got %q", pvv)
+	}
+	again, _ := utils.ComputePVV("4111111111111111", "1234", 1, pvk)
+	other, _ := utils.ComputePVV("4111111111111111", "4321", 1, pvk)
+	if again != pvv || other == pvv {
+		t.Errorf("PVV must be deterministic per PIN: %s, %s, %s", pvv, again, other)
+	}
+	if _, err := utils.ComputePVV("4111111111111111", "12345", 1, pvk); err == nil {
+		t.Error("expected error for 5-digit PIN")
+	}
+}
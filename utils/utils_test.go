@@ -4,6 +4,7 @@ import (
 	"bank-api/utils"
 	"strconv"
 	"testing"
+	"time"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -64,3 +65,124 @@ func TestHashCVV(t *testing.T) {
 		t.Errorf("hashed CVV does not match original: %v", err)
 	}
 }
+
+func TestValidateLuhn(t *testing.T) {
+	cases := map[string]bool{
+		"4111111111111111":    true,
+		"4111111111111112":    false,
+		"2200000000000004":    true,
+		"411111111111111a":    false,
+		"41111":               false,
+		"6011000990139424":    true,
+		"6759649826438453000": false,
+	}
+	for pan, want := range cases {
+		if got := utils.ValidateLuhn(pan); got != want {
+			t.Errorf("ValidateLuhn(%s) = %v, want %v", pan, got, want)
+		}
+	}
+	if pan := utils.GenerateCardNumber(); !utils.ValidateLuhn(pan) {
+		t.Errorf("generated card number %s fails the Luhn check", pan)
+	}
+}
+
+func TestGenerateCardNumberInRange(t *testing.T) {
+	for _, length := range []int{16, 19} {
+		for i := 0; i < 50; i++ {
+			pan, err := utils.GenerateCardNumberInRange("2200700", "2200799", length)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(pan) != length || !utils.ValidateLuhn(pan) {
+				t.Fatalf("generated card number %s is not a valid %d-digit PAN", pan, length)
+			}
+			if bin := pan[:7]; bin < "2200700" || bin > "2200799" {
+				t.Fatalf("card number %s is outside the BIN range", pan)
+			}
+		}
+	}
+	if pan, err := utils.GenerateCardNumberInRange("000100", "000100", 16); err != nil || pan[:6] != "000100" {
+		t.Errorf("expected leading zeros kept, got %s, %v", pan, err)
+	}
+	for _, tc := range []struct {
+		from, to string
+		length   int
+	}{
+		{"2200700", "2200799", 17},
+		{"22007", "22008", 16},
+		{"2200799", "2200700", 16},
+		{"220070", "2200799", 16},
+		{"22007a", "220079", 16},
+	} {
+		if _, err := utils.GenerateCardNumberInRange(tc.from, tc.to, tc.length); err == nil {
+			t.Errorf("expected error for range %s-%s length %d", tc.from, tc.to, tc.length)
+		}
+	}
+}
+
+func TestMaskPANAndBlindIndex(t *testing.T) {
+	if got := utils.MaskPAN(utils.NormalizePAN("4111 1111-1111 1111")); got != "411111******1111" {
+		t.Errorf("unexpected mask %s", got)
+	}
+	key := []byte("index-key")
+	a := utils.PANBlindIndex("4111111111111111", key)
+	if a != utils.PANBlindIndex("4111111111111111", key) {
+		t.Error("blind index must be deterministic")
+	}
+	if a == utils.PANBlindIndex("4111111111111111", []byte("other-key")) {
+		t.Error("blind index must depend on the key")
+	}
+}
+
+func TestAccountNumberControlKey(t *testing.T) {
+	number, err := utils.BuildAccountNumber("044525225", "40817", "810", 0, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if number != "40817810700000000001" {
+		t.Errorf("unexpected account number %s", number)
+	}
+	cases := []struct {
+		bik, number string
+		want        bool
+	}{
+		{"044525225", number, true},
+		{"044525225", "40817810800000000001", false},
+		{"044525974", number, false},
+		{"044525225", "4081781070000000000", false},
+		// Для подразделений Банка России ключ считается по 5–6 цифрам БИК.
+		{"044525000", "30101810400000000225", true},
+	}
+	for _, tc := range cases {
+		if got := utils.ValidateAccountNumber(tc.bik, tc.number); got != tc.want {
+			t.Errorf("ValidateAccountNumber(%s, %s) = %v, want %v", tc.bik, tc.number, got, tc.want)
+		}
+	}
+}
+
+func TestCardExpiresAtAndCheckCVV(t *testing.T) {
+	expiresAt, err := utils.CardExpiresAt("12/29")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Карта действует до конца указанного месяца включительно.
+	if !expiresAt.Equal(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected expiry at 2030-01-01, got %v", expiresAt)
+	}
+	if _, err := utils.CardExpiresAt("13/29"); err == nil {
+		t.Error("expected error for invalid month")
+	}
+
+	hash, err := utils.HashCVV("123")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !utils.CheckCVV("123", hash) || utils.CheckCVV("124", hash) {
+		t.Error("CheckCVV must accept only the hashed CVV")
+	}
+
+	code, err := utils.GenerateApprovalCode()
+	if err != nil || len(code) != 6 {
+		t.Errorf("expected 6-digit approval code, got %q, %v", code, err)
+	}
+}